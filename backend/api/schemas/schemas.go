@@ -0,0 +1,15 @@
+// Package schemas содержит опубликованные JSON Schema для API-клиентов
+package schemas
+
+import (
+	"embed"
+	"fmt"
+)
+
+//go:embed analysis_result/*.json
+var files embed.FS
+
+// AnalysisResult возвращает JSON Schema result_json указанной версии
+func AnalysisResult(version int) ([]byte, error) {
+	return files.ReadFile(fmt.Sprintf("analysis_result/v%d.json", version))
+}
@@ -1 +1,148 @@
 package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/analysis"
+	"github.com/DedovInside/AutoInspect/backend/internal/batch"
+	"github.com/DedovInside/AutoInspect/backend/internal/canary"
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/evaluation"
+	"github.com/DedovInside/AutoInspect/backend/internal/export"
+	"github.com/DedovInside/AutoInspect/backend/internal/fraud"
+	"github.com/DedovInside/AutoInspect/backend/internal/handler"
+	"github.com/DedovInside/AutoInspect/backend/internal/i18n"
+	"github.com/DedovInside/AutoInspect/backend/internal/labor"
+	"github.com/DedovInside/AutoInspect/backend/internal/pricing"
+	"github.com/DedovInside/AutoInspect/backend/internal/registry"
+	"github.com/DedovInside/AutoInspect/backend/internal/report"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/retention"
+	"github.com/DedovInside/AutoInspect/backend/internal/review"
+	"github.com/DedovInside/AutoInspect/backend/internal/shadow"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/DedovInside/AutoInspect/backend/internal/webhook"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 1. Конфигурация и инфраструктура
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.JWTSecret == "" {
+		log.Fatal("JWT_SECRET environment variable is not set")
+	}
+
+	db, err := database.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	store, err := storage.NewLocal(cfg.StorageDir)
+	if err != nil {
+		log.Fatalf("Failed to init storage: %v", err)
+	}
+
+	bundle, err := i18n.Load(i18n.Lang(cfg.DefaultLanguage))
+	if err != nil {
+		log.Fatalf("Failed to load localization catalogs: %v", err)
+	}
+
+	// 2. Репозитории и сервисы
+
+	users := repository.NewUserRepository(db)
+	analyses := repository.NewAnalysisRepository(db)
+	models := repository.NewModelRepository(db)
+	datasets := repository.NewDatasetRepository(db)
+	trainingJobs := repository.NewTrainingJobRepository(db)
+	reviews := repository.NewReviewRepository(db)
+	auditLogs := repository.NewAuditLogRepository(db)
+	batches := repository.NewBatchRepository(db)
+	webhooks := repository.NewWebhookRepository(db)
+	laborTimes := repository.NewLaborTimeRepository(db)
+	prices := repository.NewPricingRepository(db)
+	analytics := repository.NewAnalyticsRepository(db)
+	orgs := repository.NewOrganizationRepository(db)
+	retentionRepo := repository.NewRetentionRepository(db)
+	canaryRules := repository.NewCanaryRepository(db)
+	shadows := repository.NewShadowRepository(db)
+	artifacts := repository.NewArtifactRepository(db)
+	evaluations := repository.NewEvaluationRepository(db)
+
+	modelRouter := canary.NewRouter(models, canaryRules)
+	analysisSvc := analysis.NewService(analyses, modelRouter, store, cfg.Webhook.AllowPrivateTargets)
+	fraudSvc := fraud.NewService(analyses, store, cfg.Fraud)
+	exportSvc := export.NewService(analyses, datasets, store)
+	laborSvc := labor.NewService(laborTimes, cfg.Labor)
+	pricingSvc := pricing.NewService(db, prices, analyses, cfg.Pricing)
+	// Стоимость считается по нормо-часам, поэтому pricing - после labor
+	reviewSvc := review.NewService(db, analyses, reviews, auditLogs, cfg.Review, laborSvc, pricingSvc)
+	batchSvc := batch.NewService(db, batches, analyses, modelRouter, auditLogs, store, cfg.Batch, cfg.Webhook.AllowPrivateTargets)
+	reportSvc := report.NewService(analyses, analytics, pricingSvc)
+	webhookSvc := webhook.NewService(webhooks, analyses, batches, cfg.Webhook)
+	// Отмена пакета может его завершить, поэтому событие регистрируется и в API
+	batchSvc.OnCompleted(webhookSvc.HandleBatchCompleted)
+	registrySvc := registry.NewService(db, models, trainingJobs, datasets, artifacts, auditLogs, store)
+	canarySvc := canary.NewService(db, canaryRules, models, auditLogs)
+	shadowSvc := shadow.NewService(db, shadows, models, auditLogs, cfg.Shadow)
+	evaluationSvc := evaluation.NewService(db, evaluations, models, datasets, auditLogs, cfg.Evaluation)
+	retentionSvc := retention.NewService(db, orgs, users, retentionRepo, auditLogs, store, cfg.Retention)
+
+	// 3. HTTP-маршруты
+
+	router := handler.NewRouter(users, []byte(cfg.JWTSecret), bundle)
+	handler.NewSchemaHandler().Register(router)
+	handler.NewAnalysisHandler(analysisSvc).Register(router)
+	handler.NewFraudHandler(fraudSvc).Register(router)
+	handler.NewDatasetHandler(exportSvc).Register(router)
+	handler.NewReviewHandler(reviewSvc).Register(router)
+	handler.NewBatchHandler(batchSvc, cfg.Batch.MaxZipBytes).Register(router)
+	handler.NewReportHandler(reportSvc).Register(router)
+	handler.NewWebhookHandler(webhookSvc).Register(router)
+	handler.NewLaborHandler(laborSvc).Register(router)
+	handler.NewPricingHandler(pricingSvc).Register(router)
+	handler.NewUserHandler(users, bundle).Register(router)
+	handler.NewOrganizationHandler(retentionSvc).Register(router)
+	handler.NewModelHandler(registrySvc).Register(router)
+	handler.NewCanaryHandler(canarySvc).Register(router)
+	handler.NewShadowHandler(shadowSvc).Register(router)
+	handler.NewEvaluationHandler(evaluationSvc).Register(router)
+
+	srv := &http.Server{
+		Addr:              cfg.HTTPAddr,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Printf("API listening on %s", cfg.HTTPAddr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("HTTP server failed: %v", err)
+		}
+	}()
+
+	// 4. Корректное завершение по сигналу
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown failed: %v", err)
+	}
+}
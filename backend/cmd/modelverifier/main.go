@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/artifact"
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// report - итог проверки, выводимый в stdout
+type report struct {
+	Models     int                    `json:"models"`
+	OK         int                    `json:"ok"`
+	Corrupt    int                    `json:"corrupt"`
+	Unrecorded int                    `json:"unrecorded"`
+	Errors     int                    `json:"errors"`
+	Recorded   int                    `json:"recorded"`
+	Checks     []domain.ArtifactCheck `json:"checks"`
+}
+
+// modelverifier перечитывает файлы всех зарегистрированных моделей (или одной, -model),
+// сверяет их с дайджестами из model_artifacts и выводит отчёт в JSON.
+// С флагом -record записывает дайджесты текущих файлов моделей, зарегистрированных
+// до появления проверок: файлы при этом считаются эталонными.
+// Код выхода 1 - найден повреждённый или отсутствующий файл
+func main() {
+	modelFlag := flag.String("model", "", "id of the model to verify (default: all models)")
+	record := flag.Bool("record", false, "record digests of current files for models registered without them")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := database.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	store, err := storage.NewLocal(cfg.StorageDir)
+	if err != nil {
+		log.Fatalf("Failed to init storage: %v", err)
+	}
+
+	models := repository.NewModelRepository(db)
+	artifacts := repository.NewArtifactRepository(db)
+	verifier := artifact.NewVerifier(artifacts, store, 0)
+
+	var targets []domain.MLModel
+	if *modelFlag != "" {
+		id, err := uuid.Parse(*modelFlag)
+		if err != nil {
+			log.Fatalf("Invalid -model: %v", err)
+		}
+		m, err := models.GetByID(ctx, id)
+		if err != nil {
+			log.Fatalf("Failed to load model: %v", err)
+		}
+		targets = append(targets, *m)
+	} else {
+		const page = 100
+		for offset := 0; ; offset += page {
+			items, err := models.List(ctx, nil, page, offset)
+			if err != nil {
+				log.Fatalf("Failed to list models: %v", err)
+			}
+			targets = append(targets, items...)
+			if len(items) < page {
+				break
+			}
+		}
+	}
+
+	rep := report{Models: len(targets), Checks: []domain.ArtifactCheck{}}
+	for i := range targets {
+		m := &targets[i]
+		checks, err := verifier.Check(ctx, m)
+		if err != nil {
+			log.Fatalf("Failed to verify model %s: %v", m.Version, err)
+		}
+		for _, c := range checks {
+			if c.Status == domain.ArtifactCheckUnrecorded && *record {
+				c = recordArtifact(ctx, artifacts, store, m, c)
+				if c.Status == domain.ArtifactCheckOK {
+					rep.Recorded++
+				}
+			}
+			switch {
+			case c.IsCorrupt():
+				rep.Corrupt++
+			case c.Status == domain.ArtifactCheckUnrecorded:
+				rep.Unrecorded++
+			case c.Status == domain.ArtifactCheckError:
+				rep.Errors++
+			default:
+				rep.OK++
+			}
+			rep.Checks = append(rep.Checks, c)
+		}
+	}
+
+	printJSON(rep)
+	if rep.Corrupt > 0 {
+		os.Exit(1)
+	}
+}
+
+// recordArtifact записывает дайджест текущего файла модели и возвращает обновлённый итог проверки
+func recordArtifact(ctx context.Context, artifacts *repository.ArtifactRepository, store storage.ObjectStorage,
+	m *domain.MLModel, c domain.ArtifactCheck) domain.ArtifactCheck {
+	sum, size, err := artifact.Digest(ctx, store, c.Path)
+	switch {
+	case err == nil:
+	case errors.Is(err, storage.ErrNotFound):
+		c.Status, c.Error = domain.ArtifactCheckMissing, "file not found in storage"
+		return c
+	default:
+		c.Status, c.Error = domain.ArtifactCheckError, err.Error()
+		return c
+	}
+
+	a := &domain.ModelArtifact{ModelID: m.ID, Kind: c.Kind, Path: c.Path, SHA256: sum, SizeBytes: size}
+	if err := artifacts.Create(ctx, nil, a); err != nil {
+		c.Status, c.Error = domain.ArtifactCheckError, err.Error()
+		return c
+	}
+	c.Status = domain.ArtifactCheckOK
+	c.ExpectedSHA256, c.ActualSHA256 = sum, sum
+	c.ExpectedSize, c.ActualSize = size, size
+	return c
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Fatalf("Failed to print result: %v", err)
+	}
+}
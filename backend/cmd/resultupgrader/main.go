@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// resultupgrader переписывает result_json старых версий схемы до текущей.
+// Чтение через AnalysisResult.Scan и так обновляет данные на лету;
+// команда нужна, чтобы убрать старые версии из БД (например, перед удалением перехода)
+func main() {
+	batchSize := flag.Int("batch-size", 500, "number of rows upgraded per transaction")
+	pause := flag.Duration("pause", 100*time.Millisecond, "pause between batches to limit database load")
+	dryRun := flag.Bool("dry-run", false, "only count outdated rows")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL environment variable is not set")
+	}
+
+	db, err := database.Open(ctx, dbURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	analyses := repository.NewAnalysisRepository(db)
+
+	outdated, err := analyses.CountOutdatedResults(ctx)
+	if err != nil {
+		log.Fatalf("Failed to count outdated results: %v", err)
+	}
+	fmt.Printf("Current result schema version: %d, outdated rows: %d\n", domain.CurrentResultSchemaVersion, outdated)
+	if *dryRun || outdated == 0 {
+		return
+	}
+
+	upgrade := func(raw []byte) ([]byte, error) {
+		upgraded, _, err := domain.UpgradeResultJSON(raw)
+		return upgraded, err
+	}
+
+	var (
+		total  int
+		lastID uuid.UUID
+	)
+	for {
+		n, next, err := analyses.UpgradeResults(ctx, lastID, *batchSize, upgrade)
+		if err != nil {
+			log.Fatalf("Upgrade failed after %d rows: %v", total, err)
+		}
+		if n == 0 {
+			break
+		}
+		total += n
+		lastID = next
+		fmt.Printf("Upgraded %d/%d rows\n", total, outdated)
+
+		select {
+		case <-ctx.Done():
+			log.Fatalf("Interrupted after %d rows", total)
+		case <-time.After(*pause):
+		}
+	}
+
+	fmt.Printf("Done: %d rows upgraded to schema version %d\n", total, domain.CurrentResultSchemaVersion)
+}
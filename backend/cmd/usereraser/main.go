@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/retention"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// usereraser удаляет пользователя и все его данные (изображения, анализы, датасеты),
+// заменяет его в журнале аудита псевдонимом и сохраняет подтверждение удаления.
+// Манифест удалённых данных записывается в файл -manifest: его SHA-256 хранится
+// в подтверждении, сам манифест передаётся инициатору запроса на удаление.
+// С флагом -show выводит ранее сохранённые подтверждения для пользователя
+func main() {
+	userFlag := flag.String("user", "", "id of the user to erase")
+	requestedByFlag := flag.String("requested-by", "", "id of the administrator who requested the erasure")
+	reason := flag.String("reason", "", "erasure reason, e.g. a ticket reference")
+	manifestPath := flag.String("manifest", "", "file to write the erasure manifest to")
+	show := flag.Bool("show", false, "only print existing erasure records for the user")
+	flag.Parse()
+
+	userID, err := uuid.Parse(*userFlag)
+	if err != nil {
+		log.Fatalf("Invalid -user: %v", err)
+	}
+	var requestedBy *uuid.UUID
+	if *requestedByFlag != "" {
+		id, err := uuid.Parse(*requestedByFlag)
+		if err != nil {
+			log.Fatalf("Invalid -requested-by: %v", err)
+		}
+		requestedBy = &id
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := database.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	store, err := storage.NewLocal(cfg.StorageDir)
+	if err != nil {
+		log.Fatalf("Failed to init storage: %v", err)
+	}
+
+	svc := retention.NewService(db,
+		repository.NewOrganizationRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewRetentionRepository(db),
+		repository.NewAuditLogRepository(db),
+		store, cfg.Retention)
+
+	if *show {
+		erasures, err := svc.Erasures(ctx, userID)
+		if err != nil {
+			log.Fatalf("Failed to list erasures: %v", err)
+		}
+		printJSON(erasures)
+		return
+	}
+
+	if *manifestPath == "" {
+		log.Fatal("-manifest is required")
+	}
+	// Файл создаётся до удаления: после него манифест восстановить нельзя
+	f, err := os.OpenFile(*manifestPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Fatalf("Failed to create manifest file: %v", err)
+	}
+	defer f.Close()
+
+	erasure, manifest, err := svc.Erase(ctx, userID, requestedBy, *reason)
+	if err != nil {
+		os.Remove(*manifestPath)
+		log.Fatalf("Erasure failed: %v", err)
+	}
+	if _, err := f.Write(manifest); err != nil {
+		log.Printf("Failed to write manifest, printing it instead: %v", err)
+		fmt.Print(string(manifest))
+	}
+	printJSON(erasure)
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Fatalf("Failed to print result: %v", err)
+	}
+}
@@ -1 +1,178 @@
 package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/artifact"
+	"github.com/DedovInside/AutoInspect/backend/internal/batch"
+	"github.com/DedovInside/AutoInspect/backend/internal/canary"
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/evaluation"
+	"github.com/DedovInside/AutoInspect/backend/internal/fraud"
+	"github.com/DedovInside/AutoInspect/backend/internal/inference"
+	"github.com/DedovInside/AutoInspect/backend/internal/labor"
+	"github.com/DedovInside/AutoInspect/backend/internal/pricing"
+	"github.com/DedovInside/AutoInspect/backend/internal/redaction"
+	"github.com/DedovInside/AutoInspect/backend/internal/report"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/retention"
+	"github.com/DedovInside/AutoInspect/backend/internal/review"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/DedovInside/AutoInspect/backend/internal/webhook"
+	"github.com/DedovInside/AutoInspect/backend/internal/worker"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 1. Конфигурация и инфраструктура
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := database.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	store, err := storage.NewLocal(cfg.StorageDir)
+	if err != nil {
+		log.Fatalf("Failed to init storage: %v", err)
+	}
+
+	// Веса модели сверяются с дайджестами из реестра перед любым инференсом
+	verifier := artifact.NewVerifier(repository.NewArtifactRepository(db), store, cfg.Worker.ModelVerifyInterval)
+
+	backend, err := newInferenceBackend(cfg.Worker.InferenceBackend, store, verifier, cfg.Inference)
+	if err != nil {
+		log.Fatalf("Failed to init inference backend: %v", err)
+	}
+	// Сервер инференса может подняться позже воркера: анализы до этого повторяются по политике
+	if err := backend.Health(ctx); err != nil {
+		log.Printf("Inference backend %s is not ready: %v", cfg.Worker.InferenceBackend, err)
+	}
+	backend = artifact.NewBackend(backend, verifier)
+
+	// 2. Репозитории и сервисы
+
+	analyses := repository.NewAnalysisRepository(db)
+	models := repository.NewModelRepository(db)
+	reviews := repository.NewReviewRepository(db)
+	auditLogs := repository.NewAuditLogRepository(db)
+	batches := repository.NewBatchRepository(db)
+	webhooks := repository.NewWebhookRepository(db)
+	laborTimes := repository.NewLaborTimeRepository(db)
+	prices := repository.NewPricingRepository(db)
+	analytics := repository.NewAnalyticsRepository(db)
+	retentionRepo := repository.NewRetentionRepository(db)
+	canaryRules := repository.NewCanaryRepository(db)
+	shadows := repository.NewShadowRepository(db)
+	datasets := repository.NewDatasetRepository(db)
+	evaluations := repository.NewEvaluationRepository(db)
+
+	fraudSvc := fraud.NewService(analyses, store, cfg.Fraud)
+	laborSvc := labor.NewService(laborTimes, cfg.Labor)
+	pricingSvc := pricing.NewService(db, prices, analyses, cfg.Pricing)
+	// Стоимость считается по нормо-часам, поэтому pricing - после labor
+	reviewSvc := review.NewService(db, analyses, reviews, auditLogs, cfg.Review, laborSvc, pricingSvc)
+	batchSvc := batch.NewService(db, batches, analyses, canary.NewRouter(models, canaryRules), auditLogs, store, cfg.Batch, cfg.Webhook.AllowPrivateTargets)
+	webhookSvc := webhook.NewService(webhooks, analyses, batches, cfg.Webhook)
+	batchSvc.OnCompleted(webhookSvc.HandleBatchCompleted)
+
+	// 3. Воркер
+
+	var redactor *redaction.Redactor
+	if cfg.Redaction.ModelVersion != "" {
+		redactor = redaction.NewRedactor(models, analyses, backend, store, cfg.Redaction)
+	}
+	shadowRunner := worker.NewShadowRunner(shadows, models, store, backend, cfg.Worker.MinConfidence, cfg.Shadow)
+	processor := worker.NewInferenceProcessor(models, store, backend, redactor,
+		cfg.Worker.MinConfidence, laborSvc, pricingSvc)
+	w := worker.New(analyses, processor, cfg.Retry, cfg.Worker)
+
+	// Антифрод-проверка сразу после успешного анализа
+	w.AddHook(func(ctx context.Context, a *domain.Analysis) error {
+		if !a.IsCompleted() {
+			return nil
+		}
+		_, err := fraudSvc.Evaluate(ctx, a.ID)
+		return err
+	})
+	// Неуверенные результаты - в очередь ручной проверки
+	w.AddHook(reviewSvc.RouteIfNeeded)
+	// Уведомление клиента - после антифрода и маршрутизации на проверку
+	w.AddHook(webhookSvc.HandleAnalysisFinished)
+	// Последним - проверка завершения пакета, чтобы событие видело итоговое состояние анализа
+	w.AddHook(batchSvc.HandleAnalysisFinished)
+	// Теневой инференс - после всех хуков: уведомления не ждут кандидатов
+	w.AddHook(shadowRunner.HandleAnalysisFinished)
+
+	dispatcher := webhook.NewDispatcher(db, webhooks, analyses, cfg.Webhook)
+	refresher := report.NewRefresher(db, analytics, cfg.Analytics.RefreshInterval)
+	enforcer := retention.NewEnforcer(db, retentionRepo, store, cfg.Retention)
+	sweeper := batch.NewSweeper(batchSvc, cfg.Batch.SweepInterval)
+	evaluator := evaluation.NewRunner(db, evaluations, models, datasets, backend, store, cfg.Evaluation)
+
+	log.Printf("Worker started: concurrency=%d, backend=%s", cfg.Worker.Concurrency, cfg.Worker.InferenceBackend)
+	var wg sync.WaitGroup
+	wg.Add(6)
+	go func() {
+		defer wg.Done()
+		w.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		dispatcher.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		refresher.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		enforcer.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		evaluator.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		sweeper.Run(ctx)
+	}()
+	wg.Wait()
+	log.Println("Worker stopped")
+}
+
+// newInferenceBackend создаёт бэкенд инференса по имени из INFERENCE_BACKEND.
+// Бэкенд onnx сверяет с дайджестами байты, из которых создаёт сессию; http загружает веса
+// из репозитория моделей сервера инференса, и проверяется только копия в хранилище
+func newInferenceBackend(name string, store storage.ObjectStorage, verifier inference.ArtifactVerifier,
+	cfg config.InferenceConfig) (inference.Backend, error) {
+	switch name {
+	case "fake":
+		return inference.NewFake(), nil
+	case "http":
+		return inference.NewHTTP(cfg), nil
+	case "onnx":
+		b, err := inference.NewONNX(store, verifier, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("unknown inference backend %q", name)
+	}
+}
@@ -5,6 +5,5 @@ go 1.25.3
 require (
 	github.com/golang-migrate/migrate/v4 v4.19.1
 	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
 )
-
-require github.com/lib/pq v1.10.9 // indirect
@@ -0,0 +1,184 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/canary"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Service реализует постановку анализов в очередь и доступ к ним
+type Service struct {
+	analyses *repository.AnalysisRepository
+	router   *canary.Router
+	storage  storage.ObjectStorage
+
+	// Разрешены ли webhook_url на localhost и адреса внутренних сетей
+	allowPrivateWebhooks bool
+}
+
+// NewService создаёт сервис анализов
+func NewService(analyses *repository.AnalysisRepository, router *canary.Router, store storage.ObjectStorage,
+	allowPrivateWebhooks bool) *Service {
+	return &Service{analyses: analyses, router: router, storage: store, allowPrivateWebhooks: allowPrivateWebhooks}
+}
+
+// Create ставит в очередь анализ уже загруженного изображения
+func (s *Service) Create(ctx context.Context, user *domain.User, req domain.AnalysisCreateRequest) (*domain.Analysis, error) {
+	if err := ValidateImageKey(user.ID, req.ImageKey); err != nil {
+		return nil, err
+	}
+	if req.WebhookURL != nil {
+		if err := domain.ValidateWebhookURL(*req.WebhookURL, s.allowPrivateWebhooks); err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range []*string{req.CarMake, req.CarModel} {
+		if v != nil && len(*v) > 100 {
+			return nil, domain.InvalidInputf("car_make and car_model must be at most 100 characters")
+		}
+	}
+	if req.Region != nil {
+		region := strings.ToUpper(*req.Region)
+		if err := domain.ValidateRegion(region); err != nil {
+			return nil, err
+		}
+		req.Region = &region
+	}
+	route, err := s.router.Route(ctx, canary.Target{
+		OrganizationID: user.OrganizationID,
+		CarMake:        req.CarMake,
+		CarModel:       req.CarModel,
+		ModelVersion:   req.ModelVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a := &domain.Analysis{
+		UserID:           user.ID,
+		ImageKey:         req.ImageKey,
+		CarMake:          req.CarMake,
+		CarModel:         req.CarModel,
+		Region:           req.Region,
+		ModelVersion:     route.Model.Version,
+		ModelID:          &route.Model.ID,
+		ModelRoute:       &route.Route,
+		CanaryRuleID:     route.CanaryRuleID,
+		BaseRoute:        route.BaseRoute,
+		IncidentLocation: req.IncidentLocation,
+		WebhookURL:       req.WebhookURL,
+	}
+	if err := s.analyses.Create(ctx, nil, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Get возвращает анализ владельцу или администратору
+func (s *Service) Get(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.Analysis, error) {
+	a, err := s.analyses.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != user.ID && !user.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+	return a, nil
+}
+
+// Image открывает изображение анализа. Клиентам доступна только копия со скрытыми
+// номерами и лицами; оригинал - владельцам сервиса и администраторам.
+// Вызывающий обязан закрыть reader
+func (s *Service) Image(ctx context.Context, user *domain.User, id uuid.UUID, variant domain.ImageVariant) (io.ReadCloser, error) {
+	if !variant.IsValid() {
+		return nil, domain.InvalidInputf("variant must be one of: redacted, original")
+	}
+	if variant == domain.ImageVariantOriginal && !user.CanViewOriginalImages() {
+		return nil, domain.ErrForbidden
+	}
+	a, err := s.Get(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.ImageDeletedAt != nil {
+		return nil, repository.ErrNotFound
+	}
+
+	key := a.ImageKey
+	if variant == domain.ImageVariantRedacted {
+		if a.RedactedImageKey == nil {
+			return nil, domain.Conflictf("redacted image of analysis %s is not ready", a.ID)
+		}
+		key = *a.RedactedImageKey
+	}
+	rc, err := s.storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open image of analysis %s: %w", a.ID, err)
+	}
+	return rc, nil
+}
+
+// Search ищет завершённые анализы по дефектам. Клиенты видят только свои анализы
+func (s *Service) Search(ctx context.Context, user *domain.User, f domain.AnalysisSearchFilter) (*domain.AnalysisSearchPage, error) {
+	if err := validateSearch(&f); err != nil {
+		return nil, err
+	}
+	if !user.CanViewAllAnalyses() {
+		f.UserID = &user.ID
+	}
+
+	items, total, err := s.analyses.Search(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []domain.Analysis{}
+	}
+	return &domain.AnalysisSearchPage{Items: items, Total: total, Limit: f.Limit, Offset: f.Offset}, nil
+}
+
+func validateSearch(f *domain.AnalysisSearchFilter) error {
+	if f.Sort == "" {
+		f.Sort = domain.AnalysisSortNewest
+	}
+	if !f.Sort.IsValid() {
+		return domain.InvalidInputf("sort must be one of: -created_at, created_at, -total_defects, total_defects")
+	}
+	if f.DefectType != nil && !f.DefectType.IsValid() {
+		return domain.InvalidInputf("unknown defect type %q", *f.DefectType)
+	}
+	if f.Severity != nil && !f.Severity.IsValid() {
+		return domain.InvalidInputf("unknown severity %q", *f.Severity)
+	}
+	if f.MinConfidence != nil && (*f.MinConfidence < 0 || *f.MinConfidence > 1) {
+		return domain.InvalidInputf("min_confidence must be between 0 and 1")
+	}
+	if f.ViewAngle != nil && !slices.Contains(domain.ViewAngles, *f.ViewAngle) {
+		return domain.InvalidInputf("view_angle must be one of: %s", strings.Join(domain.ViewAngles, ", "))
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return domain.InvalidInputf("from must be before to")
+	}
+	return nil
+}
+
+// ValidateImageKey проверяет ключ изображения: изображения пользователя лежат в images/<user_id>/
+func ValidateImageKey(userID uuid.UUID, key string) error {
+	prefix := fmt.Sprintf("images/%s/", userID)
+	if !strings.HasPrefix(key, prefix) || len(key) == len(prefix) || len(key) > 500 {
+		return domain.InvalidInputf("invalid image key %q: expected %s<file>", key, prefix)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package artifact записывает дайджесты файлов моделей и сверяет с ними
+// файлы в хранилище, чтобы воркер не выполнял подменённые или повреждённые веса
+package artifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+)
+
+// Digest потоково считает SHA-256 и размер объекта хранилища
+func Digest(ctx context.Context, store storage.ObjectStorage, key string) (string, int64, error) {
+	rc, err := store.Get(ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, rc)
+	if err != nil {
+		return "", 0, fmt.Errorf("read %s: %w", key, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// Files возвращает файлы модели без дайджестов: веса и, если задан, конфиг
+func Files(m *domain.MLModel) []domain.ModelArtifact {
+	files := []domain.ModelArtifact{{ModelID: m.ID, Kind: domain.ArtifactKindWeights, Path: m.WeightsPath}}
+	if m.ConfigPath != nil {
+		files = append(files, domain.ModelArtifact{ModelID: m.ID, Kind: domain.ArtifactKindConfig, Path: *m.ConfigPath})
+	}
+	return files
+}
+
+// Collect считает дайджесты всех файлов модели
+func Collect(ctx context.Context, store storage.ObjectStorage, m *domain.MLModel) ([]domain.ModelArtifact, error) {
+	files := Files(m)
+	for i := range files {
+		sum, size, err := Digest(ctx, store, files[i].Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s artifact %s: %w", files[i].Kind, files[i].Path, err)
+		}
+		files[i].SHA256, files[i].SizeBytes = sum, size
+	}
+	return files, nil
+}
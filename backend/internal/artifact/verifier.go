@@ -0,0 +1,270 @@
+package artifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/inference"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// failedRecheck - через сколько перепроверять модель, не прошедшую проверку:
+// восстановленные файлы подхватываются без перезапуска воркера
+const failedRecheck = time.Minute
+
+// artifactStore - записанные дайджесты файлов моделей (repository.ArtifactRepository)
+type artifactStore interface {
+	ListByModel(ctx context.Context, modelID uuid.UUID) ([]domain.ModelArtifact, error)
+	MarkVerified(ctx context.Context, modelID uuid.UUID, kind domain.ArtifactKind, at time.Time, verificationError *string) error
+}
+
+// Verifier сверяет файлы моделей в хранилище с дайджестами, записанными при регистрации.
+// Итог проверки кэшируется, чтобы веса не перечитывались на каждый анализ.
+// Бэкенд onnx дополнительно сверяет через VerifyBytes байты, из которых создаёт сессию.
+// Бэкенд http (KServe) загружает веса из собственного репозитория моделей сервера инференса:
+// проверяется только копия в хранилище, а не файл, который выполняет сервер
+type Verifier struct {
+	artifacts artifactStore
+	store     storage.ObjectStorage
+	interval  time.Duration
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]*verification
+}
+
+// verification - кэшированный итог проверки модели. mu сериализует проверки одной модели,
+// чтобы параллельные обработчики не читали одни и те же веса одновременно
+type verification struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// NewVerifier создаёт проверку файлов моделей. interval - срок годности успешной проверки
+func NewVerifier(artifacts *repository.ArtifactRepository, store storage.ObjectStorage, interval time.Duration) *Verifier {
+	return &Verifier{artifacts: artifacts, store: store, interval: interval, cache: make(map[uuid.UUID]*verification)}
+}
+
+// Verify проверяет, что модель можно выполнять. Несовпадение дайджеста, размера или пути
+// и отсутствие файла возвращаются как inference.ErrIntegrity. Модели, зарегистрированные
+// до появления проверок (без записанных дайджестов), проходят проверку
+func (v *Verifier) Verify(ctx context.Context, m *domain.MLModel) error {
+	v.mu.Lock()
+	e, ok := v.cache[m.ID]
+	if !ok {
+		e = &verification{}
+		v.cache[m.ID] = e
+	}
+	v.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ttl := v.interval
+	if e.err != nil {
+		ttl = min(ttl, failedRecheck)
+	}
+	if !e.checkedAt.IsZero() && time.Since(e.checkedAt) < ttl {
+		return e.err
+	}
+
+	checks, err := v.Check(ctx, m)
+	if err != nil {
+		return err
+	}
+	for _, c := range checks {
+		if c.IsCorrupt() {
+			e.checkedAt = time.Now()
+			e.err = fmt.Errorf("model %s %s artifact %s is %s: %s: %w",
+				m.Version, c.Kind, c.Path, c.Status, c.Error, inference.ErrIntegrity)
+			log.Printf("artifact: %v", e.err)
+			return e.err
+		}
+	}
+	// Сбой хранилища - не итог проверки: он не кэшируется, анализ повторяется по политике
+	for _, c := range checks {
+		if c.Status == domain.ArtifactCheckError {
+			return fmt.Errorf("verify model %s %s artifact: %s: %w", m.Version, c.Kind, c.Error, inference.ErrUnavailable)
+		}
+	}
+	e.checkedAt, e.err = time.Now(), nil
+	return nil
+}
+
+// Forget сбрасывает кэшированный итог проверки модели: следующий Verify перечитает файлы
+func (v *Verifier) Forget(modelID uuid.UUID) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.cache, modelID)
+}
+
+// Check сверяет каждый файл модели с записанным дайджестом и записывает итог в model_artifacts.
+// Ошибка возвращается, только если не удалось прочитать записанные дайджесты
+func (v *Verifier) Check(ctx context.Context, m *domain.MLModel) ([]domain.ArtifactCheck, error) {
+	recorded, err := v.artifacts.ListByModel(ctx, m.ID)
+	if err != nil {
+		return nil, err
+	}
+	byKind := make(map[domain.ArtifactKind]domain.ModelArtifact, len(recorded))
+	for _, a := range recorded {
+		byKind[a.Kind] = a
+	}
+
+	files := Files(m)
+	checks := make([]domain.ArtifactCheck, 0, len(files))
+	for _, f := range files {
+		c := domain.ArtifactCheck{ModelID: m.ID, ModelVersion: m.Version, Kind: f.Kind, Path: f.Path}
+		a, ok := byKind[f.Kind]
+		if !ok {
+			c.Status = domain.ArtifactCheckUnrecorded
+			checks = append(checks, c)
+			continue
+		}
+		v.checkOne(ctx, &c, &a)
+		checks = append(checks, c)
+		v.record(ctx, &c)
+	}
+	return checks, nil
+}
+
+// VerifyBytes реализует inference.ArtifactVerifier: сверяет уже прочитанный файл модели с записанным
+// дайджестом. Несовпадение записывается в model_artifacts и кэшируется как итог проверки модели.
+// Файлы без записанного дайджеста (модели до появления проверок) проходят проверку
+func (v *Verifier) VerifyBytes(ctx context.Context, m *domain.MLModel, kind domain.ArtifactKind, path string, data []byte) error {
+	recorded, err := v.artifacts.ListByModel(ctx, m.ID)
+	if err != nil {
+		return fmt.Errorf("verify model %s %s artifact: %v: %w", m.Version, kind, err, inference.ErrUnavailable)
+	}
+	i := slices.IndexFunc(recorded, func(a domain.ModelArtifact) bool { return a.Kind == kind })
+	if i < 0 {
+		return nil
+	}
+
+	c := domain.ArtifactCheck{ModelID: m.ID, ModelVersion: m.Version, Kind: kind, Path: path}
+	if samePath(&c, &recorded[i]) {
+		sum := sha256.Sum256(data)
+		compare(&c, hex.EncodeToString(sum[:]), int64(len(data)))
+	}
+	v.record(ctx, &c)
+	if !c.IsCorrupt() {
+		return nil
+	}
+
+	err = fmt.Errorf("model %s %s artifact %s is %s: %s: %w", m.Version, kind, path, c.Status, c.Error, inference.ErrIntegrity)
+	log.Printf("artifact: %v", err)
+	v.mu.Lock()
+	v.cache[m.ID] = &verification{checkedAt: time.Now(), err: err}
+	v.mu.Unlock()
+	return err
+}
+
+// record записывает итог проверки файла в model_artifacts. Сбои чтения не записываются
+func (v *Verifier) record(ctx context.Context, c *domain.ArtifactCheck) {
+	if c.Status == domain.ArtifactCheckError || c.Status == domain.ArtifactCheckUnrecorded {
+		return
+	}
+	var verificationError *string
+	if c.Error != "" {
+		verificationError = &c.Error
+	}
+	if err := v.artifacts.MarkVerified(ctx, c.ModelID, c.Kind, time.Now(), verificationError); err != nil {
+		log.Printf("artifact: %v", err)
+	}
+}
+
+// checkOne сверяет файл из хранилища с записанным дайджестом a и заполняет итог c
+func (v *Verifier) checkOne(ctx context.Context, c *domain.ArtifactCheck, a *domain.ModelArtifact) {
+	if !samePath(c, a) {
+		return
+	}
+
+	sum, size, err := Digest(ctx, v.store, a.Path)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		c.Status = domain.ArtifactCheckMissing
+		c.Error = "file not found in storage"
+	case err != nil:
+		c.Status = domain.ArtifactCheckError
+		c.Error = err.Error()
+	default:
+		compare(c, sum, size)
+	}
+}
+
+// samePath заполняет ожидаемые значения c из записи a и проверяет, что путь файла не сменился
+// после регистрации: иначе выполнялся бы не тот файл, что был проверен
+func samePath(c *domain.ArtifactCheck, a *domain.ModelArtifact) bool {
+	c.ExpectedSHA256, c.ExpectedSize = a.SHA256, a.SizeBytes
+	if c.Path != a.Path {
+		c.Status = domain.ArtifactCheckMismatch
+		c.Error = fmt.Sprintf("path changed since registration (recorded %s)", a.Path)
+		return false
+	}
+	return true
+}
+
+// compare сравнивает дайджест и размер файла с ожидаемыми и заполняет итог c
+func compare(c *domain.ArtifactCheck, sum string, size int64) {
+	c.ActualSHA256, c.ActualSize = sum, size
+	switch {
+	case size != c.ExpectedSize:
+		c.Status = domain.ArtifactCheckMismatch
+		c.Error = fmt.Sprintf("size %d, recorded %d", size, c.ExpectedSize)
+	case sum != c.ExpectedSHA256:
+		c.Status = domain.ArtifactCheckMismatch
+		c.Error = "sha256 digest differs from the recorded one"
+	default:
+		c.Status = domain.ArtifactCheckOK
+	}
+}
+
+// Backend проверяет файлы модели перед каждым вызовом вложенного бэкенда инференса.
+// Через него идут основной, теневой инференс и редактирование изображений.
+// Для бэкенда http проверка подтверждает только копию весов в хранилище: сервер инференса
+// загружает модель из своего репозитория моделей, и эти байты здесь не проверяются
+
+type Backend struct {
+	next     inference.Backend
+	verifier *Verifier
+}
+
+// NewBackend оборачивает бэкенд инференса проверкой файлов моделей
+func NewBackend(next inference.Backend, verifier *Verifier) *Backend {
+	return &Backend{next: next, verifier: verifier}
+}
+
+// Load загружает модель, только если её файлы совпадают с записанными дайджестами
+func (b *Backend) Load(ctx context.Context, model *domain.MLModel) error {
+	if err := b.verifier.Verify(ctx, model); err != nil {
+		return err
+	}
+	return b.next.Load(ctx, model)
+}
+
+// Health возвращает готовность вложенного бэкенда
+func (b *Backend) Health(ctx context.Context) error {
+	return b.next.Health(ctx)
+}
+
+// Unload выгружает модель. Перед следующей загрузкой файлы модели проверяются заново
+func (b *Backend) Unload(ctx context.Context, modelID uuid.UUID) error {
+	b.verifier.Forget(modelID)
+	return b.next.Unload(ctx, modelID)
+}
+
+// Infer выполняет модель, только если её файлы совпадают с записанными дайджестами
+func (b *Backend) Infer(ctx context.Context, model *domain.MLModel, image []byte) (*inference.Prediction, error) {
+	if err := b.verifier.Verify(ctx, model); err != nil {
+		return nil, err
+	}
+	return b.next.Infer(ctx, model, image)
+}
@@ -0,0 +1,170 @@
+package artifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/inference"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// memArtifacts - записанные дайджесты в памяти вместо model_artifacts
+type memArtifacts struct {
+	mu       sync.Mutex
+	items    []domain.ModelArtifact
+	listErr  error
+	verified map[domain.ArtifactKind]*string
+}
+
+func (m *memArtifacts) ListByModel(_ context.Context, modelID uuid.UUID) ([]domain.ModelArtifact, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	var items []domain.ModelArtifact
+	for _, a := range m.items {
+		if a.ModelID == modelID {
+			items = append(items, a)
+		}
+	}
+	return items, nil
+}
+
+func (m *memArtifacts) MarkVerified(_ context.Context, _ uuid.UUID, kind domain.ArtifactKind, _ time.Time, verificationError *string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.verified[kind] = verificationError
+	return nil
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// newTestVerifier регистрирует модель с весами weights в хранилище и записывает их дайджест
+func newTestVerifier(t *testing.T, weights string) (*Verifier, *memArtifacts, storage.ObjectStorage, *domain.MLModel) {
+	t.Helper()
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &domain.MLModel{ID: uuid.New(), Version: "1.0.0", WeightsPath: "models/1.0.0/model.onnx"}
+	if err := store.Put(context.Background(), m.WeightsPath, strings.NewReader(weights), "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+	artifacts := &memArtifacts{
+		items: []domain.ModelArtifact{{
+			ModelID: m.ID, Kind: domain.ArtifactKindWeights, Path: m.WeightsPath,
+			SHA256: sha256Hex(weights), SizeBytes: int64(len(weights)),
+		}},
+		verified: make(map[domain.ArtifactKind]*string),
+	}
+	v := &Verifier{artifacts: artifacts, store: store, interval: time.Hour, cache: make(map[uuid.UUID]*verification)}
+	return v, artifacts, store, m
+}
+
+func TestVerifierVerifyBytes(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		path      string
+		wantErr   error
+		wantError string // записанная причина несовпадения, "" - файл цел
+	}{
+		{name: "recorded bytes", data: "weights-v1"},
+		{name: "same size, different bytes", data: "weights-v2", wantErr: inference.ErrIntegrity, wantError: "sha256"},
+		{name: "different size", data: "weights", wantErr: inference.ErrIntegrity, wantError: "size 7, recorded 10"},
+		{name: "path changed", data: "weights-v1", path: "models/other.onnx", wantErr: inference.ErrIntegrity, wantError: "path changed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, artifacts, _, m := newTestVerifier(t, "weights-v1")
+			path := m.WeightsPath
+			if tt.path != "" {
+				path = tt.path
+			}
+
+			err := v.VerifyBytes(context.Background(), m, domain.ArtifactKindWeights, path, []byte(tt.data))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("VerifyBytes = %v, want %v", err, tt.wantErr)
+			}
+
+			recorded, ok := artifacts.verified[domain.ArtifactKindWeights]
+			if !ok {
+				t.Fatal("verification result was not recorded")
+			}
+			switch {
+			case tt.wantError == "" && recorded != nil:
+				t.Errorf("recorded verification error %q, want none", *recorded)
+			case tt.wantError != "" && (recorded == nil || !strings.Contains(*recorded, tt.wantError)):
+				t.Errorf("recorded verification error %v, want one about %q", recorded, tt.wantError)
+			}
+
+			// Несовпадение кэшируется: модель не выполняется и через проверку хранилища
+			if tt.wantErr != nil {
+				if err := v.Verify(context.Background(), m); !errors.Is(err, inference.ErrIntegrity) {
+					t.Errorf("Verify after failed VerifyBytes = %v, want ErrIntegrity", err)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifierVerifyBytesUnrecorded(t *testing.T) {
+	v, artifacts, _, m := newTestVerifier(t, "weights-v1")
+	config := "models/1.0.0/config.json"
+	if err := v.VerifyBytes(context.Background(), m, domain.ArtifactKindConfig, config, []byte(`{}`)); err != nil {
+		t.Errorf("VerifyBytes of an unrecorded file = %v, want nil", err)
+	}
+	if len(artifacts.verified) != 0 {
+		t.Errorf("unrecorded file was marked verified: %v", artifacts.verified)
+	}
+
+	artifacts.listErr = errors.New("connection refused")
+	err := v.VerifyBytes(context.Background(), m, domain.ArtifactKindWeights, m.WeightsPath, []byte("weights-v1"))
+	if !errors.Is(err, inference.ErrUnavailable) {
+		t.Errorf("VerifyBytes with failing repository = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestVerifierVerify(t *testing.T) {
+	ctx := context.Background()
+	v, _, store, m := newTestVerifier(t, "weights-v1")
+	if err := v.Verify(ctx, m); err != nil {
+		t.Fatalf("Verify of intact weights = %v", err)
+	}
+
+	// Успешная проверка кэшируется: подмена файла видна только после Forget
+	if err := store.Put(ctx, m.WeightsPath, strings.NewReader("weights-v2"), "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(ctx, m); err != nil {
+		t.Fatalf("cached Verify = %v", err)
+	}
+	v.Forget(m.ID)
+	if err := v.Verify(ctx, m); !errors.Is(err, inference.ErrIntegrity) {
+		t.Errorf("Verify of swapped weights = %v, want ErrIntegrity", err)
+	}
+
+	if err := store.Delete(ctx, m.WeightsPath); err != nil {
+		t.Fatal(err)
+	}
+	v.Forget(m.ID)
+	checks, err := v.Check(ctx, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 1 || checks[0].Status != domain.ArtifactCheckMissing {
+		t.Errorf("Check of deleted weights = %+v, want missing", checks)
+	}
+}
@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+type ctxKey struct{}
+
+// WithUser возвращает контекст с аутентифицированным пользователем
+func WithUser(ctx context.Context, u *domain.User) context.Context {
+	return context.WithValue(ctx, ctxKey{}, u)
+}
+
+// UserFromContext возвращает пользователя текущего запроса
+func UserFromContext(ctx context.Context) (*domain.User, bool) {
+	u, ok := ctx.Value(ctxKey{}).(*domain.User)
+	return u, ok && u != nil
+}
@@ -0,0 +1,316 @@
+package batch
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/analysis"
+	"github.com/DedovInside/AutoInspect/backend/internal/canary"
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// CompletionListener вызывается один раз, когда все анализы пакета перешли в конечный статус.
+// Вызывается в транзакции q, отмечающей пакет завершённым: ошибка обработчика откатывает
+// отметку, и событие повторится при следующей проверке. После повтора пакета (Retry)
+// событие может сработать снова
+type CompletionListener func(ctx context.Context, q repository.Querier, b *domain.AnalysisBatch) error
+
+// Service реализует пакетную отправку анализов
+type Service struct {
+	db        *sql.DB
+	batches   *repository.BatchRepository
+	analyses  *repository.AnalysisRepository
+	router    *canary.Router
+	audit     *repository.AuditLogRepository
+	storage   storage.ObjectStorage
+	cfg       config.BatchConfig
+	listeners []CompletionListener
+
+	// Разрешены ли webhook_url на localhost и адреса внутренних сетей
+	allowPrivateWebhooks bool
+}
+
+// NewService создаёт сервис пакетов
+func NewService(db *sql.DB, batches *repository.BatchRepository, analyses *repository.AnalysisRepository,
+	router *canary.Router, audit *repository.AuditLogRepository,
+	store storage.ObjectStorage, cfg config.BatchConfig, allowPrivateWebhooks bool) *Service {
+	return &Service{
+		db: db, batches: batches, analyses: analyses, router: router,
+		audit: audit, storage: store, cfg: cfg, allowPrivateWebhooks: allowPrivateWebhooks,
+	}
+}
+
+// OnCompleted регистрирует обработчик события завершения пакета
+func (s *Service) OnCompleted(l CompletionListener) {
+	s.listeners = append(s.listeners, l)
+}
+
+// Create создаёт пакет по ключам уже загруженных изображений: по анализу на каждый ключ
+func (s *Service) Create(ctx context.Context, user *domain.User, req domain.BatchCreateRequest) (*domain.AnalysisBatch, error) {
+	if err := s.validateKeys(user.ID, req.ImageKeys); err != nil {
+		return nil, err
+	}
+	if err := validateName(req.Name); err != nil {
+		return nil, err
+	}
+	region, err := validateVehicle(req.CarMake, req.CarModel, req.Region)
+	if err != nil {
+		return nil, err
+	}
+	req.Region = region
+	if req.WebhookURL != nil {
+		if err := domain.ValidateWebhookURL(*req.WebhookURL, s.allowPrivateWebhooks); err != nil {
+			return nil, err
+		}
+	}
+	return s.create(ctx, user, domain.NewUUID(), req)
+}
+
+// create создаёт пакет. Модель выбирается для каждого анализа отдельно (канареечные правила),
+// в пакете записывается версия контрольной модели
+func (s *Service) create(ctx context.Context, user *domain.User, batchID uuid.UUID, req domain.BatchCreateRequest) (*domain.AnalysisBatch, error) {
+	routes, err := s.router.RouteN(ctx, canary.Target{
+		OrganizationID: user.OrganizationID,
+		CarMake:        req.CarMake,
+		CarModel:       req.CarModel,
+		ModelVersion:   req.ModelVersion,
+	}, len(req.ImageKeys))
+	if err != nil {
+		return nil, err
+	}
+
+	b := &domain.AnalysisBatch{
+		ID:           batchID,
+		UserID:       user.ID,
+		Name:         req.Name,
+		ModelVersion: batchModelVersion(routes),
+		Status:       domain.BatchStatusProcessing,
+		Progress:     domain.BatchProgress{Total: len(req.ImageKeys)},
+		WebhookURL:   req.WebhookURL,
+	}
+	err = database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.batches.Create(ctx, tx, b); err != nil {
+			return err
+		}
+		return s.analyses.CreateBatchItems(ctx, tx, b, req, routes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("batch %s: created with %d analyses (model %s)", b.ID, len(req.ImageKeys), b.ModelVersion)
+	return s.batches.GetByID(ctx, b.ID)
+}
+
+// batchModelVersion возвращает версию модели, не являющейся кандидатом: если правило
+// отправило на кандидата все анализы пакета - версию кандидата
+func batchModelVersion(routes []domain.RoutingDecision) string {
+	for _, d := range routes {
+		if d.Route != domain.ModelRouteCanary {
+			return d.Model.Version
+		}
+	}
+	return routes[0].Model.Version
+}
+
+// Get возвращает пакет с текущим прогрессом
+func (s *Service) Get(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.AnalysisBatch, error) {
+	b, err := s.batches.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !b.IsOwnedBy(user.ID) && !user.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+	return b, nil
+}
+
+// List возвращает пакеты пользователя
+func (s *Service) List(ctx context.Context, user *domain.User, limit, offset int) ([]domain.AnalysisBatch, error) {
+	return s.batches.ListByUser(ctx, user.ID, limit, offset)
+}
+
+// Items возвращает анализы пакета, при необходимости только с заданным статусом
+func (s *Service) Items(ctx context.Context, user *domain.User, id uuid.UUID, status *domain.AnalysisStatus,
+	limit, offset int) ([]domain.Analysis, error) {
+	if status != nil && !status.IsValid() {
+		return nil, domain.InvalidInputf("unknown status %q", *status)
+	}
+	if _, err := s.Get(ctx, user, id); err != nil {
+		return nil, err
+	}
+	return s.analyses.ListByBatch(ctx, id, status, limit, offset)
+}
+
+// Cancel отменяет анализы пакета, ещё не взятые в обработку.
+// Анализы в обработке завершаются как обычно, после чего пакет считается завершённым.
+// Статус проверяется под блокировкой строки пакета, чтобы не перезаписать параллельное завершение
+func (s *Service) Cancel(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.AnalysisBatch, error) {
+	if _, err := s.Get(ctx, user, id); err != nil {
+		return nil, err
+	}
+
+	err := database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		status, err := s.batches.GetStatusForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if status != domain.BatchStatusProcessing {
+			return domain.Conflictf("batch is already %s", status)
+		}
+		n, err := s.analyses.CancelQueuedInBatch(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.batches.SetStatus(ctx, tx, id, domain.BatchStatusCancelled); err != nil {
+			return err
+		}
+		return s.writeAudit(ctx, tx, &user.ID, domain.AuditActionBatchCancelled, id, domain.AuditDetails{"cancelled": n})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.checkCompletion(ctx, id)
+	return s.batches.GetByID(ctx, id)
+}
+
+// Retry возвращает в очередь отменённые анализы пакета и анализы с временными ошибками.
+// Анализы с постоянными ошибками (повреждённый файл и т.п.) не повторяются
+func (s *Service) Retry(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.AnalysisBatch, error) {
+	if _, err := s.Get(ctx, user, id); err != nil {
+		return nil, err
+	}
+
+	err := database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		n, err := s.analyses.RequeueBatchFailures(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return domain.Conflictf("batch has no retryable analyses")
+		}
+		if err := s.batches.SetStatus(ctx, tx, id, domain.BatchStatusProcessing); err != nil {
+			return err
+		}
+		return s.writeAudit(ctx, tx, &user.ID, domain.AuditActionBatchRetried, id, domain.AuditDetails{"requeued": n})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.batches.GetByID(ctx, id)
+}
+
+// HandleAnalysisFinished проверяет, не завершился ли пакет анализа. Используется как хук воркера
+func (s *Service) HandleAnalysisFinished(ctx context.Context, a *domain.Analysis) error {
+	if a.BatchID == nil {
+		return nil
+	}
+	s.checkCompletion(ctx, *a.BatchID)
+	return nil
+}
+
+// checkCompletion переводит пакет в завершённые и рассылает событие. Отметка, запись аудита
+// и обработчики выполняются в одной транзакции; событие получает только тот вызов,
+// который фактически отметил пакет (см. MarkCompleted)
+func (s *Service) checkCompletion(ctx context.Context, id uuid.UUID) {
+	var b *domain.AnalysisBatch
+	err := database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		var err error
+		b, err = s.batches.MarkCompleted(ctx, tx, id)
+		if err != nil || b == nil {
+			return err
+		}
+
+		p := b.Progress
+		details := domain.AuditDetails{
+			"status": string(b.Status), "total": p.Total,
+			"completed": p.Completed, "failed": p.Failed, "cancelled": p.Cancelled,
+		}
+		if err := s.writeAudit(ctx, tx, nil, domain.AuditActionBatchCompleted, id, details); err != nil {
+			return err
+		}
+		for _, l := range s.listeners {
+			if err := l(ctx, tx, b); err != nil {
+				return fmt.Errorf("completion listener: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("batch %s: %v", id, err)
+		return
+	}
+	if b == nil {
+		return
+	}
+	p := b.Progress
+	log.Printf("batch %s: finished (%s), completed=%d failed=%d cancelled=%d",
+		id, b.Status, p.Completed, p.Failed, p.Cancelled)
+}
+
+func (s *Service) writeAudit(ctx context.Context, q repository.Querier, userID *uuid.UUID, action string,
+	batchID uuid.UUID, details domain.AuditDetails) error {
+	entity := domain.AuditEntityBatch
+	return s.audit.Create(ctx, q, domain.AuditLogCreateRequest{
+		UserID:     userID,
+		Action:     action,
+		EntityType: &entity,
+		EntityID:   &batchID,
+		Details:    &details,
+	})
+}
+
+// validateKeys проверяет число и уникальность ключей изображений пакета
+func (s *Service) validateKeys(userID uuid.UUID, keys []string) error {
+	if len(keys) == 0 {
+		return domain.InvalidInputf("image_keys must not be empty")
+	}
+	if len(keys) > s.cfg.MaxItems {
+		return domain.InvalidInputf("batch is limited to %d images, got %d", s.cfg.MaxItems, len(keys))
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if err := analysis.ValidateImageKey(userID, k); err != nil {
+			return err
+		}
+		if seen[k] {
+			return domain.InvalidInputf("duplicate image key %q", k)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+func validateName(name *string) error {
+	if name != nil && len(*name) > 255 {
+		return domain.InvalidInputf("name must be at most 255 characters")
+	}
+	return nil
+}
+
+// validateVehicle проверяет марку, модель и регион автомобиля пакета и возвращает регион
+// в верхнем регистре
+func validateVehicle(carMake, carModel, region *string) (*string, error) {
+	for _, v := range []*string{carMake, carModel} {
+		if v != nil && len(*v) > 100 {
+			return nil, domain.InvalidInputf("car_make and car_model must be at most 100 characters")
+		}
+	}
+	if region == nil {
+		return nil, nil
+	}
+	r := strings.ToUpper(*region)
+	if err := domain.ValidateRegion(r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
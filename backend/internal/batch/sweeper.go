@@ -0,0 +1,55 @@
+package batch
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// sweepLimit - число пакетов, проверяемых за один проход
+const sweepLimit = 500
+
+// Sweeper периодически отмечает завершёнными пакеты, все анализы которых уже в конечном статусе.
+// Страхует проверку из хука воркера: она не выполняется, если хук не уложился в таймаут,
+// получил ошибку БД или воркер остановился между сохранением анализа и хуком.
+// Повторная проверка безопасна: событие получает только вызов, отметивший пакет
+type Sweeper struct {
+	svc      *Service
+	interval time.Duration
+}
+
+// NewSweeper создаёт планировщик проверки завершения пакетов
+func NewSweeper(svc *Service, interval time.Duration) *Sweeper {
+	return &Sweeper{svc: svc, interval: interval}
+}
+
+// Run проверяет пакеты сразу и затем каждые interval до отмены ctx
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("sweep batches: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep отмечает завершёнными пакеты без анализов в очереди и в обработке
+func (s *Sweeper) Sweep(ctx context.Context) error {
+	ids, err := s.svc.batches.ListFinishable(ctx, sweepLimit)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.svc.checkCompletion(ctx, id)
+	}
+	return nil
+}
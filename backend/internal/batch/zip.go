@@ -0,0 +1,166 @@
+package batch
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// maxImageBytes - максимальный размер одного изображения в архиве после распаковки
+const maxImageBytes = 32 << 20
+
+// imageContentTypes - поддерживаемые расширения изображений в архиве
+var imageContentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+}
+
+// ZipRequest описывает пакет, загружаемый одним zip-архивом
+type ZipRequest struct {
+	Name         *string
+	ModelVersion *string
+	CarMake      *string
+	CarModel     *string
+	Region       *string
+	WebhookURL   *string
+	Archive      io.Reader // ограничение размера - на стороне вызывающего
+}
+
+// CreateFromZip распаковывает изображения из архива в хранилище
+// (images/<user_id>/batches/<batch_id>/...) и создаёт по анализу на каждое.
+// Файлы, не являющиеся изображениями, и служебные каталоги пропускаются
+func (s *Service) CreateFromZip(ctx context.Context, user *domain.User, req ZipRequest) (*domain.AnalysisBatch, error) {
+	if err := validateName(req.Name); err != nil {
+		return nil, err
+	}
+	region, err := validateVehicle(req.CarMake, req.CarModel, req.Region)
+	if err != nil {
+		return nil, err
+	}
+	if req.WebhookURL != nil {
+		if err := domain.ValidateWebhookURL(*req.WebhookURL, s.allowPrivateWebhooks); err != nil {
+			return nil, err
+		}
+	}
+
+	tmp, err := os.CreateTemp("", "batch-upload-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, req.Archive)
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return nil, domain.InvalidInputf("invalid zip archive: %v", err)
+	}
+
+	var images []*zip.File
+	for _, f := range zr.File {
+		if isImageEntry(f) {
+			images = append(images, f)
+		}
+	}
+	if len(images) == 0 {
+		return nil, domain.InvalidInputf("archive contains no jpg or png images")
+	}
+	if len(images) > s.cfg.MaxItems {
+		return nil, domain.InvalidInputf("batch is limited to %d images, got %d", s.cfg.MaxItems, len(images))
+	}
+
+	batchID := domain.NewUUID()
+	keys := make([]string, 0, len(images))
+	cleanup := func() {
+		for _, k := range keys {
+			if err := s.storage.Delete(context.WithoutCancel(ctx), k); err != nil {
+				log.Printf("batch %s: delete %s: %v", batchID, k, err)
+			}
+		}
+	}
+
+	for i, f := range images {
+		key := fmt.Sprintf("images/%s/batches/%s/%04d_%s", user.ID, batchID, i+1, safeName(path.Base(f.Name)))
+		if err := s.putEntry(ctx, f, key); err != nil {
+			cleanup()
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	b, err := s.create(ctx, user, batchID, domain.BatchCreateRequest{
+		Name:         req.Name,
+		ImageKeys:    keys,
+		ModelVersion: req.ModelVersion,
+		CarMake:      req.CarMake,
+		CarModel:     req.CarModel,
+		Region:       region,
+		WebhookURL:   req.WebhookURL,
+	})
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *Service) putEntry(ctx context.Context, f *zip.File, key string) error {
+	if f.UncompressedSize64 > maxImageBytes {
+		return domain.InvalidInputf("%s exceeds %d MB", f.Name, maxImageBytes>>20)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return domain.InvalidInputf("%s: %v", f.Name, err)
+	}
+	defer rc.Close()
+
+	// Заголовок архива может врать о размере: читаем не больше лимита
+	lr := &io.LimitedReader{R: rc, N: maxImageBytes + 1}
+	contentType := imageContentTypes[strings.ToLower(path.Ext(f.Name))]
+	if err := s.storage.Put(ctx, key, lr, contentType); err != nil {
+		if errors.Is(err, zip.ErrChecksum) || errors.Is(err, zip.ErrFormat) {
+			return domain.InvalidInputf("%s: %v", f.Name, err)
+		}
+		return fmt.Errorf("upload %s: %w", f.Name, err)
+	}
+	if lr.N == 0 {
+		_ = s.storage.Delete(context.WithoutCancel(ctx), key)
+		return domain.InvalidInputf("%s exceeds %d MB", f.Name, maxImageBytes>>20)
+	}
+	return nil
+}
+
+// isImageEntry отбирает изображения, пропуская каталоги и служебные файлы (__MACOSX, .DS_Store)
+func isImageEntry(f *zip.File) bool {
+	if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") || strings.HasPrefix(path.Base(f.Name), ".") {
+		return false
+	}
+	_, ok := imageContentTypes[strings.ToLower(path.Ext(f.Name))]
+	return ok
+}
+
+// safeName оставляет в имени файла только латиницу, цифры, точку, дефис и подчёркивание
+func safeName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
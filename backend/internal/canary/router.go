@@ -0,0 +1,145 @@
+// Package canary выбирает модель для новых анализов: указанную клиентом,
+// специализированную для автомобиля, активную или модель-кандидата по канареечным правилам
+package canary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Target описывает анализ, для которого выбирается модель
+type Target struct {
+	OrganizationID *uuid.UUID // организация пользователя
+	CarMake        *string
+	CarModel       *string
+	ModelVersion   *string // версия, явно указанная клиентом
+}
+
+// Router выбирает модель для новых анализов. Базовая модель - лучшая готовая модель
+// для марки и модели автомобиля, иначе активная. Канареечные правила распределяют
+// анализы между базовой моделью и кандидатом
+type Router struct {
+	models *repository.ModelRepository
+	rules  *repository.CanaryRepository
+}
+
+// NewRouter создаёт маршрутизатор моделей
+func NewRouter(models *repository.ModelRepository, rules *repository.CanaryRepository) *Router {
+	return &Router{models: models, rules: rules}
+}
+
+// Route выбирает модель для одного анализа
+func (r *Router) Route(ctx context.Context, t Target) (domain.RoutingDecision, error) {
+	decisions, err := r.RouteN(ctx, t, 1)
+	if err != nil {
+		return domain.RoutingDecision{}, err
+	}
+	return decisions[0], nil
+}
+
+// RouteN выбирает модель для n анализов с одинаковой целью (пакет).
+// Доля кандидата разыгрывается для каждого анализа отдельно. При совпадении правила
+// причина выбора базовой модели сохраняется в BaseRoute
+func (r *Router) RouteN(ctx context.Context, t Target, n int) ([]domain.RoutingDecision, error) {
+	decisions := make([]domain.RoutingDecision, n)
+	if t.ModelVersion != nil {
+		m, err := r.models.GetByVersion(ctx, *t.ModelVersion)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, domain.InvalidInputf("model version %q not found", *t.ModelVersion)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if m.Status == domain.ModelStatusTraining {
+			return nil, domain.InvalidInputf("model version %q is still training", *t.ModelVersion)
+		}
+		for i := range decisions {
+			decisions[i] = domain.RoutingDecision{Model: m, Route: domain.ModelRoutePinned}
+		}
+		return decisions, nil
+	}
+
+	active, err := r.models.GetActive(ctx)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, domain.Conflictf("no active model")
+	}
+	if err != nil {
+		return nil, err
+	}
+	base, baseRoute, err := r.baseModel(ctx, t, active)
+	if err != nil {
+		return nil, err
+	}
+	rule, candidate, err := r.match(ctx, t, base)
+	if err != nil {
+		return nil, err
+	}
+	for i := range decisions {
+		switch {
+		case rule == nil:
+			decisions[i] = domain.RoutingDecision{Model: base, Route: baseRoute}
+		case rand.Float64()*100 < rule.Percent:
+			decisions[i] = domain.RoutingDecision{
+				Model: candidate, Route: domain.ModelRouteCanary, CanaryRuleID: &rule.ID, BaseRoute: &baseRoute,
+			}
+		default:
+			decisions[i] = domain.RoutingDecision{
+				Model: base, Route: domain.ModelRouteControl, CanaryRuleID: &rule.ID, BaseRoute: &baseRoute,
+			}
+		}
+	}
+	return decisions, nil
+}
+
+// baseModel возвращает специализированную модель для автомобиля цели или активную модель
+func (r *Router) baseModel(ctx context.Context, t Target, active *domain.MLModel) (*domain.MLModel, domain.ModelRoute, error) {
+	if t.CarMake == nil {
+		return active, domain.ModelRouteActive, nil
+	}
+	m, err := r.models.FindForVehicle(ctx, *t.CarMake, t.CarModel)
+	if errors.Is(err, repository.ErrNotFound) {
+		return active, domain.ModelRouteActive, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if m.CarModel != nil {
+		return m, domain.ModelRouteMakeModel, nil
+	}
+	return m, domain.ModelRouteMake, nil
+}
+
+// match находит самое точное включённое правило для цели и его кандидата.
+// При равной точности побеждает более новое правило. Правила, кандидат которых
+// совпадает с базовой моделью или больше не готов (снят с использования), пропускаются
+func (r *Router) match(ctx context.Context, t Target, base *domain.MLModel) (*domain.CanaryRule, *domain.MLModel, error) {
+	rules, err := r.rules.ListEnabled(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	rules = slices.DeleteFunc(rules, func(rule domain.CanaryRule) bool {
+		return rule.CandidateModelID == base.ID || !rule.Matches(t.OrganizationID, t.CarMake)
+	})
+	// ListEnabled возвращает правила от новых к старым, стабильная сортировка это сохраняет
+	slices.SortStableFunc(rules, func(a, b domain.CanaryRule) int {
+		return b.Specificity() - a.Specificity()
+	})
+
+	for i := range rules {
+		candidate, err := r.models.GetByID(ctx, rules[i].CandidateModelID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("canary rule %s: %w", rules[i].ID, err)
+		}
+		if candidate.IsReady() {
+			return &rules[i], candidate, nil
+		}
+	}
+	return nil, nil, nil
+}
@@ -0,0 +1,143 @@
+package canary
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Service управляет канареечными правилами и отчётами по ним
+type Service struct {
+	db     *sql.DB
+	rules  *repository.CanaryRepository
+	models *repository.ModelRepository
+	audit  *repository.AuditLogRepository
+}
+
+// NewService создаёт сервис канареечных правил
+func NewService(db *sql.DB, rules *repository.CanaryRepository, models *repository.ModelRepository,
+	audit *repository.AuditLogRepository) *Service {
+	return &Service{db: db, rules: rules, models: models, audit: audit}
+}
+
+// List возвращает правила, начиная с новых
+func (s *Service) List(ctx context.Context, user *domain.User, limit, offset int) ([]domain.CanaryRule, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	return s.rules.List(ctx, limit, offset)
+}
+
+// Create создаёт включённое правило. Кандидат должен быть готов и не быть активной моделью
+func (s *Service) Create(ctx context.Context, user *domain.User, req domain.CanaryRuleRequest) (*domain.CanaryRule, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	candidate, err := s.models.GetByID(ctx, req.CandidateModelID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, domain.InvalidInputf("model %s not found", req.CandidateModelID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if candidate.Active {
+		return nil, domain.Conflictf("model %s is already active", candidate.Version)
+	}
+	if !candidate.IsReady() {
+		return nil, domain.Conflictf("model %s is %s: only ready models can be canaries", candidate.Version, candidate.Status)
+	}
+
+	rule := &domain.CanaryRule{
+		CandidateModelID: candidate.ID,
+		CandidateVersion: candidate.Version,
+		Percent:          req.Percent,
+		OrganizationID:   req.OrganizationID,
+		CarMake:          req.CarMake,
+		CreatedBy:        &user.ID,
+	}
+	err = database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.rules.Create(ctx, tx, rule); err != nil {
+			return err
+		}
+		return s.writeAudit(ctx, tx, user, domain.AuditActionCanaryCreated, rule.ID, domain.AuditDetails{
+			"candidate_version": candidate.Version,
+			"percent":           rule.Percent,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Update меняет долю кандидата или включает и отключает правило
+func (s *Service) Update(ctx context.Context, user *domain.User, id uuid.UUID, req domain.CanaryRuleUpdateRequest) (*domain.CanaryRule, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	details := domain.AuditDetails{}
+	if req.Percent != nil {
+		details["percent"] = *req.Percent
+	}
+	if req.Enabled != nil {
+		details["enabled"] = *req.Enabled
+	}
+	err := database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.rules.Update(ctx, tx, id, req); err != nil {
+			return err
+		}
+		return s.writeAudit(ctx, tx, user, domain.AuditActionCanaryUpdated, id, details)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.rules.GetByID(ctx, id)
+}
+
+// Report сравнивает кандидата и базовую модель на анализах, распределённых правилом
+// за период [from, to)
+func (s *Service) Report(ctx context.Context, user *domain.User, id uuid.UUID, from, to *time.Time) (*domain.CanaryReport, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, domain.InvalidInputf("from must be before to")
+	}
+	rule, err := s.rules.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.rules.Report(ctx, id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = []domain.CanaryReportRow{}
+	}
+	return &domain.CanaryReport{Rule: *rule, From: from, To: to, Rows: rows}, nil
+}
+
+func (s *Service) writeAudit(ctx context.Context, q repository.Querier, user *domain.User, action string,
+	ruleID uuid.UUID, details domain.AuditDetails) error {
+	entity := domain.AuditEntityCanary
+	return s.audit.Create(ctx, q, domain.AuditLogCreateRequest{
+		UserID:     &user.ID,
+		Action:     action,
+		EntityType: &entity,
+		EntityID:   &ruleID,
+		Details:    &details,
+	})
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadWorkerConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		concurrency  string
+		pollInterval string
+		want         WorkerConfig
+		wantErr      string
+	}{
+		{name: "defaults", want: WorkerConfig{Concurrency: 4, PollInterval: 2 * time.Second}},
+		{name: "explicit values", concurrency: "8", pollInterval: "500ms", want: WorkerConfig{Concurrency: 8, PollInterval: 500 * time.Millisecond}},
+		{name: "zero concurrency", concurrency: "0", wantErr: "WORKER_CONCURRENCY"},
+		{name: "negative concurrency", concurrency: "-2", wantErr: "WORKER_CONCURRENCY"},
+		{name: "zero poll interval", pollInterval: "0s", wantErr: "WORKER_POLL_INTERVAL"},
+		{name: "negative poll interval", pollInterval: "-1s", wantErr: "WORKER_POLL_INTERVAL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DATABASE_URL", "postgres://localhost/autoinspect")
+			t.Setenv("WORKER_CONCURRENCY", tt.concurrency)
+			t.Setenv("WORKER_POLL_INTERVAL", tt.pollInterval)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want error about %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Worker.Concurrency != tt.want.Concurrency || cfg.Worker.PollInterval != tt.want.PollInterval {
+				t.Errorf("worker concurrency %d, poll interval %v, want %d and %v",
+					cfg.Worker.Concurrency, cfg.Worker.PollInterval, tt.want.Concurrency, tt.want.PollInterval)
+			}
+		})
+	}
+}
+
+func TestLoadPositiveDurations(t *testing.T) {
+	tests := []struct {
+		env  string
+		want func(*Config) time.Duration
+		def  time.Duration
+	}{
+		{env: "WORKER_HOOK_TIMEOUT", want: func(c *Config) time.Duration { return c.Worker.HookTimeout }, def: 10 * time.Second},
+		{env: "BATCH_SWEEP_INTERVAL", want: func(c *Config) time.Duration { return c.Batch.SweepInterval }, def: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("DATABASE_URL", "postgres://localhost/autoinspect")
+
+			t.Setenv(tt.env, "")
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := tt.want(cfg); got != tt.def {
+				t.Errorf("default %s = %v, want %v", tt.env, got, tt.def)
+			}
+
+			t.Setenv(tt.env, "30s")
+			if cfg, err = Load(); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := tt.want(cfg); got != 30*time.Second {
+				t.Errorf("%s = %v, want 30s", tt.env, got)
+			}
+
+			for _, bad := range []string{"0s", "-1s"} {
+				t.Setenv(tt.env, bad)
+				if _, err := Load(); err == nil || !strings.Contains(err.Error(), tt.env) {
+					t.Errorf("Load() with %s=%s error = %v, want error about %s", tt.env, bad, err, tt.env)
+				}
+			}
+		})
+	}
+}
@@ -39,6 +39,15 @@ const (
 	DefectTypeBrokenGlass DefectType = "broken_glass"
 )
 
+// IsValid проверяет, является ли тип дефекта допустимым
+func (dt DefectType) IsValid() bool {
+	switch dt {
+	case DefectTypeScratch, DefectTypeDent, DefectTypeCrack, DefectTypeBrokenGlass:
+		return true
+	}
+	return false
+}
+
 // DefectSeverity представляет серьёзность повреждения
 type DefectSeverity string
 
@@ -47,6 +56,20 @@ const (
 	DefectSeverityMajor DefectSeverity = "major"
 )
 
+// IsValid проверяет, является ли серьёзность допустимой
+func (ds DefectSeverity) IsValid() bool {
+	return ds == DefectSeverityMinor || ds == DefectSeverityMajor
+}
+
+// DefectSource представляет происхождение дефекта в результате
+type DefectSource string
+
+const (
+	DefectSourceModel          DefectSource = "model"           // найден моделью
+	DefectSourceReviewerEdited DefectSource = "reviewer_edited" // найден моделью и исправлен проверяющим
+	DefectSourceReviewerAdded  DefectSource = "reviewer_added"  // добавлен проверяющим
+)
+
 // BoundingBox представляет координаты ограничивающего прямоугольника
 type BoundingBox struct {
 	X      int `json:"x"`
@@ -66,20 +89,53 @@ type Defect struct {
 	Mask              *string        `json:"mask,omitempty"`
 	Confidence        float64        `json:"confidence"`
 	RecommendedAction *string        `json:"recommended_action,omitempty"`
+
+	// С версии схемы 3
+	Source   DefectSource `json:"source"`
+	Verified bool         `json:"verified"` // подтверждён при ручной проверке
+
+	// С версии схемы 4. Nil, если для детали и класса повреждения нет нормы
+	Labor *DefectLabor `json:"labor,omitempty"`
 }
 
-// AnalysisResult представляет результат анализа изображения
+// ResultSummary представляет сводку по результату анализа
+type ResultSummary struct {
+	TotalDefects  int                `json:"total_defects"`
+	CriticalCount int                `json:"critical_count"`
+	DefectsByType map[DefectType]int `json:"defects_by_type"`          // с версии схемы 2
+	EstimatedCost *Money             `json:"estimated_cost,omitempty"` // с версии схемы 5 - сумма с валютой
+
+	// С версии схемы 4. Nil, если трудоёмкость не оценивалась
+	LaborHours   *float64 `json:"labor_hours"`   // сумма нормо-часов по дефектам
+	WorkshopDays *int     `json:"workshop_days"` // рекомендуемое число дней в цеху
+}
+
+// AnalysisResult представляет результат анализа изображения.
+// Структура версионируется (см. result_schema.go): старые записи
+// обновляются до текущей версии при чтении из БД
 type AnalysisResult struct {
-	ViewAngle string   `json:"view_angle,omitempty"` // front, rear, side_left, side_right
-	Defects   []Defect `json:"defects"`
-	Summary   struct {
-		TotalDefects  int      `json:"total_defects"`
-		CriticalCount int      `json:"critical_count"`
-		EstimatedCost *float64 `json:"estimated_cost,omitempty"`
-	} `json:"summary"`
+	SchemaVersion int           `json:"schema_version"`
+	ViewAngle     string        `json:"view_angle,omitempty"` // front, rear, side_left, side_right
+	Defects       []Defect      `json:"defects"`
+	Summary       ResultSummary `json:"summary"`
+}
+
+// RecomputeSummary пересчитывает сводку по текущему списку дефектов.
+// Оценка стоимости не меняется
+func (ar *AnalysisResult) RecomputeSummary() {
+	ar.Summary.TotalDefects = len(ar.Defects)
+	ar.Summary.CriticalCount = 0
+	ar.Summary.DefectsByType = make(map[DefectType]int)
+	for _, d := range ar.Defects {
+		ar.Summary.DefectsByType[d.DefectType]++
+		if d.Severity == DefectSeverityMajor {
+			ar.Summary.CriticalCount++
+		}
+	}
 }
 
-// Scan реализует интерфейс sql.Scanner для AnalysisResult
+// Scan реализует интерфейс sql.Scanner для AnalysisResult.
+// JSON старых версий схемы прозрачно обновляется до текущей
 func (ar *AnalysisResult) Scan(value interface{}) error {
 	if value == nil {
 		return nil
@@ -88,11 +144,18 @@ func (ar *AnalysisResult) Scan(value interface{}) error {
 	if !ok {
 		return nil
 	}
-	return json.Unmarshal(bytes, ar)
+	upgraded, _, err := UpgradeResultJSON(bytes)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, ar)
 }
 
 // Value реализует интерфейс driver.Valuer для AnalysisResult
 func (ar AnalysisResult) Value() (driver.Value, error) {
+	if ar.SchemaVersion == 0 {
+		ar.SchemaVersion = CurrentResultSchemaVersion
+	}
 	return json.Marshal(ar)
 }
 
@@ -104,6 +167,7 @@ type ImageMetadata struct {
 		Width  int `json:"width"`
 		Height int `json:"height"`
 	} `json:"dimensions"`
+	Exif *ExifData `json:"exif,omitempty"`
 }
 
 // Scan реализует интерфейс sql.Scanner для ImageMetadata
@@ -125,36 +189,91 @@ func (im ImageMetadata) Value() (driver.Value, error) {
 
 // Analysis представляет задачу анализа изображения
 type Analysis struct {
-	ID     uuid.UUID      `json:"id" db:"id"`
-	UserID uuid.UUID      `json:"user_id" db:"user_id"`
-	Status AnalysisStatus `json:"status" db:"status"`
+	ID      uuid.UUID      `json:"id" db:"id"`
+	UserID  uuid.UUID      `json:"user_id" db:"user_id"`
+	BatchID *uuid.UUID     `json:"batch_id,omitempty" db:"batch_id"` // пакет, в составе которого отправлен анализ
+	Status  AnalysisStatus `json:"status" db:"status"`
 
 	// Изображение
 	ImageKey      string         `json:"image_key" db:"image_key"`
 	ImageMetadata *ImageMetadata `json:"image_metadata,omitempty" db:"image_metadata"`
 
+	// Копия со скрытыми номерами и лицами - единственный вариант, доступный клиентам
+	RedactedImageKey *string           `json:"redacted_image_key,omitempty" db:"redacted_image_key"`
+	RedactionRegions *RedactionRegions `json:"redaction_regions,omitempty" db:"redaction_regions"`
+	RedactedAt       *time.Time        `json:"redacted_at,omitempty" db:"redacted_at"`
+
+	// Политика хранения: изображение удалено, данные о съёмке и происшествии стёрты
+	ImageDeletedAt *time.Time `json:"image_deleted_at,omitempty" db:"image_deleted_at"`
+	AnonymizedAt   *time.Time `json:"anonymized_at,omitempty" db:"anonymized_at"`
+
+	// Автомобиль (заявлен клиентом)
+	CarMake  *string `json:"car_make,omitempty" db:"car_make"`
+	CarModel *string `json:"car_model,omitempty" db:"car_model"`
+
+	// Регион ремонта для выбора прайс-листа (nil - регион по умолчанию)
+	Region *string `json:"region,omitempty" db:"region"`
+
 	// ML модель
-	ModelVersion string     `json:"model_version" db:"model_version"`
-	ModelID      *uuid.UUID `json:"model_id,omitempty" db:"model_id"`
+	ModelVersion string      `json:"model_version" db:"model_version"`
+	ModelID      *uuid.UUID  `json:"model_id,omitempty" db:"model_id"`
+	ModelRoute   *ModelRoute `json:"model_route,omitempty" db:"model_route"`       // как выбрана модель
+	CanaryRuleID *uuid.UUID  `json:"canary_rule_id,omitempty" db:"canary_rule_id"` // канареечное правило выбора
+	BaseRoute    *ModelRoute `json:"base_route,omitempty" db:"base_route"`         // для canary и control - как выбрана базовая модель
 
 	// Результаты
-	Result *AnalysisResult `json:"result,omitempty" db:"result_json"`
+	Result         *AnalysisResult `json:"result,omitempty" db:"result_json"`
+	OriginalResult *AnalysisResult `json:"original_result,omitempty" db:"original_result_json"` // выход модели до ручной проверки
+	ResultVersion  int             `json:"result_version" db:"result_version"`                  // растёт с каждой проверкой
+
+	// Ручная проверка результата
+	ReviewStatus ReviewStatus `json:"review_status" db:"review_status"`
+	ReviewReason *string      `json:"review_reason,omitempty" db:"review_reason"` // почему анализ отправлен на проверку
+	ReviewedAt   *time.Time   `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewedBy   *uuid.UUID   `json:"reviewed_by,omitempty" db:"reviewed_by"`
 
 	// Ошибки
-	ErrorMessage *string `json:"error_message,omitempty" db:"error_message"`
-	ErrorCode    *string `json:"error_code,omitempty" db:"error_code"`
-	RetryCount   int     `json:"retry_count" db:"retry_count"`
+	ErrorMessage *string    `json:"error_message,omitempty" db:"error_message"`
+	ErrorCode    *ErrorCode `json:"error_code,omitempty" db:"error_code"`
+	RetryCount   int        `json:"retry_count" db:"retry_count"`
+	NextRetryAt  *time.Time `json:"next_retry_at,omitempty" db:"next_retry_at"` // воркер не возьмёт анализ раньше
+
+	// Антифрод
+	IncidentLocation *GeoPoint     `json:"incident_location,omitempty" db:"incident_location"`
+	ImageHash        *int64        `json:"-" db:"image_phash"` // перцептивный хэш для поиска почти-дубликатов
+	FraudScore       *float64      `json:"fraud_score,omitempty" db:"fraud_score"`
+	FraudReasons     *FraudReasons `json:"fraud_reasons,omitempty" db:"fraud_reasons"`
+	FraudCheckedAt   *time.Time    `json:"fraud_checked_at,omitempty" db:"fraud_checked_at"`
+
+	// Уведомление о завершении (если не задан, используется webhook аккаунта)
+	WebhookURL    *string    `json:"webhook_url,omitempty" db:"webhook_url"`
+	WebhookSentAt *time.Time `json:"webhook_sent_at,omitempty" db:"webhook_sent_at"` // первая успешная доставка
 
 	// Временные метки
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
-	ProcessedAt *time.Time `json:"processed_at,omitempty" db:"processed_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	QueuedAt     *time.Time `json:"queued_at,omitempty" db:"queued_at"`         // последняя постановка в очередь
+	ProcessingAt *time.Time `json:"processing_at,omitempty" db:"processing_at"` // начало последней обработки
+	CompletedAt  *time.Time `json:"completed_at,omitempty" db:"completed_at"`   // переход в completed или failed
+
+	// Метрики последней попытки
+	ProcessingTimeMs *int64 `json:"processing_time_ms,omitempty" db:"processing_time_ms"`
+	QueueWaitTimeMs  *int64 `json:"queue_wait_time_ms,omitempty" db:"queue_wait_time_ms"`
 }
 
 // AnalysisCreateRequest DTO для создания нового анализа
 type AnalysisCreateRequest struct {
 	ImageKey     string  `json:"image_key" validate:"required"`
 	ModelVersion *string `json:"model_version,omitempty"`
+	CarMake      *string `json:"car_make,omitempty" validate:"omitempty,max=100"`
+	CarModel     *string `json:"car_model,omitempty" validate:"omitempty,max=100"`
+	Region       *string `json:"region,omitempty"` // ISO 3166: RU, RU-MOW, KZ, DE
+
+	// Место происшествия, заявленное клиентом (для сверки с GPS из EXIF)
+	IncidentLocation *GeoPoint `json:"incident_location,omitempty"`
+
+	// Адрес для уведомления о завершении именно этого анализа
+	WebhookURL *string `json:"webhook_url,omitempty"`
 }
 
 // IsCompleted проверяет, завершён ли анализ
@@ -167,7 +286,20 @@ func (a *Analysis) IsFailed() bool {
 	return a.Status == AnalysisStatusFailed
 }
 
-// CanRetry проверяет, можно ли повторить анализ
-func (a *Analysis) CanRetry() bool {
-	return a.Status == AnalysisStatusFailed && a.RetryCount < 3
+// IsHighRisk проверяет, превышает ли антифрод-оценка анализа порог
+func (a *Analysis) IsHighRisk(threshold float64) bool {
+	return a.FraudScore != nil && *a.FraudScore >= threshold
+}
+
+// FailureCode возвращает код ошибки анализа. Для старых записей без кода - ErrorCodeInternal
+func (a *Analysis) FailureCode() ErrorCode {
+	if a.ErrorCode == nil || !a.ErrorCode.IsValid() {
+		return ErrorCodeInternal
+	}
+	return *a.ErrorCode
+}
+
+// CanRetry проверяет, можно ли повторить анализ согласно политике повторов
+func (a *Analysis) CanRetry(policy RetryPolicy) bool {
+	return a.Status == AnalysisStatusFailed && policy.ShouldRetry(a.FailureCode(), a.RetryCount)
 }
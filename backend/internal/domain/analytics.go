@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"time"
+)
+
+// DefectStatsGroup представляет разрез аналитики по дефектам
+type DefectStatsGroup string
+
+const (
+	DefectStatsByPart       DefectStatsGroup = "part"        // по детали
+	DefectStatsByDefectType DefectStatsGroup = "defect_type" // по типу дефекта
+	DefectStatsByVehicle    DefectStatsGroup = "vehicle"     // по марке и модели автомобиля
+	DefectStatsByWeek       DefectStatsGroup = "week"        // по неделе создания анализа
+)
+
+// IsValid проверяет, что разрез известен
+func (g DefectStatsGroup) IsValid() bool {
+	switch g {
+	case DefectStatsByPart, DefectStatsByDefectType, DefectStatsByVehicle, DefectStatsByWeek:
+		return true
+	}
+	return false
+}
+
+// IsDefectLevel сообщает, строится ли разрез по отдельным дефектам (а не по анализам)
+func (g DefectStatsGroup) IsDefectLevel() bool {
+	return g == DefectStatsByPart || g == DefectStatsByDefectType
+}
+
+// DefectStatsFilter представляет параметры аналитического запроса.
+// Данные агрегированы по неделям (понедельник, UTC), поэтому границы периода
+// округляются до недели
+type DefectStatsFilter struct {
+	Group    DefectStatsGroup
+	From     time.Time // неделя, содержащая From, включается
+	To       time.Time // недели, начинающиеся с To и позже, не включаются
+	CarMake  *string   // без учёта регистра
+	CarModel *string   // без учёта регистра
+	Limit    int       // для разрезов part, defect_type и vehicle - самые частые группы
+	Currency Currency  // валюта средней стоимости; оценки в других валютах пересчитываются
+}
+
+// DefectStatsRow представляет агрегат по одной группе. Заполнены только поля разреза:
+// part - PartID/PartName, defect_type - DefectType, vehicle - CarMake/CarModel, week - Week.
+//
+// Для разрезов по дефектам Analyses - число анализов с дефектами группы,
+// а AvgEstimatedCost - средняя оценка стоимости этих анализов. Анализ с дефектами
+// нескольких типов на одной детали учитывается в разрезе part по разу на каждый тип
+type DefectStatsRow struct {
+	Week       *time.Time  `json:"week,omitempty"`
+	CarMake    *string     `json:"car_make,omitempty"`
+	CarModel   *string     `json:"car_model,omitempty"`
+	PartID     *string     `json:"part_id,omitempty"`
+	PartName   *string     `json:"part_name,omitempty"`
+	DefectType *DefectType `json:"defect_type,omitempty"`
+	Label      string      `json:"label,omitempty"` // локализованное название детали или типа дефекта
+
+	Analyses           int      `json:"analyses"`
+	Defects            int      `json:"defects"`
+	MajorDefects       int      `json:"major_defects"`
+	MajorShare         float64  `json:"major_share"`                    // доля серьёзных дефектов (0..1)
+	DefectsPerAnalysis *float64 `json:"defects_per_analysis,omitempty"` // только для разрезов vehicle и week
+	AvgEstimatedCost   *Money   `json:"avg_estimated_cost"`             // nil, если оценок нет
+
+	CostTotals []CostTotal `json:"-"` // суммы оценок по исходным валютам, из них считается AvgEstimatedCost
+}
+
+// CostTotal представляет сумму и число оценок стоимости в одной валюте
+type CostTotal struct {
+	Currency Currency `json:"currency"`
+	Sum      int64    `json:"sum"` // в минимальных единицах
+	Count    int64    `json:"count"`
+}
+
+// DefectStats представляет результат аналитического запроса
+type DefectStats struct {
+	Group       DefectStatsGroup `json:"group_by"`
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	Currency    Currency         `json:"currency"`
+	RefreshedAt *time.Time       `json:"refreshed_at"` // когда данные последний раз пересчитывались
+	Rows        []DefectStatsRow `json:"rows"`
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ArtifactKind представляет вид файла модели
+type ArtifactKind string
+
+const (
+	ArtifactKindWeights ArtifactKind = "weights"
+	ArtifactKindConfig  ArtifactKind = "config"
+)
+
+// ModelArtifact представляет файл модели в хранилище и его дайджест на момент регистрации
+type ModelArtifact struct {
+	ModelID   uuid.UUID    `json:"model_id" db:"model_id"`
+	Kind      ArtifactKind `json:"kind" db:"kind"`
+	Path      string       `json:"path" db:"path"`
+	SHA256    string       `json:"sha256" db:"sha256"`
+	SizeBytes int64        `json:"size_bytes" db:"size_bytes"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+
+	// Последняя проверка; VerificationError - причина несовпадения, nil - файл цел
+	VerifiedAt        *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	VerificationError *string    `json:"verification_error,omitempty" db:"verification_error"`
+}
+
+// ArtifactCheckStatus представляет итог проверки файла модели
+type ArtifactCheckStatus string
+
+const (
+	ArtifactCheckOK         ArtifactCheckStatus = "ok"         // файл совпадает с дайджестом
+	ArtifactCheckMismatch   ArtifactCheckStatus = "mismatch"   // дайджест или размер не совпадают
+	ArtifactCheckMissing    ArtifactCheckStatus = "missing"    // файла нет в хранилище
+	ArtifactCheckUnrecorded ArtifactCheckStatus = "unrecorded" // дайджест не записан (модель зарегистрирована до проверок)
+	ArtifactCheckError      ArtifactCheckStatus = "error"      // хранилище недоступно, итог неизвестен
+)
+
+// ArtifactCheck представляет результат проверки одного файла модели
+type ArtifactCheck struct {
+	ModelID        uuid.UUID           `json:"model_id"`
+	ModelVersion   string              `json:"model_version"`
+	Kind           ArtifactKind        `json:"kind"`
+	Path           string              `json:"path"`
+	Status         ArtifactCheckStatus `json:"status"`
+	ExpectedSHA256 string              `json:"expected_sha256,omitempty"`
+	ActualSHA256   string              `json:"actual_sha256,omitempty"`
+	ExpectedSize   int64               `json:"expected_size,omitempty"`
+	ActualSize     int64               `json:"actual_size,omitempty"`
+	Error          string              `json:"error,omitempty"`
+}
+
+// IsCorrupt проверяет, что файл повреждён или отсутствует
+func (c *ArtifactCheck) IsCorrupt() bool {
+	return c.Status == ArtifactCheckMismatch || c.Status == ArtifactCheckMissing
+}
+
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// NormalizeSHA256 приводит hex-дайджест SHA-256 к нижнему регистру и проверяет формат
+func NormalizeSHA256(field, digest string) (string, error) {
+	d := strings.ToLower(strings.TrimSpace(digest))
+	if !sha256Hex.MatchString(d) {
+		return "", InvalidInputf("%s must be a hex-encoded SHA-256 digest", field)
+	}
+	return d, nil
+}
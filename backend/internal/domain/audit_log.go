@@ -9,6 +9,35 @@ import (
 	"github.com/google/uuid"
 )
 
+// Действия, записываемые в журнал аудита
+const (
+	AuditActionAnalysisReviewed = "analysis.reviewed"
+	AuditActionBatchCancelled   = "batch.cancelled"
+	AuditActionBatchRetried     = "batch.retried"
+	AuditActionBatchCompleted   = "batch.completed"
+	AuditActionUserErased       = "user.erased"
+	AuditActionModelRegistered  = "model.registered"
+	AuditActionModelPromoted    = "model.promoted"
+	AuditActionModelRolledBack  = "model.rolled_back"
+	AuditActionCanaryCreated    = "canary_rule.created"
+	AuditActionCanaryUpdated    = "canary_rule.updated"
+	AuditActionShadowCreated    = "shadow_deployment.created"
+	AuditActionShadowUpdated    = "shadow_deployment.updated"
+	AuditActionEvaluationQueued = "evaluation_job.queued"
+)
+
+// Типы сущностей журнала аудита
+const (
+	AuditEntityAnalysis   = "analysis"
+	AuditEntityModel      = "model"
+	AuditEntityDataset    = "dataset"
+	AuditEntityBatch      = "analysis_batch"
+	AuditEntityErasure    = "user_erasure"
+	AuditEntityCanary     = "canary_rule"
+	AuditEntityShadow     = "shadow_deployment"
+	AuditEntityEvaluation = "evaluation_job"
+)
+
 // AuditLog представляет запись в журнале аудита
 type AuditLog struct {
 	ID int64 `json:"id" db:"id"`
@@ -18,6 +47,9 @@ type AuditLog struct {
 	EntityType *string    `json:"entity_type,omitempty" db:"entity_type"`
 	EntityID   *uuid.UUID `json:"entity_id,omitempty" db:"entity_id"`
 
+	// Псевдоним автора вместо user_id после удаления пользователя
+	ActorPseudonym *string `json:"actor_pseudonym,omitempty" db:"actor_pseudonym"`
+
 	// Контекст запроса
 	IPAddress *net.IP    `json:"ip_address,omitempty" db:"ip_address"`
 	UserAgent *string    `json:"user_agent,omitempty" db:"user_agent"`
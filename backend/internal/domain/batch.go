@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BatchStatus представляет статус пакета анализов
+type BatchStatus string
+
+const (
+	BatchStatusProcessing BatchStatus = "processing" // есть анализы в очереди или в обработке
+	BatchStatusCompleted  BatchStatus = "completed"  // все анализы в конечном статусе
+	BatchStatusCancelled  BatchStatus = "cancelled"  // пакет отменён пользователем
+)
+
+// IsValid проверяет, является ли статус пакета допустимым
+func (bs BatchStatus) IsValid() bool {
+	switch bs {
+	case BatchStatusProcessing, BatchStatusCompleted, BatchStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// BatchProgress представляет агрегированный прогресс пакета по статусам анализов
+type BatchProgress struct {
+	Total      int `json:"total"`
+	Queued     int `json:"queued"`
+	Processing int `json:"processing"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Cancelled  int `json:"cancelled"`
+}
+
+// Done возвращает число анализов в конечном статусе
+func (bp BatchProgress) Done() int {
+	return bp.Completed + bp.Failed + bp.Cancelled
+}
+
+// IsFinished проверяет, что в пакете не осталось анализов в очереди и в обработке
+func (bp BatchProgress) IsFinished() bool {
+	return bp.Queued == 0 && bp.Processing == 0
+}
+
+// AnalysisBatch представляет пакет анализов, отправленных одним запросом
+type AnalysisBatch struct {
+	ID           uuid.UUID     `json:"id" db:"id"`
+	UserID       uuid.UUID     `json:"user_id" db:"user_id"`
+	Name         *string       `json:"name,omitempty" db:"name"`
+	ModelVersion string        `json:"model_version" db:"model_version"`
+	Status       BatchStatus   `json:"status" db:"status"`
+	Progress     BatchProgress `json:"progress"` // считается по analyses, total - из total_count
+	WebhookURL   *string       `json:"webhook_url,omitempty" db:"webhook_url"`
+
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// IsOwnedBy проверяет, принадлежит ли пакет пользователю
+func (b *AnalysisBatch) IsOwnedBy(userID uuid.UUID) bool {
+	return b.UserID == userID
+}
+
+// BatchCreateRequest DTO для создания пакета по ключам уже загруженных изображений
+type BatchCreateRequest struct {
+	Name         *string  `json:"name,omitempty"`
+	ImageKeys    []string `json:"image_keys" validate:"required,min=1"`
+	ModelVersion *string  `json:"model_version,omitempty"`
+
+	// Автомобиль, общий для всех изображений пакета. Марка и модель участвуют в выборе модели анализа
+	CarMake  *string `json:"car_make,omitempty" validate:"omitempty,max=100"`
+	CarModel *string `json:"car_model,omitempty" validate:"omitempty,max=100"`
+	Region   *string `json:"region,omitempty"` // ISO 3166: RU, RU-MOW, KZ, DE
+
+	// Место происшествия, общее для всех изображений пакета
+	IncidentLocation *GeoPoint `json:"incident_location,omitempty"`
+
+	// Адрес для уведомлений о завершении каждого анализа пакета и всего пакета (batch.completed)
+	WebhookURL *string `json:"webhook_url,omitempty"`
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModelRoute описывает, как для анализа была выбрана модель
+type ModelRoute string
+
+const (
+	ModelRouteActive    ModelRoute = "active"     // базовая (активная) модель: специализированной нет
+	ModelRouteMakeModel ModelRoute = "make_model" // модель, обученная на марку и модель автомобиля
+	ModelRouteMake      ModelRoute = "make"       // модель, обученная на марку автомобиля
+	ModelRoutePinned    ModelRoute = "pinned"     // версия указана клиентом
+	ModelRouteCanary    ModelRoute = "canary"     // по правилу на модель-кандидата
+	ModelRouteControl   ModelRoute = "control"    // по правилу на базовую модель (контрольная группа)
+)
+
+// CanaryRule представляет правило канареечной маршрутизации: percent процентов новых
+// анализов в своей области отправляются на модель-кандидата.
+// Область задаётся организацией пользователя и маркой автомобиля; nil - без ограничения
+type CanaryRule struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	CandidateModelID uuid.UUID  `json:"candidate_model_id" db:"candidate_model_id"`
+	CandidateVersion string     `json:"candidate_version" db:"-"`
+	Percent          float64    `json:"percent" db:"percent"`
+	OrganizationID   *uuid.UUID `json:"organization_id,omitempty" db:"organization_id"`
+	CarMake          *string    `json:"car_make,omitempty" db:"car_make"`
+	Enabled          bool       `json:"enabled" db:"enabled"`
+	CreatedBy        *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+
+	TimestampFields
+}
+
+// Matches проверяет, попадает ли анализ организации orgID с маркой carMake в область правила
+func (r *CanaryRule) Matches(orgID *uuid.UUID, carMake *string) bool {
+	if r.OrganizationID != nil && (orgID == nil || *orgID != *r.OrganizationID) {
+		return false
+	}
+	if r.CarMake != nil && (carMake == nil || !strings.EqualFold(*carMake, *r.CarMake)) {
+		return false
+	}
+	return true
+}
+
+// Specificity возвращает точность области правила: организация важнее марки
+func (r *CanaryRule) Specificity() int {
+	n := 0
+	if r.OrganizationID != nil {
+		n += 2
+	}
+	if r.CarMake != nil {
+		n++
+	}
+	return n
+}
+
+// CanaryRuleRequest представляет тело запроса на создание канареечного правила
+type CanaryRuleRequest struct {
+	CandidateModelID uuid.UUID  `json:"candidate_model_id"`
+	Percent          float64    `json:"percent"`
+	OrganizationID   *uuid.UUID `json:"organization_id,omitempty"`
+	CarMake          *string    `json:"car_make,omitempty"`
+}
+
+// Validate проверяет и нормализует запрос правила
+func (r *CanaryRuleRequest) Validate() error {
+	if r.CandidateModelID == uuid.Nil {
+		return InvalidInputf("candidate_model_id is required")
+	}
+	if err := validateTrafficPercent(r.Percent); err != nil {
+		return err
+	}
+	if r.CarMake != nil {
+		carMake := strings.TrimSpace(*r.CarMake)
+		if carMake == "" || len(carMake) > 100 {
+			return InvalidInputf("car_make must be non-empty and at most 100 characters")
+		}
+		r.CarMake = &carMake
+	}
+	return nil
+}
+
+// CanaryRuleUpdateRequest представляет тело запроса на изменение доли или отключение правила
+type CanaryRuleUpdateRequest struct {
+	Percent *float64 `json:"percent,omitempty"`
+	Enabled *bool    `json:"enabled,omitempty"`
+}
+
+// Validate проверяет запрос изменения правила
+func (r *CanaryRuleUpdateRequest) Validate() error {
+	if r.Percent == nil && r.Enabled == nil {
+		return InvalidInputf("percent or enabled is required")
+	}
+	if r.Percent != nil {
+		return validateTrafficPercent(*r.Percent)
+	}
+	return nil
+}
+
+func validateTrafficPercent(p float64) error {
+	if p <= 0 || p > 100 {
+		return InvalidInputf("percent must be greater than 0 and at most 100")
+	}
+	return nil
+}
+
+// RoutingDecision представляет выбранную для анализа модель и причину выбора
+type RoutingDecision struct {
+	Model        *MLModel
+	Route        ModelRoute
+	CanaryRuleID *uuid.UUID  // правило, по которому выбрана canary или control
+	BaseRoute    *ModelRoute // для canary и control - как выбрана базовая модель (active, make_model, make)
+}
+
+// CanaryReportRow представляет показатели одной ветки канареечного правила.
+// Дефекты считаются по выходу модели (до ручной проверки)
+type CanaryReportRow struct {
+	Route              ModelRoute  `json:"route"`
+	BaseRoute          *ModelRoute `json:"base_route,omitempty"` // как выбрана базовая модель; пусто для старых анализов
+	ModelVersion       string      `json:"model_version"`
+	Analyses           int         `json:"analyses"`
+	Completed          int         `json:"completed"`
+	Failed             int         `json:"failed"`
+	FailureRate        float64     `json:"failure_rate"`         // failed / (completed + failed)
+	Defects            int         `json:"defects"`              // всего дефектов в завершённых анализах
+	MajorDefects       int         `json:"major_defects"`        // из них серьёзных
+	DefectsPerAnalysis float64     `json:"defects_per_analysis"` // defects / completed
+	DefectRate         float64     `json:"defect_rate"`          // доля завершённых анализов хотя бы с одним дефектом
+}
+
+// CanaryReport представляет сравнение кандидата и базовой модели по правилу
+type CanaryReport struct {
+	Rule CanaryRule        `json:"rule"`
+	From *time.Time        `json:"from,omitempty"`
+	To   *time.Time        `json:"to,omitempty"`
+	Rows []CanaryReportRow `json:"rows"`
+}
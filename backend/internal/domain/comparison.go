@@ -0,0 +1,75 @@
+package domain
+
+import "github.com/google/uuid"
+
+// ComparisonThresholds задаёт условия, при которых кандидат рекомендуется вместо базовой модели
+type ComparisonThresholds struct {
+	MinMAPGain         float64 `json:"min_map_gain"`         // минимальный прирост mAP@[.5:.95]
+	MaxClassAPDrop     float64 `json:"max_class_ap_drop"`    // допустимое падение AP любого типа дефекта
+	MaxLatencyIncrease float64 `json:"max_latency_increase"` // допустимый относительный рост p95 времени инференса
+	MaxECEIncrease     float64 `json:"max_ece_increase"`     // допустимый рост ошибки калибровки
+}
+
+// ComparedModel представляет модель в сравнении и оценку, по которой она сравнивается
+type ComparedModel struct {
+	Model        MLModel       `json:"model"`
+	EvaluationID uuid.UUID     `json:"evaluation_id"`
+	Metrics      *ModelMetrics `json:"metrics"`
+	Latency      *LatencyStats `json:"latency,omitempty"`
+	ImagesFailed int           `json:"images_failed"`
+}
+
+// ClassMetricsDelta представляет разность метрик типа дефекта (кандидат - базовая модель)
+type ClassMetricsDelta struct {
+	DefectType  DefectType              `json:"defect_type"`
+	Support     int                     `json:"support"`
+	BaselineAP  float64                 `json:"baseline_ap"`
+	CandidateAP float64                 `json:"candidate_ap"`
+	AP          float64                 `json:"ap"`
+	Thresholds  []ClassThresholdMetrics `json:"thresholds"` // разности при общих порогах IoU
+}
+
+// ModelDisagreement представляет изображение, на котором выходы моделей расходятся.
+// Score - доля несогласованных детекций: 0 - совпадают, 1 - не совпадает ни одна
+type ModelDisagreement struct {
+	ImageKey  string               `json:"image_key"`
+	Score     float64              `json:"score"`
+	Baseline  EvaluationDetections `json:"baseline"`
+	Candidate EvaluationDetections `json:"candidate"`
+}
+
+// RecommendationCheck представляет одно условие рекомендации
+type RecommendationCheck struct {
+	Name      string  `json:"name"`
+	Passed    bool    `json:"passed"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Detail    string  `json:"detail,omitempty"`
+}
+
+// ComparisonRecommendation показывает, стоит ли заменить базовую модель кандидатом
+type ComparisonRecommendation struct {
+	Recommended bool                  `json:"recommended"`
+	Thresholds  ComparisonThresholds  `json:"thresholds"`
+	Checks      []RecommendationCheck `json:"checks"`
+}
+
+// ModelComparison представляет сравнение двух моделей на одном оценочном наборе
+type ModelComparison struct {
+	Baseline  ComparedModel `json:"baseline"`
+	Candidate ComparedModel `json:"candidate"`
+
+	DatasetID    uuid.UUID `json:"dataset_id"`
+	CommonImages int       `json:"common_images"` // изображений, обработанных обеими моделями
+
+	// Разности (кандидат - базовая модель)
+	MAP          float64             `json:"map"`
+	MAP50        float64             `json:"map50"`
+	ECE          *float64            `json:"ece,omitempty"`
+	LatencyP50Ms *float64            `json:"latency_p50_ms,omitempty"`
+	LatencyP95Ms *float64            `json:"latency_p95_ms,omitempty"`
+	PerClass     []ClassMetricsDelta `json:"per_class"`
+
+	Disagreements  []ModelDisagreement      `json:"disagreements"`
+	Recommendation ComparisonRecommendation `json:"recommendation"`
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorCode представляет код ошибки обработки анализа
+type ErrorCode string
+
+const (
+	// Временные ошибки: повтор имеет смысл
+	ErrorCodeStorageTimeout       ErrorCode = "storage_timeout"       // хранилище не ответило вовремя
+	ErrorCodeStorageUnavailable   ErrorCode = "storage_unavailable"   // хранилище недоступно
+	ErrorCodeInferenceUnavailable ErrorCode = "inference_unavailable" // сервис инференса недоступен
+	ErrorCodeInferenceTimeout     ErrorCode = "inference_timeout"     // инференс не уложился в таймаут
+	ErrorCodeInternal             ErrorCode = "internal_error"        // неклассифицированная ошибка
+	ErrorCodeModelIntegrity       ErrorCode = "model_integrity"       // файлы модели не совпадают с дайджестом (до восстановления)
+
+	// Постоянные ошибки: повтор даст тот же результат
+	ErrorCodeCorruptImage      ErrorCode = "corrupt_image"      // файл повреждён и не декодируется
+	ErrorCodeUnsupportedFormat ErrorCode = "unsupported_format" // формат изображения не поддерживается
+	ErrorCodeImageNotFound     ErrorCode = "image_not_found"    // изображения нет в хранилище
+	ErrorCodeModelNotFound     ErrorCode = "model_not_found"    // указанная версия модели не найдена
+)
+
+// ErrorClass представляет класс ошибки с точки зрения повторных попыток
+type ErrorClass string
+
+const (
+	ErrorClassTransient ErrorClass = "transient"
+	ErrorClassPermanent ErrorClass = "permanent"
+)
+
+// IsValid проверяет, является ли код ошибки допустимым
+func (ec ErrorCode) IsValid() bool {
+	switch ec {
+	case ErrorCodeStorageTimeout, ErrorCodeStorageUnavailable, ErrorCodeInferenceUnavailable,
+		ErrorCodeInferenceTimeout, ErrorCodeInternal, ErrorCodeModelIntegrity,
+		ErrorCodeCorruptImage, ErrorCodeUnsupportedFormat, ErrorCodeImageNotFound, ErrorCodeModelNotFound:
+		return true
+	}
+	return false
+}
+
+// Class возвращает класс ошибки. Неизвестные коды считаются временными
+func (ec ErrorCode) Class() ErrorClass {
+	switch ec {
+	case ErrorCodeCorruptImage, ErrorCodeUnsupportedFormat, ErrorCodeImageNotFound, ErrorCodeModelNotFound:
+		return ErrorClassPermanent
+	}
+	return ErrorClassTransient
+}
+
+// IsTransient проверяет, имеет ли смысл повторять обработку после этой ошибки
+func (ec ErrorCode) IsTransient() bool {
+	return ec.Class() == ErrorClassTransient
+}
+
+// TransientErrorCodes возвращает все известные временные коды ошибок
+func TransientErrorCodes() []ErrorCode {
+	return []ErrorCode{
+		ErrorCodeStorageTimeout, ErrorCodeStorageUnavailable,
+		ErrorCodeInferenceUnavailable, ErrorCodeInferenceTimeout, ErrorCodeInternal, ErrorCodeModelIntegrity,
+	}
+}
+
+// AnalysisError представляет ошибку обработки анализа с кодом
+type AnalysisError struct {
+	Code ErrorCode
+	Err  error
+}
+
+// NewAnalysisError оборачивает ошибку кодом
+func NewAnalysisError(code ErrorCode, err error) *AnalysisError {
+	return &AnalysisError{Code: code, Err: err}
+}
+
+func (e *AnalysisError) Error() string {
+	if e.Err == nil {
+		return string(e.Code)
+	}
+	return string(e.Code) + ": " + e.Err.Error()
+}
+
+func (e *AnalysisError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCodeOf определяет код ошибки: из AnalysisError в цепочке,
+// по таймауту контекста или ErrorCodeInternal для всего остального
+func ErrorCodeOf(err error) ErrorCode {
+	var ae *AnalysisError
+	if errors.As(err, &ae) {
+		return ae.Code
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorCodeInferenceTimeout
+	}
+	return ErrorCodeInternal
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidInput возвращается сервисами при некорректных входных данных (HTTP 400)
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrForbidden возвращается, если у пользователя нет прав на операцию (HTTP 403)
+	ErrForbidden = errors.New("forbidden")
+	// ErrConflict возвращается, если операция несовместима с текущим состоянием (HTTP 409)
+	ErrConflict = errors.New("conflict")
+)
+
+// InvalidInputf создаёт ошибку валидации с описанием, понятным клиенту API
+func InvalidInputf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidInput, fmt.Sprintf(format, args...))
+}
+
+// Conflictf создаёт ошибку конфликта состояния с описанием
+func Conflictf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrConflict, fmt.Sprintf(format, args...))
+}
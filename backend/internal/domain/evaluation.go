@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultEvaluationIoUThresholds - пороги IoU, для которых по умолчанию считаются
+// precision, recall и AP по типам дефектов
+var DefaultEvaluationIoUThresholds = []float64{0.5, 0.75}
+
+// EvaluationParams представляет параметры офлайн-оценки модели
+type EvaluationParams struct {
+	IoUThresholds []float64 `json:"iou_thresholds"`
+	// Доля изображений в валидационной части, если в датасете она не выделена явно
+	ValidationPercent float64 `json:"validation_percent"`
+	// Рабочий порог уверенности: precision, recall и матрица путаницы считаются по детекциям не ниже него
+	MinConfidence float64 `json:"min_confidence"`
+}
+
+// Scan реализует интерфейс sql.Scanner для EvaluationParams
+func (p *EvaluationParams) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+// Value реализует интерфейс driver.Valuer для EvaluationParams
+func (p EvaluationParams) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// LatencyStats представляет распределение времени инференса по изображениям, мс
+type LatencyStats struct {
+	Count  int     `json:"count"`
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// Scan реализует интерфейс sql.Scanner для LatencyStats
+func (ls *LatencyStats) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, ls)
+}
+
+// Value реализует интерфейс driver.Valuer для LatencyStats
+func (ls LatencyStats) Value() (driver.Value, error) {
+	return json.Marshal(ls)
+}
+
+// EvaluationJob представляет задачу офлайн-оценки модели на датасете
+type EvaluationJob struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	ModelID      uuid.UUID `json:"model_id" db:"model_id"`
+	ModelVersion string    `json:"model_version" db:"-"`
+	DatasetID    uuid.UUID `json:"dataset_id" db:"dataset_id"`
+
+	Status JobStatus        `json:"status" db:"status"`
+	Params EvaluationParams `json:"params" db:"params_json"`
+
+	// Результат
+	Metrics *ModelMetrics `json:"metrics,omitempty" db:"metrics_json"`
+	Latency *LatencyStats `json:"latency,omitempty" db:"latency_json"`
+
+	// Прогресс
+	ImagesTotal     int `json:"images_total" db:"images_total"`
+	ImagesProcessed int `json:"images_processed" db:"images_processed"`
+	ImagesFailed    int `json:"images_failed" db:"images_failed"`
+
+	ErrorMessage *string    `json:"error_message,omitempty" db:"error_message"`
+	CreatedBy    *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// IsCompleted проверяет, завершена ли оценка
+func (j *EvaluationJob) IsCompleted() bool {
+	return j.Status == JobStatusCompleted
+}
+
+// EvaluationJobCreateRequest DTO для запуска оценки модели.
+// Незаданные параметры берутся из конфигурации
+type EvaluationJobCreateRequest struct {
+	DatasetID         uuid.UUID `json:"dataset_id"`
+	IoUThresholds     []float64 `json:"iou_thresholds,omitempty"`
+	ValidationPercent *float64  `json:"validation_percent,omitempty"`
+	MinConfidence     *float64  `json:"min_confidence,omitempty"`
+}
+
+// Validate проверяет запрос запуска оценки
+func (r *EvaluationJobCreateRequest) Validate() error {
+	if r.DatasetID == uuid.Nil {
+		return InvalidInputf("dataset_id is required")
+	}
+	for i, t := range r.IoUThresholds {
+		if t <= 0 || t > 1 {
+			return InvalidInputf("iou_thresholds must be in (0, 1]")
+		}
+		for _, prev := range r.IoUThresholds[:i] {
+			if sameIoU(prev, t) {
+				return InvalidInputf("iou_thresholds contains %g twice", t)
+			}
+		}
+	}
+	if p := r.ValidationPercent; p != nil && (*p <= 0 || *p > 100) {
+		return InvalidInputf("validation_percent must be greater than 0 and at most 100")
+	}
+	if c := r.MinConfidence; c != nil && (*c < 0 || *c >= 1) {
+		return InvalidInputf("min_confidence must be at least 0 and less than 1")
+	}
+	return nil
+}
+
+// EvaluationDetection представляет детекцию модели на изображении оценки
+type EvaluationDetection struct {
+	DefectType DefectType  `json:"defect_type"`
+	Confidence float64     `json:"confidence"`
+	BBox       BoundingBox `json:"bbox"`
+}
+
+// EvaluationDetections представляет выход модели на одном изображении
+type EvaluationDetections []EvaluationDetection
+
+// Scan реализует интерфейс sql.Scanner для EvaluationDetections
+func (d *EvaluationDetections) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+// Value реализует интерфейс driver.Valuer для EvaluationDetections
+func (d EvaluationDetections) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// EvaluationPrediction представляет выход модели на изображении оценки.
+// Detections == nil - инференс завершился ошибкой
+type EvaluationPrediction struct {
+	JobID            uuid.UUID             `json:"job_id" db:"job_id"`
+	ImageKey         string                `json:"image_key" db:"image_key"`
+	Detections       *EvaluationDetections `json:"detections,omitempty" db:"detections_json"`
+	ErrorMessage     *string               `json:"error_message,omitempty" db:"error_message"`
+	ProcessingTimeMs *int64                `json:"processing_time_ms,omitempty" db:"processing_time_ms"`
+	CreatedAt        time.Time             `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"time"
+)
+
+// ExportFormat представляет формат разметки выгружаемого датасета
+type ExportFormat string
+
+const (
+	ExportFormatCOCO ExportFormat = "coco"
+	ExportFormatYOLO ExportFormat = "yolo"
+)
+
+// IsValid проверяет, является ли формат выгрузки допустимым
+func (ef ExportFormat) IsValid() bool {
+	switch ef {
+	case ExportFormatCOCO, ExportFormatYOLO:
+		return true
+	}
+	return false
+}
+
+// AnnotationFormat возвращает значение для datasets.annotation_format
+func (ef ExportFormat) AnnotationFormat() string {
+	switch ef {
+	case ExportFormatCOCO:
+		return "COCO"
+	case ExportFormatYOLO:
+		return "YOLO"
+	}
+	return "custom"
+}
+
+// AnalysisExportFilter задаёт отбор завершённых анализов для выгрузки в обучающий датасет
+type AnalysisExportFilter struct {
+	ModelVersions []string     `json:"model_versions,omitempty"`
+	From          *time.Time   `json:"from,omitempty"` // по created_at, включительно
+	To            *time.Time   `json:"to,omitempty"`   // по created_at, не включительно
+	DefectTypes   []DefectType `json:"defect_types,omitempty"`
+	MinConfidence float64      `json:"min_confidence,omitempty"` // дефекты ниже порога не выгружаются
+	ReviewedOnly  bool         `json:"reviewed_only,omitempty"`  // только проверенные вручную
+}
+
+// AcceptsDefect проверяет, попадает ли дефект в выгрузку
+func (f *AnalysisExportFilter) AcceptsDefect(d *Defect) bool {
+	if d.Confidence < f.MinConfidence {
+		return false
+	}
+	if len(f.DefectTypes) == 0 {
+		return true
+	}
+	for _, t := range f.DefectTypes {
+		if d.DefectType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// DatasetExportRequest DTO для выгрузки анализов в новый датасет
+type DatasetExportRequest struct {
+	Name        string               `json:"name" validate:"required,min=3,max=255"`
+	Description *string              `json:"description,omitempty"`
+	Format      ExportFormat         `json:"format" validate:"required,oneof=coco yolo"`
+	Filter      AnalysisExportFilter `json:"filter"`
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FraudSignal представляет тип признака возможной подделки или повторного использования фото
+type FraudSignal string
+
+const (
+	// FraudSignalCaptureTimeGap - фото снято задолго до загрузки (или время съёмки позже загрузки)
+	FraudSignalCaptureTimeGap FraudSignal = "capture_time_gap"
+	// FraudSignalEditingSoftware - в EXIF указан графический редактор
+	FraudSignalEditingSoftware FraudSignal = "editing_software"
+	// FraudSignalGPSMismatch - координаты съёмки далеко от заявленного места происшествия
+	FraudSignalGPSMismatch FraudSignal = "gps_mismatch"
+	// FraudSignalDuplicateImage - почти такое же фото уже загружал другой пользователь
+	FraudSignalDuplicateImage FraudSignal = "duplicate_image"
+	// FraudSignalHighDefectCount - аномально большое количество дефектов на одном фото
+	FraudSignalHighDefectCount FraudSignal = "high_defect_count"
+)
+
+// IsValid проверяет, является ли признак допустимым
+func (fs FraudSignal) IsValid() bool {
+	switch fs {
+	case FraudSignalCaptureTimeGap, FraudSignalEditingSoftware, FraudSignalGPSMismatch,
+		FraudSignalDuplicateImage, FraudSignalHighDefectCount:
+		return true
+	}
+	return false
+}
+
+// FraudReason представляет сработавший признак с его вкладом в итоговую оценку
+type FraudReason struct {
+	Signal            FraudSignal `json:"signal"`
+	Weight            float64     `json:"weight"`
+	Details           string      `json:"details"`
+	RelatedAnalysisID *uuid.UUID  `json:"related_analysis_id,omitempty"` // для дубликатов
+}
+
+// FraudReasons представляет список причин, хранится в БД в формате JSON
+type FraudReasons []FraudReason
+
+// Scan реализует интерфейс sql.Scanner для FraudReasons
+func (fr *FraudReasons) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, fr)
+}
+
+// Value реализует интерфейс driver.Valuer для FraudReasons
+func (fr FraudReasons) Value() (driver.Value, error) {
+	return json.Marshal(fr)
+}
+
+// FraudAssessment представляет результат антифрод-проверки анализа
+type FraudAssessment struct {
+	Score     float64      `json:"score"` // от 0 (чисто) до 1 (почти наверняка подделка)
+	Reasons   FraudReasons `json:"reasons"`
+	CheckedAt time.Time    `json:"checked_at"`
+}
+
+// GeoPoint представляет географические координаты
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Scan реализует интерфейс sql.Scanner для GeoPoint
+func (gp *GeoPoint) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, gp)
+}
+
+// Value реализует интерфейс driver.Valuer для GeoPoint
+func (gp GeoPoint) Value() (driver.Value, error) {
+	return json.Marshal(gp)
+}
+
+// ExifData представляет значимые для антифрода поля EXIF изображения
+type ExifData struct {
+	CapturedAt  *time.Time `json:"captured_at,omitempty"` // DateTimeOriginal
+	Software    *string    `json:"software,omitempty"`
+	CameraMake  *string    `json:"camera_make,omitempty"`
+	CameraModel *string    `json:"camera_model,omitempty"`
+	GPS         *GeoPoint  `json:"gps,omitempty"`
+}
+
+// HighRiskAnalysisFilter представляет параметры выборки подозрительных анализов
+type HighRiskAnalysisFilter struct {
+	MinScore float64
+	Limit    int
+	Offset   int
+}
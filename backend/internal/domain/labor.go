@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LaborOperation представляет вид работ по устранению дефекта
+type LaborOperation string
+
+const (
+	LaborOperationRemoveRefit LaborOperation = "remove_refit" // снятие и установка
+	LaborOperationRepair      LaborOperation = "repair"       // ремонт (рихтовка, шпатлёвка)
+	LaborOperationPaint       LaborOperation = "paint"        // окраска
+	LaborOperationBlend       LaborOperation = "blend"        // переход краски на соседние детали
+)
+
+// DamageClass представляет класс повреждения, по которому нормируется трудоёмкость
+type DamageClass string
+
+const (
+	DamageClassLight  DamageClass = "light"  // поверхностное: незначительная царапина
+	DamageClassMedium DamageClass = "medium" // серьёзная царапина или небольшая вмятина
+	DamageClassHeavy  DamageClass = "heavy"  // деталь под замену: большая вмятина, трещина, стекло
+)
+
+// IsValid проверяет, является ли класс повреждения допустимым
+func (dc DamageClass) IsValid() bool {
+	return dc == DamageClassLight || dc == DamageClassMedium || dc == DamageClassHeavy
+}
+
+// DamageClassOf определяет класс повреждения по типу и серьёзности дефекта
+func DamageClassOf(dt DefectType, severity DefectSeverity) DamageClass {
+	switch {
+	case dt == DefectTypeCrack, dt == DefectTypeBrokenGlass:
+		return DamageClassHeavy
+	case dt == DefectTypeDent && severity == DefectSeverityMajor:
+		return DamageClassHeavy
+	case dt == DefectTypeScratch && severity == DefectSeverityMinor:
+		return DamageClassLight
+	default:
+		return DamageClassMedium
+	}
+}
+
+// AnyPart - part_id строки нормы, действующей для деталей без собственной нормы
+const AnyPart = "*"
+
+// LaborTime представляет норму трудоёмкости (в нормо-часах) для детали и класса повреждения
+type LaborTime struct {
+	ID               uuid.UUID   `json:"id"`
+	PartID           string      `json:"part_id"` // "*" - норма по умолчанию
+	DamageClass      DamageClass `json:"damage_class"`
+	RemoveRefitHours float64     `json:"remove_refit_hours"`
+	RepairHours      float64     `json:"repair_hours"`
+	PaintHours       float64     `json:"paint_hours"`
+	BlendHours       float64     `json:"blend_hours"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// Hours возвращает ненулевые нормы по видам работ
+func (lt *LaborTime) Hours() map[LaborOperation]float64 {
+	hours := make(map[LaborOperation]float64, 4)
+	for op, h := range map[LaborOperation]float64{
+		LaborOperationRemoveRefit: lt.RemoveRefitHours,
+		LaborOperationRepair:      lt.RepairHours,
+		LaborOperationPaint:       lt.PaintHours,
+		LaborOperationBlend:       lt.BlendHours,
+	} {
+		if h > 0 {
+			hours[op] = h
+		}
+	}
+	return hours
+}
+
+// LaborTimeRequest представляет тело запроса на создание или изменение нормы
+type LaborTimeRequest struct {
+	PartID           string      `json:"part_id"`
+	DamageClass      DamageClass `json:"damage_class"`
+	RemoveRefitHours float64     `json:"remove_refit_hours"`
+	RepairHours      float64     `json:"repair_hours"`
+	PaintHours       float64     `json:"paint_hours"`
+	BlendHours       float64     `json:"blend_hours"`
+}
+
+// Validate проверяет запрос нормы
+func (r *LaborTimeRequest) Validate() error {
+	if r.PartID == "" {
+		return InvalidInputf("part_id is required (use %q for the default)", AnyPart)
+	}
+	if !r.DamageClass.IsValid() {
+		return InvalidInputf("unknown damage_class %q", r.DamageClass)
+	}
+	for _, h := range []float64{r.RemoveRefitHours, r.RepairHours, r.PaintHours, r.BlendHours} {
+		if h < 0 || h > 100 {
+			return InvalidInputf("hours must be between 0 and 100")
+		}
+	}
+	return nil
+}
+
+// DefectLabor представляет оценку трудоёмкости устранения дефекта (с версии схемы 4)
+type DefectLabor struct {
+	DamageClass DamageClass                `json:"damage_class"`
+	Hours       map[LaborOperation]float64 `json:"hours"`
+	TotalHours  float64                    `json:"total_hours"`
+}
+
+// ResultEnricher дополняет собранный результат анализа a (трудоёмкость, стоимость и т.п.).
+// Вызывается воркером после инференса и сервисом проверки после исправлений
+type ResultEnricher interface {
+	Enrich(ctx context.Context, a *Analysis, result *AnalysisResult) error
+}
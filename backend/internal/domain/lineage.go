@@ -0,0 +1,43 @@
+package domain
+
+// LineageNode представляет модель в родословной вместе с задачей обучения,
+// которая её создала, и датасетом этой задачи
+type LineageNode struct {
+	Model       MLModel      `json:"model"`
+	TrainingJob *TrainingJob `json:"training_job,omitempty"`
+	Dataset     *Dataset     `json:"dataset,omitempty"`
+
+	// Изменение метрик относительно родительской модели; nil, если у одной из них нет метрик
+	MetricsDelta *ModelMetricsDelta `json:"metrics_delta,omitempty"`
+
+	Children []LineageNode `json:"children,omitempty"` // только в дереве потомков
+}
+
+// ModelMetricsDelta представляет разность метрик модели и её родителя (модель - родитель)
+type ModelMetricsDelta struct {
+	Accuracy float64 `json:"accuracy"`
+	MAP      float64 `json:"map"`
+	Loss     float64 `json:"loss"`
+}
+
+// NewModelMetricsDelta возвращает разность метрик или nil, если одной из них нет
+func NewModelMetricsDelta(model, parent *ModelMetrics) *ModelMetricsDelta {
+	if model == nil || parent == nil {
+		return nil
+	}
+	return &ModelMetricsDelta{
+		Accuracy: model.Accuracy - parent.Accuracy,
+		MAP:      model.MAP - parent.MAP,
+		Loss:     model.Loss - parent.Loss,
+	}
+}
+
+// ModelLineage представляет родословную модели: цепочку предков от родителя
+// к корню (базовой модели) и дерево потомков в Model.Children
+type ModelLineage struct {
+	Model     LineageNode   `json:"model"`
+	Ancestors []LineageNode `json:"ancestors"`
+
+	// Цепочка предков замкнута (данные записаны в обход проверки); обход остановлен на повторе
+	CycleDetected bool `json:"cycle_detected"`
+}
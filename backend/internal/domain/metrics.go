@@ -0,0 +1,154 @@
+package domain
+
+import "math"
+
+// ClassMetrics представляет качество модели на одном типе дефекта
+type ClassMetrics struct {
+	DefectType DefectType `json:"defect_type"`
+	Support    int        `json:"support"` // размеченных дефектов этого типа в оценочном наборе
+	AP         float64    `json:"ap"`      // среднее AP по порогам IoU 0.50:0.05:0.95
+
+	Thresholds []ClassThresholdMetrics `json:"thresholds"`
+}
+
+// ClassThresholdMetrics представляет precision, recall и AP типа дефекта при одном пороге IoU
+type ClassThresholdMetrics struct {
+	IoU       float64 `json:"iou"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	AP        float64 `json:"ap"`
+}
+
+// ConfusionMatrix представляет матрицу путаницы типов дефектов при пороге IoU.
+// Пустой тип - фон: пропущенный дефект (Predicted == "") или ложное срабатывание (Actual == "")
+type ConfusionMatrix struct {
+	IoU   float64         `json:"iou"`
+	Cells []ConfusionCell `json:"cells"`
+}
+
+// ConfusionCell представляет число пар "размеченный тип - предсказанный тип"
+type ConfusionCell struct {
+	Actual    DefectType `json:"actual"`
+	Predicted DefectType `json:"predicted"`
+	Count     int        `json:"count"`
+}
+
+// CalibrationMetrics показывает, насколько уверенность модели соответствует доле верных детекций.
+// Детекция верна, если совпадает по типу с размеченным дефектом при IoU >= 0.5
+type CalibrationMetrics struct {
+	ECE  float64          `json:"ece"` // ожидаемая ошибка калибровки: взвешенное расхождение по корзинам
+	MCE  float64          `json:"mce"` // максимальное расхождение по корзинам
+	Bins []CalibrationBin `json:"bins"`
+}
+
+// CalibrationBin представляет корзину детекций по уверенности [From, To)
+type CalibrationBin struct {
+	From           float64 `json:"from"`
+	To             float64 `json:"to"`
+	Count          int     `json:"count"`
+	MeanConfidence float64 `json:"mean_confidence"`
+	Precision      float64 `json:"precision"` // доля верных детекций
+}
+
+// sameIoU сравнивает пороги IoU с учётом погрешности записи в JSON
+func sameIoU(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// Class возвращает метрики типа дефекта или nil, если тип не оценивался
+func (mm *ModelMetrics) Class(dt DefectType) *ClassMetrics {
+	for i := range mm.PerClass {
+		if mm.PerClass[i].DefectType == dt {
+			return &mm.PerClass[i]
+		}
+	}
+	return nil
+}
+
+// AtIoU возвращает метрики при пороге iou или nil, если порог не оценивался
+func (cm *ClassMetrics) AtIoU(iou float64) *ClassThresholdMetrics {
+	for i := range cm.Thresholds {
+		if sameIoU(cm.Thresholds[i].IoU, iou) {
+			return &cm.Thresholds[i]
+		}
+	}
+	return nil
+}
+
+// Validate проверяет согласованность метрик, переданных при регистрации модели
+func (mm *ModelMetrics) Validate() error {
+	switch {
+	case mm.Accuracy < 0 || mm.Accuracy > 1:
+		return InvalidInputf("metrics.accuracy must be between 0 and 1")
+	case mm.MAP < 0 || mm.MAP > 1 || mm.MAP50 < 0 || mm.MAP50 > 1:
+		return InvalidInputf("metrics.map and metrics.map50 must be between 0 and 1")
+	case mm.Loss < 0:
+		return InvalidInputf("metrics.loss must not be negative")
+	case len(mm.PerClass) > 0 && len(mm.IoUThresholds) == 0:
+		return InvalidInputf("metrics.per_class requires metrics.iou_thresholds")
+	}
+
+	for i, t := range mm.IoUThresholds {
+		if t <= 0 || t > 1 {
+			return InvalidInputf("metrics.iou_thresholds must be in (0, 1]")
+		}
+		for _, prev := range mm.IoUThresholds[:i] {
+			if sameIoU(prev, t) {
+				return InvalidInputf("metrics.iou_thresholds contains %g twice", t)
+			}
+		}
+	}
+	knownIoU := func(iou float64) bool {
+		for _, t := range mm.IoUThresholds {
+			if sameIoU(t, iou) {
+				return true
+			}
+		}
+		return false
+	}
+
+	seen := make(map[DefectType]bool, len(mm.PerClass))
+	for _, c := range mm.PerClass {
+		switch {
+		case !c.DefectType.IsValid():
+			return InvalidInputf("metrics.per_class: unknown defect type %q", c.DefectType)
+		case seen[c.DefectType]:
+			return InvalidInputf("metrics.per_class: defect type %s is listed twice", c.DefectType)
+		case c.Support < 0:
+			return InvalidInputf("metrics.per_class: support of %s must not be negative", c.DefectType)
+		case c.AP < 0 || c.AP > 1:
+			return InvalidInputf("metrics.per_class: ap of %s must be between 0 and 1", c.DefectType)
+		}
+		seen[c.DefectType] = true
+		for _, t := range c.Thresholds {
+			if !knownIoU(t.IoU) {
+				return InvalidInputf("metrics.per_class: %s IoU %g is not in metrics.iou_thresholds", c.DefectType, t.IoU)
+			}
+			for _, v := range []float64{t.Precision, t.Recall, t.AP} {
+				if v < 0 || v > 1 {
+					return InvalidInputf("metrics.per_class: %s precision, recall and ap must be between 0 and 1", c.DefectType)
+				}
+			}
+		}
+	}
+
+	if mm.Confusion != nil {
+		if mm.Confusion.IoU <= 0 || mm.Confusion.IoU > 1 {
+			return InvalidInputf("metrics.confusion.iou must be in (0, 1]")
+		}
+		for _, cell := range mm.Confusion.Cells {
+			for _, dt := range []DefectType{cell.Actual, cell.Predicted} {
+				if dt != "" && !dt.IsValid() {
+					return InvalidInputf("metrics.confusion: unknown defect type %q", dt)
+				}
+			}
+			if cell.Actual == "" && cell.Predicted == "" {
+				return InvalidInputf("metrics.confusion: a cell needs an actual or a predicted defect type")
+			}
+			if cell.Count < 0 {
+				return InvalidInputf("metrics.confusion: count must not be negative")
+			}
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -35,8 +36,16 @@ func (ms ModelStatus) IsValid() bool {
 // Хранится в БД в формате JSON.
 type ModelMetrics struct {
 	Accuracy float64 `json:"accuracy,omitempty"`
-	MAP      float64 `json:"map,omitempty"`
+	MAP      float64 `json:"map,omitempty"` // после офлайн-оценки - mAP@[.5:.95] в стиле COCO
+	MAP50    float64 `json:"map50,omitempty"`
 	Loss     float64 `json:"loss,omitempty"`
+
+	// Расширенная оценка по типам дефектов; у моделей, зарегистрированных раньше, отсутствует
+	EvaluationDatasetID *uuid.UUID          `json:"evaluation_dataset_id,omitempty"`
+	IoUThresholds       []float64           `json:"iou_thresholds,omitempty"`
+	PerClass            []ClassMetrics      `json:"per_class,omitempty"`
+	Confusion           *ConfusionMatrix    `json:"confusion,omitempty"`
+	Calibration         *CalibrationMetrics `json:"calibration,omitempty"`
 }
 
 // Scan реализует интерфейс sql.Scanner для ModelMetrics для чтения из базы данных.
@@ -56,6 +65,28 @@ func (mm ModelMetrics) Value() (driver.Value, error) {
 	return json.Marshal(mm)
 }
 
+// UnmarshalJSON читает метрики, в том числе старые записи с ключом "mAP"
+// (так ключ описан в миграции models). Ключ "map" имеет приоритет
+func (mm *ModelMetrics) UnmarshalJSON(data []byte) error {
+	type plain ModelMetrics
+	var v struct {
+		plain
+		MAP       *float64 `json:"map"`
+		LegacyMAP *float64 `json:"mAP"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*mm = ModelMetrics(v.plain)
+	switch {
+	case v.MAP != nil:
+		mm.MAP = *v.MAP
+	case v.LegacyMAP != nil:
+		mm.MAP = *v.LegacyMAP
+	}
+	return nil
+}
+
 // MLModel представляет модель машинного обучения в системе.
 
 type MLModel struct {
@@ -84,21 +115,98 @@ type MLModel struct {
 	Description   *string    `json:"description,omitempty" db:"description"`
 
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
 	CreatedBy *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+
+	// Файлы модели с дайджестами (заполняется при регистрации)
+	Artifacts []ModelArtifact `json:"artifacts,omitempty" db:"-"`
 }
 
 // DTO ModelCreateRequest для создания новой модели
 type ModelCreateRequest struct {
-	Version     string        `json:"version" validate:"required"`
-	Name        string        `json:"name" validate:"required"`
-	WeightsPath string        `json:"weights_path" validate:"required"`
-	CarMake     *string       `json:"car_make,omitempty"`
-	CarModel    *string       `json:"car_model,omitempty"`
-	Description *string       `json:"description,omitempty"`
-	Metrics     *ModelMetrics `json:"metrics,omitempty"`
+	Version       string        `json:"version" validate:"required"`
+	Name          string        `json:"name" validate:"required"`
+	WeightsPath   string        `json:"weights_path" validate:"required"`
+	ConfigPath    *string       `json:"config_path,omitempty"`
+	CarMake       *string       `json:"car_make,omitempty"`
+	CarModel      *string       `json:"car_model,omitempty"`
+	Description   *string       `json:"description,omitempty"`
+	Metrics       *ModelMetrics `json:"metrics,omitempty"`
+	ParentModelID *uuid.UUID    `json:"parent_model_id,omitempty"` // модель, от которой дообучена
+
+	// Ожидаемые дайджесты файлов. Если заданы, файлы в хранилище обязаны им соответствовать
+	WeightsSHA256 *string `json:"weights_sha256,omitempty"`
+	ConfigSHA256  *string `json:"config_sha256,omitempty"`
+}
+
+// Validate проверяет и нормализует запрос регистрации модели
+func (r *ModelCreateRequest) Validate() error {
+	r.Version = strings.TrimSpace(r.Version)
+	r.Name = strings.TrimSpace(r.Name)
+	r.WeightsPath = strings.TrimSpace(r.WeightsPath)
+	switch {
+	case r.Version == "" || len(r.Version) > 50:
+		return InvalidInputf("version is required and must be at most 50 characters")
+	case r.Name == "" || len(r.Name) > 255:
+		return InvalidInputf("name is required and must be at most 255 characters")
+	case r.WeightsPath == "" || len(r.WeightsPath) > 500:
+		return InvalidInputf("weights_path is required and must be at most 500 characters")
+	case r.ConfigPath != nil && len(*r.ConfigPath) > 500:
+		return InvalidInputf("config_path must be at most 500 characters")
+	case r.CarModel != nil && r.CarMake == nil:
+		return InvalidInputf("car_model requires car_make")
+	}
+	for _, v := range []*string{r.CarMake, r.CarModel} {
+		if v != nil && (strings.TrimSpace(*v) == "" || len(*v) > 100) {
+			return InvalidInputf("car_make and car_model must be non-empty and at most 100 characters")
+		}
+	}
+	if r.Metrics != nil {
+		if err := r.Metrics.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.ConfigSHA256 != nil && r.ConfigPath == nil {
+		return InvalidInputf("config_sha256 requires config_path")
+	}
+	for field, digest := range map[string]*string{"weights_sha256": r.WeightsSHA256, "config_sha256": r.ConfigSHA256} {
+		if digest == nil {
+			continue
+		}
+		d, err := NormalizeSHA256(field, *digest)
+		if err != nil {
+			return err
+		}
+		*digest = d
+	}
+	return nil
 }
 
 // IsReady проверяет, готова ли модель к использованию
 func (m *MLModel) IsReady() bool {
 	return m.Status == ModelStatusReady || m.Status == ModelStatusActive
 }
+
+// PromotionAction представляет способ, которым модель стала активной
+type PromotionAction string
+
+const (
+	PromotionActionPromote  PromotionAction = "promote"  // продвижение новой модели
+	PromotionActionRollback PromotionAction = "rollback" // возврат к предыдущей активной модели
+)
+
+// ModelPromotion представляет запись истории переключения активной модели
+type ModelPromotion struct {
+	ID              uuid.UUID       `json:"id" db:"id"`
+	ModelID         uuid.UUID       `json:"model_id" db:"model_id"`
+	PreviousModelID *uuid.UUID      `json:"previous_model_id,omitempty" db:"previous_model_id"`
+	Action          PromotionAction `json:"action" db:"action"`
+	Reason          *string         `json:"reason,omitempty" db:"reason"`
+	PerformedBy     *uuid.UUID      `json:"performed_by,omitempty" db:"performed_by"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+}
+
+// ModelPromoteRequest представляет тело запроса на продвижение или откат модели
+type ModelPromoteRequest struct {
+	Reason *string `json:"reason,omitempty"`
+}
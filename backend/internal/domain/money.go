@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Currency представляет код валюты ISO 4217
+type Currency string
+
+const (
+	CurrencyRUB Currency = "RUB"
+	CurrencyEUR Currency = "EUR"
+	CurrencyKZT Currency = "KZT"
+	CurrencyUSD Currency = "USD"
+)
+
+// IsValid проверяет, что валюта поддерживается
+func (c Currency) IsValid() bool {
+	switch c {
+	case CurrencyRUB, CurrencyEUR, CurrencyKZT, CurrencyUSD:
+		return true
+	}
+	return false
+}
+
+// LegacyCostCurrency - валюта оценок стоимости, записанных числом до версии схемы 5
+const LegacyCostCurrency = CurrencyRUB
+
+// minorUnits - число минимальных единиц в основной (у всех поддерживаемых валют - 2 знака)
+const minorUnits = 100
+
+// Amount представляет сумму в минимальных единицах валюты (копейки, центы, тиыны).
+// В JSON передаётся десятичной строкой с двумя знаками ("1234.50"), чтобы не терять точность
+type Amount int64
+
+// ParseAmount разбирает десятичную строку вида "1234.5" или "1234.50"
+func ParseAmount(s string) (Amount, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	r.Mul(r, big.NewRat(minorUnits, 1))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("invalid amount %q: at most 2 decimal places", s)
+	}
+	if !r.Num().IsInt64() {
+		return 0, fmt.Errorf("amount %q is out of range", s)
+	}
+	return Amount(r.Num().Int64()), nil
+}
+
+// AmountFromFloat переводит сумму в основных единицах в Amount с округлением до минимальной единицы
+func AmountFromFloat(f float64) Amount {
+	return Amount(math.Round(f * minorUnits))
+}
+
+// String возвращает сумму десятичной строкой с двумя знаками
+func (a Amount) String() string {
+	sign := ""
+	v := int64(a)
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/minorUnits, v%minorUnits)
+}
+
+// MarshalJSON реализует json.Marshaler
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON реализует json.Unmarshaler: принимает строку или число
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// Money представляет денежную сумму в конкретной валюте
+type Money struct {
+	Amount   Amount   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// String возвращает сумму с кодом валюты, например "1234.50 RUB"
+func (m Money) String() string {
+	return m.Amount.String() + " " + string(m.Currency)
+}
+
+// Add складывает суммы одной валюты
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("cannot add %s to %s", o.Currency, m.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Mul умножает сумму на коэффициент (например, ставку на нормо-часы) с округлением
+func (m Money) Mul(f float64) Money {
+	return Money{Amount: Amount(math.Round(float64(m.Amount) * f)), Currency: m.Currency}
+}
+
+// Convert переводит сумму в другую валюту по курсу (единиц to за единицу m.Currency).
+// Округление - до минимальной единицы, половина - от нуля
+func (m Money) Convert(to Currency, rate *big.Rat) Money {
+	r := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(m.Amount)), rate)
+	f, _ := r.Float64()
+	return Money{Amount: Amount(math.Round(f)), Currency: to}
+}
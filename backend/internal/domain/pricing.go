@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExchangeRate представляет курс: сколько единиц Quote стоит одна единица Base
+// на дату EffectiveDate. Курс действует до следующей даты по той же паре
+type ExchangeRate struct {
+	Base          Currency  `json:"base"`
+	Quote         Currency  `json:"quote"`
+	Rate          string    `json:"rate"` // десятичная строка, например "0.0102"
+	EffectiveDate time.Time `json:"effective_date"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// RateRat возвращает курс как точную дробь
+func (er *ExchangeRate) RateRat() (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(er.Rate)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate %q for %s/%s", er.Rate, er.Base, er.Quote)
+	}
+	return r, nil
+}
+
+// ExchangeRateRequest представляет тело запроса на установку курса
+type ExchangeRateRequest struct {
+	Base          Currency `json:"base"`
+	Quote         Currency `json:"quote"`
+	Rate          string   `json:"rate"`
+	EffectiveDate string   `json:"effective_date"` // YYYY-MM-DD
+}
+
+// Validate проверяет запрос курса и возвращает дату начала действия
+func (r *ExchangeRateRequest) Validate() (time.Time, error) {
+	if !r.Base.IsValid() || !r.Quote.IsValid() {
+		return time.Time{}, InvalidInputf("base and quote must be supported currencies (RUB, EUR, KZT, USD)")
+	}
+	if r.Base == r.Quote {
+		return time.Time{}, InvalidInputf("base and quote must differ")
+	}
+	if rate, ok := new(big.Rat).SetString(r.Rate); !ok || rate.Sign() <= 0 {
+		return time.Time{}, InvalidInputf("rate must be a positive decimal number")
+	}
+	date, err := time.Parse(time.DateOnly, r.EffectiveDate)
+	if err != nil {
+		return time.Time{}, InvalidInputf("effective_date must be a date (YYYY-MM-DD)")
+	}
+	return date, nil
+}
+
+// PriceCatalog представляет региональный прайс-лист, действующий с EffectiveFrom
+// до даты следующего прайс-листа того же региона. Все суммы - в валюте прайс-листа
+type PriceCatalog struct {
+	ID                uuid.UUID          `json:"id"`
+	Region            string             `json:"region"` // например RU, RU-MOW, KZ, DE
+	Currency          Currency           `json:"currency"`
+	EffectiveFrom     time.Time          `json:"effective_from"`
+	LaborRate         Amount             `json:"labor_rate"`          // стоимость нормо-часа
+	PaintMaterialRate Amount             `json:"paint_material_rate"` // материалы на нормо-час окраски
+	Items             []PriceCatalogItem `json:"items"`
+	CreatedAt         time.Time          `json:"created_at"`
+	CreatedBy         *uuid.UUID         `json:"created_by,omitempty"`
+}
+
+// PriceCatalogItem представляет цену новой детали (учитывается при замене)
+type PriceCatalogItem struct {
+	PartID    string `json:"part_id"`
+	PartPrice Amount `json:"part_price"`
+}
+
+// PartPrice возвращает цену детали из прайс-листа
+func (pc *PriceCatalog) PartPrice(partID string) (Amount, bool) {
+	for _, it := range pc.Items {
+		if it.PartID == partID {
+			return it.PartPrice, true
+		}
+	}
+	return 0, false
+}
+
+// PriceCatalogRequest представляет тело запроса на создание прайс-листа
+type PriceCatalogRequest struct {
+	Region            string             `json:"region"`
+	Currency          Currency           `json:"currency"`
+	EffectiveFrom     string             `json:"effective_from"` // YYYY-MM-DD
+	LaborRate         Amount             `json:"labor_rate"`
+	PaintMaterialRate Amount             `json:"paint_material_rate"`
+	Items             []PriceCatalogItem `json:"items"`
+}
+
+// Validate проверяет запрос прайс-листа и возвращает дату начала действия
+func (r *PriceCatalogRequest) Validate() (time.Time, error) {
+	r.Region = strings.ToUpper(strings.TrimSpace(r.Region))
+	if err := ValidateRegion(r.Region); err != nil {
+		return time.Time{}, err
+	}
+	if !r.Currency.IsValid() {
+		return time.Time{}, InvalidInputf("currency must be one of RUB, EUR, KZT, USD")
+	}
+	date, err := time.Parse(time.DateOnly, r.EffectiveFrom)
+	if err != nil {
+		return time.Time{}, InvalidInputf("effective_from must be a date (YYYY-MM-DD)")
+	}
+	if r.LaborRate < 0 || r.PaintMaterialRate < 0 {
+		return time.Time{}, InvalidInputf("rates must not be negative")
+	}
+	seen := make(map[string]bool, len(r.Items))
+	for _, it := range r.Items {
+		if it.PartID == "" || it.PartPrice < 0 {
+			return time.Time{}, InvalidInputf("items require part_id and a non-negative part_price")
+		}
+		if seen[it.PartID] {
+			return time.Time{}, InvalidInputf("duplicate part_id %q", it.PartID)
+		}
+		seen[it.PartID] = true
+	}
+	return date, nil
+}
+
+// ValidateRegion проверяет код региона: код страны ISO 3166-1 и, через дефис, код субъекта
+func ValidateRegion(region string) error {
+	country, sub, hasSub := strings.Cut(region, "-")
+	if len(country) != 2 || !isUpperAlnum(country) || (hasSub && (sub == "" || len(sub) > 3 || !isUpperAlnum(sub))) {
+		return InvalidInputf("invalid region %q: expected ISO 3166 code such as RU or RU-MOW", region)
+	}
+	return nil
+}
+
+func isUpperAlnum(s string) bool {
+	for _, c := range s {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// CostQuote представляет оценку стоимости ремонта по анализу в запрошенной валюте
+type CostQuote struct {
+	AnalysisID    uuid.UUID  `json:"analysis_id"`
+	Region        string     `json:"region"`
+	CatalogID     uuid.UUID  `json:"catalog_id"`
+	EffectiveFrom time.Time  `json:"catalog_effective_from"`
+	LaborHours    float64    `json:"labor_hours"`
+	Cost          Money      `json:"cost"`                // в валюте прайс-листа
+	Quoted        Money      `json:"quoted"`              // в запрошенной валюте
+	Rate          *string    `json:"rate,omitempty"`      // курс пересчёта, если валюты различаются
+	RateDate      *time.Time `json:"rate_date,omitempty"` // дата курса
+}
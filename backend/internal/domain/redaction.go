@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// RedactionClass представляет класс объекта, скрываемого на изображении
+type RedactionClass string
+
+const (
+	RedactionClassLicensePlate RedactionClass = "license_plate" // номерной знак
+	RedactionClassFace         RedactionClass = "face"          // лицо человека в кадре
+)
+
+// IsValid проверяет, является ли класс допустимым
+func (rc RedactionClass) IsValid() bool {
+	return rc == RedactionClassLicensePlate || rc == RedactionClassFace
+}
+
+// ImageVariant представляет вариант изображения анализа
+type ImageVariant string
+
+const (
+	ImageVariantRedacted ImageVariant = "redacted" // со скрытыми номерами и лицами
+	ImageVariantOriginal ImageVariant = "original" // как загружено клиентом, доступ ограничен
+)
+
+// IsValid проверяет, является ли вариант допустимым
+func (iv ImageVariant) IsValid() bool {
+	return iv == ImageVariantRedacted || iv == ImageVariantOriginal
+}
+
+// RedactionRegion представляет скрытую область изображения
+type RedactionRegion struct {
+	Class      RedactionClass `json:"class"`
+	BBox       BoundingBox    `json:"bbox"`
+	Confidence float64        `json:"confidence"`
+}
+
+// RedactionRegions представляет список скрытых областей, хранится в БД в формате JSON
+type RedactionRegions []RedactionRegion
+
+// Scan реализует интерфейс sql.Scanner для RedactionRegions
+func (rr *RedactionRegions) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, rr)
+}
+
+// Value реализует интерфейс driver.Valuer для RedactionRegions
+func (rr RedactionRegions) Value() (driver.Value, error) {
+	if rr == nil {
+		rr = RedactionRegions{}
+	}
+	return json.Marshal(rr)
+}
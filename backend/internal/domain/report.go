@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+)
+
+// LatencyPercentiles представляет перцентили длительности в миллисекундах.
+// Nil, если в группе нет измерений
+type LatencyPercentiles struct {
+	P50 *float64 `json:"p50"`
+	P95 *float64 `json:"p95"`
+	P99 *float64 `json:"p99"`
+}
+
+// SLAReportRow представляет тайминги завершённых анализов одной версии модели за день.
+// Строка с Day == nil - итог по версии модели за весь период
+type SLAReportRow struct {
+	ModelVersion string             `json:"model_version"`
+	Day          *time.Time         `json:"day,omitempty"` // дата завершения (UTC)
+	Count        int                `json:"count"`
+	QueueWaitMs  LatencyPercentiles `json:"queue_wait_ms"`
+	ProcessingMs LatencyPercentiles `json:"processing_ms"`
+}
+
+// SLAReportFilter представляет параметры SLA-отчёта
+type SLAReportFilter struct {
+	From         time.Time // по completed_at, включительно
+	To           time.Time // по completed_at, не включительно
+	ModelVersion *string
+}
+
+// SLAReport представляет SLA-отчёт за период
+type SLAReport struct {
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	ByDay   []SLAReportRow `json:"by_day"`
+	ByModel []SLAReportRow `json:"by_model"`
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentResultSchemaVersion - текущая версия схемы result_json.
+// При любом изменении Defect или ResultSummary версия увеличивается,
+// а в resultUpgrades добавляется функция перехода с предыдущей версии.
+// JSON Schema каждой версии публикуется в api/schemas/analysis_result
+const CurrentResultSchemaVersion = 5
+
+// ResultUpgrade преобразует result_json версии N в версию N+1.
+// Работает с сырым JSON-объектом, т.к. Go-типы описывают только текущую версию
+type ResultUpgrade func(doc map[string]any) error
+
+// resultUpgrades - реестр переходов: ключ - исходная версия
+var resultUpgrades = map[int]ResultUpgrade{
+	1: upgradeResultV1ToV2,
+	2: upgradeResultV2ToV3,
+	3: upgradeResultV3ToV4,
+	4: upgradeResultV4ToV5,
+}
+
+// ResultSchemaVersion возвращает версию схемы result_json.
+// Записи без поля schema_version созданы до версионирования и считаются версией 1
+func ResultSchemaVersion(raw []byte) (int, error) {
+	var head struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return 0, fmt.Errorf("decode result schema version: %w", err)
+	}
+	if head.SchemaVersion == nil {
+		return 1, nil
+	}
+	return *head.SchemaVersion, nil
+}
+
+// UpgradeResultJSON последовательно применяет переходы до текущей версии.
+// Возвращает обновлённый JSON и исходную версию. JSON текущей версии возвращается без изменений
+func UpgradeResultJSON(raw []byte) ([]byte, int, error) {
+	from, err := ResultSchemaVersion(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	if from == CurrentResultSchemaVersion {
+		return raw, from, nil
+	}
+	if from < 1 || from > CurrentResultSchemaVersion {
+		return nil, from, fmt.Errorf("unsupported result schema version %d (current %d)", from, CurrentResultSchemaVersion)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, from, fmt.Errorf("decode result: %w", err)
+	}
+
+	for v := from; v < CurrentResultSchemaVersion; v++ {
+		upgrade, ok := resultUpgrades[v]
+		if !ok {
+			return nil, from, fmt.Errorf("no upgrade registered from result schema version %d", v)
+		}
+		if err := upgrade(doc); err != nil {
+			return nil, from, fmt.Errorf("upgrade result schema %d -> %d: %w", v, v+1, err)
+		}
+		doc["schema_version"] = v + 1
+	}
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, from, err
+	}
+	return upgraded, from, nil
+}
+
+// upgradeResultV1ToV2 добавляет в сводку количество дефектов по типам
+func upgradeResultV1ToV2(doc map[string]any) error {
+	summary, _ := doc["summary"].(map[string]any)
+	if summary == nil {
+		summary = map[string]any{}
+		doc["summary"] = summary
+	}
+
+	byType := map[string]int{}
+	defects, _ := doc["defects"].([]any)
+	for _, d := range defects {
+		defect, ok := d.(map[string]any)
+		if !ok {
+			return fmt.Errorf("defect is not an object")
+		}
+		if t, ok := defect["defect_type"].(string); ok && t != "" {
+			byType[t]++
+		}
+	}
+	summary["defects_by_type"] = byType
+	return nil
+}
+
+// upgradeResultV2ToV3 помечает все дефекты как найденные моделью и не проверенные
+func upgradeResultV2ToV3(doc map[string]any) error {
+	defects, _ := doc["defects"].([]any)
+	for _, d := range defects {
+		defect, ok := d.(map[string]any)
+		if !ok {
+			return fmt.Errorf("defect is not an object")
+		}
+		if _, ok := defect["source"]; !ok {
+			defect["source"] = string(DefectSourceModel)
+		}
+		if _, ok := defect["verified"]; !ok {
+			defect["verified"] = false
+		}
+	}
+	return nil
+}
+
+// upgradeResultV3ToV4 добавляет в сводку поля трудоёмкости. Старые результаты
+// не оценивались, поэтому значения пустые, а у дефектов нет labor
+func upgradeResultV3ToV4(doc map[string]any) error {
+	summary, _ := doc["summary"].(map[string]any)
+	if summary == nil {
+		return fmt.Errorf("summary is not an object")
+	}
+	if _, ok := summary["labor_hours"]; !ok {
+		summary["labor_hours"] = nil
+	}
+	if _, ok := summary["workshop_days"]; !ok {
+		summary["workshop_days"] = nil
+	}
+	return nil
+}
+
+// upgradeResultV4ToV5 переводит оценку стоимости из числа в сумму с валютой.
+// До версии 5 стоимость считалась в LegacyCostCurrency
+func upgradeResultV4ToV5(doc map[string]any) error {
+	summary, _ := doc["summary"].(map[string]any)
+	if summary == nil {
+		return fmt.Errorf("summary is not an object")
+	}
+	switch cost := summary["estimated_cost"].(type) {
+	case nil:
+	case float64:
+		summary["estimated_cost"] = map[string]any{
+			"amount":   AmountFromFloat(cost).String(),
+			"currency": string(LegacyCostCurrency),
+		}
+	default:
+		return fmt.Errorf("estimated_cost is not a number")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxRetentionDays ограничивает сроки хранения, задаваемые организациям (10 лет)
+const MaxRetentionDays = 3650
+
+// Organization представляет организацию клиента и её политику хранения данных
+type Organization struct {
+	ID   uuid.UUID `json:"id" db:"id"`
+	Name string    `json:"name" db:"name"`
+
+	// Сроки хранения. Nil - значение по умолчанию из конфигурации
+	ImageRetentionDays  *int `json:"image_retention_days" db:"image_retention_days"`   // удаление изображений
+	ResultRetentionDays *int `json:"result_retention_days" db:"result_retention_days"` // обезличивание результатов
+
+	TimestampFields
+}
+
+// OrganizationRequest представляет тело запроса на создание или изменение организации
+type OrganizationRequest struct {
+	Name                string `json:"name"`
+	ImageRetentionDays  *int   `json:"image_retention_days"`
+	ResultRetentionDays *int   `json:"result_retention_days"`
+}
+
+// Validate проверяет и нормализует запрос организации
+func (r *OrganizationRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 255 {
+		return InvalidInputf("name is required and must be at most 255 characters")
+	}
+	for field, days := range map[string]*int{
+		"image_retention_days":  r.ImageRetentionDays,
+		"result_retention_days": r.ResultRetentionDays,
+	} {
+		if days != nil && (*days < 1 || *days > MaxRetentionDays) {
+			return InvalidInputf("%s must be between 1 and %d", field, MaxRetentionDays)
+		}
+	}
+	return nil
+}
+
+// UserOrganizationRequest представляет тело запроса на перевод пользователя в организацию
+type UserOrganizationRequest struct {
+	OrganizationID *uuid.UUID `json:"organization_id"` // null - исключить из организации
+}
+
+// RetentionRun представляет итог одного прохода политики хранения
+type RetentionRun struct {
+	ImagesDeleted     int `json:"images_deleted"`
+	ResultsAnonymized int `json:"results_anonymized"`
+	ObjectsPurged     int `json:"objects_purged"`
+}
+
+// UserErasure представляет подтверждение удаления данных пользователя.
+// Персональных данных не содержит: субъект указан псевдонимом, удалённые
+// объекты и записи - SHA-256 манифеста, который получает инициатор удаления
+type UserErasure struct {
+	ID                     uuid.UUID  `json:"id" db:"id"`
+	SubjectPseudonym       string     `json:"subject_pseudonym" db:"subject_pseudonym"`
+	RequestedBy            *uuid.UUID `json:"requested_by,omitempty" db:"requested_by"`
+	Reason                 string     `json:"reason" db:"reason"`
+	AnalysesDeleted        int        `json:"analyses_deleted" db:"analyses_deleted"`
+	DatasetsDeleted        int        `json:"datasets_deleted" db:"datasets_deleted"`
+	ObjectsDeleted         int        `json:"objects_deleted" db:"objects_deleted"`
+	AuditLogsPseudonymized int        `json:"audit_logs_pseudonymized" db:"audit_logs_pseudonymized"`
+	ManifestSHA256         string     `json:"manifest_sha256" db:"manifest_sha256"`
+	StartedAt              time.Time  `json:"started_at" db:"started_at"`
+	CompletedAt            time.Time  `json:"completed_at" db:"completed_at"`
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// RetryRule задаёт число попыток и параметры экспоненциальной задержки
+type RetryRule struct {
+	MaxAttempts int           `json:"max_attempts"` // всего попыток, включая первую
+	BaseDelay   time.Duration `json:"base_delay"`
+	MaxDelay    time.Duration `json:"max_delay"`
+}
+
+// RetryPolicy определяет, повторять ли анализ после ошибки и через какое время
+type RetryPolicy struct {
+	Default RetryRule               `json:"default"`
+	Rules   map[ErrorCode]RetryRule `json:"rules,omitempty"` // переопределения по кодам ошибок
+	Jitter  float64                 `json:"jitter"`          // доля задержки, которая рандомизируется (0..1)
+}
+
+// DefaultRetryPolicy возвращает политику по умолчанию: 3 попытки,
+// задержка от 5 секунд до 5 минут, недоступность инференса повторяется дольше
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Default: RetryRule{MaxAttempts: 3, BaseDelay: 5 * time.Second, MaxDelay: 5 * time.Minute},
+		Rules: map[ErrorCode]RetryRule{
+			ErrorCodeInferenceUnavailable: {MaxAttempts: 6, BaseDelay: 15 * time.Second, MaxDelay: 10 * time.Minute},
+			ErrorCodeStorageTimeout:       {MaxAttempts: 5, BaseDelay: 5 * time.Second, MaxDelay: 2 * time.Minute},
+		},
+		Jitter: 0.2,
+	}
+}
+
+// Rule возвращает правило для кода ошибки
+func (p RetryPolicy) Rule(code ErrorCode) RetryRule {
+	if r, ok := p.Rules[code]; ok {
+		return r
+	}
+	return p.Default
+}
+
+// ShouldRetry проверяет, допускает ли политика ещё одну попытку
+// после retryCount уже выполненных повторов
+func (p RetryPolicy) ShouldRetry(code ErrorCode, retryCount int) bool {
+	if !code.IsTransient() {
+		return false
+	}
+	return p.Rule(code).Allows(retryCount)
+}
+
+// NextDelay вычисляет задержку перед повтором номер retryCount+1 по правилу для кода ошибки
+func (p RetryPolicy) NextDelay(code ErrorCode, retryCount int, random func() float64) time.Duration {
+	return p.Rule(code).Delay(retryCount, p.Jitter, random)
+}
+
+// Allows проверяет, допускает ли правило ещё одну попытку после retryCount уже выполненных повторов
+func (r RetryRule) Allows(retryCount int) bool {
+	// retryCount повторов + первая попытка уже выполнены
+	return retryCount+1 < r.MaxAttempts
+}
+
+// Delay вычисляет задержку перед повтором номер retryCount+1:
+// BaseDelay * 2^retryCount, не больше MaxDelay, с отклонением ±jitter.
+// random должен возвращать число в [0, 1)
+func (r RetryRule) Delay(retryCount int, jitter float64, random func() float64) time.Duration {
+	delay := float64(r.BaseDelay) * math.Pow(2, float64(retryCount))
+	if r.MaxDelay > 0 && delay > float64(r.MaxDelay) {
+		delay = float64(r.MaxDelay)
+	}
+
+	if jitter > 0 && random != nil {
+		delay *= 1 - jitter + 2*jitter*random()
+	}
+	return time.Duration(delay)
+}
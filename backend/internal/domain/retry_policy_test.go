@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryRuleAllows(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		retryCount  int
+		want        bool
+	}{
+		{"first failure of three attempts", 3, 0, true},
+		{"second failure of three attempts", 3, 1, true},
+		{"last attempt used", 3, 2, false},
+		{"beyond the limit", 3, 5, false},
+		{"single attempt", 1, 0, false},
+		{"retries disabled", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := RetryRule{MaxAttempts: tt.maxAttempts, BaseDelay: time.Second}
+			if got := r.Allows(tt.retryCount); got != tt.want {
+				t.Errorf("Allows(%d) with %d attempts = %v, want %v", tt.retryCount, tt.maxAttempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryRuleDelay(t *testing.T) {
+	rule := RetryRule{MaxAttempts: 10, BaseDelay: 5 * time.Second, MaxDelay: time.Minute}
+	tests := []struct {
+		name       string
+		rule       RetryRule
+		retryCount int
+		want       time.Duration
+	}{
+		{"first retry uses base delay", rule, 0, 5 * time.Second},
+		{"delay doubles", rule, 1, 10 * time.Second},
+		{"third retry", rule, 2, 20 * time.Second},
+		{"just below the cap", rule, 3, 40 * time.Second},
+		{"capped at max delay", rule, 4, time.Minute},
+		{"stays capped", rule, 30, time.Minute},
+		{"no cap without max delay", RetryRule{BaseDelay: time.Second}, 10, 1024 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Delay(tt.retryCount, 0, nil); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.retryCount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryRuleDelayJitter(t *testing.T) {
+	rule := RetryRule{MaxAttempts: 5, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+	tests := []struct {
+		name       string
+		retryCount int
+		random     float64
+		want       time.Duration
+	}{
+		{"lower bound", 0, 0, 8 * time.Second},
+		{"middle keeps delay", 0, 0.5, 10 * time.Second},
+		{"upper bound", 0, 0.999999, 12 * time.Second},
+		{"jitter applies after the cap", 5, 0, 48 * time.Second},
+		{"capped upper bound", 5, 0.999999, 72 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rule.Delay(tt.retryCount, 0.2, func() float64 { return tt.random })
+			if diff := got - tt.want; diff < -time.Millisecond || diff > time.Millisecond {
+				t.Errorf("Delay(%d) with random %v = %v, want %v", tt.retryCount, tt.random, got, tt.want)
+			}
+		})
+	}
+
+	// Для любого значения random задержка остаётся в пределах ±jitter
+	for i := range 100 {
+		random := float64(i) / 100
+		got := rule.Delay(1, 0.2, func() float64 { return random })
+		if got < 16*time.Second || got >= 24*time.Second {
+			t.Fatalf("Delay with random %v = %v, want within [16s, 24s)", random, got)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	p := DefaultRetryPolicy()
+	p.Jitter = 0
+
+	tests := []struct {
+		name       string
+		code       ErrorCode
+		retryCount int
+		retry      bool
+		delay      time.Duration
+	}{
+		{"default rule", ErrorCodeInternal, 0, true, 5 * time.Second},
+		{"default rule exhausted", ErrorCodeInternal, 2, false, 20 * time.Second},
+		{"inference override", ErrorCodeInferenceUnavailable, 4, true, 240 * time.Second},
+		{"inference override exhausted", ErrorCodeInferenceUnavailable, 5, false, 8 * time.Minute},
+		{"storage override capped", ErrorCodeStorageTimeout, 5, false, 2 * time.Minute},
+		{"permanent error is never retried", ErrorCodeCorruptImage, 0, false, 5 * time.Second},
+		{"unknown code is transient", ErrorCode("unknown"), 0, true, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.ShouldRetry(tt.code, tt.retryCount); got != tt.retry {
+				t.Errorf("ShouldRetry(%s, %d) = %v, want %v", tt.code, tt.retryCount, got, tt.retry)
+			}
+			if got := p.NextDelay(tt.code, tt.retryCount, nil); got != tt.delay {
+				t.Errorf("NextDelay(%s, %d) = %v, want %v", tt.code, tt.retryCount, got, tt.delay)
+			}
+		})
+	}
+}
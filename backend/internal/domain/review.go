@@ -0,0 +1,220 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewStatus представляет состояние ручной проверки анализа
+type ReviewStatus string
+
+const (
+	ReviewStatusNotRequired ReviewStatus = "not_required" // проверка не требуется
+	ReviewStatusPending     ReviewStatus = "pending"      // в очереди на проверку
+	ReviewStatusReviewed    ReviewStatus = "reviewed"     // проверен
+)
+
+// IsValid проверяет, является ли статус проверки допустимым
+func (rs ReviewStatus) IsValid() bool {
+	switch rs {
+	case ReviewStatusNotRequired, ReviewStatusPending, ReviewStatusReviewed:
+		return true
+	}
+	return false
+}
+
+// ReviewAction представляет действие проверяющего над дефектом
+type ReviewAction string
+
+const (
+	ReviewActionAccept ReviewAction = "accept" // дефект найден верно
+	ReviewActionReject ReviewAction = "reject" // ложное срабатывание, дефект удаляется
+	ReviewActionEdit   ReviewAction = "edit"   // исправить рамку, тип, серьёзность или деталь
+	ReviewActionAdd    ReviewAction = "add"    // добавить пропущенный моделью дефект
+)
+
+// IsValid проверяет, является ли действие допустимым
+func (ra ReviewAction) IsValid() bool {
+	switch ra {
+	case ReviewActionAccept, ReviewActionReject, ReviewActionEdit, ReviewActionAdd:
+		return true
+	}
+	return false
+}
+
+// DefectPatch содержит исправляемые поля дефекта. Nil-поля не меняются
+type DefectPatch struct {
+	BBox       *BoundingBox    `json:"bbox,omitempty"`
+	DefectType *DefectType     `json:"defect_type,omitempty"`
+	Severity   *DefectSeverity `json:"severity,omitempty"`
+	PartName   *string         `json:"part_name,omitempty"`
+	PartID     *string         `json:"part_id,omitempty"`
+}
+
+// ReviewOperation представляет одно действие проверяющего.
+// Для accept/reject/edit задаётся DefectID, для edit и add - Patch
+type ReviewOperation struct {
+	Action   ReviewAction `json:"action"`
+	DefectID string       `json:"defect_id,omitempty"`
+	Patch    *DefectPatch `json:"patch,omitempty"`
+}
+
+// ReviewOperations представляет список действий, хранится в БД в формате JSON
+type ReviewOperations []ReviewOperation
+
+// Scan реализует интерфейс sql.Scanner для ReviewOperations
+func (ro *ReviewOperations) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, ro)
+}
+
+// Value реализует интерфейс driver.Valuer для ReviewOperations
+func (ro ReviewOperations) Value() (driver.Value, error) {
+	return json.Marshal(ro)
+}
+
+// AnalysisReview представляет одну ручную проверку: какие действия выполнены
+// и какой результат (версии ResultVersion) получился
+type AnalysisReview struct {
+	ID            uuid.UUID        `json:"id" db:"id"`
+	AnalysisID    uuid.UUID        `json:"analysis_id" db:"analysis_id"`
+	ReviewerID    *uuid.UUID       `json:"reviewer_id,omitempty" db:"reviewer_id"`
+	ResultVersion int              `json:"result_version" db:"result_version"`
+	Operations    ReviewOperations `json:"operations" db:"operations_json"`
+	Result        *AnalysisResult  `json:"result" db:"result_json"`
+	Comment       *string          `json:"comment,omitempty" db:"comment"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+}
+
+// ReviewSubmitRequest DTO для отправки результата проверки
+type ReviewSubmitRequest struct {
+	// Версия результата, которую видел проверяющий (защита от одновременных правок)
+	ResultVersion int               `json:"result_version" validate:"required"`
+	Operations    []ReviewOperation `json:"operations"`
+	Comment       *string           `json:"comment,omitempty"`
+}
+
+// ApplyReview применяет действия проверяющего к копии результата и возвращает её.
+// Дефекты без действий остаются как есть; сводка пересчитывается.
+// newVersion - версия результата, которая получится после проверки
+func ApplyReview(result *AnalysisResult, ops []ReviewOperation, newVersion int) (*AnalysisResult, error) {
+	out := *result
+	out.Defects = append([]Defect(nil), result.Defects...)
+
+	index := make(map[string]int, len(out.Defects))
+	for i, d := range out.Defects {
+		index[d.ID] = i
+	}
+	rejected := make(map[string]bool)
+	touched := make(map[string]bool)
+	added := 0
+
+	for i, op := range ops {
+		if !op.Action.IsValid() {
+			return nil, InvalidInputf("operation %d: unknown action %q", i, op.Action)
+		}
+
+		if op.Action == ReviewActionAdd {
+			d, err := newReviewerDefect(op.Patch)
+			if err != nil {
+				return nil, InvalidInputf("operation %d: %v", i, err)
+			}
+			added++
+			d.ID = fmt.Sprintf("defect_v%d_%d", newVersion, added)
+			out.Defects = append(out.Defects, d)
+			continue
+		}
+
+		pos, ok := index[op.DefectID]
+		if !ok {
+			return nil, InvalidInputf("operation %d: defect %q not found", i, op.DefectID)
+		}
+		if touched[op.DefectID] {
+			return nil, InvalidInputf("operation %d: defect %q is already reviewed in this request", i, op.DefectID)
+		}
+		touched[op.DefectID] = true
+
+		d := &out.Defects[pos]
+		switch op.Action {
+		case ReviewActionAccept:
+			d.Verified = true
+		case ReviewActionReject:
+			rejected[op.DefectID] = true
+		case ReviewActionEdit:
+			if err := applyPatch(d, op.Patch); err != nil {
+				return nil, InvalidInputf("operation %d: %v", i, err)
+			}
+			if d.Source == DefectSourceModel {
+				d.Source = DefectSourceReviewerEdited
+			}
+			d.Verified = true
+		}
+	}
+
+	kept := out.Defects[:0]
+	for _, d := range out.Defects {
+		if !rejected[d.ID] {
+			kept = append(kept, d)
+		}
+	}
+	out.Defects = kept
+	out.SchemaVersion = CurrentResultSchemaVersion
+	out.RecomputeSummary()
+	return &out, nil
+}
+
+func applyPatch(d *Defect, p *DefectPatch) error {
+	if p == nil {
+		return fmt.Errorf("edit requires a patch")
+	}
+	if p.BBox != nil {
+		if p.BBox.Width <= 0 || p.BBox.Height <= 0 {
+			return fmt.Errorf("bbox width and height must be positive")
+		}
+		d.BBox = *p.BBox
+	}
+	if p.DefectType != nil {
+		if !p.DefectType.IsValid() {
+			return fmt.Errorf("unknown defect type %q", *p.DefectType)
+		}
+		d.DefectType = *p.DefectType
+	}
+	if p.Severity != nil {
+		if !p.Severity.IsValid() {
+			return fmt.Errorf("unknown severity %q", *p.Severity)
+		}
+		d.Severity = *p.Severity
+	}
+	if p.PartName != nil {
+		d.PartName = *p.PartName
+	}
+	if p.PartID != nil {
+		d.PartID = *p.PartID
+	}
+	return nil
+}
+
+func newReviewerDefect(p *DefectPatch) (Defect, error) {
+	if p == nil || p.BBox == nil || p.DefectType == nil || p.Severity == nil {
+		return Defect{}, fmt.Errorf("add requires bbox, defect_type and severity")
+	}
+	d := Defect{
+		Confidence: 1,
+		Source:     DefectSourceReviewerAdded,
+		Verified:   true,
+	}
+	if err := applyPatch(&d, p); err != nil {
+		return Defect{}, err
+	}
+	return d, nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnalysisSort задаёт порядок результатов поиска
+type AnalysisSort string
+
+const (
+	AnalysisSortNewest       AnalysisSort = "-created_at"    // сначала новые (по умолчанию)
+	AnalysisSortOldest       AnalysisSort = "created_at"     // сначала старые
+	AnalysisSortMostDefects  AnalysisSort = "-total_defects" // больше дефектов - выше
+	AnalysisSortFewestDefect AnalysisSort = "total_defects"  // меньше дефектов - выше
+)
+
+// IsValid проверяет, является ли порядок сортировки допустимым
+func (s AnalysisSort) IsValid() bool {
+	switch s {
+	case AnalysisSortNewest, AnalysisSortOldest, AnalysisSortMostDefects, AnalysisSortFewestDefect:
+		return true
+	}
+	return false
+}
+
+// ViewAngles - допустимые ракурсы снимка в результате анализа
+var ViewAngles = []string{"front", "rear", "side_left", "side_right"}
+
+// AnalysisSearchFilter задаёт поиск завершённых анализов по дефектам.
+// Условия на дефект (тип, деталь, серьёзность, уверенность) должны выполняться
+// для одного и того же дефекта результата
+type AnalysisSearchFilter struct {
+	UserID *uuid.UUID // ограничение владельцем; nil - все анализы (для персонала)
+
+	DefectType    *DefectType
+	PartID        *string
+	Severity      *DefectSeverity
+	MinConfidence *float64
+
+	ViewAngle     *string
+	ModelVersions []string
+	CarMake       *string    // без учёта регистра
+	From          *time.Time // по created_at, включительно
+	To            *time.Time // по created_at, не включительно
+
+	Sort   AnalysisSort
+	Limit  int
+	Offset int
+}
+
+// HasDefectConditions проверяет, задано ли хотя бы одно условие на дефект
+func (f *AnalysisSearchFilter) HasDefectConditions() bool {
+	return f.DefectType != nil || f.PartID != nil || f.Severity != nil || f.MinConfidence != nil
+}
+
+// AnalysisSearchPage представляет страницу результатов поиска с общим количеством
+type AnalysisSearchPage struct {
+	Items  []Analysis `json:"items"`
+	Total  int        `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShadowDeployment представляет теневое развёртывание модели-кандидата: воркер прогоняет
+// через кандидата percent процентов анализов активной модели. Результаты клиентам не видны
+type ShadowDeployment struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ModelID      uuid.UUID  `json:"model_id" db:"model_id"`
+	ModelVersion string     `json:"model_version" db:"-"`
+	Percent      float64    `json:"percent" db:"percent"`
+	Enabled      bool       `json:"enabled" db:"enabled"`
+	CreatedBy    *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+
+	TimestampFields
+}
+
+// ShadowDeploymentRequest представляет тело запроса на создание теневого развёртывания
+type ShadowDeploymentRequest struct {
+	ModelID uuid.UUID `json:"model_id"`
+	Percent *float64  `json:"percent,omitempty"` // по умолчанию 100
+}
+
+// Validate проверяет запрос и подставляет долю по умолчанию
+func (r *ShadowDeploymentRequest) Validate() error {
+	if r.ModelID == uuid.Nil {
+		return InvalidInputf("model_id is required")
+	}
+	if r.Percent == nil {
+		all := 100.0
+		r.Percent = &all
+	}
+	return validateTrafficPercent(*r.Percent)
+}
+
+// ShadowDeploymentUpdateRequest представляет тело запроса на изменение доли или отключение
+type ShadowDeploymentUpdateRequest struct {
+	Percent *float64 `json:"percent,omitempty"`
+	Enabled *bool    `json:"enabled,omitempty"`
+}
+
+// Validate проверяет запрос изменения теневого развёртывания
+func (r *ShadowDeploymentUpdateRequest) Validate() error {
+	if r.Percent == nil && r.Enabled == nil {
+		return InvalidInputf("percent or enabled is required")
+	}
+	if r.Percent != nil {
+		return validateTrafficPercent(*r.Percent)
+	}
+	return nil
+}
+
+// ShadowResult представляет выход модели-кандидата на изображении анализа
+type ShadowResult struct {
+	ID               uuid.UUID       `json:"id" db:"id"`
+	DeploymentID     uuid.UUID       `json:"deployment_id" db:"deployment_id"`
+	AnalysisID       uuid.UUID       `json:"analysis_id" db:"analysis_id"`
+	ModelVersion     string          `json:"model_version" db:"model_version"`
+	PrimaryVersion   string          `json:"primary_version" db:"primary_version"`
+	Result           *AnalysisResult `json:"result,omitempty" db:"result_json"`
+	ErrorMessage     *string         `json:"error_message,omitempty" db:"error_message"`
+	ProcessingTimeMs *int64          `json:"processing_time_ms,omitempty" db:"processing_time_ms"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+}
+
+// ShadowTypeConfusion представляет число дефектов с типом Primary у активной модели
+// и Shadow у кандидата. Пустой тип - дефект не сопоставлен (найден только одной моделью)
+type ShadowTypeConfusion struct {
+	Primary DefectType `json:"primary"`
+	Shadow  DefectType `json:"shadow"`
+	Count   int        `json:"count"`
+}
+
+// ShadowReport представляет согласованность активной модели и кандидата.
+// Дефекты сопоставляются по IoU рамок не ниже MatchIoU, независимо от типа
+type ShadowReport struct {
+	Deployment ShadowDeployment `json:"deployment"`
+	From       *time.Time       `json:"from,omitempty"`
+	To         *time.Time       `json:"to,omitempty"`
+	MatchIoU   float64          `json:"match_iou"`
+
+	Analyses       int     `json:"analyses"`        // сравнённые анализы
+	ShadowFailed   int     `json:"shadow_failed"`   // ошибки инференса кандидата
+	FullAgreement  int     `json:"full_agreement"`  // анализы, где все дефекты сопоставлены с тем же типом
+	AgreementRate  float64 `json:"agreement_rate"`  // full_agreement / analyses
+	PrimaryDefects int     `json:"primary_defects"` // дефектов у активной модели
+	ShadowDefects  int     `json:"shadow_defects"`  // дефектов у кандидата
+
+	Matched       int     `json:"matched"`        // сопоставленные пары
+	SameType      int     `json:"same_type"`      // из них с совпавшим типом
+	OnlyPrimary   int     `json:"only_primary"`   // пропущены кандидатом
+	OnlyShadow    int     `json:"only_shadow"`    // найдены только кандидатом
+	DefectF1      float64 `json:"defect_f1"`      // 2 * same_type / (primary_defects + shadow_defects)
+	MeanIoU       float64 `json:"mean_iou"`       // по сопоставленным парам
+	TypeAgreement float64 `json:"type_agreement"` // same_type / matched
+
+	// Разница уверенности кандидата и активной модели по сопоставленным парам
+	MeanConfidenceDelta    float64 `json:"mean_confidence_delta"`
+	MeanAbsConfidenceDelta float64 `json:"mean_abs_confidence_delta"`
+
+	TypeConfusion []ShadowTypeConfusion `json:"type_confusion"`
+}
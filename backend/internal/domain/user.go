@@ -36,6 +36,12 @@ type User struct {
 	EmailVerified bool `json:"email_verified" db:"email_verified"`
 	IsActive      bool `json:"is_active" db:"is_active"`
 
+	// Организация, политика хранения которой применяется к данным пользователя
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" db:"organization_id"`
+
+	// Язык интерфейса (ru, en). Nil - по заголовку Accept-Language
+	Language *string `json:"language,omitempty" db:"language"`
+
 	// Метаданные
 	TimestampFields
 	LastLogin *time.Time `json:"last_login,omitempty" db:"last_login"`
@@ -55,27 +61,36 @@ type UserCreateRequest struct {
 
 // DTO UserResponse представляет данные пользователя, возвращаемые API
 type UserResponse struct {
-	ID            uuid.UUID  `json:"id"`
-	Username      string     `json:"username"`
-	Email         string     `json:"email"`
-	Role          Role       `json:"role"`
-	EmailVerified bool       `json:"email_verified"`
-	IsActive      bool       `json:"is_active"`
-	CreatedAt     time.Time  `json:"created_at"`
-	LastLogin     *time.Time `json:"last_login,omitempty"`
+	ID             uuid.UUID  `json:"id"`
+	Username       string     `json:"username"`
+	Email          string     `json:"email"`
+	Role           Role       `json:"role"`
+	EmailVerified  bool       `json:"email_verified"`
+	IsActive       bool       `json:"is_active"`
+	Language       *string    `json:"language,omitempty"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastLogin      *time.Time `json:"last_login,omitempty"`
+}
+
+// UserPreferencesRequest DTO для изменения настроек пользователя
+type UserPreferencesRequest struct {
+	Language *string `json:"language"` // null - сбросить и определять по Accept-Language
 }
 
 // ToUserResponse преобразует User в UserResponse для API
 func (u *User) ToUserResponse() UserResponse {
 	return UserResponse{
-		ID:            u.ID,
-		Username:      u.Username,
-		Email:         u.Email,
-		Role:          u.Role,
-		EmailVerified: u.EmailVerified,
-		IsActive:      u.IsActive,
-		CreatedAt:     u.CreatedAt,
-		LastLogin:     u.LastLogin,
+		ID:             u.ID,
+		Username:       u.Username,
+		Email:          u.Email,
+		Role:           u.Role,
+		EmailVerified:  u.EmailVerified,
+		IsActive:       u.IsActive,
+		Language:       u.Language,
+		OrganizationID: u.OrganizationID,
+		CreatedAt:      u.CreatedAt,
+		LastLogin:      u.LastLogin,
 	}
 }
 
@@ -88,3 +103,19 @@ func (u *User) IsAdmin() bool {
 func (u *User) CanManageModels() bool {
 	return u.Role == RoleOwner || u.Role == RoleAdmin
 }
+
+// CanReview проверяет, может ли пользователь проверять и исправлять результаты анализов
+func (u *User) CanReview() bool {
+	return u.Role == RoleOwner || u.Role == RoleAdmin
+}
+
+// CanViewOriginalImages проверяет, может ли пользователь видеть изображения
+// без скрытия номеров и лиц
+func (u *User) CanViewOriginalImages() bool {
+	return u.Role == RoleOwner || u.Role == RoleAdmin
+}
+
+// CanViewAllAnalyses проверяет, может ли пользователь искать по анализам всех клиентов
+func (u *User) CanViewAllAnalyses() bool {
+	return u.Role == RoleOwner || u.Role == RoleAdmin
+}
@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"encoding/json"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEvent представляет тип события, отправляемого клиенту
+type WebhookEvent string
+
+const (
+	WebhookEventAnalysisCompleted WebhookEvent = "analysis.completed"
+	WebhookEventAnalysisFailed    WebhookEvent = "analysis.failed"
+	WebhookEventBatchCompleted    WebhookEvent = "batch.completed" // все анализы пакета в конечном статусе
+)
+
+// WebhookDeliveryStatus представляет статус доставки события
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // ожидает очередной попытки
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded" // получен ответ 2xx
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"    // попытки исчерпаны
+)
+
+// WebhookEndpoint представляет webhook аккаунта и секрет подписи событий
+type WebhookEndpoint struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	URL       *string    `json:"url,omitempty" db:"url"`
+	Secret    string     `json:"secret" db:"secret"`
+	Active    bool       `json:"active" db:"active"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// WebhookEndpointRequest DTO для настройки webhook аккаунта
+type WebhookEndpointRequest struct {
+	URL    *string `json:"url,omitempty"`
+	Active *bool   `json:"active,omitempty"`
+}
+
+// WebhookDelivery представляет отправку одного события на один адрес.
+// Событие относится либо к анализу (AnalysisID), либо к пакету (BatchID)
+type WebhookDelivery struct {
+	ID           uuid.UUID             `json:"id" db:"id"`
+	AnalysisID   *uuid.UUID            `json:"analysis_id,omitempty" db:"analysis_id"`
+	BatchID      *uuid.UUID            `json:"batch_id,omitempty" db:"batch_id"`
+	UserID       uuid.UUID             `json:"user_id" db:"user_id"`
+	RedeliveryOf *uuid.UUID            `json:"redelivery_of,omitempty" db:"redelivery_of"`
+	Event        WebhookEvent          `json:"event" db:"event"`
+	URL          string                `json:"url" db:"url"`
+	Payload      json.RawMessage       `json:"payload" db:"payload"`
+	Status       WebhookDeliveryStatus `json:"status" db:"status"`
+
+	AttemptCount     int        `json:"attempt_count" db:"attempt_count"`
+	NextAttemptAt    *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastError        *string    `json:"last_error,omitempty" db:"last_error"`
+	LastResponseCode *int       `json:"last_response_code,omitempty" db:"last_response_code"`
+
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+
+	Attempts []WebhookAttempt `json:"attempts,omitempty"`
+}
+
+// WebhookAttempt представляет одну попытку доставки
+type WebhookAttempt struct {
+	ID           int64     `json:"id" db:"id"`
+	DeliveryID   uuid.UUID `json:"delivery_id" db:"delivery_id"`
+	Attempt      int       `json:"attempt" db:"attempt"`
+	ResponseCode *int      `json:"response_code,omitempty" db:"response_code"`
+	Error        *string   `json:"error,omitempty" db:"error"`
+	DurationMs   int64     `json:"duration_ms" db:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// WebhookPayload - тело запроса, отправляемого клиенту. Заполнено одно из Analysis и Batch
+type WebhookPayload struct {
+	Event      WebhookEvent     `json:"event"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	Analysis   *WebhookAnalysis `json:"analysis,omitempty"`
+	Batch      *WebhookBatch    `json:"batch,omitempty"`
+}
+
+// WebhookAnalysis - данные анализа, передаваемые в webhook (без внутренних полей антифрода)
+type WebhookAnalysis struct {
+	ID               uuid.UUID       `json:"id"`
+	BatchID          *uuid.UUID      `json:"batch_id,omitempty"`
+	Status           AnalysisStatus  `json:"status"`
+	ImageKey         string          `json:"image_key"`
+	ModelVersion     string          `json:"model_version"`
+	Result           *AnalysisResult `json:"result,omitempty"`
+	ReviewStatus     ReviewStatus    `json:"review_status"`
+	ErrorCode        *ErrorCode      `json:"error_code,omitempty"`
+	ErrorMessage     *string         `json:"error_message,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+	CompletedAt      *time.Time      `json:"completed_at,omitempty"`
+	ProcessingTimeMs *int64          `json:"processing_time_ms,omitempty"`
+}
+
+// WebhookBatch - данные пакета, передаваемые в событии batch.completed
+type WebhookBatch struct {
+	ID           uuid.UUID     `json:"id"`
+	Name         *string       `json:"name,omitempty"`
+	ModelVersion string        `json:"model_version"`
+	Status       BatchStatus   `json:"status"`
+	Progress     BatchProgress `json:"progress"`
+	CreatedAt    time.Time     `json:"created_at"`
+	CompletedAt  *time.Time    `json:"completed_at,omitempty"`
+}
+
+// NewWebhookPayload собирает событие по анализу в конечном статусе
+func NewWebhookPayload(a *Analysis, now time.Time) WebhookPayload {
+	event := WebhookEventAnalysisCompleted
+	if a.IsFailed() {
+		event = WebhookEventAnalysisFailed
+	}
+	return WebhookPayload{
+		Event:      event,
+		OccurredAt: now,
+		Analysis: &WebhookAnalysis{
+			ID:               a.ID,
+			BatchID:          a.BatchID,
+			Status:           a.Status,
+			ImageKey:         a.ImageKey,
+			ModelVersion:     a.ModelVersion,
+			Result:           a.Result,
+			ReviewStatus:     a.ReviewStatus,
+			ErrorCode:        a.ErrorCode,
+			ErrorMessage:     a.ErrorMessage,
+			CreatedAt:        a.CreatedAt,
+			CompletedAt:      a.CompletedAt,
+			ProcessingTimeMs: a.ProcessingTimeMs,
+		},
+	}
+}
+
+// NewBatchWebhookPayload собирает событие о завершении пакета
+func NewBatchWebhookPayload(b *AnalysisBatch, now time.Time) WebhookPayload {
+	return WebhookPayload{
+		Event:      WebhookEventBatchCompleted,
+		OccurredAt: now,
+		Batch: &WebhookBatch{
+			ID:           b.ID,
+			Name:         b.Name,
+			ModelVersion: b.ModelVersion,
+			Status:       b.Status,
+			Progress:     b.Progress,
+			CreatedAt:    b.CreatedAt,
+			CompletedAt:  b.CompletedAt,
+		},
+	}
+}
+
+// ValidateWebhookURL проверяет адрес webhook: абсолютный http(s) URL без учётных данных.
+// Если allowPrivate выключен, адрес не может указывать на localhost или IP-адрес внутренней сети.
+// Имя хоста здесь не разрешается: адрес, в который оно разрешилось, проверяет отправитель при подключении
+func ValidateWebhookURL(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return InvalidInputf("webhook url must be an absolute http(s) URL")
+	}
+	if u.User != nil {
+		return InvalidInputf("webhook url must not contain credentials")
+	}
+	if len(raw) > 2000 {
+		return InvalidInputf("webhook url must be at most 2000 characters")
+	}
+	if !allowPrivate && isLocalHost(u.Hostname()) {
+		return InvalidInputf("webhook url must not point to a local or private network address")
+	}
+	return nil
+}
+
+// IsPublicAddr проверяет, можно ли отправлять webhook на IP-адрес. Запрещены loopback, link-local,
+// частные сети (RFC 1918 и ULA fc00::/7), неуказанный адрес и multicast
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// isLocalHost проверяет, указывает ли хост из URL на localhost или на непубличный IP-адрес
+func isLocalHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && !IsPublicAddr(addr)
+}
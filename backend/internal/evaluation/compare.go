@@ -0,0 +1,220 @@
+package evaluation
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/shadow"
+	"github.com/google/uuid"
+)
+
+// Compare сравнивает кандидата с базовой моделью по их последним завершённым оценкам
+// на общем наборе (датасет и доля валидационной части). datasetID == nil - любой общий датасет
+func (s *Service) Compare(ctx context.Context, user *domain.User, baselineID, candidateID uuid.UUID,
+	datasetID *uuid.UUID) (*domain.ModelComparison, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	if baselineID == candidateID {
+		return nil, domain.InvalidInputf("baseline and candidate must be different models")
+	}
+	baseline, err := s.models.GetByID(ctx, baselineID)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := s.models.GetByID(ctx, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	bj, cj, err := s.jobs.LatestCommon(ctx, baselineID, candidateID, datasetID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, domain.Conflictf("models %s and %s have no completed evaluations on a common dataset",
+			baseline.Version, candidate.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	bp, err := s.jobs.Predictions(ctx, bj.ID)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := s.jobs.Predictions(ctx, cj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &domain.ModelComparison{
+		Baseline:  compared(baseline, bj),
+		Candidate: compared(candidate, cj),
+		DatasetID: bj.DatasetID,
+	}
+	diffMetrics(c, c.Baseline.Metrics, c.Candidate.Metrics)
+	if bl, cl := bj.Latency, cj.Latency; bl != nil && cl != nil {
+		p50, p95 := cl.P50Ms-bl.P50Ms, cl.P95Ms-bl.P95Ms
+		c.LatencyP50Ms, c.LatencyP95Ms = &p50, &p95
+	}
+	c.Disagreements, c.CommonImages = disagreements(bp, cp, bj.Params.MinConfidence, cj.Params.MinConfidence,
+		s.cfg.CompareMatchIoU, s.cfg.CompareExamples)
+	c.Recommendation = recommend(c, s.cfg.CompareThreshold)
+	return c, nil
+}
+
+func compared(m *domain.MLModel, j *domain.EvaluationJob) domain.ComparedModel {
+	metrics := j.Metrics
+	if metrics == nil {
+		metrics = &domain.ModelMetrics{}
+	}
+	return domain.ComparedModel{Model: *m, EvaluationID: j.ID, Metrics: metrics, Latency: j.Latency, ImagesFailed: j.ImagesFailed}
+}
+
+// diffMetrics заполняет разности общих метрик и метрик по типам дефектов
+func diffMetrics(c *domain.ModelComparison, base, cand *domain.ModelMetrics) {
+	c.MAP = cand.MAP - base.MAP
+	c.MAP50 = cand.MAP50 - base.MAP50
+	if base.Calibration != nil && cand.Calibration != nil {
+		ece := cand.Calibration.ECE - base.Calibration.ECE
+		c.ECE = &ece
+	}
+
+	c.PerClass = []domain.ClassMetricsDelta{}
+	for _, dt := range classes {
+		bc, cc := base.Class(dt), cand.Class(dt)
+		if bc == nil && cc == nil {
+			continue
+		}
+		if bc == nil {
+			bc = &domain.ClassMetrics{DefectType: dt, Support: cc.Support}
+		}
+		if cc == nil {
+			cc = &domain.ClassMetrics{DefectType: dt, Support: bc.Support}
+		}
+		d := domain.ClassMetricsDelta{
+			DefectType:  dt,
+			Support:     bc.Support,
+			BaselineAP:  bc.AP,
+			CandidateAP: cc.AP,
+			AP:          cc.AP - bc.AP,
+			Thresholds:  []domain.ClassThresholdMetrics{},
+		}
+		for _, bt := range bc.Thresholds {
+			if ct := cc.AtIoU(bt.IoU); ct != nil {
+				d.Thresholds = append(d.Thresholds, domain.ClassThresholdMetrics{
+					IoU:       bt.IoU,
+					Precision: ct.Precision - bt.Precision,
+					Recall:    ct.Recall - bt.Recall,
+					AP:        ct.AP - bt.AP,
+				})
+			}
+		}
+		c.PerClass = append(c.PerClass, d)
+	}
+}
+
+// disagreements сопоставляет выходы моделей на общих изображениях и возвращает до limit
+// изображений с наибольшей долей несогласованных детекций, а также число общих изображений.
+// Учитываются детекции не ниже рабочего порога уверенности каждой модели
+func disagreements(base, cand map[string]*domain.EvaluationPrediction, baseMin, candMin, iou float64,
+	limit int) ([]domain.ModelDisagreement, int) {
+	var items []domain.ModelDisagreement
+	common := 0
+	for key, bp := range base {
+		cp, ok := cand[key]
+		if !ok || bp.Detections == nil || cp.Detections == nil {
+			continue
+		}
+		common++
+		bd, cd := confident(*bp.Detections, baseMin), confident(*cp.Detections, candMin)
+		total := len(bd) + len(cd)
+		if total == 0 {
+			continue
+		}
+		same := 0
+		for _, m := range shadow.MatchDefects(asDefects(bd), asDefects(cd), iou) {
+			if bd[m.Primary].DefectType == cd[m.Shadow].DefectType {
+				same++
+			}
+		}
+		if score := 1 - 2*float64(same)/float64(total); score > 0 {
+			items = append(items, domain.ModelDisagreement{ImageKey: key, Score: score, Baseline: bd, Candidate: cd})
+		}
+	}
+	slices.SortFunc(items, func(a, b domain.ModelDisagreement) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(len(b.Baseline)+len(b.Candidate), len(a.Baseline)+len(a.Candidate)),
+			cmp.Compare(a.ImageKey, b.ImageKey),
+		)
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	if items == nil {
+		items = []domain.ModelDisagreement{}
+	}
+	return items, common
+}
+
+func confident(detections domain.EvaluationDetections, minConfidence float64) domain.EvaluationDetections {
+	out := domain.EvaluationDetections{}
+	for _, d := range detections {
+		if d.Confidence >= minConfidence {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func asDefects(detections domain.EvaluationDetections) []domain.Defect {
+	defects := make([]domain.Defect, len(detections))
+	for i, d := range detections {
+		defects[i] = domain.Defect{DefectType: d.DefectType, BBox: d.BBox, Confidence: d.Confidence}
+	}
+	return defects
+}
+
+// recommend проверяет условия замены базовой модели кандидатом.
+// Условие, для которого нет данных (время инференса, калибровка), считается выполненным
+func recommend(c *domain.ModelComparison, t domain.ComparisonThresholds) domain.ComparisonRecommendation {
+	rec := domain.ComparisonRecommendation{Thresholds: t}
+
+	rec.Checks = append(rec.Checks, domain.RecommendationCheck{
+		Name: "map_gain", Value: c.MAP, Threshold: t.MinMAPGain, Passed: c.MAP >= t.MinMAPGain,
+	})
+
+	drop := domain.RecommendationCheck{Name: "class_ap_drop", Threshold: t.MaxClassAPDrop}
+	for _, d := range c.PerClass {
+		if d.Support > 0 && -d.AP > drop.Value {
+			drop.Value, drop.Detail = -d.AP, string(d.DefectType)
+		}
+	}
+	drop.Passed = drop.Value <= t.MaxClassAPDrop
+	rec.Checks = append(rec.Checks, drop)
+
+	latency := domain.RecommendationCheck{Name: "latency_increase", Threshold: t.MaxLatencyIncrease, Passed: true}
+	if bl := c.Baseline.Latency; bl != nil && c.LatencyP95Ms != nil && bl.P95Ms > 0 {
+		latency.Value = *c.LatencyP95Ms / bl.P95Ms
+		latency.Passed = latency.Value <= t.MaxLatencyIncrease
+	} else {
+		latency.Detail = "latency was not measured for both models"
+	}
+	rec.Checks = append(rec.Checks, latency)
+
+	ece := domain.RecommendationCheck{Name: "ece_increase", Threshold: t.MaxECEIncrease, Passed: true}
+	if c.ECE != nil {
+		ece.Value = *c.ECE
+		ece.Passed = ece.Value <= t.MaxECEIncrease
+	} else {
+		ece.Detail = "calibration was not measured for both models"
+	}
+	rec.Checks = append(rec.Checks, ece)
+
+	rec.Recommended = true
+	for _, ch := range rec.Checks {
+		rec.Recommended = rec.Recommended && ch.Passed
+	}
+	return rec
+}
@@ -0,0 +1,270 @@
+package evaluation
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+)
+
+// Файлы явно выделенной валидационной части. Если их нет в архиве, валидационная
+// часть отбирается из всего датасета детерминированно по ключу изображения
+const (
+	cocoValidationFile = "annotations/instances_val.json"
+	cocoFile           = "annotations/instances.json"
+	yoloValidationFile = "images_val.txt"
+	yoloFile           = "images.txt"
+)
+
+// Sample представляет изображение валидационной части с разметкой
+type Sample struct {
+	ImageKey string
+	Truth    []Box
+
+	// Разметка YOLO задана в долях кадра и переводится в пиксели в Resolve
+	normalized []normalizedBox
+}
+
+type normalizedBox struct {
+	defectType            domain.DefectType
+	xc, yc, width, height float64
+}
+
+// Resolve переводит разметку в пиксели по размерам изображения
+func (s *Sample) Resolve(width, height int) {
+	for _, b := range s.normalized {
+		w, h := b.width*float64(width), b.height*float64(height)
+		s.Truth = append(s.Truth, Box{DefectType: b.defectType, BBox: domain.BoundingBox{
+			X:      int(math.Round(b.xc*float64(width) - w/2)),
+			Y:      int(math.Round(b.yc*float64(height) - h/2)),
+			Width:  int(math.Round(w)),
+			Height: int(math.Round(h)),
+		}})
+	}
+	s.normalized = nil
+}
+
+// LoadSamples читает архив датасета (COCO или YOLO, как их пишет выгрузка) и возвращает
+// изображения валидационной части. Разметка с типами, которых нет в системе, пропускается
+func LoadSamples(ctx context.Context, store storage.ObjectStorage, ds *domain.Dataset, validationPercent float64) ([]Sample, error) {
+	if ds.FileKey == nil {
+		return nil, domain.InvalidInputf("dataset %s has no file", ds.ID)
+	}
+	tmp, err := os.CreateTemp("", "dataset-eval-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	rc, err := store.Get(ctx, *ds.FileKey)
+	if err != nil {
+		return nil, fmt.Errorf("open dataset archive %s: %w", *ds.FileKey, err)
+	}
+	size, err := io.Copy(tmp, rc)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("download dataset archive %s: %w", *ds.FileKey, err)
+	}
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return nil, domain.InvalidInputf("dataset %s archive is not a valid zip: %v", ds.ID, err)
+	}
+
+	format := ""
+	if ds.AnnotationFormat != nil {
+		format = strings.ToUpper(*ds.AnnotationFormat)
+	}
+	split := splitFilter(validationPercent)
+	switch format {
+	case "COCO":
+		if f := findFile(zr, cocoValidationFile); f != nil {
+			return readCOCO(f, nil)
+		}
+		if f := findFile(zr, cocoFile); f != nil {
+			return readCOCO(f, split)
+		}
+		return nil, domain.InvalidInputf("dataset %s archive has no %s", ds.ID, cocoFile)
+	case "YOLO":
+		if f := findFile(zr, yoloValidationFile); f != nil {
+			return readYOLO(zr, f, nil)
+		}
+		if f := findFile(zr, yoloFile); f != nil {
+			return readYOLO(zr, f, split)
+		}
+		return nil, domain.InvalidInputf("dataset %s archive has no %s", ds.ID, yoloFile)
+	default:
+		return nil, domain.InvalidInputf("dataset %s has unsupported annotation format %q: COCO or YOLO expected", ds.ID, format)
+	}
+}
+
+// splitFilter отбирает в валидационную часть percent процентов изображений по хэшу ключа.
+// Отбор не зависит от порядка изображений в архиве и повторяется между запусками
+func splitFilter(percent float64) func(key string) bool {
+	return func(key string) bool {
+		sum := sha256.Sum256([]byte(key))
+		bucket := binary.BigEndian.Uint64(sum[:8]) % 10000
+		return float64(bucket) < percent*100
+	}
+}
+
+func findFile(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Структуры COCO, нужные для чтения разметки
+type cocoDoc struct {
+	Images []struct {
+		ID       int    `json:"id"`
+		FileName string `json:"file_name"`
+	} `json:"images"`
+	Annotations []struct {
+		ImageID    int        `json:"image_id"`
+		CategoryID int        `json:"category_id"`
+		BBox       [4]float64 `json:"bbox"`
+	} `json:"annotations"`
+	Categories []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"categories"`
+}
+
+func readCOCO(f *zip.File, accept func(string) bool) ([]Sample, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var doc cocoDoc
+	if err := json.NewDecoder(rc).Decode(&doc); err != nil {
+		return nil, domain.InvalidInputf("parse %s: %v", f.Name, err)
+	}
+
+	categories := make(map[int]domain.DefectType, len(doc.Categories))
+	for _, c := range doc.Categories {
+		categories[c.ID] = domain.DefectType(c.Name)
+	}
+	index := make(map[int]int, len(doc.Images))
+	var samples []Sample
+	for _, img := range doc.Images {
+		if accept != nil && !accept(img.FileName) {
+			continue
+		}
+		index[img.ID] = len(samples)
+		samples = append(samples, Sample{ImageKey: img.FileName})
+	}
+	for _, a := range doc.Annotations {
+		i, ok := index[a.ImageID]
+		dt := categories[a.CategoryID]
+		if !ok || !dt.IsValid() {
+			continue
+		}
+		samples[i].Truth = append(samples[i].Truth, Box{DefectType: dt, BBox: domain.BoundingBox{
+			X:      int(math.Round(a.BBox[0])),
+			Y:      int(math.Round(a.BBox[1])),
+			Width:  int(math.Round(a.BBox[2])),
+			Height: int(math.Round(a.BBox[3])),
+		}})
+	}
+	return samples, nil
+}
+
+// readYOLO читает список "<файл разметки> <ключ изображения>" и файлы разметки из архива
+func readYOLO(zr *zip.Reader, list *zip.File, accept func(string) bool) ([]Sample, error) {
+	classFile := findFile(zr, "classes.txt")
+	if classFile == nil {
+		return nil, domain.InvalidInputf("dataset archive has no classes.txt")
+	}
+	classNames, err := readLines(classFile)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readLines(list)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []Sample
+	for _, line := range entries {
+		labelName, key, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, domain.InvalidInputf("%s: malformed line %q", list.Name, line)
+		}
+		if accept != nil && !accept(key) {
+			continue
+		}
+		s := Sample{ImageKey: key}
+		if f := findFile(zr, path.Clean(labelName)); f != nil {
+			labels, err := readLines(f)
+			if err != nil {
+				return nil, err
+			}
+			for _, l := range labels {
+				b, err := parseYOLOLabel(l, classNames)
+				if err != nil {
+					return nil, domain.InvalidInputf("%s: %v", f.Name, err)
+				}
+				if b.defectType.IsValid() {
+					s.normalized = append(s.normalized, b)
+				}
+			}
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+func parseYOLOLabel(line string, classNames []string) (normalizedBox, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 5 {
+		return normalizedBox{}, fmt.Errorf("malformed label %q", line)
+	}
+	class, err := strconv.Atoi(fields[0])
+	if err != nil || class < 0 || class >= len(classNames) {
+		return normalizedBox{}, fmt.Errorf("unknown class in label %q", line)
+	}
+	var v [4]float64
+	for i := range v {
+		if v[i], err = strconv.ParseFloat(fields[i+1], 64); err != nil {
+			return normalizedBox{}, fmt.Errorf("malformed label %q", line)
+		}
+	}
+	return normalizedBox{defectType: domain.DefectType(classNames[class]), xc: v[0], yc: v[1], width: v[2], height: v[3]}, nil
+}
+
+// readLines читает непустые строки файла архива
+func readLines(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var lines []string
+	sc := bufio.NewScanner(rc)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", f.Name, err)
+	}
+	return lines, nil
+}
@@ -0,0 +1,312 @@
+package evaluation
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/shadow"
+)
+
+// classes - типы дефектов в порядке вывода метрик
+var classes = []domain.DefectType{
+	domain.DefectTypeScratch, domain.DefectTypeDent, domain.DefectTypeCrack, domain.DefectTypeBrokenGlass,
+}
+
+// cocoIoUThresholds - пороги IoU для mAP в стиле COCO: 0.50, 0.55, ..., 0.95
+var cocoIoUThresholds = func() []float64 {
+	t := make([]float64, 10)
+	for i := range t {
+		t[i] = 0.5 + 0.05*float64(i)
+	}
+	return t
+}()
+
+// calibrationBins - число корзин уверенности
+const calibrationBins = 10
+
+// Box представляет размеченный дефект
+type Box struct {
+	DefectType domain.DefectType
+	BBox       domain.BoundingBox
+}
+
+// Result представляет разметку изображения и выход модели на нём
+type Result struct {
+	Truth      []Box
+	Detections domain.EvaluationDetections
+}
+
+// scored - детекция одного типа в общем списке по всем изображениям
+type scored struct {
+	image      int
+	det        int
+	confidence float64
+}
+
+// classMatch - итог сопоставления детекций одного типа с разметкой при одном пороге IoU.
+// Detections отсортированы по убыванию уверенности, TP[i] - верна ли i-я детекция
+type classMatch struct {
+	detections []scored
+	tp         []bool
+	support    int
+}
+
+// matchClass жадно сопоставляет детекции типа dt с разметкой, как в COCO: детекции
+// перебираются по убыванию уверенности, каждая занимает свободный размеченный дефект
+// того же типа с наибольшим IoU не ниже iou
+func matchClass(results []Result, dt domain.DefectType, iou float64) classMatch {
+	var m classMatch
+	matched := make([][]bool, len(results))
+	for i, r := range results {
+		matched[i] = make([]bool, len(r.Truth))
+		for _, b := range r.Truth {
+			if b.DefectType == dt {
+				m.support++
+			}
+		}
+		for j, d := range r.Detections {
+			if d.DefectType == dt {
+				m.detections = append(m.detections, scored{image: i, det: j, confidence: d.Confidence})
+			}
+		}
+	}
+	slices.SortStableFunc(m.detections, func(a, b scored) int { return cmp.Compare(b.confidence, a.confidence) })
+
+	m.tp = make([]bool, len(m.detections))
+	for k, s := range m.detections {
+		r := &results[s.image]
+		best, bestIoU := -1, iou
+		for j, b := range r.Truth {
+			if b.DefectType != dt || matched[s.image][j] {
+				continue
+			}
+			if v := shadow.IoU(b.BBox, r.Detections[s.det].BBox); v >= bestIoU && v > 0 {
+				best, bestIoU = j, v
+			}
+		}
+		if best >= 0 {
+			matched[s.image][best] = true
+			m.tp[k] = true
+		}
+	}
+	return m
+}
+
+// averagePrecision считает AP по 101 точке полноты с огибающей точности, как в COCO
+func (m *classMatch) averagePrecision() float64 {
+	if m.support == 0 || len(m.tp) == 0 {
+		return 0
+	}
+	precision := make([]float64, len(m.tp))
+	recall := make([]float64, len(m.tp))
+	hits := 0
+	for i, ok := range m.tp {
+		if ok {
+			hits++
+		}
+		precision[i] = float64(hits) / float64(i+1)
+		recall[i] = float64(hits) / float64(m.support)
+	}
+	for i := len(precision) - 2; i >= 0; i-- {
+		precision[i] = max(precision[i], precision[i+1])
+	}
+
+	sum, j := 0.0, 0
+	for k := 0; k <= 100; k++ {
+		r := float64(k) / 100
+		for j < len(recall) && recall[j] < r {
+			j++
+		}
+		if j < len(recall) {
+			sum += precision[j]
+		}
+	}
+	return sum / 101
+}
+
+// operatingPoint возвращает precision и recall детекций с уверенностью не ниже minConfidence
+func (m *classMatch) operatingPoint(minConfidence float64) (precision, recall float64) {
+	n, hits := 0, 0
+	for i, s := range m.detections {
+		if s.confidence < minConfidence {
+			break
+		}
+		n++
+		if m.tp[i] {
+			hits++
+		}
+	}
+	if n > 0 {
+		precision = float64(hits) / float64(n)
+	}
+	if m.support > 0 {
+		recall = float64(hits) / float64(m.support)
+	}
+	return precision, recall
+}
+
+// Compute считает метрики модели по результатам на валидационной части:
+// mAP@[.5:.95] и mAP@.5 в стиле COCO, precision, recall и AP по типам дефектов
+// при порогах params.IoUThresholds, матрицу путаницы и калибровку уверенности
+func Compute(results []Result, params domain.EvaluationParams) *domain.ModelMetrics {
+	mm := &domain.ModelMetrics{IoUThresholds: params.IoUThresholds}
+
+	var mapSum, map50Sum float64
+	evaluated := 0
+	var outcomes []scoredOutcome
+	for _, dt := range classes {
+		// Первый порог COCO (0.5) даёт и mAP@.5, и исходы для калибровки
+		m50 := matchClass(results, dt, cocoIoUThresholds[0])
+		if m50.support == 0 && len(m50.detections) == 0 {
+			continue
+		}
+		for i, s := range m50.detections {
+			outcomes = append(outcomes, scoredOutcome{confidence: s.confidence, correct: m50.tp[i]})
+		}
+
+		cm := domain.ClassMetrics{DefectType: dt, Support: m50.support}
+		ap50 := m50.averagePrecision()
+		apSum := ap50
+		for _, t := range cocoIoUThresholds[1:] {
+			m := matchClass(results, dt, t)
+			apSum += m.averagePrecision()
+		}
+		cm.AP = apSum / float64(len(cocoIoUThresholds))
+
+		for _, t := range params.IoUThresholds {
+			m := matchClass(results, dt, t)
+			p, r := m.operatingPoint(params.MinConfidence)
+			cm.Thresholds = append(cm.Thresholds, domain.ClassThresholdMetrics{
+				IoU: t, Precision: p, Recall: r, AP: m.averagePrecision(),
+			})
+		}
+		mm.PerClass = append(mm.PerClass, cm)
+
+		// Тип без разметки не входит в mAP: AP для него не определён
+		if cm.Support > 0 {
+			mapSum += cm.AP
+			map50Sum += ap50
+			evaluated++
+		}
+	}
+	if evaluated > 0 {
+		mm.MAP = mapSum / float64(evaluated)
+		mm.MAP50 = map50Sum / float64(evaluated)
+	}
+
+	if len(params.IoUThresholds) > 0 {
+		mm.Confusion = confusion(results, slices.Min(params.IoUThresholds), params.MinConfidence)
+	}
+	mm.Calibration = calibrate(outcomes)
+	return mm
+}
+
+// confusion строит матрицу путаницы: детекции не ниже minConfidence сопоставляются
+// с разметкой по IoU без учёта типа, несопоставленные попадают в строку или столбец фона
+func confusion(results []Result, iou, minConfidence float64) *domain.ConfusionMatrix {
+	counts := make(map[[2]domain.DefectType]int)
+	for _, r := range results {
+		truth := make([]domain.Defect, len(r.Truth))
+		for i, b := range r.Truth {
+			truth[i] = domain.Defect{DefectType: b.DefectType, BBox: b.BBox}
+		}
+		var predicted []domain.Defect
+		for _, d := range r.Detections {
+			if d.Confidence >= minConfidence {
+				predicted = append(predicted, domain.Defect{DefectType: d.DefectType, BBox: d.BBox, Confidence: d.Confidence})
+			}
+		}
+
+		matchedTruth := make([]bool, len(truth))
+		matchedPredicted := make([]bool, len(predicted))
+		for _, m := range shadow.MatchDefects(truth, predicted, iou) {
+			matchedTruth[m.Primary], matchedPredicted[m.Shadow] = true, true
+			counts[[2]domain.DefectType{truth[m.Primary].DefectType, predicted[m.Shadow].DefectType}]++
+		}
+		for i, ok := range matchedTruth {
+			if !ok {
+				counts[[2]domain.DefectType{truth[i].DefectType, ""}]++
+			}
+		}
+		for j, ok := range matchedPredicted {
+			if !ok {
+				counts[[2]domain.DefectType{"", predicted[j].DefectType}]++
+			}
+		}
+	}
+
+	cm := &domain.ConfusionMatrix{IoU: iou, Cells: make([]domain.ConfusionCell, 0, len(counts))}
+	for k, n := range counts {
+		cm.Cells = append(cm.Cells, domain.ConfusionCell{Actual: k[0], Predicted: k[1], Count: n})
+	}
+	slices.SortFunc(cm.Cells, func(a, b domain.ConfusionCell) int {
+		return cmp.Or(cmp.Compare(a.Actual, b.Actual), cmp.Compare(a.Predicted, b.Predicted))
+	})
+	return cm
+}
+
+// scoredOutcome - уверенность детекции и то, верна ли она
+type scoredOutcome struct {
+	confidence float64
+	correct    bool
+}
+
+// calibrate раскладывает детекции по корзинам уверенности равной ширины
+// и считает ожидаемую (ECE) и максимальную (MCE) ошибки калибровки
+func calibrate(outcomes []scoredOutcome) *domain.CalibrationMetrics {
+	c := &domain.CalibrationMetrics{Bins: make([]domain.CalibrationBin, calibrationBins)}
+	hits := make([]int, calibrationBins)
+	for i := range c.Bins {
+		c.Bins[i].From = float64(i) / calibrationBins
+		c.Bins[i].To = float64(i+1) / calibrationBins
+	}
+	for _, o := range outcomes {
+		i := min(int(o.confidence*calibrationBins), calibrationBins-1)
+		i = max(i, 0)
+		c.Bins[i].Count++
+		c.Bins[i].MeanConfidence += o.confidence
+		if o.correct {
+			hits[i]++
+		}
+	}
+	for i := range c.Bins {
+		b := &c.Bins[i]
+		if b.Count == 0 {
+			continue
+		}
+		b.MeanConfidence /= float64(b.Count)
+		b.Precision = float64(hits[i]) / float64(b.Count)
+		gap := math.Abs(b.Precision - b.MeanConfidence)
+		c.ECE += gap * float64(b.Count) / float64(len(outcomes))
+		c.MCE = max(c.MCE, gap)
+	}
+	return c
+}
+
+// Latency считает распределение времени инференса по изображениям
+func Latency(ms []int64) *domain.LatencyStats {
+	if len(ms) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(ms)
+	slices.Sort(sorted)
+	var sum int64
+	for _, v := range sorted {
+		sum += v
+	}
+	// Перцентиль по ближайшему рангу
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		return float64(sorted[max(rank, 1)-1])
+	}
+	return &domain.LatencyStats{
+		Count:  len(sorted),
+		MeanMs: float64(sum) / float64(len(sorted)),
+		P50Ms:  percentile(50),
+		P95Ms:  percentile(95),
+		P99Ms:  percentile(99),
+		MaxMs:  float64(sorted[len(sorted)-1]),
+	}
+}
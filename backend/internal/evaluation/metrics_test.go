@@ -0,0 +1,307 @@
+package evaluation
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+const eps = 1e-9
+
+func box(x, y, w, h int) domain.BoundingBox {
+	return domain.BoundingBox{X: x, Y: y, Width: w, Height: h}
+}
+
+func det(dt domain.DefectType, confidence float64, b domain.BoundingBox) domain.EvaluationDetection {
+	return domain.EvaluationDetection{DefectType: dt, Confidence: confidence, BBox: b}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < eps
+}
+
+func TestMatchClassIoU(t *testing.T) {
+	truth := box(0, 0, 100, 100)
+	tests := []struct {
+		name string
+		det  domain.EvaluationDetection
+		iou  float64
+		want bool
+	}{
+		{"identical at 0.95", det(domain.DefectTypeScratch, 0.9, truth), 0.95, true},
+		{"half of the box at 0.5", det(domain.DefectTypeScratch, 0.9, box(0, 0, 50, 100)), 0.5, true},
+		{"half of the box at 0.55", det(domain.DefectTypeScratch, 0.9, box(0, 0, 50, 100)), 0.55, false},
+		{"shifted by half has iou 1/3", det(domain.DefectTypeScratch, 0.9, box(50, 0, 100, 100)), 0.5, false},
+		{"shifted by half at 0.3", det(domain.DefectTypeScratch, 0.9, box(50, 0, 100, 100)), 0.3, true},
+		{"quarter inside at 0.25", det(domain.DefectTypeScratch, 0.9, box(25, 25, 50, 50)), 0.25, true},
+		{"quarter inside at 0.3", det(domain.DefectTypeScratch, 0.9, box(25, 25, 50, 50)), 0.3, false},
+		{"touching boxes never match", det(domain.DefectTypeScratch, 0.9, box(100, 0, 100, 100)), 0, false},
+		{"other defect type", det(domain.DefectTypeDent, 0.9, truth), 0.5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := []Result{{
+				Truth:      []Box{{DefectType: domain.DefectTypeScratch, BBox: truth}},
+				Detections: domain.EvaluationDetections{tt.det},
+			}}
+			m := matchClass(results, tt.det.DefectType, tt.iou)
+			if len(m.tp) != 1 || m.tp[0] != tt.want {
+				t.Errorf("tp = %v, want [%v]", m.tp, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchClassGreedy(t *testing.T) {
+	results := []Result{{
+		Truth: []Box{
+			{DefectType: domain.DefectTypeDent, BBox: box(0, 0, 100, 100)},
+			{DefectType: domain.DefectTypeDent, BBox: box(60, 0, 100, 100)},
+		},
+		Detections: domain.EvaluationDetections{
+			// Точное совпадение с первым дефектом, но с наименьшей уверенностью
+			det(domain.DefectTypeDent, 0.4, box(0, 0, 100, 100)),
+			// Обрабатывается первой и занимает первый дефект (IoU 0.82), со вторым IoU 1/3
+			det(domain.DefectTypeDent, 0.9, box(10, 0, 100, 100)),
+			// Первый дефект уже занят, со вторым IoU 0.25
+			det(domain.DefectTypeDent, 0.7, box(0, 0, 100, 100)),
+		},
+	}}
+	m := matchClass(results, domain.DefectTypeDent, 0.5)
+	if m.support != 2 {
+		t.Errorf("support = %d, want 2", m.support)
+	}
+	var confidences []float64
+	for _, s := range m.detections {
+		confidences = append(confidences, s.confidence)
+	}
+	if !slices.Equal(confidences, []float64{0.9, 0.7, 0.4}) {
+		t.Errorf("detections sorted as %v, want by descending confidence", confidences)
+	}
+	if want := []bool{true, false, false}; !slices.Equal(m.tp, want) {
+		t.Errorf("tp = %v, want %v", m.tp, want)
+	}
+}
+
+func TestAveragePrecision(t *testing.T) {
+	tests := []struct {
+		name    string
+		tp      []bool
+		support int
+		want    float64
+	}{
+		{"single hit", []bool{true}, 1, 1},
+		{"false positive first", []bool{false, true}, 1, 0.5},
+		{"envelope lifts precision", []bool{true, false, true}, 2, (51 + 50*2.0/3) / 101},
+		{"half recall", []bool{true}, 2, 51.0 / 101},
+		{"trailing misses do not matter", []bool{true, true, false, false}, 4, 51.0 / 101},
+		{"hits after misses", []bool{false, false, true, true}, 2, 0.5},
+		{"all misses", []bool{false, false}, 2, 0},
+		{"no detections", nil, 3, 0},
+		{"no support", []bool{false}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := classMatch{tp: tt.tp, support: tt.support}
+			if got := m.averagePrecision(); !near(got, tt.want) {
+				t.Errorf("AP = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes []scoredOutcome
+		ece, mce float64
+		counts   map[int]int
+	}{
+		{
+			name: "empty",
+			ece:  0, mce: 0,
+			counts: map[int]int{},
+		},
+		{
+			name:     "perfectly calibrated bin",
+			outcomes: []scoredOutcome{{0.75, true}, {0.75, true}, {0.75, true}, {0.75, false}},
+			ece:      0, mce: 0,
+			counts: map[int]int{7: 4},
+		},
+		{
+			name: "mixed bins",
+			outcomes: []scoredOutcome{
+				{0.95, true},
+				{0.85, true}, {0.85, false},
+				{0.35, false},
+				{0.05, true},
+			},
+			// (0.05*1 + 0.35*2 + 0.35*1 + 0.95*1) / 5
+			ece: 0.41, mce: 0.95,
+			counts: map[int]int{9: 1, 8: 2, 3: 1, 0: 1},
+		},
+		{
+			name:     "confidence of one falls into the last bin",
+			outcomes: []scoredOutcome{{1, true}, {0.9, false}},
+			// корзина 9: средняя уверенность 0.95, точность 0.5
+			ece: 0.45, mce: 0.45,
+			counts: map[int]int{9: 2},
+		},
+		{
+			name:     "bin boundary belongs to the upper bin",
+			outcomes: []scoredOutcome{{0.5, false}},
+			ece:      0.5, mce: 0.5,
+			counts: map[int]int{5: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := calibrate(tt.outcomes)
+			if !near(c.ECE, tt.ece) || !near(c.MCE, tt.mce) {
+				t.Errorf("ECE = %v, MCE = %v, want %v and %v", c.ECE, c.MCE, tt.ece, tt.mce)
+			}
+			if len(c.Bins) != calibrationBins {
+				t.Fatalf("got %d bins, want %d", len(c.Bins), calibrationBins)
+			}
+			for i, b := range c.Bins {
+				if !near(b.From, float64(i)/10) || !near(b.To, float64(i+1)/10) {
+					t.Errorf("bin %d = [%v, %v)", i, b.From, b.To)
+				}
+				if b.Count != tt.counts[i] {
+					t.Errorf("bin %d count = %d, want %d", i, b.Count, tt.counts[i])
+				}
+			}
+		})
+	}
+}
+
+// knownResults - два изображения с заранее посчитанными метриками:
+//   - scratch: детекции 0.9 (верная), 0.8 (ложная), 0.7 (верная) на два дефекта;
+//   - dent: 0.95 сдвинута на половину рамки (IoU 1/3), 0.6 верная, дефектов два;
+//   - crack: одна ложная детекция без разметки
+func knownResults() []Result {
+	return []Result{
+		{
+			Truth: []Box{
+				{DefectType: domain.DefectTypeScratch, BBox: box(0, 0, 100, 100)},
+				{DefectType: domain.DefectTypeScratch, BBox: box(200, 0, 100, 100)},
+				{DefectType: domain.DefectTypeDent, BBox: box(0, 200, 100, 100)},
+			},
+			Detections: domain.EvaluationDetections{
+				det(domain.DefectTypeScratch, 0.9, box(0, 0, 100, 100)),
+				det(domain.DefectTypeScratch, 0.8, box(500, 500, 50, 50)),
+				det(domain.DefectTypeScratch, 0.7, box(200, 0, 100, 100)),
+				det(domain.DefectTypeDent, 0.6, box(0, 200, 100, 100)),
+			},
+		},
+		{
+			Truth: []Box{{DefectType: domain.DefectTypeDent, BBox: box(0, 0, 100, 100)}},
+			Detections: domain.EvaluationDetections{
+				det(domain.DefectTypeDent, 0.95, box(50, 0, 100, 100)),
+				det(domain.DefectTypeCrack, 0.4, box(300, 300, 40, 40)),
+			},
+		},
+	}
+}
+
+func TestCompute(t *testing.T) {
+	params := domain.EvaluationParams{IoUThresholds: []float64{0.3, 0.5}, MinConfidence: 0.65}
+	mm := Compute(knownResults(), params)
+
+	scratchAP := (51 + 50*2.0/3) / 101 // [верная, ложная, верная] на два дефекта
+	dentAP := 25.5 / 101               // [ложная, верная] на два дефекта: точность 0.5 до полноты 0.5
+	if !near(mm.MAP, (scratchAP+dentAP)/2) || !near(mm.MAP50, (scratchAP+dentAP)/2) {
+		t.Errorf("mAP = %v, mAP@.5 = %v, want %v", mm.MAP, mm.MAP50, (scratchAP+dentAP)/2)
+	}
+
+	want := []domain.ClassMetrics{
+		{DefectType: domain.DefectTypeScratch, Support: 2, AP: scratchAP, Thresholds: []domain.ClassThresholdMetrics{
+			{IoU: 0.3, Precision: 2.0 / 3, Recall: 1, AP: scratchAP},
+			{IoU: 0.5, Precision: 2.0 / 3, Recall: 1, AP: scratchAP},
+		}},
+		{DefectType: domain.DefectTypeDent, Support: 2, AP: dentAP, Thresholds: []domain.ClassThresholdMetrics{
+			{IoU: 0.3, Precision: 1, Recall: 0.5, AP: 1}, // при IoU 0.3 сдвинутая детекция верна
+			{IoU: 0.5, Precision: 0, Recall: 0, AP: dentAP},
+		}},
+		{DefectType: domain.DefectTypeCrack, Support: 0, AP: 0, Thresholds: []domain.ClassThresholdMetrics{
+			{IoU: 0.3}, {IoU: 0.5},
+		}},
+	}
+	assertClassMetrics(t, mm.PerClass, want)
+
+	// Исходы при IoU 0.5: корзина 9 - 0.9 верная и 0.95 ложная, 8 - 0.8 ложная,
+	// 7 - 0.7 верная, 6 - 0.6 верная, 4 - 0.4 ложная
+	if mm.Calibration == nil {
+		t.Fatal("no calibration")
+	}
+	if ece := (0.425*2 + 0.8 + 0.3 + 0.4 + 0.4) / 6; !near(mm.Calibration.ECE, ece) || !near(mm.Calibration.MCE, 0.8) {
+		t.Errorf("ECE = %v, MCE = %v, want %v and 0.8", mm.Calibration.ECE, mm.Calibration.MCE, ece)
+	}
+
+	if mm.Confusion == nil || mm.Confusion.IoU != 0.3 {
+		t.Fatalf("confusion = %+v, want matrix at IoU 0.3", mm.Confusion)
+	}
+	wantCells := []domain.ConfusionCell{
+		{Actual: "", Predicted: domain.DefectTypeScratch, Count: 1},
+		{Actual: domain.DefectTypeDent, Predicted: "", Count: 1},
+		{Actual: domain.DefectTypeDent, Predicted: domain.DefectTypeDent, Count: 1},
+		{Actual: domain.DefectTypeScratch, Predicted: domain.DefectTypeScratch, Count: 2},
+	}
+	if !slices.Equal(mm.Confusion.Cells, wantCells) {
+		t.Errorf("confusion cells = %+v, want %+v", mm.Confusion.Cells, wantCells)
+	}
+}
+
+func TestComputeEmpty(t *testing.T) {
+	mm := Compute(nil, domain.EvaluationParams{IoUThresholds: []float64{0.5}, MinConfidence: 0.3})
+	if mm.MAP != 0 || mm.MAP50 != 0 || len(mm.PerClass) != 0 {
+		t.Errorf("metrics = %+v, want empty", mm)
+	}
+	if mm.Calibration == nil || mm.Calibration.ECE != 0 {
+		t.Errorf("calibration = %+v, want zero ECE", mm.Calibration)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	if Latency(nil) != nil {
+		t.Error("Latency(nil) should be nil")
+	}
+	ms := make([]int64, 0, 100)
+	for i := 100; i >= 1; i-- {
+		ms = append(ms, int64(i))
+	}
+	got := Latency(ms)
+	want := domain.LatencyStats{Count: 100, MeanMs: 50.5, P50Ms: 50, P95Ms: 95, P99Ms: 99, MaxMs: 100}
+	if *got != want {
+		t.Errorf("Latency = %+v, want %+v", *got, want)
+	}
+	if one := Latency([]int64{7}); one.P50Ms != 7 || one.P99Ms != 7 {
+		t.Errorf("Latency of one value = %+v", *one)
+	}
+}
+
+func assertClassMetrics(t *testing.T, got, want []domain.ClassMetrics) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("per class = %+v, want %d classes", got, len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.DefectType != w.DefectType || g.Support != w.Support || !near(g.AP, w.AP) {
+			t.Errorf("class %d = %s support %d AP %v, want %s support %d AP %v",
+				i, g.DefectType, g.Support, g.AP, w.DefectType, w.Support, w.AP)
+		}
+		if len(g.Thresholds) != len(w.Thresholds) {
+			t.Errorf("%s thresholds = %+v, want %+v", w.DefectType, g.Thresholds, w.Thresholds)
+			continue
+		}
+		for j, wt := range w.Thresholds {
+			gt := g.Thresholds[j]
+			if !near(gt.IoU, wt.IoU) || !near(gt.Precision, wt.Precision) || !near(gt.Recall, wt.Recall) || !near(gt.AP, wt.AP) {
+				t.Errorf("%s at IoU %v = %+v, want %+v", w.DefectType, wt.IoU, gt, wt)
+			}
+		}
+	}
+}
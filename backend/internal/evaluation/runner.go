@@ -0,0 +1,225 @@
+package evaluation
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // декодер JPEG
+	_ "image/png"  // декодер PNG
+	"log"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/inference"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// maxFailedShare - доля изображений с ошибкой, начиная с которой оценка считается неудачной:
+// метрики по оставшимся изображениям были бы смещены
+const maxFailedShare = 0.1
+
+// Runner забирает задачи оценки из очереди и прогоняет модель по валидационной части датасета
+type Runner struct {
+	db       *sql.DB
+	jobs     *repository.EvaluationRepository
+	models   *repository.ModelRepository
+	datasets *repository.DatasetRepository
+	backend  inference.Backend
+	store    storage.ObjectStorage
+	cfg      config.EvaluationConfig
+}
+
+// predictionStore сохраняет выходы модели и прогресс задачи. Реализуется EvaluationRepository
+type predictionStore interface {
+	SavePrediction(ctx context.Context, p *domain.EvaluationPrediction) error
+	UpdateProgress(ctx context.Context, id uuid.UUID, total, processed, failed int) error
+}
+
+// evaluator прогоняет модель по валидационной части датасета и считает метрики
+type evaluator struct {
+	backend inference.Backend
+	store   storage.ObjectStorage
+	preds   predictionStore
+}
+
+// NewRunner создаёт исполнитель задач оценки
+func NewRunner(db *sql.DB, jobs *repository.EvaluationRepository, models *repository.ModelRepository,
+	datasets *repository.DatasetRepository, backend inference.Backend, store storage.ObjectStorage,
+	cfg config.EvaluationConfig) *Runner {
+	return &Runner{db: db, jobs: jobs, models: models, datasets: datasets, backend: backend, store: store, cfg: cfg}
+}
+
+// Run обрабатывает задачи по одной и блокируется до отмены ctx
+func (r *Runner) Run(ctx context.Context) {
+	for {
+		job, err := r.jobs.ClaimNext(ctx, r.cfg.Lease)
+		switch {
+		case err == nil:
+			r.process(ctx, job)
+			continue
+		case errors.Is(err, repository.ErrNotFound):
+			// очередь пуста
+		case ctx.Err() != nil:
+			return
+		default:
+			log.Printf("claim evaluation job: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
+
+// process выполняет задачу. При остановке воркера задача остаётся в running
+// и после истечения аренды продолжается с уже сохранённых выходов модели
+func (r *Runner) process(ctx context.Context, job *domain.EvaluationJob) {
+	err := r.Evaluate(ctx, job)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	log.Printf("evaluation job %s: %v", job.ID, err)
+	if ferr := r.jobs.Fail(context.WithoutCancel(ctx), job.ID, err.Error()); ferr != nil {
+		log.Printf("evaluation job %s: %v", job.ID, ferr)
+	}
+}
+
+// Evaluate прогоняет модель задачи по валидационной части датасета, считает метрики
+// и в одной транзакции завершает задачу и записывает метрики в модель
+func (r *Runner) Evaluate(ctx context.Context, job *domain.EvaluationJob) error {
+	model, err := r.models.GetByID(ctx, job.ModelID)
+	if err != nil {
+		return fmt.Errorf("load model: %w", err)
+	}
+	ds, err := r.datasets.GetByID(ctx, job.DatasetID)
+	if err != nil {
+		return fmt.Errorf("load dataset: %w", err)
+	}
+	samples, err := LoadSamples(ctx, r.store, ds, job.Params.ValidationPercent)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("validation split of dataset %s is empty", ds.ID)
+	}
+	done, err := r.jobs.Predictions(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	ev := &evaluator{backend: r.backend, store: r.store, preds: r.jobs}
+	if err := ev.run(ctx, model, job, samples, done); err != nil {
+		return err
+	}
+	job.Metrics.EvaluationDatasetID = &ds.ID
+
+	return database.WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := r.jobs.Complete(ctx, tx, job); err != nil {
+			return err
+		}
+		// Метрики обучения (accuracy, loss) сохраняются, остальное заменяется итогом оценки
+		current, err := r.models.GetForUpdate(ctx, tx, job.ModelID)
+		if err != nil {
+			return err
+		}
+		metrics := *job.Metrics
+		if current.Metrics != nil {
+			metrics.Accuracy, metrics.Loss = current.Metrics.Accuracy, current.Metrics.Loss
+		}
+		return r.models.UpdateMetrics(ctx, tx, job.ModelID, &metrics)
+	})
+}
+
+// run прогоняет модель по выборке, беря выходы из done для изображений, обработанных прошлой
+// попыткой, и записывает в задачу прогресс, метрики и распределение времени инференса
+func (e *evaluator) run(ctx context.Context, model *domain.MLModel, job *domain.EvaluationJob, samples []Sample,
+	done map[string]*domain.EvaluationPrediction) error {
+	job.ImagesTotal, job.ImagesProcessed, job.ImagesFailed = len(samples), 0, 0
+	results := make([]Result, 0, len(samples))
+	latencies := make([]int64, 0, len(samples))
+	for i := range samples {
+		s := &samples[i]
+		p, err := e.predict(ctx, model, job, s, done[s.ImageKey])
+		if err != nil {
+			return err
+		}
+		job.ImagesProcessed++
+		if p.Detections == nil {
+			job.ImagesFailed++
+		} else {
+			results = append(results, Result{Truth: s.Truth, Detections: *p.Detections})
+			if p.ProcessingTimeMs != nil {
+				latencies = append(latencies, *p.ProcessingTimeMs)
+			}
+		}
+		if err := e.preds.UpdateProgress(ctx, job.ID, job.ImagesTotal, job.ImagesProcessed, job.ImagesFailed); err != nil {
+			return err
+		}
+	}
+	if float64(job.ImagesFailed) > maxFailedShare*float64(job.ImagesTotal) {
+		return fmt.Errorf("%d of %d images failed", job.ImagesFailed, job.ImagesTotal)
+	}
+
+	job.Metrics = Compute(results, job.Params)
+	job.Latency = Latency(latencies)
+	return nil
+}
+
+// predict возвращает выход модели на изображении выборки: сохранённый прошлой попыткой
+// или полученный заново. Ошибки отдельного изображения записываются в выход (Detections == nil),
+// ошибка возвращается, только если продолжать оценку нельзя
+func (e *evaluator) predict(ctx context.Context, model *domain.MLModel, job *domain.EvaluationJob, s *Sample,
+	prev *domain.EvaluationPrediction) (*domain.EvaluationPrediction, error) {
+	p := &domain.EvaluationPrediction{JobID: job.ID, ImageKey: s.ImageKey}
+	fail := func(err error) (*domain.EvaluationPrediction, error) {
+		msg := err.Error()
+		p.ErrorMessage = &msg
+		return p, e.preds.SavePrediction(ctx, p)
+	}
+
+	img, err := storage.ReadAll(ctx, e.store, s.ImageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fail(err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read image %s: %w", s.ImageKey, err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		return fail(fmt.Errorf("decode image: %w", err))
+	}
+	s.Resolve(cfg.Width, cfg.Height)
+	if prev != nil && prev.Detections != nil {
+		return prev, nil
+	}
+
+	started := time.Now()
+	pred, err := e.backend.Infer(ctx, model, img)
+	elapsed := time.Since(started).Milliseconds()
+	if err != nil {
+		// Повреждённая модель и остановка воркера прерывают оценку целиком
+		if errors.Is(err, inference.ErrIntegrity) || ctx.Err() != nil {
+			return nil, err
+		}
+		return fail(err)
+	}
+
+	// Уверенность не фильтруется: AP считается по всей кривой precision-recall
+	detections := make(domain.EvaluationDetections, 0, len(pred.Detections))
+	for _, d := range pred.Detections {
+		if dt := domain.DefectType(d.Class); dt.IsValid() {
+			detections = append(detections, domain.EvaluationDetection{DefectType: dt, Confidence: d.Confidence, BBox: d.BBox})
+		}
+	}
+	p.Detections = &detections
+	p.ProcessingTimeMs = &elapsed
+	return p, e.preds.SavePrediction(ctx, p)
+}
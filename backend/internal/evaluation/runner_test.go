@@ -0,0 +1,376 @@
+package evaluation
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/inference"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// memPredictions хранит выходы модели и прогресс задачи в памяти
+type memPredictions struct {
+	saved    map[string]*domain.EvaluationPrediction
+	progress [][3]int
+}
+
+func newMemPredictions() *memPredictions {
+	return &memPredictions{saved: make(map[string]*domain.EvaluationPrediction)}
+}
+
+func (m *memPredictions) SavePrediction(_ context.Context, p *domain.EvaluationPrediction) error {
+	m.saved[p.ImageKey] = p
+	return nil
+}
+
+func (m *memPredictions) UpdateProgress(_ context.Context, _ uuid.UUID, total, processed, failed int) error {
+	m.progress = append(m.progress, [3]int{total, processed, failed})
+	return nil
+}
+
+// countingBackend считает вызовы инференса
+type countingBackend struct {
+	inference.Backend
+	calls atomic.Int32
+}
+
+func (b *countingBackend) Infer(ctx context.Context, model *domain.MLModel, img []byte) (*inference.Prediction, error) {
+	b.calls.Add(1)
+	return b.Backend.Infer(ctx, model, img)
+}
+
+var testCategories = map[domain.DefectType]int{
+	domain.DefectTypeScratch: 1, domain.DefectTypeDent: 2, domain.DefectTypeCrack: 3, domain.DefectTypeBrokenGlass: 4,
+}
+
+// testImage рисует уникальное для i изображение, чтобы фейковый бэкенд давал разные детекции
+func testImage(t *testing.T, i int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 320+16*i, 240))
+	for y := range 240 {
+		for x := range img.Bounds().Dx() {
+			img.Set(x, y, color.RGBA{R: uint8(x + i*37), G: uint8(y * i), B: uint8(i * 19), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testDataset - датасет в локальном хранилище
+type testDataset struct {
+	store   *storage.LocalStorage
+	dataset *domain.Dataset
+	// детекции фейкового бэкенда по ключу изображения
+	fake map[string][]inference.Detection
+}
+
+// newTestDataset сохраняет images изображений, испорченные файлы corrupt и архив COCO
+// с явной валидационной частью. truth получает детекции фейкового бэкенда на изображении
+// и возвращает его разметку
+func newTestDataset(t *testing.T, model *domain.MLModel, images int, corrupt []string,
+	truth func([]inference.Detection) []Box) *testDataset {
+	t.Helper()
+	ctx := context.Background()
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	td := &testDataset{store: store, fake: make(map[string][]inference.Detection)}
+
+	type annotation struct {
+		ImageID    int        `json:"image_id"`
+		CategoryID int        `json:"category_id"`
+		BBox       [4]float64 `json:"bbox"`
+	}
+	var doc struct {
+		Images      []map[string]any `json:"images"`
+		Annotations []annotation     `json:"annotations"`
+		Categories  []map[string]any `json:"categories"`
+	}
+	for dt, id := range testCategories {
+		doc.Categories = append(doc.Categories, map[string]any{"id": id, "name": string(dt)})
+	}
+
+	fake := inference.NewFake()
+	for i := range images {
+		key := fmt.Sprintf("eval/img-%02d.png", i)
+		img := testImage(t, i)
+		if err := store.Put(ctx, key, bytes.NewReader(img), "image/png"); err != nil {
+			t.Fatal(err)
+		}
+		pred, err := fake.Infer(ctx, model, img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range pred.Detections {
+			if domain.DefectType(d.Class).IsValid() {
+				td.fake[key] = append(td.fake[key], d)
+			}
+		}
+		doc.Images = append(doc.Images, map[string]any{"id": i, "file_name": key})
+		for _, b := range truth(td.fake[key]) {
+			doc.Annotations = append(doc.Annotations, annotation{
+				ImageID:    i,
+				CategoryID: testCategories[b.DefectType],
+				BBox:       [4]float64{float64(b.BBox.X), float64(b.BBox.Y), float64(b.BBox.Width), float64(b.BBox.Height)},
+			})
+		}
+	}
+	for j, key := range corrupt {
+		if err := store.Put(ctx, key, strings.NewReader("not an image"), "image/png"); err != nil {
+			t.Fatal(err)
+		}
+		doc.Images = append(doc.Images, map[string]any{"id": images + j, "file_name": key})
+	}
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, err := zw.Create(cocoValidationFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	fileKey, format := "datasets/eval.zip", "COCO"
+	if err := store.Put(ctx, fileKey, &archive, "application/zip"); err != nil {
+		t.Fatal(err)
+	}
+	td.dataset = &domain.Dataset{ID: uuid.New(), FileKey: &fileKey, AnnotationFormat: &format}
+	return td
+}
+
+// exactTruth размечает дефекты ровно там, где их находит модель
+func exactTruth(dets []inference.Detection) []Box {
+	boxes := make([]Box, 0, len(dets))
+	for _, d := range dets {
+		boxes = append(boxes, Box{DefectType: domain.DefectType(d.Class), BBox: d.BBox})
+	}
+	return boxes
+}
+
+// distantTruth размечает дефекты далеко от найденных моделью
+func distantTruth(dets []inference.Detection) []Box {
+	boxes := exactTruth(dets)
+	for i := range boxes {
+		boxes[i].BBox.X += 100000
+	}
+	return boxes
+}
+
+func runEvaluation(t *testing.T, td *testDataset, model *domain.MLModel, backend inference.Backend,
+	preds *memPredictions, done map[string]*domain.EvaluationPrediction) (*domain.EvaluationJob, error) {
+	t.Helper()
+	ctx := context.Background()
+	job := &domain.EvaluationJob{
+		ID:        uuid.New(),
+		ModelID:   model.ID,
+		DatasetID: td.dataset.ID,
+		Params:    domain.EvaluationParams{IoUThresholds: []float64{0.5, 0.75}, ValidationPercent: 20, MinConfidence: 0.3},
+	}
+	samples, err := LoadSamples(ctx, td.store, td.dataset, job.Params.ValidationPercent)
+	if err != nil {
+		t.Fatalf("LoadSamples: %v", err)
+	}
+	ev := &evaluator{backend: backend, store: td.store, preds: preds}
+	return job, ev.run(ctx, model, job, samples, done)
+}
+
+// detectionStats возвращает число детекций по типам и их уверенности
+func detectionStats(td *testDataset) (map[domain.DefectType]int, []float64) {
+	counts := make(map[domain.DefectType]int)
+	var confidences []float64
+	for _, dets := range td.fake {
+		for _, d := range dets {
+			counts[domain.DefectType(d.Class)]++
+			confidences = append(confidences, d.Confidence)
+		}
+	}
+	return counts, confidences
+}
+
+func TestEvaluatorExactTruth(t *testing.T) {
+	model := &domain.MLModel{ID: uuid.New(), Version: "1.4.0"}
+	td := newTestDataset(t, model, 11, []string{"eval/broken.png"}, exactTruth)
+	preds := newMemPredictions()
+
+	job, err := runEvaluation(t, td, model, inference.NewFake(), preds, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if job.ImagesTotal != 12 || job.ImagesProcessed != 12 || job.ImagesFailed != 1 {
+		t.Errorf("progress = %d/%d, %d failed, want 12/12, 1 failed", job.ImagesProcessed, job.ImagesTotal, job.ImagesFailed)
+	}
+	if len(preds.progress) != 12 || preds.progress[11] != [3]int{12, 12, 1} {
+		t.Errorf("progress updates = %v", preds.progress)
+	}
+	if p := preds.saved["eval/broken.png"]; p == nil || p.Detections != nil || p.ErrorMessage == nil {
+		t.Errorf("prediction for broken image = %+v, want error message", p)
+	}
+
+	counts, confidences := detectionStats(td)
+	if len(counts) == 0 {
+		t.Fatal("fake backend produced no defects, test dataset is too small")
+	}
+	mm := job.Metrics
+	if !near(mm.MAP, 1) || !near(mm.MAP50, 1) {
+		t.Errorf("mAP = %v, mAP@.5 = %v, want 1", mm.MAP, mm.MAP50)
+	}
+	if len(mm.PerClass) != len(counts) {
+		t.Errorf("per class = %+v, want %d classes", mm.PerClass, len(counts))
+	}
+	for _, cm := range mm.PerClass {
+		if cm.Support != counts[cm.DefectType] || !near(cm.AP, 1) {
+			t.Errorf("%s: support %d AP %v, want support %d AP 1", cm.DefectType, cm.Support, cm.AP, counts[cm.DefectType])
+		}
+		for _, th := range cm.Thresholds {
+			if !near(th.Precision, 1) || !near(th.Recall, 1) || !near(th.AP, 1) {
+				t.Errorf("%s at IoU %v = %+v, want precision, recall and AP of 1", cm.DefectType, th.IoU, th)
+			}
+		}
+	}
+
+	// Все детекции верны: ECE равна доле, на которую средняя уверенность меньше единицы
+	var sum float64
+	minBinMean := 1.0
+	bins := make(map[int][]float64)
+	for _, c := range confidences {
+		sum += c
+		i := min(int(c*calibrationBins), calibrationBins-1)
+		bins[i] = append(bins[i], c)
+	}
+	for _, cs := range bins {
+		var s float64
+		for _, c := range cs {
+			s += c
+		}
+		minBinMean = min(minBinMean, s/float64(len(cs)))
+	}
+	if ece := 1 - sum/float64(len(confidences)); !near(mm.Calibration.ECE, ece) {
+		t.Errorf("ECE = %v, want %v", mm.Calibration.ECE, ece)
+	}
+	if !near(mm.Calibration.MCE, 1-minBinMean) {
+		t.Errorf("MCE = %v, want %v", mm.Calibration.MCE, 1-minBinMean)
+	}
+	for i, b := range mm.Calibration.Bins {
+		if b.Count != len(bins[i]) || (b.Count > 0 && !near(b.Precision, 1)) {
+			t.Errorf("bin %d = %+v, want %d detections with precision 1", i, b, len(bins[i]))
+		}
+	}
+	if job.Latency == nil || job.Latency.Count != 11 {
+		t.Errorf("latency = %+v, want 11 images", job.Latency)
+	}
+}
+
+func TestEvaluatorDistantTruth(t *testing.T) {
+	model := &domain.MLModel{ID: uuid.New(), Version: "1.4.0"}
+	td := newTestDataset(t, model, 10, nil, distantTruth)
+
+	job, err := runEvaluation(t, td, model, inference.NewFake(), newMemPredictions(), nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	counts, confidences := detectionStats(td)
+	mm := job.Metrics
+	if mm.MAP != 0 || mm.MAP50 != 0 {
+		t.Errorf("mAP = %v, mAP@.5 = %v, want 0", mm.MAP, mm.MAP50)
+	}
+	for _, cm := range mm.PerClass {
+		if cm.Support != counts[cm.DefectType] || cm.AP != 0 {
+			t.Errorf("%s: support %d AP %v, want support %d AP 0", cm.DefectType, cm.Support, cm.AP, counts[cm.DefectType])
+		}
+		for _, th := range cm.Thresholds {
+			if th.Precision != 0 || th.Recall != 0 {
+				t.Errorf("%s at IoU %v = %+v, want zero precision and recall", cm.DefectType, th.IoU, th)
+			}
+		}
+	}
+	// Все детекции неверны: ECE равна средней уверенности
+	var sum float64
+	for _, c := range confidences {
+		sum += c
+	}
+	if ece := sum / float64(len(confidences)); !near(mm.Calibration.ECE, ece) {
+		t.Errorf("ECE = %v, want %v", mm.Calibration.ECE, ece)
+	}
+}
+
+func TestEvaluatorResumesFromSavedPredictions(t *testing.T) {
+	model := &domain.MLModel{ID: uuid.New(), Version: "2.0.0"}
+	td := newTestDataset(t, model, 6, nil, exactTruth)
+
+	first := newMemPredictions()
+	backend := &countingBackend{Backend: inference.NewFake()}
+	job1, err := runEvaluation(t, td, model, backend, first, nil)
+	if err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if backend.calls.Load() != 6 {
+		t.Errorf("first run made %d inference calls, want 6", backend.calls.Load())
+	}
+
+	// Повтор после остановки воркера берёт сохранённые выходы и не вызывает модель
+	backend.calls.Store(0)
+	job2, err := runEvaluation(t, td, model, backend, newMemPredictions(), first.saved)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if backend.calls.Load() != 0 {
+		t.Errorf("resumed run made %d inference calls, want 0", backend.calls.Load())
+	}
+	if job1.Metrics.MAP != job2.Metrics.MAP || job1.Metrics.Calibration.ECE != job2.Metrics.Calibration.ECE {
+		t.Errorf("resumed metrics differ: %+v vs %+v", job1.Metrics, job2.Metrics)
+	}
+}
+
+func TestEvaluatorTooManyFailures(t *testing.T) {
+	model := &domain.MLModel{ID: uuid.New(), Version: "1.4.0"}
+	td := newTestDataset(t, model, 3, []string{"eval/a.png", "eval/b.png"}, exactTruth)
+
+	job, err := runEvaluation(t, td, model, inference.NewFake(), newMemPredictions(), nil)
+	if err == nil || !strings.Contains(err.Error(), "2 of 5 images failed") {
+		t.Fatalf("err = %v, want too many failures", err)
+	}
+	if job.Metrics != nil {
+		t.Errorf("metrics = %+v, want none", job.Metrics)
+	}
+}
+
+func TestEvaluatorStopsOnIntegrityError(t *testing.T) {
+	model := &domain.MLModel{ID: uuid.New(), Version: "1.4.0"}
+	td := newTestDataset(t, model, 2, nil, exactTruth)
+	preds := newMemPredictions()
+
+	_, err := runEvaluation(t, td, model, integrityBackend{inference.NewFake()}, preds, nil)
+	if !errors.Is(err, inference.ErrIntegrity) {
+		t.Fatalf("err = %v, want integrity error", err)
+	}
+	if len(preds.saved) != 0 {
+		t.Errorf("saved %d predictions, want none", len(preds.saved))
+	}
+}
+
+// integrityBackend отвечает ошибкой проверки целостности модели
+type integrityBackend struct{ *inference.Fake }
+
+func (integrityBackend) Infer(context.Context, *domain.MLModel, []byte) (*inference.Prediction, error) {
+	return nil, fmt.Errorf("weights digest mismatch: %w", inference.ErrIntegrity)
+}
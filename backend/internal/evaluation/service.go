@@ -0,0 +1,122 @@
+// Package evaluation выполняет офлайн-оценку моделей на валидационной части
+// размеченных датасетов: mAP в стиле COCO, метрики по типам дефектов и калибровку
+package evaluation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Service ставит задачи оценки в очередь и отдаёт их результаты
+type Service struct {
+	db       *sql.DB
+	jobs     *repository.EvaluationRepository
+	models   *repository.ModelRepository
+	datasets *repository.DatasetRepository
+	audit    *repository.AuditLogRepository
+	cfg      config.EvaluationConfig
+}
+
+// NewService создаёт сервис оценки моделей
+func NewService(db *sql.DB, jobs *repository.EvaluationRepository, models *repository.ModelRepository,
+	datasets *repository.DatasetRepository, audit *repository.AuditLogRepository, cfg config.EvaluationConfig) *Service {
+	return &Service{db: db, jobs: jobs, models: models, datasets: datasets, audit: audit, cfg: cfg}
+}
+
+// Create ставит в очередь оценку модели на готовом датасете
+func (s *Service) Create(ctx context.Context, user *domain.User, modelID uuid.UUID, req domain.EvaluationJobCreateRequest) (*domain.EvaluationJob, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	model, err := s.models.GetByID(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if model.Status == domain.ModelStatusTraining {
+		return nil, domain.Conflictf("model %s is still training", model.Version)
+	}
+	ds, err := s.datasets.GetByID(ctx, req.DatasetID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, domain.InvalidInputf("dataset %s not found", req.DatasetID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ds.IsReady() || ds.FileKey == nil {
+		return nil, domain.Conflictf("dataset %s is %s: only ready datasets can be used for evaluation", ds.ID, ds.Status)
+	}
+
+	params := domain.EvaluationParams{
+		IoUThresholds:     req.IoUThresholds,
+		ValidationPercent: s.cfg.ValidationPercent,
+		MinConfidence:     s.cfg.MinConfidence,
+	}
+	if len(params.IoUThresholds) == 0 {
+		params.IoUThresholds = domain.DefaultEvaluationIoUThresholds
+	}
+	params.IoUThresholds = slices.Sorted(slices.Values(params.IoUThresholds))
+	if req.ValidationPercent != nil {
+		params.ValidationPercent = *req.ValidationPercent
+	}
+	if req.MinConfidence != nil {
+		params.MinConfidence = *req.MinConfidence
+	}
+
+	job := &domain.EvaluationJob{
+		ModelID:      model.ID,
+		ModelVersion: model.Version,
+		DatasetID:    ds.ID,
+		Params:       params,
+		CreatedBy:    &user.ID,
+	}
+	err = database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.jobs.Create(ctx, tx, job); err != nil {
+			return err
+		}
+		entity := domain.AuditEntityEvaluation
+		return s.audit.Create(ctx, tx, domain.AuditLogCreateRequest{
+			UserID:     &user.ID,
+			Action:     domain.AuditActionEvaluationQueued,
+			EntityType: &entity,
+			EntityID:   &job.ID,
+			Details: &domain.AuditDetails{
+				"model_version": model.Version,
+				"dataset_id":    ds.ID,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Get возвращает задачу оценки с прогрессом и результатом
+func (s *Service) Get(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.EvaluationJob, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	return s.jobs.GetByID(ctx, id)
+}
+
+// ListByModel возвращает оценки модели, начиная с новых
+func (s *Service) ListByModel(ctx context.Context, user *domain.User, modelID uuid.UUID, limit, offset int) ([]domain.EvaluationJob, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	if _, err := s.models.GetByID(ctx, modelID); err != nil {
+		return nil, err
+	}
+	return s.jobs.ListByModel(ctx, modelID, limit, offset)
+}
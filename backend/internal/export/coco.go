@@ -0,0 +1,111 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// Структуры формата COCO (object detection)
+
+type cocoInfo struct {
+	Description string `json:"description"`
+	Version     string `json:"version"`
+	DateCreated string `json:"date_created"`
+}
+
+type cocoImage struct {
+	ID       int    `json:"id"`
+	FileName string `json:"file_name"` // ключ изображения в объектном хранилище
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+type cocoCategory struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Supercategory string `json:"supercategory"`
+}
+
+type cocoAnnotation struct {
+	ID         int               `json:"id"`
+	ImageID    int               `json:"image_id"`
+	CategoryID int               `json:"category_id"`
+	BBox       [4]float64        `json:"bbox"` // x, y, width, height в пикселях
+	Area       float64           `json:"area"`
+	IsCrowd    int               `json:"iscrowd"`
+	Score      float64           `json:"score"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+type cocoDataset struct {
+	Info        cocoInfo         `json:"info"`
+	Images      []cocoImage      `json:"images"`
+	Annotations []cocoAnnotation `json:"annotations"`
+	Categories  []cocoCategory   `json:"categories"`
+}
+
+// cocoWriter накапливает изображения и аннотации и пишет annotations/instances.json
+type cocoWriter struct {
+	classes    []domain.DefectType
+	categoryID map[domain.DefectType]int
+	doc        cocoDataset
+}
+
+func newCOCOWriter(classes []domain.DefectType) *cocoWriter {
+	w := &cocoWriter{
+		classes:    classes,
+		categoryID: make(map[domain.DefectType]int, len(classes)),
+		doc: cocoDataset{
+			Info: cocoInfo{
+				Description: "AutoInspect analyses export",
+				Version:     "1.0",
+				DateCreated: time.Now().UTC().Format(time.RFC3339),
+			},
+			Images:      []cocoImage{},
+			Annotations: []cocoAnnotation{},
+		},
+	}
+	for i, c := range classes {
+		w.categoryID[c] = i + 1 // в COCO категории нумеруются с 1
+		w.doc.Categories = append(w.doc.Categories, cocoCategory{ID: i + 1, Name: string(c), Supercategory: "damage"})
+	}
+	return w
+}
+
+func (w *cocoWriter) Add(_ *zip.Writer, img imageRef, defects []domain.Defect) error {
+	imageID := len(w.doc.Images) + 1
+	w.doc.Images = append(w.doc.Images, cocoImage{
+		ID:       imageID,
+		FileName: img.Key,
+		Width:    img.Width,
+		Height:   img.Height,
+	})
+
+	for _, d := range defects {
+		attrs := map[string]string{"severity": string(d.Severity), "analysis_id": img.AnalysisID.String()}
+		if d.PartName != "" {
+			attrs["part_name"] = d.PartName
+		}
+		w.doc.Annotations = append(w.doc.Annotations, cocoAnnotation{
+			ID:         len(w.doc.Annotations) + 1,
+			ImageID:    imageID,
+			CategoryID: w.categoryID[d.DefectType],
+			BBox:       [4]float64{float64(d.BBox.X), float64(d.BBox.Y), float64(d.BBox.Width), float64(d.BBox.Height)},
+			Area:       float64(d.BBox.Width * d.BBox.Height),
+			Score:      d.Confidence,
+			Attributes: attrs,
+		})
+	}
+	return nil
+}
+
+func (w *cocoWriter) Close(zw *zip.Writer) error {
+	f, err := zw.Create("annotations/instances.json")
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(f).Encode(w.doc)
+}
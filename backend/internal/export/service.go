@@ -0,0 +1,232 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// pageSize - число анализов, читаемых из БД за один запрос
+const pageSize = 500
+
+// allClasses - классы в каноническом порядке (определяет номера классов в разметке)
+var allClasses = []domain.DefectType{
+	domain.DefectTypeScratch, domain.DefectTypeDent, domain.DefectTypeCrack, domain.DefectTypeBrokenGlass,
+}
+
+// imageRef - изображение анализа в выгрузке
+type imageRef struct {
+	AnalysisID uuid.UUID
+	Key        string
+	Width      int
+	Height     int
+}
+
+// annotationWriter пишет разметку в одном формате
+type annotationWriter interface {
+	Add(zw *zip.Writer, img imageRef, defects []domain.Defect) error
+	Close(zw *zip.Writer) error
+}
+
+// manifest описывает содержимое архива выгрузки
+type manifest struct {
+	DatasetID        uuid.UUID                   `json:"dataset_id"`
+	Format           domain.ExportFormat         `json:"format"`
+	Filter           domain.AnalysisExportFilter `json:"filter"`
+	Classes          []domain.DefectType         `json:"classes"`
+	ImagesCount      int                         `json:"images_count"`
+	AnnotationsCount int                         `json:"annotations_count"`
+	SkippedCount     int                         `json:"skipped_count"` // анализы без размеров изображения
+	CreatedAt        time.Time                   `json:"created_at"`
+}
+
+// Service выгружает завершённые анализы в обучающие датасеты
+type Service struct {
+	analyses *repository.AnalysisRepository
+	datasets *repository.DatasetRepository
+	storage  storage.ObjectStorage
+}
+
+// NewService создаёт сервис выгрузки
+func NewService(analyses *repository.AnalysisRepository, datasets *repository.DatasetRepository, store storage.ObjectStorage) *Service {
+	return &Service{analyses: analyses, datasets: datasets, storage: store}
+}
+
+// Export отбирает анализы по фильтру, пишет разметку COCO или YOLO в zip,
+// загружает архив в хранилище и регистрирует его как датасет типа generated
+func (s *Service) Export(ctx context.Context, ownerID uuid.UUID, req domain.DatasetExportRequest) (*domain.Dataset, error) {
+	if err := validateRequest(&req); err != nil {
+		return nil, err
+	}
+
+	format := req.Format.AnnotationFormat()
+	ds := &domain.Dataset{
+		OwnerID:          ownerID,
+		Name:             req.Name,
+		Description:      req.Description,
+		DatasetType:      domain.DatasetTypeGenerated,
+		Status:           domain.DatasetStatusProcessing,
+		AnnotationFormat: &format,
+	}
+	if err := s.datasets.Create(ctx, ds); err != nil {
+		return nil, err
+	}
+
+	if err := s.build(ctx, ds, req); err != nil {
+		ds.Status = domain.DatasetStatusFailed
+		if uerr := s.datasets.UpdateStatus(context.WithoutCancel(ctx), ds.ID, domain.DatasetStatusFailed); uerr != nil {
+			log.Printf("dataset %s: %v", ds.ID, uerr)
+		}
+		return nil, fmt.Errorf("export dataset %s: %w", ds.ID, err)
+	}
+
+	return s.datasets.GetByID(ctx, ds.ID)
+}
+
+func (s *Service) build(ctx context.Context, ds *domain.Dataset, req domain.DatasetExportRequest) error {
+	classes := allClasses
+	if len(req.Filter.DefectTypes) > 0 {
+		classes = req.Filter.DefectTypes
+	}
+
+	var w annotationWriter
+	switch req.Format {
+	case domain.ExportFormatCOCO:
+		w = newCOCOWriter(classes)
+	case domain.ExportFormatYOLO:
+		w = newYOLOWriter(classes)
+	}
+
+	tmp, err := os.CreateTemp("", "dataset-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	m := manifest{DatasetID: ds.ID, Format: req.Format, Filter: req.Filter, Classes: classes, CreatedAt: time.Now().UTC()}
+
+	var afterID uuid.UUID
+	for {
+		page, err := s.analyses.ListForExport(ctx, req.Filter, afterID, pageSize)
+		if err != nil {
+			return err
+		}
+		for i := range page {
+			a := &page[i]
+			if a.ImageMetadata == nil || a.ImageMetadata.Dimensions.Width == 0 || a.ImageMetadata.Dimensions.Height == 0 {
+				m.SkippedCount++
+				continue
+			}
+
+			var defects []domain.Defect
+			for _, d := range a.Result.Defects {
+				if req.Filter.AcceptsDefect(&d) {
+					defects = append(defects, d)
+				}
+			}
+			// Без фильтра по дефектам выгружаются и изображения без повреждений (негативные примеры)
+			if len(defects) == 0 && (len(req.Filter.DefectTypes) > 0 || req.Filter.MinConfidence > 0) {
+				continue
+			}
+
+			img := imageRef{
+				AnalysisID: a.ID,
+				Key:        a.ImageKey,
+				Width:      a.ImageMetadata.Dimensions.Width,
+				Height:     a.ImageMetadata.Dimensions.Height,
+			}
+			if err := w.Add(zw, img, defects); err != nil {
+				return err
+			}
+			m.ImagesCount++
+			m.AnnotationsCount += len(defects)
+		}
+		if len(page) < pageSize {
+			break
+		}
+		afterID = page[len(page)-1].ID
+	}
+
+	if m.ImagesCount == 0 {
+		return domain.InvalidInputf("no analyses match the filter")
+	}
+
+	if err := w.Close(zw); err != nil {
+		return err
+	}
+	mf, err := zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(mf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, 0); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("datasets/%s/%s.zip", ds.ID, req.Format)
+	if err := s.storage.Put(ctx, key, tmp, "application/zip"); err != nil {
+		return fmt.Errorf("upload archive: %w", err)
+	}
+
+	size := info.Size()
+	names := make(domain.DatasetClasses, len(classes))
+	for i, c := range classes {
+		names[i] = string(c)
+	}
+	ds.FileKey = &key
+	ds.TotalSizeBytes = &size
+	ds.ImagesCount = m.ImagesCount
+	ds.AnnotationsCount = m.AnnotationsCount
+	ds.Classes = &names
+	return s.datasets.MarkReady(ctx, ds)
+}
+
+func validateRequest(req *domain.DatasetExportRequest) error {
+	if len(req.Name) < 3 || len(req.Name) > 255 {
+		return domain.InvalidInputf("name must be between 3 and 255 characters")
+	}
+	if !req.Format.IsValid() {
+		return domain.InvalidInputf("format must be one of: coco, yolo")
+	}
+	f := &req.Filter
+	if f.MinConfidence < 0 || f.MinConfidence > 1 {
+		return domain.InvalidInputf("min_confidence must be between 0 and 1")
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return domain.InvalidInputf("from must be before to")
+	}
+	seen := make(map[domain.DefectType]bool, len(f.DefectTypes))
+	for _, t := range f.DefectTypes {
+		if !t.IsValid() {
+			return domain.InvalidInputf("unknown defect type %q", t)
+		}
+		if seen[t] {
+			return domain.InvalidInputf("duplicate defect type %q", t)
+		}
+		seen[t] = true
+	}
+	return nil
+}
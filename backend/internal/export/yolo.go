@@ -0,0 +1,71 @@
+package export
+
+import (
+	"archive/zip"
+	"fmt"
+	"strings"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// yoloWriter пишет по одному labels/<analysis_id>.txt на изображение,
+// а также classes.txt, data.yaml и images.txt (соответствие меток ключам изображений)
+type yoloWriter struct {
+	classes []domain.DefectType
+	classID map[domain.DefectType]int
+	images  strings.Builder
+}
+
+func newYOLOWriter(classes []domain.DefectType) *yoloWriter {
+	w := &yoloWriter{classes: classes, classID: make(map[domain.DefectType]int, len(classes))}
+	for i, c := range classes {
+		w.classID[c] = i // в YOLO классы нумеруются с 0
+	}
+	return w
+}
+
+func (w *yoloWriter) Add(zw *zip.Writer, img imageRef, defects []domain.Defect) error {
+	name := "labels/" + img.AnalysisID.String() + ".txt"
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	// Формат строки: <class> <x_center> <y_center> <width> <height>, координаты нормированы на [0, 1]
+	for _, d := range defects {
+		xc := (float64(d.BBox.X) + float64(d.BBox.Width)/2) / float64(img.Width)
+		yc := (float64(d.BBox.Y) + float64(d.BBox.Height)/2) / float64(img.Height)
+		bw := float64(d.BBox.Width) / float64(img.Width)
+		bh := float64(d.BBox.Height) / float64(img.Height)
+		if _, err := fmt.Fprintf(f, "%d %.6f %.6f %.6f %.6f\n", w.classID[d.DefectType], xc, yc, bw, bh); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(&w.images, "%s %s\n", name, img.Key)
+	return nil
+}
+
+func (w *yoloWriter) Close(zw *zip.Writer) error {
+	var classes, names strings.Builder
+	for i, c := range w.classes {
+		fmt.Fprintf(&classes, "%s\n", c)
+		fmt.Fprintf(&names, "  %d: %s\n", i, c)
+	}
+
+	files := map[string]string{
+		"classes.txt": classes.String(),
+		"data.yaml":   fmt.Sprintf("nc: %d\nnames:\n%s", len(w.classes), names.String()),
+		"images.txt":  w.images.String(),
+	}
+	for _, name := range []string{"classes.txt", "data.yaml", "images.txt"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write([]byte(files[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
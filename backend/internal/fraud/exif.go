@@ -0,0 +1,250 @@
+package fraud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// ErrNoExif возвращается, если в изображении нет блока EXIF
+var ErrNoExif = errors.New("no exif data")
+
+// Теги EXIF, которые нужны антифроду
+const (
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagSoftware         = 0x0131
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagOffsetTimeOrig   = 0x9011
+	tagGPSLatitudeRef   = 0x0001
+	tagGPSLatitude      = 0x0002
+	tagGPSLongitudeRef  = 0x0003
+	tagGPSLongitude     = 0x0004
+)
+
+// Размеры типов TIFF-полей в байтах
+var tiffTypeSize = map[uint16]uint32{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8,
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+type tiffReader struct {
+	buf []byte
+	bo  binary.ByteOrder
+}
+
+// ParseExif извлекает время съёмки, программу обработки, камеру и GPS из JPEG.
+// Для изображений без EXIF возвращает ErrNoExif
+func ParseExif(img []byte) (*domain.ExifData, error) {
+	tiff, err := findExifSegment(img)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tiff) < 8 {
+		return nil, errors.New("exif: truncated tiff header")
+	}
+	r := &tiffReader{buf: tiff}
+	switch string(tiff[:2]) {
+	case "II":
+		r.bo = binary.LittleEndian
+	case "MM":
+		r.bo = binary.BigEndian
+	default:
+		return nil, errors.New("exif: invalid byte order")
+	}
+	if r.bo.Uint16(tiff[2:4]) != 42 {
+		return nil, errors.New("exif: invalid tiff magic")
+	}
+
+	ifd0, err := r.readIFD(r.bo.Uint32(tiff[4:8]))
+	if err != nil {
+		return nil, err
+	}
+
+	exif := &domain.ExifData{
+		Software:    r.stringPtr(ifd0, tagSoftware),
+		CameraMake:  r.stringPtr(ifd0, tagMake),
+		CameraModel: r.stringPtr(ifd0, tagModel),
+	}
+
+	var capturedRaw, offsetRaw string
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if sub, err := r.readIFD(r.uint32(e)); err == nil {
+			capturedRaw = r.string(sub, tagDateTimeOriginal)
+			offsetRaw = r.string(sub, tagOffsetTimeOrig)
+		}
+	}
+	if capturedRaw == "" {
+		capturedRaw = r.string(ifd0, tagDateTime)
+	}
+	if t, ok := parseExifTime(capturedRaw, offsetRaw); ok {
+		exif.CapturedAt = &t
+	}
+
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if gps, err := r.readIFD(r.uint32(e)); err == nil {
+			exif.GPS = r.gpsPoint(gps)
+		}
+	}
+
+	return exif, nil
+}
+
+// findExifSegment находит сегмент APP1 с EXIF и возвращает TIFF-часть
+func findExifSegment(img []byte) ([]byte, error) {
+	if len(img) < 4 || img[0] != 0xFF || img[1] != 0xD8 {
+		return nil, ErrNoExif // не JPEG
+	}
+
+	pos := 2
+	for pos+4 <= len(img) {
+		if img[pos] != 0xFF {
+			return nil, ErrNoExif
+		}
+		marker := img[pos+1]
+		if marker == 0xD9 || marker == 0xDA { // конец файла или начало данных скана
+			break
+		}
+		size := int(binary.BigEndian.Uint16(img[pos+2 : pos+4]))
+		if size < 2 || pos+2+size > len(img) {
+			return nil, errors.New("exif: truncated jpeg segment")
+		}
+		seg := img[pos+4 : pos+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return seg[6:], nil
+		}
+		pos += 2 + size
+	}
+	return nil, ErrNoExif
+}
+
+func (r *tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	if uint64(offset)+2 > uint64(len(r.buf)) {
+		return nil, errors.New("exif: ifd offset out of range")
+	}
+	n := uint32(r.bo.Uint16(r.buf[offset:]))
+	if uint64(offset)+2+uint64(n)*12 > uint64(len(r.buf)) {
+		return nil, errors.New("exif: truncated ifd")
+	}
+
+	entries := make(map[uint16]ifdEntry, n)
+	for i := uint32(0); i < n; i++ {
+		p := offset + 2 + i*12
+		tag := r.bo.Uint16(r.buf[p:])
+		typ := r.bo.Uint16(r.buf[p+2:])
+		count := r.bo.Uint32(r.buf[p+4:])
+
+		size, ok := tiffTypeSize[typ]
+		if !ok {
+			continue
+		}
+		total := uint64(size) * uint64(count)
+
+		var data []byte
+		if total <= 4 {
+			data = r.buf[p+8 : p+8+uint32(total)]
+		} else {
+			valOff := uint64(r.bo.Uint32(r.buf[p+8:]))
+			if valOff+total > uint64(len(r.buf)) {
+				continue
+			}
+			data = r.buf[valOff : valOff+total]
+		}
+		entries[tag] = ifdEntry{typ: typ, count: count, data: data}
+	}
+	return entries, nil
+}
+
+func (r *tiffReader) uint32(e ifdEntry) uint32 {
+	switch {
+	case e.typ == 4 && len(e.data) >= 4:
+		return r.bo.Uint32(e.data)
+	case e.typ == 3 && len(e.data) >= 2:
+		return uint32(r.bo.Uint16(e.data))
+	}
+	return 0
+}
+
+func (r *tiffReader) string(ifd map[uint16]ifdEntry, tag uint16) string {
+	e, ok := ifd[tag]
+	if !ok || e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.data), "\x00"))
+}
+
+func (r *tiffReader) stringPtr(ifd map[uint16]ifdEntry, tag uint16) *string {
+	s := r.string(ifd, tag)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// rationals читает массив беззнаковых дробей (тип RATIONAL)
+func (r *tiffReader) rationals(e ifdEntry) []float64 {
+	if e.typ != 5 {
+		return nil
+	}
+	out := make([]float64, 0, e.count)
+	for i := 0; i+8 <= len(e.data); i += 8 {
+		num := r.bo.Uint32(e.data[i:])
+		den := r.bo.Uint32(e.data[i+4:])
+		if den == 0 {
+			return nil
+		}
+		out = append(out, float64(num)/float64(den))
+	}
+	return out
+}
+
+func (r *tiffReader) gpsPoint(gps map[uint16]ifdEntry) *domain.GeoPoint {
+	lat := r.rationals(gps[tagGPSLatitude])
+	lon := r.rationals(gps[tagGPSLongitude])
+	if len(lat) != 3 || len(lon) != 3 {
+		return nil
+	}
+
+	p := &domain.GeoPoint{
+		Latitude:  lat[0] + lat[1]/60 + lat[2]/3600,
+		Longitude: lon[0] + lon[1]/60 + lon[2]/3600,
+	}
+	if r.string(gps, tagGPSLatitudeRef) == "S" {
+		p.Latitude = -p.Latitude
+	}
+	if r.string(gps, tagGPSLongitudeRef) == "W" {
+		p.Longitude = -p.Longitude
+	}
+	return p
+}
+
+// parseExifTime разбирает время в формате EXIF ("2006:01:02 15:04:05").
+// Если смещение часового пояса не записано камерой, время считается UTC
+func parseExifTime(raw, offset string) (time.Time, bool) {
+	if raw == "" {
+		return time.Time{}, false
+	}
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", raw+offset); err == nil {
+			return t, true
+		}
+	}
+	t, err := time.Parse("2006:01:02 15:04:05", raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,369 @@
+package fraud
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// byteOrder читает и дописывает числа в порядке байтов TIFF
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// testTag - поле IFD для сборки тестового EXIF
+type testTag struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func asciiTag(tag uint16, s string) testTag {
+	return testTag{tag: tag, typ: 2, count: uint32(len(s) + 1), data: append([]byte(s), 0)}
+}
+
+func longTag(bo byteOrder, tag uint16, v uint32) testTag {
+	return testTag{tag: tag, typ: 4, count: 1, data: bo.AppendUint32(nil, v)}
+}
+
+func rationalTag(bo byteOrder, tag uint16, vals ...[2]uint32) testTag {
+	var data []byte
+	for _, v := range vals {
+		data = bo.AppendUint32(data, v[0])
+		data = bo.AppendUint32(data, v[1])
+	}
+	return testTag{tag: tag, typ: 5, count: uint32(len(vals)), data: data}
+}
+
+// ifdSize возвращает размер IFD вместе с вынесенными за него значениями
+func ifdSize(tags []testTag) uint32 {
+	size := uint32(2 + 12*len(tags) + 4)
+	for _, t := range tags {
+		if len(t.data) > 4 {
+			size += uint32(len(t.data))
+		}
+	}
+	return size
+}
+
+// appendIFD дописывает IFD, расположенный по смещению offset, и его значения
+func appendIFD(buf []byte, bo byteOrder, tags []testTag, offset uint32) []byte {
+	buf = bo.AppendUint16(buf, uint16(len(tags)))
+	valOff := offset + uint32(2+12*len(tags)+4)
+	var values []byte
+	for _, t := range tags {
+		buf = bo.AppendUint16(buf, t.tag)
+		buf = bo.AppendUint16(buf, t.typ)
+		buf = bo.AppendUint32(buf, t.count)
+		if len(t.data) <= 4 {
+			var inline [4]byte
+			copy(inline[:], t.data)
+			buf = append(buf, inline[:]...)
+			continue
+		}
+		buf = bo.AppendUint32(buf, valOff)
+		values = append(values, t.data...)
+		valOff += uint32(len(t.data))
+	}
+	buf = bo.AppendUint32(buf, 0) // следующего IFD нет
+	return append(buf, values...)
+}
+
+// buildTIFF собирает TIFF-часть EXIF: IFD0 и, если заданы, Exif IFD и GPS IFD
+func buildTIFF(bo byteOrder, ifd0, exifIFD, gpsIFD []testTag) []byte {
+	var extra int
+	if exifIFD != nil {
+		extra++
+	}
+	if gpsIFD != nil {
+		extra++
+	}
+	ifd0Off := uint32(8)
+	// Указатели на вложенные IFD занимают по одному полю без вынесенных значений
+	next := ifd0Off + ifdSize(ifd0) + uint32(12*extra)
+	if exifIFD != nil {
+		ifd0 = append(ifd0, longTag(bo, tagExifIFD, next))
+		next += ifdSize(exifIFD)
+	}
+	if gpsIFD != nil {
+		ifd0 = append(ifd0, longTag(bo, tagGPSIFD, next))
+	}
+
+	buf := []byte("II")
+	if bo == binary.BigEndian {
+		buf = []byte("MM")
+	}
+	buf = bo.AppendUint16(buf, 42)
+	buf = bo.AppendUint32(buf, ifd0Off)
+	buf = appendIFD(buf, bo, ifd0, ifd0Off)
+	if exifIFD != nil {
+		buf = appendIFD(buf, bo, exifIFD, uint32(len(buf)))
+	}
+	if gpsIFD != nil {
+		buf = appendIFD(buf, bo, gpsIFD, uint32(len(buf)))
+	}
+	return buf
+}
+
+// jpegSegment возвращает сегмент JPEG с маркером marker
+func jpegSegment(marker byte, payload []byte) []byte {
+	seg := []byte{0xFF, marker}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// buildJPEG оборачивает TIFF в APP1 после APP0 и добавляет начало скана
+func buildJPEG(tiff []byte) []byte {
+	img := []byte{0xFF, 0xD8}
+	img = append(img, jpegSegment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))...)
+	img = append(img, jpegSegment(0xE1, append([]byte("Exif\x00\x00"), tiff...))...)
+	img = append(img, jpegSegment(0xDA, []byte{0x00})...)
+	return append(img, 0xFF, 0xD9)
+}
+
+// fullExif возвращает JPEG со всеми полями, которые читает ParseExif
+func fullExif(bo byteOrder) []byte {
+	return buildJPEG(buildTIFF(bo,
+		[]testTag{
+			asciiTag(tagMake, "Apple"),
+			asciiTag(tagModel, "iPhone 15 Pro"),
+			asciiTag(tagSoftware, "Adobe Photoshop 25.0"),
+			asciiTag(tagDateTime, "2024:05:02 09:00:00"),
+		},
+		[]testTag{
+			asciiTag(tagDateTimeOriginal, "2024:05:01 10:30:00"),
+			asciiTag(tagOffsetTimeOrig, "+03:00"),
+		},
+		[]testTag{
+			asciiTag(tagGPSLatitudeRef, "N"),
+			rationalTag(bo, tagGPSLatitude, [2]uint32{55, 1}, [2]uint32{45, 1}, [2]uint32{36, 1}),
+			asciiTag(tagGPSLongitudeRef, "E"),
+			rationalTag(bo, tagGPSLongitude, [2]uint32{37, 1}, [2]uint32{37, 1}, [2]uint32{1800, 100}),
+		},
+	))
+}
+
+func TestParseExif(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tm := func(s string) *time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &v
+	}
+	le, be := binary.LittleEndian, binary.BigEndian
+
+	tests := []struct {
+		name string
+		img  []byte
+		want *domain.ExifData
+	}{
+		{
+			name: "little endian with all fields",
+			img:  fullExif(le),
+			want: &domain.ExifData{
+				CapturedAt:  tm("2024-05-01T10:30:00+03:00"),
+				Software:    str("Adobe Photoshop 25.0"),
+				CameraMake:  str("Apple"),
+				CameraModel: str("iPhone 15 Pro"),
+				GPS:         &domain.GeoPoint{Latitude: 55.76, Longitude: 37.6216666667},
+			},
+		},
+		{
+			name: "big endian with all fields",
+			img:  fullExif(be),
+			want: &domain.ExifData{
+				CapturedAt:  tm("2024-05-01T10:30:00+03:00"),
+				Software:    str("Adobe Photoshop 25.0"),
+				CameraMake:  str("Apple"),
+				CameraModel: str("iPhone 15 Pro"),
+				GPS:         &domain.GeoPoint{Latitude: 55.76, Longitude: 37.6216666667},
+			},
+		},
+		{
+			name: "southern and western hemispheres",
+			img: buildJPEG(buildTIFF(be, []testTag{}, nil, []testTag{
+				asciiTag(tagGPSLatitudeRef, "S"),
+				rationalTag(be, tagGPSLatitude, [2]uint32{33, 1}, [2]uint32{52, 1}, [2]uint32{0, 1}),
+				asciiTag(tagGPSLongitudeRef, "W"),
+				rationalTag(be, tagGPSLongitude, [2]uint32{70, 1}, [2]uint32{30, 1}, [2]uint32{0, 1}),
+			})),
+			want: &domain.ExifData{GPS: &domain.GeoPoint{Latitude: -33.8666666667, Longitude: -70.5}},
+		},
+		{
+			name: "ifd0 date time without offset is utc",
+			img:  buildJPEG(buildTIFF(le, []testTag{asciiTag(tagDateTime, "2024:05:02 09:00:00")}, nil, nil)),
+			want: &domain.ExifData{CapturedAt: tm("2024-05-02T09:00:00Z")},
+		},
+		{
+			name: "invalid offset falls back to utc",
+			img: buildJPEG(buildTIFF(le, []testTag{}, []testTag{
+				asciiTag(tagDateTimeOriginal, "2024:05:01 10:30:00"),
+				asciiTag(tagOffsetTimeOrig, "MSK"),
+			}, nil)),
+			want: &domain.ExifData{CapturedAt: tm("2024-05-01T10:30:00Z")},
+		},
+		{
+			name: "malformed date is ignored",
+			img:  buildJPEG(buildTIFF(le, []testTag{asciiTag(tagDateTime, "0000:00:00 00:00:00")}, nil, nil)),
+			want: &domain.ExifData{},
+		},
+		{
+			name: "gps with zero denominator is ignored",
+			img: buildJPEG(buildTIFF(le, []testTag{asciiTag(tagMake, "Canon")}, nil, []testTag{
+				rationalTag(le, tagGPSLatitude, [2]uint32{55, 0}, [2]uint32{45, 1}, [2]uint32{36, 1}),
+				rationalTag(le, tagGPSLongitude, [2]uint32{37, 1}, [2]uint32{37, 1}, [2]uint32{18, 1}),
+			})),
+			want: &domain.ExifData{CameraMake: str("Canon")},
+		},
+		{
+			name: "gps with two components is ignored",
+			img: buildJPEG(buildTIFF(le, []testTag{}, nil, []testTag{
+				rationalTag(le, tagGPSLatitude, [2]uint32{55, 1}, [2]uint32{45, 1}),
+				rationalTag(le, tagGPSLongitude, [2]uint32{37, 1}, [2]uint32{37, 1}),
+			})),
+			want: &domain.ExifData{},
+		},
+		{
+			name: "tags of wrong type and unknown type are ignored",
+			img: buildJPEG(buildTIFF(le, []testTag{
+				longTag(le, tagSoftware, 7),
+				{tag: tagModel, typ: 99, count: 4, data: []byte("abc\x00")},
+				asciiTag(tagMake, "  Nikon \x00\x00"),
+			}, nil, nil)),
+			want: &domain.ExifData{CameraMake: str("Nikon")},
+		},
+		{
+			name: "value offset out of range skips the tag",
+			img: func() []byte {
+				tiff := buildTIFF(le, []testTag{asciiTag(tagSoftware, "GIMP 2.10.36")}, nil, nil)
+				le.PutUint32(tiff[8+2+8:], 0xFFFFFF00)
+				return buildJPEG(tiff)
+			}(),
+			want: &domain.ExifData{},
+		},
+		{
+			name: "sub ifd pointers out of range are ignored",
+			img: buildJPEG(buildTIFF(le, []testTag{
+				asciiTag(tagMake, "Sony"),
+				longTag(le, tagExifIFD, 1<<20),
+				longTag(le, tagGPSIFD, 1<<31),
+			}, nil, nil)),
+			want: &domain.ExifData{CameraMake: str("Sony")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExif(tt.img)
+			if err != nil {
+				t.Fatalf("ParseExif: %v", err)
+			}
+			assertExif(t, got, tt.want)
+		})
+	}
+}
+
+func TestParseExifErrors(t *testing.T) {
+	le := binary.LittleEndian
+	valid := buildTIFF(le, []testTag{asciiTag(tagMake, "Apple")}, nil, nil)
+	patch := func(offset int, b ...byte) []byte {
+		tiff := append([]byte(nil), valid...)
+		copy(tiff[offset:], b)
+		return buildJPEG(tiff)
+	}
+
+	tests := []struct {
+		name    string
+		img     []byte
+		noExif  bool
+		wantErr bool
+	}{
+		{name: "empty", img: nil, noExif: true},
+		{name: "png", img: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), noExif: true},
+		{name: "jpeg without app1", img: []byte{0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9}, noExif: true},
+		{name: "app1 with xmp", img: append([]byte{0xFF, 0xD8}, jpegSegment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00"))...), noExif: true},
+		{name: "garbage between segments", img: []byte{0xFF, 0xD8, 0x00, 0x01, 0x02, 0x03}, noExif: true},
+		{name: "segment longer than file", img: []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x10, 0x00, 'E', 'x'}, wantErr: true},
+		{name: "segment size below minimum", img: []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0x00, 0x00}, wantErr: true},
+		{name: "truncated tiff header", img: buildJPEG([]byte("II*\x00")), wantErr: true},
+		{name: "invalid byte order", img: patch(0, 'X', 'X'), wantErr: true},
+		{name: "invalid magic", img: patch(2, 43, 0), wantErr: true},
+		{name: "ifd0 offset out of range", img: patch(4, 0xFF, 0xFF, 0xFF, 0xFF), wantErr: true},
+		{name: "ifd entry count beyond data", img: patch(8, 0xFF, 0x00), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExif(tt.img)
+			if got != nil {
+				t.Errorf("got %+v, want nil", got)
+			}
+			switch {
+			case tt.noExif && !errors.Is(err, ErrNoExif):
+				t.Errorf("err = %v, want ErrNoExif", err)
+			case tt.wantErr && (err == nil || errors.Is(err, ErrNoExif)):
+				t.Errorf("err = %v, want a parse error", err)
+			}
+		})
+	}
+}
+
+// TestParseExifCorrupted проверяет, что обрезанные и испорченные блоки не вызывают панику
+func TestParseExifCorrupted(t *testing.T) {
+	for _, bo := range []byteOrder{binary.LittleEndian, binary.BigEndian} {
+		img := fullExif(bo)
+		for n := range len(img) {
+			_, _ = ParseExif(img[:n])
+		}
+		for i := range img {
+			for _, b := range []byte{0x00, 0x7F, 0xFF} {
+				corrupted := append([]byte(nil), img...)
+				corrupted[i] = b
+				_, _ = ParseExif(corrupted)
+			}
+		}
+	}
+}
+
+func assertExif(t *testing.T, got, want *domain.ExifData) {
+	t.Helper()
+	if got == nil {
+		t.Fatal("got nil exif")
+	}
+	assertString(t, "software", got.Software, want.Software)
+	assertString(t, "camera make", got.CameraMake, want.CameraMake)
+	assertString(t, "camera model", got.CameraModel, want.CameraModel)
+
+	switch {
+	case (got.CapturedAt == nil) != (want.CapturedAt == nil):
+		t.Errorf("captured at = %v, want %v", got.CapturedAt, want.CapturedAt)
+	case got.CapturedAt != nil && !got.CapturedAt.Equal(*want.CapturedAt):
+		t.Errorf("captured at = %v, want %v", *got.CapturedAt, *want.CapturedAt)
+	}
+
+	switch {
+	case (got.GPS == nil) != (want.GPS == nil):
+		t.Errorf("gps = %+v, want %+v", got.GPS, want.GPS)
+	case got.GPS != nil && (math.Abs(got.GPS.Latitude-want.GPS.Latitude) > 1e-6 ||
+		math.Abs(got.GPS.Longitude-want.GPS.Longitude) > 1e-6):
+		t.Errorf("gps = %+v, want %+v", *got.GPS, *want.GPS)
+	}
+}
+
+func assertString(t *testing.T, field string, got, want *string) {
+	t.Helper()
+	switch {
+	case (got == nil) != (want == nil):
+		t.Errorf("%s = %v, want %v", field, got, want)
+	case got != nil && *got != *want:
+		t.Errorf("%s = %q, want %q", field, *got, *want)
+	}
+}
@@ -0,0 +1,62 @@
+package fraud
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // декодер JPEG
+	_ "image/png"  // декодер PNG
+)
+
+// DHash вычисляет 64-битный разностный перцептивный хэш изображения.
+// Хэш устойчив к масштабированию, пережатию и небольшой цветокоррекции,
+// поэтому близкие по расстоянию Хэмминга хэши означают почти одинаковые фото
+func DHash(img []byte) (int64, error) {
+	src, _, err := image.Decode(bytes.NewReader(img))
+	if err != nil {
+		return 0, fmt.Errorf("decode image: %w", err)
+	}
+
+	const w, h = 9, 8
+	gray := downscaleGray(src, w, h)
+
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if gray[y*w+x] < gray[y*w+x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return int64(hash), nil
+}
+
+// downscaleGray уменьшает изображение до w×h усреднением яркости по областям
+func downscaleGray(src image.Image, w, h int) []float64 {
+	b := src.Bounds()
+	out := make([]float64, w*h)
+
+	for cy := 0; cy < h; cy++ {
+		y0 := b.Min.Y + cy*b.Dy()/h
+		y1 := max(b.Min.Y+(cy+1)*b.Dy()/h, y0+1)
+		for cx := 0; cx < w; cx++ {
+			x0 := b.Min.X + cx*b.Dx()/w
+			x1 := max(b.Min.X+(cx+1)*b.Dx()/w, x0+1)
+
+			var sum float64
+			var n int
+			for y := y0; y < y1 && y < b.Max.Y; y++ {
+				for x := x0; x < x1 && x < b.Max.X; x++ {
+					r, g, bl, _ := src.At(x, y).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+					n++
+				}
+			}
+			if n > 0 {
+				out[cy*w+cx] = sum / float64(n)
+			}
+		}
+	}
+	return out
+}
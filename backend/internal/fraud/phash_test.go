@@ -0,0 +1,152 @@
+package fraud
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/bits"
+	"testing"
+)
+
+// pattern - яркость тестового изображения в точке (u, v) из [0, 1)
+type pattern func(u, v float64) uint8
+
+func horizontalGradient(u, _ float64) uint8 { return uint8(255 * u) }
+
+func reversedGradient(u, _ float64) uint8 { return uint8(255 * (1 - u)) }
+
+func uniform(_, _ float64) uint8 { return 128 }
+
+// scene - плавное изображение с несколькими пятнами, похожее по спектру на фото
+func scene(u, v float64) uint8 {
+	l := 0.5 + 0.25*math.Sin(2*math.Pi*u*1.5) + 0.2*math.Cos(2*math.Pi*v*2.3) + 0.05*math.Sin(2*math.Pi*(u+v)*3.7)
+	return uint8(255 * math.Max(0, math.Min(1, l)))
+}
+
+// otherScene - изображение с другой структурой яркости
+func otherScene(u, v float64) uint8 {
+	l := 0.5 + 0.3*math.Cos(2*math.Pi*u*2.7+1) + 0.2*math.Sin(2*math.Pi*v*1.1+2)
+	return uint8(255 * math.Max(0, math.Min(1, l)))
+}
+
+func renderRGBA(p pattern, w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			l := p(float64(x)/float64(w), float64(y)/float64(h))
+			img.Set(x, y, color.RGBA{R: l, G: l, B: l, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image, quality int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func hamming(a, b int64) int {
+	return bits.OnesCount64(uint64(a ^ b))
+}
+
+func TestDHashKnownValues(t *testing.T) {
+	tests := []struct {
+		name string
+		p    pattern
+		w, h int
+		want int64
+	}{
+		{"brightness grows to the right", horizontalGradient, 180, 80, -1}, // все 64 бита
+		{"brightness falls to the right", reversedGradient, 180, 80, 0},
+		{"uniform", uniform, 90, 90, 0},
+		// Соседние ячейки делят столбец пикселей, и растёт только каждая вторая пара
+		{"smaller than the hash grid", horizontalGradient, 4, 3, 0x2A2A2A2A2A2A2A2A},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DHash(encodePNG(t, renderRGBA(tt.p, tt.w, tt.h)))
+			if err != nil {
+				t.Fatalf("DHash: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DHash = %064b, want %064b", uint64(got), uint64(tt.want))
+			}
+		})
+	}
+}
+
+func TestDHashSimilarity(t *testing.T) {
+	original, err := DHash(encodePNG(t, renderRGBA(scene, 640, 480)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		img     func(t *testing.T) []byte
+		maxDist int
+		minDist int
+	}{
+		{"same image", func(t *testing.T) []byte { return encodePNG(t, renderRGBA(scene, 640, 480)) }, 0, 0},
+		{"downscaled", func(t *testing.T) []byte { return encodePNG(t, renderRGBA(scene, 160, 120)) }, 6, 0},
+		{"recompressed jpeg", func(t *testing.T) []byte { return encodeJPEG(t, renderRGBA(scene, 640, 480), 60) }, 6, 0},
+		{"brightened", func(t *testing.T) []byte {
+			return encodePNG(t, renderRGBA(func(u, v float64) uint8 { return uint8(min(255, int(scene(u, v))+20)) }, 640, 480))
+		}, 6, 0},
+		{"different scene", func(t *testing.T) []byte { return encodePNG(t, renderRGBA(otherScene, 640, 480)) }, 64, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DHash(tt.img(t))
+			if err != nil {
+				t.Fatalf("DHash: %v", err)
+			}
+			d := hamming(original, got)
+			if d > tt.maxDist || d < tt.minDist {
+				t.Errorf("distance = %d, want between %d and %d", d, tt.minDist, tt.maxDist)
+			}
+		})
+	}
+}
+
+func TestDHashInvalidImage(t *testing.T) {
+	for _, img := range [][]byte{nil, []byte("not an image"), {0xFF, 0xD8, 0xFF, 0xE0, 0x00}} {
+		if _, err := DHash(img); err == nil {
+			t.Errorf("DHash(%q) returned no error", img)
+		}
+	}
+}
+
+func TestDownscaleGraySubImage(t *testing.T) {
+	// Область с ненулевым началом координат уменьшается так же, как её копия от нуля
+	full := renderRGBA(scene, 400, 300)
+	sub := full.SubImage(image.Rect(100, 50, 300, 250))
+	copied := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	for y := range 200 {
+		for x := range 200 {
+			copied.Set(x, y, sub.At(100+x, 50+y))
+		}
+	}
+	got, want := downscaleGray(sub, 9, 8), downscaleGray(copied, 9, 8)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("cell %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
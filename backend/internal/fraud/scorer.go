@@ -0,0 +1,129 @@
+package fraud
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Веса признаков. Итоговая оценка считается как noisy-OR: 1 - Π(1 - w),
+// поэтому она остаётся в [0, 1] и растёт с каждым новым сработавшим признаком
+var signalWeights = map[domain.FraudSignal]float64{
+	domain.FraudSignalCaptureTimeGap:  0.25,
+	domain.FraudSignalEditingSoftware: 0.35,
+	domain.FraudSignalGPSMismatch:     0.30,
+	domain.FraudSignalDuplicateImage:  0.50,
+	domain.FraudSignalHighDefectCount: 0.20,
+}
+
+// editingSoftware - подстроки тега Software, характерные для графических редакторов.
+// Прошивки телефонов и камер (например "iOS 17.1") сюда не попадают
+var editingSoftware = []string{
+	"photoshop", "lightroom", "gimp", "snapseed", "picsart", "affinity",
+	"pixelmator", "paint.net", "facetune", "canva", "photoscape", "fotor",
+}
+
+// Duplicate представляет найденное почти-дублирующее изображение
+type Duplicate struct {
+	AnalysisID uuid.UUID
+	Distance   int
+}
+
+// Input содержит всё, что нужно для оценки одного анализа
+type Input struct {
+	UploadedAt       time.Time
+	Exif             *domain.ExifData
+	IncidentLocation *domain.GeoPoint
+	Duplicates       []Duplicate
+	DefectCount      int
+}
+
+// Score вычисляет антифрод-оценку по сработавшим признакам
+func Score(in Input, cfg config.FraudConfig, now time.Time) domain.FraudAssessment {
+	var reasons domain.FraudReasons
+
+	add := func(signal domain.FraudSignal, details string, related *uuid.UUID) {
+		reasons = append(reasons, domain.FraudReason{
+			Signal:            signal,
+			Weight:            signalWeights[signal],
+			Details:           details,
+			RelatedAnalysisID: related,
+		})
+	}
+
+	if in.Exif != nil && in.Exif.CapturedAt != nil {
+		gap := in.UploadedAt.Sub(*in.Exif.CapturedAt)
+		switch {
+		case gap > cfg.MaxCaptureUploadGap:
+			add(domain.FraudSignalCaptureTimeGap,
+				fmt.Sprintf("photo taken %s before upload", gap.Round(time.Hour)), nil)
+		case gap < -24*time.Hour: // больше суток "из будущего" не объясняется часовыми поясами
+			add(domain.FraudSignalCaptureTimeGap,
+				fmt.Sprintf("capture time is %s after upload", (-gap).Round(time.Hour)), nil)
+		}
+	}
+
+	if in.Exif != nil && in.Exif.Software != nil {
+		sw := strings.ToLower(*in.Exif.Software)
+		for _, editor := range editingSoftware {
+			if strings.Contains(sw, editor) {
+				add(domain.FraudSignalEditingSoftware, "software tag: "+*in.Exif.Software, nil)
+				break
+			}
+		}
+	}
+
+	if in.Exif != nil && in.Exif.GPS != nil && in.IncidentLocation != nil {
+		dist := HaversineKm(*in.Exif.GPS, *in.IncidentLocation)
+		if dist > cfg.MaxGPSDistanceKm {
+			add(domain.FraudSignalGPSMismatch,
+				fmt.Sprintf("photo taken %.1f km from the reported incident location", dist), nil)
+		}
+	}
+
+	if len(in.Duplicates) > 0 {
+		closest := in.Duplicates[0]
+		for _, d := range in.Duplicates[1:] {
+			if d.Distance < closest.Distance {
+				closest = d
+			}
+		}
+		id := closest.AnalysisID
+		add(domain.FraudSignalDuplicateImage,
+			fmt.Sprintf("%d similar image(s) submitted by other users, closest distance %d bits",
+				len(in.Duplicates), closest.Distance), &id)
+	}
+
+	if cfg.HighDefectCount > 0 && in.DefectCount >= cfg.HighDefectCount {
+		add(domain.FraudSignalHighDefectCount,
+			fmt.Sprintf("%d defects detected on a single photo", in.DefectCount), nil)
+	}
+
+	clean := 1.0
+	for _, r := range reasons {
+		clean *= 1 - r.Weight
+	}
+
+	return domain.FraudAssessment{
+		Score:     math.Round((1-clean)*1000) / 1000,
+		Reasons:   reasons,
+		CheckedAt: now,
+	}
+}
+
+// HaversineKm вычисляет расстояние между двумя точками на сфере в километрах
+func HaversineKm(a, b domain.GeoPoint) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(b.Latitude - a.Latitude)
+	dLon := toRad(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Latitude))*math.Cos(toRad(b.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
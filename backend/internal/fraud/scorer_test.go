@@ -0,0 +1,197 @@
+package fraud
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+var testFraudConfig = config.FraudConfig{
+	MaxCaptureUploadGap:  72 * time.Hour,
+	MaxGPSDistanceKm:     5,
+	DuplicateMaxDistance: 6,
+	HighDefectCount:      15,
+	HighRiskThreshold:    0.7,
+}
+
+func TestScore(t *testing.T) {
+	uploaded := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		v := uploaded.Add(d)
+		return &v
+	}
+	str := func(s string) *string { return &s }
+	moscow := domain.GeoPoint{Latitude: 55.7558, Longitude: 37.6173}
+	nearby := domain.GeoPoint{Latitude: 55.7600, Longitude: 37.6200}
+	spb := domain.GeoPoint{Latitude: 59.9343, Longitude: 30.3351}
+	dupA, dupB := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		in      Input
+		want    float64
+		signals []domain.FraudSignal
+		related *uuid.UUID
+	}{
+		{
+			name: "no signals",
+			in:   Input{UploadedAt: uploaded},
+			want: 0,
+		},
+		{
+			name: "clean exif",
+			in: Input{
+				UploadedAt:       uploaded,
+				Exif:             &domain.ExifData{CapturedAt: at(-time.Hour), Software: str("iOS 17.1"), GPS: &nearby},
+				IncidentLocation: &moscow,
+				DefectCount:      3,
+			},
+			want: 0,
+		},
+		{
+			name:    "old photo",
+			in:      Input{UploadedAt: uploaded, Exif: &domain.ExifData{CapturedAt: at(-96 * time.Hour)}},
+			want:    0.25,
+			signals: []domain.FraudSignal{domain.FraudSignalCaptureTimeGap},
+		},
+		{
+			name: "capture time within timezone slack",
+			in:   Input{UploadedAt: uploaded, Exif: &domain.ExifData{CapturedAt: at(12 * time.Hour)}},
+			want: 0,
+		},
+		{
+			name:    "capture time in the future",
+			in:      Input{UploadedAt: uploaded, Exif: &domain.ExifData{CapturedAt: at(48 * time.Hour)}},
+			want:    0.25,
+			signals: []domain.FraudSignal{domain.FraudSignalCaptureTimeGap},
+		},
+		{
+			name:    "editing software is case insensitive",
+			in:      Input{UploadedAt: uploaded, Exif: &domain.ExifData{Software: str("Adobe PHOTOSHOP 25.0")}},
+			want:    0.35,
+			signals: []domain.FraudSignal{domain.FraudSignalEditingSoftware},
+		},
+		{
+			name:    "gps far from incident",
+			in:      Input{UploadedAt: uploaded, Exif: &domain.ExifData{GPS: &spb}, IncidentLocation: &moscow},
+			want:    0.3,
+			signals: []domain.FraudSignal{domain.FraudSignalGPSMismatch},
+		},
+		{
+			name: "gps without incident location",
+			in:   Input{UploadedAt: uploaded, Exif: &domain.ExifData{GPS: &spb}},
+			want: 0,
+		},
+		{
+			name: "closest duplicate is related",
+			in: Input{
+				UploadedAt: uploaded,
+				Duplicates: []Duplicate{{AnalysisID: dupA, Distance: 5}, {AnalysisID: dupB, Distance: 2}},
+			},
+			want:    0.5,
+			signals: []domain.FraudSignal{domain.FraudSignalDuplicateImage},
+			related: &dupB,
+		},
+		{
+			name:    "defect count at threshold",
+			in:      Input{UploadedAt: uploaded, DefectCount: 15},
+			want:    0.2,
+			signals: []domain.FraudSignal{domain.FraudSignalHighDefectCount},
+		},
+		{
+			name: "two signals combine as noisy-or",
+			in: Input{
+				UploadedAt:       uploaded,
+				Exif:             &domain.ExifData{Software: str("GIMP 2.10"), GPS: &spb},
+				IncidentLocation: &moscow,
+			},
+			want:    0.545, // 1 - 0.65 * 0.7
+			signals: []domain.FraudSignal{domain.FraudSignalEditingSoftware, domain.FraudSignalGPSMismatch},
+		},
+		{
+			name: "all signals",
+			in: Input{
+				UploadedAt:       uploaded,
+				Exif:             &domain.ExifData{CapturedAt: at(-30 * 24 * time.Hour), Software: str("Snapseed"), GPS: &spb},
+				IncidentLocation: &moscow,
+				Duplicates:       []Duplicate{{AnalysisID: dupA, Distance: 0}},
+				DefectCount:      40,
+			},
+			// 1 - 0.75*0.65*0.7*0.5*0.8 = 0.8635, в float64 произведение чуть больше 0.1365
+			want: 0.863,
+			signals: []domain.FraudSignal{
+				domain.FraudSignalCaptureTimeGap, domain.FraudSignalEditingSoftware, domain.FraudSignalGPSMismatch,
+				domain.FraudSignalDuplicateImage, domain.FraudSignalHighDefectCount,
+			},
+			related: &dupA,
+		},
+	}
+
+	now := uploaded.Add(time.Minute)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fa := Score(tt.in, testFraudConfig, now)
+			if math.Abs(fa.Score-tt.want) > 1e-9 {
+				t.Errorf("score = %v, want %v", fa.Score, tt.want)
+			}
+			if !fa.CheckedAt.Equal(now) {
+				t.Errorf("checked at = %v, want %v", fa.CheckedAt, now)
+			}
+			if len(fa.Reasons) != len(tt.signals) {
+				t.Fatalf("reasons = %+v, want signals %v", fa.Reasons, tt.signals)
+			}
+			for i, r := range fa.Reasons {
+				if r.Signal != tt.signals[i] {
+					t.Errorf("reason %d signal = %s, want %s", i, r.Signal, tt.signals[i])
+				}
+				if r.Weight != signalWeights[r.Signal] {
+					t.Errorf("reason %d weight = %v, want %v", i, r.Weight, signalWeights[r.Signal])
+				}
+				if r.Signal == domain.FraudSignalDuplicateImage {
+					if r.RelatedAnalysisID == nil || *r.RelatedAnalysisID != *tt.related {
+						t.Errorf("related analysis = %v, want %v", r.RelatedAnalysisID, *tt.related)
+					}
+				} else if r.RelatedAnalysisID != nil {
+					t.Errorf("reason %d has related analysis %v", i, *r.RelatedAnalysisID)
+				}
+			}
+		})
+	}
+}
+
+func TestScoreHighDefectCountDisabled(t *testing.T) {
+	cfg := testFraudConfig
+	cfg.HighDefectCount = 0
+	fa := Score(Input{UploadedAt: time.Now(), DefectCount: 100}, cfg, time.Now())
+	if fa.Score != 0 || len(fa.Reasons) != 0 {
+		t.Errorf("got score %v with reasons %+v, want no signals", fa.Score, fa.Reasons)
+	}
+}
+
+func TestHaversineKm(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b domain.GeoPoint
+		want float64
+		tol  float64
+	}{
+		{"same point", domain.GeoPoint{Latitude: 55.75, Longitude: 37.61}, domain.GeoPoint{Latitude: 55.75, Longitude: 37.61}, 0, 1e-9},
+		{"one degree of latitude", domain.GeoPoint{Latitude: 0, Longitude: 0}, domain.GeoPoint{Latitude: 1, Longitude: 0}, 111.195, 0.01},
+		{"moscow to saint petersburg", domain.GeoPoint{Latitude: 55.7558, Longitude: 37.6173}, domain.GeoPoint{Latitude: 59.9343, Longitude: 30.3351}, 634, 2},
+		{"across the antimeridian", domain.GeoPoint{Latitude: 0, Longitude: 179.5}, domain.GeoPoint{Latitude: 0, Longitude: -179.5}, 111.195, 0.01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HaversineKm(tt.a, tt.b); math.Abs(got-tt.want) > tt.tol {
+				t.Errorf("HaversineKm = %v, want %v ± %v", got, tt.want, tt.tol)
+			}
+			if got, back := HaversineKm(tt.a, tt.b), HaversineKm(tt.b, tt.a); math.Abs(got-back) > 1e-9 {
+				t.Errorf("distance is not symmetric: %v vs %v", got, back)
+			}
+		})
+	}
+}
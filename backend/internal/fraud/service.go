@@ -0,0 +1,122 @@
+package fraud
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// maxDuplicateCandidates ограничивает число почти-дубликатов, попадающих в причины
+const maxDuplicateCandidates = 5
+
+// Service выполняет антифрод-проверку анализов и сохраняет её результат
+type Service struct {
+	analyses *repository.AnalysisRepository
+	storage  storage.ObjectStorage
+	cfg      config.FraudConfig
+}
+
+// NewService создаёт антифрод-сервис
+func NewService(analyses *repository.AnalysisRepository, store storage.ObjectStorage, cfg config.FraudConfig) *Service {
+	return &Service{analyses: analyses, storage: store, cfg: cfg}
+}
+
+// Evaluate читает изображение анализа, извлекает EXIF и перцептивный хэш,
+// ищет почти-дубликаты у других пользователей организации за FRAUD_DUPLICATE_WINDOW
+// до загрузки и сохраняет оценку в анализе
+func (s *Service) Evaluate(ctx context.Context, analysisID uuid.UUID) (*domain.FraudAssessment, error) {
+	a, err := s.analyses.GetByID(ctx, analysisID)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := storage.ReadAll(ctx, s.storage, a.ImageKey)
+	if err != nil {
+		return nil, fmt.Errorf("read image %s: %w", a.ImageKey, err)
+	}
+
+	meta := a.ImageMetadata
+	if meta == nil {
+		meta = &domain.ImageMetadata{}
+	}
+	fillImageMetadata(meta, img)
+
+	exif, err := ParseExif(img)
+	if err != nil && !errors.Is(err, ErrNoExif) {
+		return nil, fmt.Errorf("parse exif: %w", err)
+	}
+	meta.Exif = exif
+
+	hash, err := DHash(img)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.analyses.UpdateImageFingerprint(ctx, a.ID, meta, hash); err != nil {
+		return nil, err
+	}
+
+	since := a.CreatedAt.Add(-s.cfg.DuplicateWindow)
+	near, err := s.analyses.FindNearDuplicates(ctx, a.UserID, hash, since, s.cfg.DuplicateMaxDistance, maxDuplicateCandidates)
+	if err != nil {
+		return nil, err
+	}
+	dups := make([]Duplicate, 0, len(near))
+	for _, d := range near {
+		dups = append(dups, Duplicate{AnalysisID: d.AnalysisID, Distance: d.Distance})
+	}
+
+	var defectCount int
+	if a.Result != nil {
+		defectCount = len(a.Result.Defects)
+	}
+
+	fa := Score(Input{
+		UploadedAt:       a.CreatedAt,
+		Exif:             exif,
+		IncidentLocation: a.IncidentLocation,
+		Duplicates:       dups,
+		DefectCount:      defectCount,
+	}, s.cfg, time.Now())
+
+	if err := s.analyses.UpdateFraudAssessment(ctx, a.ID, &fa); err != nil {
+		return nil, err
+	}
+	return &fa, nil
+}
+
+// ListHighRisk возвращает анализы с оценкой не ниже порога.
+// Если порог не задан, используется FRAUD_HIGH_RISK_THRESHOLD
+func (s *Service) ListHighRisk(ctx context.Context, filter domain.HighRiskAnalysisFilter) ([]domain.Analysis, error) {
+	if filter.MinScore <= 0 {
+		filter.MinScore = s.cfg.HighRiskThreshold
+	}
+	return s.analyses.ListHighRisk(ctx, filter)
+}
+
+// fillImageMetadata дополняет размер, формат и разрешение, если они ещё не заполнены
+func fillImageMetadata(meta *domain.ImageMetadata, img []byte) {
+	if meta.Size == 0 {
+		meta.Size = int64(len(img))
+	}
+	if meta.Format != "" && meta.Dimensions.Width != 0 {
+		return
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		return
+	}
+	if meta.Format == "" {
+		meta.Format = format
+	}
+	meta.Dimensions.Width = cfg.Width
+	meta.Dimensions.Height = cfg.Height
+}
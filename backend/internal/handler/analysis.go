@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/analysis"
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/i18n"
+)
+
+// AnalysisHandler обслуживает запросы к анализам
+type AnalysisHandler struct {
+	svc *analysis.Service
+}
+
+// NewAnalysisHandler создаёт обработчик анализов
+func NewAnalysisHandler(svc *analysis.Service) *AnalysisHandler {
+	return &AnalysisHandler{svc: svc}
+}
+
+// Register регистрирует маршруты анализов
+func (h *AnalysisHandler) Register(rt *Router) {
+	rt.Handle("POST /api/v1/analyses", h.create)
+	rt.Handle("GET /api/v1/analyses/search", h.search)
+	rt.Handle("GET /api/v1/analyses/{id}", h.get)
+	rt.Handle("GET /api/v1/analyses/{id}/image", h.image)
+}
+
+// create ставит в очередь анализ загруженного изображения.
+// POST /api/v1/analyses
+func (h *AnalysisHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req domain.AnalysisCreateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	a, err := h.svc.Create(r.Context(), user, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, localizeAnalysis(r, a))
+}
+
+// get возвращает анализ.
+// GET /api/v1/analyses/{id}
+func (h *AnalysisHandler) get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid analysis id")
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	a, err := h.svc.Get(r.Context(), user, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, localizeAnalysis(r, a))
+}
+
+// image отдаёт изображение анализа: по умолчанию копию со скрытыми номерами и лицами.
+// GET /api/v1/analyses/{id}/image?variant=redacted|original
+func (h *AnalysisHandler) image(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid analysis id")
+		return
+	}
+	variant := domain.ImageVariantRedacted
+	if v := r.URL.Query().Get("variant"); v != "" {
+		variant = domain.ImageVariant(v)
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	rc, err := h.svc.Image(r.Context(), user, id, variant)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+	head, _ := br.Peek(512)
+	w.Header().Set("Content-Type", http.DetectContentType(head))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, br); err != nil {
+		log.Printf("write image of analysis %s: %v", id, err)
+	}
+}
+
+// searchResponse - страница поиска с переводами кодов
+type searchResponse struct {
+	*domain.AnalysisSearchPage
+	Labels *i18n.Labels `json:"labels"`
+}
+
+// search ищет завершённые анализы по дефектам результата. Условия на дефект
+// (defect_type, part_id, severity, min_confidence) относятся к одному дефекту.
+// GET /api/v1/analyses/search?defect_type=dent&part_id=bumper_01&severity=major&min_confidence=0.8
+// &view_angle=front&model_version=v1&model_version=v2&car_make=Toyota&from=2026-01-01&to=2026-02-01
+// &sort=-total_defects&limit=50&offset=0
+func (h *AnalysisHandler) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := domain.AnalysisSearchFilter{
+		Sort:          domain.AnalysisSort(q.Get("sort")),
+		ModelVersions: q["model_version"],
+	}
+	f.Limit, f.Offset = pagination(r)
+
+	if v := q.Get("defect_type"); v != "" {
+		t := domain.DefectType(v)
+		f.DefectType = &t
+	}
+	if v := q.Get("severity"); v != "" {
+		s := domain.DefectSeverity(v)
+		f.Severity = &s
+	}
+	f.PartID = queryString(r, "part_id")
+	f.ViewAngle = queryString(r, "view_angle")
+	f.CarMake = queryString(r, "car_make")
+
+	if v := q.Get("min_confidence"); v != "" {
+		c, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "min_confidence must be a number between 0 and 1")
+			return
+		}
+		f.MinConfidence = &c
+	}
+	if q.Get("from") != "" {
+		from, ok := queryTime(w, r, "from", time.Time{})
+		if !ok {
+			return
+		}
+		f.From = &from
+	}
+	if q.Get("to") != "" {
+		to, ok := queryTime(w, r, "to", time.Time{})
+		if !ok {
+			return
+		}
+		f.To = &to
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	page, err := h.svc.Search(r.Context(), user, f)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, searchResponse{AnalysisSearchPage: page, Labels: analysisLabels(r, page.Items)})
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/batch"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// BatchHandler обслуживает пакетную отправку анализов
+type BatchHandler struct {
+	svc         *batch.Service
+	maxZipBytes int64
+}
+
+// NewBatchHandler создаёт обработчик пакетов
+func NewBatchHandler(svc *batch.Service, maxZipBytes int64) *BatchHandler {
+	return &BatchHandler{svc: svc, maxZipBytes: maxZipBytes}
+}
+
+// Register регистрирует маршруты пакетов (владелец пакета или администратор)
+func (h *BatchHandler) Register(rt *Router) {
+	rt.Handle("POST /api/v1/batches", h.create)
+	rt.Handle("GET /api/v1/batches", h.list)
+	rt.Handle("GET /api/v1/batches/{id}", h.get)
+	rt.Handle("GET /api/v1/batches/{id}/analyses", h.items)
+	rt.Handle("POST /api/v1/batches/{id}/cancel", h.cancel)
+	rt.Handle("POST /api/v1/batches/{id}/retry", h.retry)
+}
+
+// create создаёт пакет. Принимает JSON со списком ключей изображений
+// или zip-архив (Content-Type: application/zip,
+// ?name=&model_version=&car_make=&car_model=&region=&webhook_url=).
+// POST /api/v1/batches
+func (h *BatchHandler) create(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFromContext(r.Context())
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var (
+		b   *domain.AnalysisBatch
+		err error
+	)
+	switch mediaType {
+	case "application/zip", "application/x-zip-compressed":
+		q := r.URL.Query()
+		req := batch.ZipRequest{Archive: http.MaxBytesReader(w, r.Body, h.maxZipBytes)}
+		if v := q.Get("name"); v != "" {
+			req.Name = &v
+		}
+		if v := q.Get("model_version"); v != "" {
+			req.ModelVersion = &v
+		}
+		if v := q.Get("car_make"); v != "" {
+			req.CarMake = &v
+		}
+		if v := q.Get("car_model"); v != "" {
+			req.CarModel = &v
+		}
+		if v := q.Get("region"); v != "" {
+			req.Region = &v
+		}
+		if v := q.Get("webhook_url"); v != "" {
+			req.WebhookURL = &v
+		}
+		b, err = h.svc.CreateFromZip(r.Context(), user, req)
+	case "application/json", "":
+		var req domain.BatchCreateRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		b, err = h.svc.Create(r.Context(), user, req)
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, "content type must be application/json or application/zip")
+		return
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "archive is too large")
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, b)
+}
+
+// list возвращает пакеты текущего пользователя.
+// GET /api/v1/batches?limit=50&offset=0
+func (h *BatchHandler) list(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFromContext(r.Context())
+	limit, offset := pagination(r)
+
+	batches, err := h.svc.List(r.Context(), user, limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(batches), Limit: limit, Offset: offset})
+}
+
+// get возвращает пакет с прогрессом.
+// GET /api/v1/batches/{id}
+func (h *BatchHandler) get(w http.ResponseWriter, r *http.Request) {
+	h.withBatch(w, r, h.svc.Get)
+}
+
+// items возвращает анализы пакета.
+// GET /api/v1/batches/{id}/analyses?status=failed&limit=50&offset=0
+func (h *BatchHandler) items(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid batch id")
+		return
+	}
+	user, _ := auth.UserFromContext(r.Context())
+	limit, offset := pagination(r)
+
+	var status *domain.AnalysisStatus
+	if v := r.URL.Query().Get("status"); v != "" {
+		s := domain.AnalysisStatus(v)
+		status = &s
+	}
+
+	analyses, err := h.svc.Items(r.Context(), user, id, status, limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(analyses), Limit: limit, Offset: offset,
+		Labels: analysisLabels(r, analyses)})
+}
+
+// cancel отменяет ещё не обработанные анализы пакета.
+// POST /api/v1/batches/{id}/cancel
+func (h *BatchHandler) cancel(w http.ResponseWriter, r *http.Request) {
+	h.withBatch(w, r, h.svc.Cancel)
+}
+
+// retry повторяет отменённые анализы и анализы с временными ошибками.
+// POST /api/v1/batches/{id}/retry
+func (h *BatchHandler) retry(w http.ResponseWriter, r *http.Request) {
+	h.withBatch(w, r, h.svc.Retry)
+}
+
+// withBatch выполняет операцию над пакетом из пути и возвращает его состояние
+func (h *BatchHandler) withBatch(w http.ResponseWriter, r *http.Request,
+	op func(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.AnalysisBatch, error)) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid batch id")
+		return
+	}
+	user, _ := auth.UserFromContext(r.Context())
+
+	b, err := op(r.Context(), user, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/canary"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// CanaryHandler обслуживает канареечные правила моделей
+type CanaryHandler struct {
+	svc *canary.Service
+}
+
+// NewCanaryHandler создаёт обработчик канареечных правил
+func NewCanaryHandler(svc *canary.Service) *CanaryHandler {
+	return &CanaryHandler{svc: svc}
+}
+
+// Register регистрирует маршруты канареечных правил (владельцы и администраторы)
+func (h *CanaryHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/admin/canary-rules", h.list, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("POST /api/v1/admin/canary-rules", h.create, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("PUT /api/v1/admin/canary-rules/{id}", h.update, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/admin/canary-rules/{id}/report", h.report, domain.RoleOwner, domain.RoleAdmin)
+}
+
+// list возвращает правила.
+// GET /api/v1/admin/canary-rules?limit=50&offset=0
+func (h *CanaryHandler) list(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	user, _ := auth.UserFromContext(r.Context())
+	items, err := h.svc.List(r.Context(), user, limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(items), Limit: limit, Offset: offset})
+}
+
+// create создаёт правило.
+// POST /api/v1/admin/canary-rules
+func (h *CanaryHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req domain.CanaryRuleRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	rule, err := h.svc.Create(r.Context(), user, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+// update меняет долю кандидата или включает и отключает правило.
+// PUT /api/v1/admin/canary-rules/{id}
+func (h *CanaryHandler) update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid canary rule id")
+		return
+	}
+	var req domain.CanaryRuleUpdateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	rule, err := h.svc.Update(r.Context(), user, id, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// report сравнивает кандидата и активную модель по правилу.
+// GET /api/v1/admin/canary-rules/{id}/report?from=2024-01-01&to=2024-02-01
+func (h *CanaryHandler) report(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid canary rule id")
+		return
+	}
+	from, to, ok := queryTimeRange(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	report, err := h.svc.Report(r.Context(), user, id, from, to)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/export"
+)
+
+// DatasetHandler обслуживает запросы к датасетам
+type DatasetHandler struct {
+	exporter *export.Service
+}
+
+// NewDatasetHandler создаёт обработчик датасетов
+func NewDatasetHandler(exporter *export.Service) *DatasetHandler {
+	return &DatasetHandler{exporter: exporter}
+}
+
+// Register регистрирует маршруты датасетов
+func (h *DatasetHandler) Register(rt *Router) {
+	rt.Handle("POST /api/v1/datasets/exports", h.export, domain.RoleOwner, domain.RoleAdmin)
+}
+
+// export выгружает завершённые анализы в новый датасет (COCO или YOLO).
+// POST /api/v1/datasets/exports
+func (h *DatasetHandler) export(w http.ResponseWriter, r *http.Request) {
+	var req domain.DatasetExportRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	ds, err := h.exporter.Export(r.Context(), user.ID, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, ds)
+}
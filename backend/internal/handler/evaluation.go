@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/evaluation"
+	"github.com/google/uuid"
+)
+
+// EvaluationHandler обслуживает офлайн-оценку моделей
+type EvaluationHandler struct {
+	svc *evaluation.Service
+}
+
+// NewEvaluationHandler создаёт обработчик оценки моделей
+func NewEvaluationHandler(svc *evaluation.Service) *EvaluationHandler {
+	return &EvaluationHandler{svc: svc}
+}
+
+// Register регистрирует маршруты оценки моделей (владельцы и администраторы)
+func (h *EvaluationHandler) Register(rt *Router) {
+	rt.Handle("POST /api/v1/admin/models/{id}/evaluations", h.create, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/admin/models/{id}/evaluations", h.list, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/admin/evaluations/{id}", h.get, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/admin/models/compare", h.compare, domain.RoleOwner, domain.RoleAdmin)
+}
+
+// create ставит оценку модели в очередь.
+// POST /api/v1/admin/models/{id}/evaluations
+func (h *EvaluationHandler) create(w http.ResponseWriter, r *http.Request) {
+	modelID, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid model id")
+		return
+	}
+	var req domain.EvaluationJobCreateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	job, err := h.svc.Create(r.Context(), user, modelID, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// list возвращает оценки модели.
+// GET /api/v1/admin/models/{id}/evaluations?limit=50&offset=0
+func (h *EvaluationHandler) list(w http.ResponseWriter, r *http.Request) {
+	modelID, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid model id")
+		return
+	}
+	limit, offset := pagination(r)
+	user, _ := auth.UserFromContext(r.Context())
+	items, err := h.svc.ListByModel(r.Context(), user, modelID, limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(items), Limit: limit, Offset: offset})
+}
+
+// get возвращает задачу оценки с прогрессом и метриками.
+// GET /api/v1/admin/evaluations/{id}
+func (h *EvaluationHandler) get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid evaluation id")
+		return
+	}
+	user, _ := auth.UserFromContext(r.Context())
+	job, err := h.svc.Get(r.Context(), user, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// compare сравнивает кандидата с базовой моделью по их оценкам на общем наборе.
+// GET /api/v1/admin/models/compare?baseline=<id>&candidate=<id>&dataset_id=<id>
+func (h *EvaluationHandler) compare(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	baselineID, err := uuid.Parse(q.Get("baseline"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "baseline must be a model id")
+		return
+	}
+	candidateID, err := uuid.Parse(q.Get("candidate"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "candidate must be a model id")
+		return
+	}
+	var datasetID *uuid.UUID
+	if v := queryString(r, "dataset_id"); v != nil {
+		id, err := uuid.Parse(*v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid dataset_id")
+			return
+		}
+		datasetID = &id
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	report, err := h.svc.Compare(r.Context(), user, baselineID, candidateID, datasetID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/fraud"
+)
+
+// FraudHandler обслуживает административные запросы антифрода
+type FraudHandler struct {
+	svc *fraud.Service
+}
+
+// NewFraudHandler создаёт обработчик антифрод-запросов
+func NewFraudHandler(svc *fraud.Service) *FraudHandler {
+	return &FraudHandler{svc: svc}
+}
+
+// Register регистрирует маршруты антифрода (только для администраторов)
+func (h *FraudHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/admin/analyses/high-risk", h.listHighRisk, domain.RoleAdmin)
+	rt.Handle("POST /api/v1/admin/analyses/{id}/fraud-check", h.evaluate, domain.RoleAdmin)
+}
+
+// listHighRisk возвращает анализы с высокой антифрод-оценкой.
+// GET /api/v1/admin/analyses/high-risk?min_score=0.7&limit=50&offset=0
+func (h *FraudHandler) listHighRisk(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	filter := domain.HighRiskAnalysisFilter{Limit: limit, Offset: offset}
+
+	if v := r.URL.Query().Get("min_score"); v != "" {
+		score, err := strconv.ParseFloat(v, 64)
+		if err != nil || score < 0 || score > 1 {
+			writeError(w, http.StatusBadRequest, "min_score must be a number between 0 and 1")
+			return
+		}
+		filter.MinScore = score
+	}
+
+	analyses, err := h.svc.ListHighRisk(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(analyses), Limit: limit, Offset: offset,
+		Labels: analysisLabels(r, analyses)})
+}
+
+// evaluate повторно выполняет антифрод-проверку анализа.
+// POST /api/v1/admin/analyses/{id}/fraud-check
+func (h *FraudHandler) evaluate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid analysis id")
+		return
+	}
+
+	fa, err := h.svc.Evaluate(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, fa)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/labor"
+)
+
+// LaborHandler обслуживает нормы трудоёмкости
+type LaborHandler struct {
+	svc *labor.Service
+}
+
+// NewLaborHandler создаёт обработчик норм трудоёмкости
+func NewLaborHandler(svc *labor.Service) *LaborHandler {
+	return &LaborHandler{svc: svc}
+}
+
+// Register регистрирует маршруты норм (владельцы и администраторы)
+func (h *LaborHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/admin/labor-times", h.list, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("PUT /api/v1/admin/labor-times", h.put, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("DELETE /api/v1/admin/labor-times/{id}", h.delete, domain.RoleOwner, domain.RoleAdmin)
+}
+
+// list возвращает все нормы трудоёмкости.
+// GET /api/v1/admin/labor-times
+func (h *LaborHandler) list(w http.ResponseWriter, r *http.Request) {
+	items, err := h.svc.List(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(items))
+}
+
+// put создаёт или заменяет норму для пары (part_id, damage_class).
+// Новые нормы применяются к анализам, завершённым или проверенным после изменения.
+// PUT /api/v1/admin/labor-times
+func (h *LaborHandler) put(w http.ResponseWriter, r *http.Request) {
+	var req domain.LaborTimeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	lt, err := h.svc.Put(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, lt)
+}
+
+// delete удаляет норму.
+// DELETE /api/v1/admin/labor-times/{id}
+func (h *LaborHandler) delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid labor time id")
+		return
+	}
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
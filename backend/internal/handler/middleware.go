@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/i18n"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// UserLoader загружает пользователя по идентификатору из токена
+type UserLoader interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+}
+
+// Authenticate проверяет Bearer-токен и кладёт пользователя в контекст запроса
+func Authenticate(users UserLoader, secret []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				writeError(w, http.StatusUnauthorized, "missing bearer token")
+				return
+			}
+
+			userID, err := auth.ParseToken(token, secret, time.Now())
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			user, err := users.GetByID(r.Context(), userID)
+			if errors.Is(err, repository.ErrNotFound) || (err == nil && !user.IsActive) {
+				writeError(w, http.StatusUnauthorized, "user not found or inactive")
+				return
+			}
+			if err != nil {
+				writeServiceError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// RequireRole пропускает только пользователей с одной из перечисленных ролей
+func RequireRole(roles ...domain.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := auth.UserFromContext(r.Context())
+			if !ok {
+				writeError(w, http.StatusUnauthorized, "unauthenticated")
+				return
+			}
+			for _, role := range roles {
+				if user.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			writeError(w, http.StatusForbidden, "forbidden")
+		})
+	}
+}
+
+// Localize выбирает язык ответа: язык из настроек пользователя, иначе по заголовку
+// Accept-Language, иначе язык по умолчанию. Выбранный язык возвращается в Content-Language
+func Localize(bundle *i18n.Bundle) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var lang i18n.Lang
+			if user, ok := auth.UserFromContext(r.Context()); ok && user.Language != nil && bundle.Supports(i18n.Lang(*user.Language)) {
+				lang = i18n.Lang(*user.Language)
+			} else if matched, ok := bundle.Match(r.Header.Get("Accept-Language")); ok {
+				lang = matched
+			}
+
+			l := bundle.Localizer(lang)
+			w.Header().Set("Content-Language", string(l.Lang()))
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r.WithContext(i18n.WithLocalizer(r.Context(), l)))
+		})
+	}
+}
+
+// Logging логирует метод, путь, статус и длительность запроса
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/registry"
+)
+
+// ModelHandler обслуживает реестр моделей
+type ModelHandler struct {
+	svc *registry.Service
+}
+
+// NewModelHandler создаёт обработчик реестра моделей
+func NewModelHandler(svc *registry.Service) *ModelHandler {
+	return &ModelHandler{svc: svc}
+}
+
+// Register регистрирует маршруты реестра (владельцы и администраторы)
+func (h *ModelHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/admin/models", h.list, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("POST /api/v1/admin/models", h.create, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/admin/models/{id}/lineage", h.lineage, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/admin/models/promotions", h.promotions, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("POST /api/v1/admin/models/{id}/promote", h.promote, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("POST /api/v1/admin/models/rollback", h.rollback, domain.RoleOwner, domain.RoleAdmin)
+}
+
+// list возвращает модели.
+// GET /api/v1/admin/models?status=ready&limit=50&offset=0
+func (h *ModelHandler) list(w http.ResponseWriter, r *http.Request) {
+	var status *domain.ModelStatus
+	if v := queryString(r, "status"); v != nil {
+		s := domain.ModelStatus(*v)
+		status = &s
+	}
+	limit, offset := pagination(r)
+	items, err := h.svc.List(r.Context(), status, limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(items), Limit: limit, Offset: offset})
+}
+
+// create регистрирует обученную модель.
+// POST /api/v1/admin/models
+func (h *ModelHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req domain.ModelCreateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	m, err := h.svc.Create(r.Context(), user, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, m)
+}
+
+// lineage возвращает предков и потомков модели.
+// GET /api/v1/admin/models/{id}/lineage
+func (h *ModelHandler) lineage(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid model id")
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	lineage, err := h.svc.Lineage(r.Context(), user, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, lineage)
+}
+
+// promotions возвращает историю переключений активной модели.
+// GET /api/v1/admin/models/promotions
+func (h *ModelHandler) promotions(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	items, err := h.svc.Promotions(r.Context(), limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(items), Limit: limit, Offset: offset})
+}
+
+// promote делает модель активной.
+// POST /api/v1/admin/models/{id}/promote
+func (h *ModelHandler) promote(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid model id")
+		return
+	}
+	var req domain.ModelPromoteRequest
+	if r.ContentLength != 0 && !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	p, err := h.svc.Promote(r.Context(), user, id, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// rollback возвращает предыдущую активную модель.
+// POST /api/v1/admin/models/rollback
+func (h *ModelHandler) rollback(w http.ResponseWriter, r *http.Request) {
+	var req domain.ModelPromoteRequest
+	if r.ContentLength != 0 && !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	p, err := h.svc.Rollback(r.Context(), user, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/retention"
+)
+
+// OrganizationHandler обслуживает организации и их политику хранения данных
+type OrganizationHandler struct {
+	svc *retention.Service
+}
+
+// NewOrganizationHandler создаёт обработчик организаций
+func NewOrganizationHandler(svc *retention.Service) *OrganizationHandler {
+	return &OrganizationHandler{svc: svc}
+}
+
+// Register регистрирует маршруты организаций (только администраторы)
+func (h *OrganizationHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/admin/organizations", h.list, domain.RoleAdmin)
+	rt.Handle("POST /api/v1/admin/organizations", h.create, domain.RoleAdmin)
+	rt.Handle("PUT /api/v1/admin/organizations/{id}", h.update, domain.RoleAdmin)
+	rt.Handle("PUT /api/v1/admin/users/{id}/organization", h.setUserOrganization, domain.RoleAdmin)
+}
+
+// list возвращает все организации.
+// GET /api/v1/admin/organizations
+func (h *OrganizationHandler) list(w http.ResponseWriter, r *http.Request) {
+	items, err := h.svc.ListOrganizations(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(items))
+}
+
+// create создаёт организацию.
+// POST /api/v1/admin/organizations
+func (h *OrganizationHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req domain.OrganizationRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	org, err := h.svc.CreateOrganization(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, org)
+}
+
+// update меняет имя и сроки хранения организации.
+// PUT /api/v1/admin/organizations/{id}
+func (h *OrganizationHandler) update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid organization id")
+		return
+	}
+	var req domain.OrganizationRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	org, err := h.svc.UpdateOrganization(r.Context(), id, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, org)
+}
+
+// setUserOrganization переводит пользователя в организацию.
+// PUT /api/v1/admin/users/{id}/organization
+func (h *OrganizationHandler) setUserOrganization(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	var req domain.UserOrganizationRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	user, err := h.svc.SetUserOrganization(r.Context(), id, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, user.ToUserResponse())
+}
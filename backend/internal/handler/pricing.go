@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/pricing"
+)
+
+// PricingHandler обслуживает прайс-листы, курсы валют и расчёт стоимости ремонта
+type PricingHandler struct {
+	svc *pricing.Service
+}
+
+// NewPricingHandler создаёт обработчик цен
+func NewPricingHandler(svc *pricing.Service) *PricingHandler {
+	return &PricingHandler{svc: svc}
+}
+
+// Register регистрирует маршруты цен. Прайс-листы и курсы ведут владельцы и администраторы
+func (h *PricingHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/analyses/{id}/quote", h.quote)
+
+	rt.Handle("GET /api/v1/admin/price-catalogs", h.listCatalogs, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("POST /api/v1/admin/price-catalogs", h.createCatalog, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/admin/price-catalogs/{id}", h.getCatalog, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/admin/exchange-rates", h.listRates, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("PUT /api/v1/admin/exchange-rates", h.putRate, domain.RoleOwner, domain.RoleAdmin)
+}
+
+// quote оценивает стоимость ремонта по анализу в запрошенной валюте.
+// region - прайс-лист другого региона (по умолчанию - регион анализа).
+// GET /api/v1/analyses/{id}/quote?currency=EUR&region=DE
+func (h *PricingHandler) quote(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid analysis id")
+		return
+	}
+	currency := domain.Currency(strings.ToUpper(r.URL.Query().Get("currency")))
+	if currency == "" {
+		currency = h.svc.ReportCurrency()
+	}
+	region := queryString(r, "region")
+	if region != nil {
+		*region = strings.ToUpper(*region)
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	q, err := h.svc.Quote(r.Context(), user, id, currency, region)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, q)
+}
+
+// listCatalogs возвращает прайс-листы без цен деталей.
+// GET /api/v1/admin/price-catalogs?region=RU
+func (h *PricingHandler) listCatalogs(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	region := queryString(r, "region")
+	if region != nil {
+		*region = strings.ToUpper(*region)
+	}
+	items, err := h.svc.ListCatalogs(r.Context(), region, limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(items), Limit: limit, Offset: offset})
+}
+
+// createCatalog создаёт прайс-лист региона.
+// POST /api/v1/admin/price-catalogs
+func (h *PricingHandler) createCatalog(w http.ResponseWriter, r *http.Request) {
+	var req domain.PriceCatalogRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	pc, err := h.svc.CreateCatalog(r.Context(), user, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, pc)
+}
+
+// getCatalog возвращает прайс-лист с ценами деталей.
+// GET /api/v1/admin/price-catalogs/{id}
+func (h *PricingHandler) getCatalog(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid price catalog id")
+		return
+	}
+	pc, err := h.svc.GetCatalog(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pc)
+}
+
+// listRates возвращает историю курсов; currency - пары с этой валютой.
+// GET /api/v1/admin/exchange-rates?currency=EUR
+func (h *PricingHandler) listRates(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	var currency *domain.Currency
+	if v := r.URL.Query().Get("currency"); v != "" {
+		c := domain.Currency(strings.ToUpper(v))
+		currency = &c
+	}
+	items, err := h.svc.ListRates(r.Context(), currency, limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(items), Limit: limit, Offset: offset})
+}
+
+// putRate устанавливает курс пары на дату.
+// PUT /api/v1/admin/exchange-rates
+func (h *PricingHandler) putRate(w http.ResponseWriter, r *http.Request) {
+	var req domain.ExchangeRateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	er, err := h.svc.PutRate(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, er)
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/i18n"
+	"github.com/DedovInside/AutoInspect/backend/internal/report"
+)
+
+// ReportHandler обслуживает операционные отчёты
+type ReportHandler struct {
+	svc *report.Service
+}
+
+// NewReportHandler создаёт обработчик отчётов
+func NewReportHandler(svc *report.Service) *ReportHandler {
+	return &ReportHandler{svc: svc}
+}
+
+// Register регистрирует маршруты отчётов (владельцы и администраторы)
+func (h *ReportHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/admin/reports/sla", h.sla, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/admin/analytics/defects", h.defectStats, domain.RoleOwner, domain.RoleAdmin)
+}
+
+// sla возвращает перцентили ожидания и обработки по версиям моделей и дням.
+// По умолчанию - последние 30 дней.
+// GET /api/v1/admin/reports/sla?from=2026-01-01&to=2026-02-01&model_version=v1.2.0
+func (h *ReportHandler) sla(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	now := time.Now().UTC()
+	f := domain.SLAReportFilter{From: now.AddDate(0, 0, -30), To: now}
+
+	var ok bool
+	if f.From, ok = queryTime(w, r, "from", f.From); !ok {
+		return
+	}
+	if f.To, ok = queryTime(w, r, "to", f.To); !ok {
+		return
+	}
+	if v := q.Get("model_version"); v != "" {
+		f.ModelVersion = &v
+	}
+
+	rep, err := h.svc.SLA(r.Context(), f)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rep)
+}
+
+// defectStats возвращает агрегаты по дефектам в разрезе group_by (part, defect_type, vehicle, week).
+// По умолчанию - последние 12 недель. С format=csv ответ отдаётся в CSV.
+// Средняя стоимость - в currency (по умолчанию валюта отчётов), по курсу на конец периода.
+// GET /api/v1/admin/analytics/defects?group_by=part&from=2026-01-01&car_make=toyota&currency=EUR&format=csv
+func (h *ReportHandler) defectStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	now := time.Now().UTC()
+	f := domain.DefectStatsFilter{
+		Group:    domain.DefectStatsGroup(q.Get("group_by")),
+		From:     now.AddDate(0, 0, -12*7),
+		To:       now,
+		CarMake:  queryString(r, "car_make"),
+		CarModel: queryString(r, "car_model"),
+		Limit:    100,
+		Currency: domain.Currency(strings.ToUpper(q.Get("currency"))),
+	}
+	if f.Group == "" {
+		f.Group = domain.DefectStatsByPart
+	}
+
+	var ok bool
+	if f.From, ok = queryTime(w, r, "from", f.From); !ok {
+		return
+	}
+	if f.To, ok = queryTime(w, r, "to", f.To); !ok {
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		f.Limit = n
+	}
+
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+
+	stats, err := h.svc.DefectStats(r.Context(), f)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	l := localizer(r)
+	for i := range stats.Rows {
+		row := &stats.Rows[i]
+		switch {
+		case row.PartName != nil:
+			row.Label = l.Part(*row.PartName)
+		case row.DefectType != nil:
+			row.Label = l.DefectType(*row.DefectType)
+		}
+	}
+	if format == "csv" {
+		writeCSV(w, "defects_by_"+string(stats.Group)+".csv", defectStatsCSV(l, stats))
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// defectStatsCSV формирует таблицу: колонки разреза, затем показатели.
+// Заголовки и названия деталей и типов дефектов - на языке запроса, коды остаются отдельной колонкой
+func defectStatsCSV(l *i18n.Localizer, stats *domain.DefectStats) [][]string {
+	var columns []string
+	switch stats.Group {
+	case domain.DefectStatsByPart:
+		columns = []string{"part_id", "part"}
+	case domain.DefectStatsByDefectType:
+		columns = []string{"defect_type_code", "defect_type"}
+	case domain.DefectStatsByVehicle:
+		columns = []string{"car_make", "car_model"}
+	case domain.DefectStatsByWeek:
+		columns = []string{"week"}
+	}
+	columns = append(columns, "analyses", "defects", "major_defects", "major_share",
+		"defects_per_analysis", "avg_estimated_cost", "currency")
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = l.ReportColumn(c)
+	}
+
+	records := [][]string{header}
+	for _, row := range stats.Rows {
+		var rec []string
+		switch stats.Group {
+		case domain.DefectStatsByPart:
+			rec = []string{csvString(row.PartID), row.Label}
+		case domain.DefectStatsByDefectType:
+			rec = []string{csvString((*string)(row.DefectType)), row.Label}
+		case domain.DefectStatsByVehicle:
+			rec = []string{csvString(row.CarMake), csvString(row.CarModel)}
+		case domain.DefectStatsByWeek:
+			rec = []string{row.Week.Format(time.DateOnly)}
+		}
+		rec = append(rec,
+			strconv.Itoa(row.Analyses),
+			strconv.Itoa(row.Defects),
+			strconv.Itoa(row.MajorDefects),
+			strconv.FormatFloat(row.MajorShare, 'f', 4, 64),
+			csvFloat(row.DefectsPerAnalysis, 2),
+			csvMoney(row.AvgEstimatedCost),
+			string(stats.Currency),
+		)
+		records = append(records, rec)
+	}
+	return records
+}
+
+func csvString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func csvMoney(m *domain.Money) string {
+	if m == nil {
+		return ""
+	}
+	return m.Amount.String()
+}
+
+func csvFloat(f *float64, prec int) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', prec, 64)
+}
+
+// queryTime читает момент времени из query-параметра: дату (2006-01-02, UTC) или RFC 3339.
+// При ошибке пишет ответ 400 и возвращает false
+func queryTime(w http.ResponseWriter, r *http.Request, name string, fallback time.Time) (time.Time, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return fallback, true
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, name+" must be a date (YYYY-MM-DD) or RFC 3339 time")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// queryTimeRange читает необязательные границы периода from и to (nil - без границы)
+func queryTimeRange(w http.ResponseWriter, r *http.Request) (from, to *time.Time, ok bool) {
+	optional := func(name string) (*time.Time, bool) {
+		if r.URL.Query().Get(name) == "" {
+			return nil, true
+		}
+		t, ok := queryTime(w, r, name, time.Time{})
+		return &t, ok
+	}
+	if from, ok = optional("from"); !ok {
+		return nil, nil, false
+	}
+	if to, ok = optional("to"); !ok {
+		return nil, nil, false
+	}
+	return from, to, true
+}
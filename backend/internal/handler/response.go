@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/i18n"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// errorResponse - формат ошибки, возвращаемой API
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// listResponse - формат ответа для постраничных списков.
+// Labels - переводы кодов, встречающихся в элементах (для списков анализов)
+type listResponse struct {
+	Items  any          `json:"items"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+	Labels *i18n.Labels `json:"labels,omitempty"`
+}
+
+// analysisResponse - анализ вместе с переводами кодов дефектов, деталей и ошибок
+type analysisResponse struct {
+	*domain.Analysis
+	Labels *i18n.Labels `json:"labels"`
+}
+
+// localizer возвращает переводчик запроса (язык выбирается middleware Localize)
+func localizer(r *http.Request) *i18n.Localizer {
+	return i18n.FromContext(r.Context())
+}
+
+// localizeAnalysis добавляет к анализу переводы на язык запроса
+func localizeAnalysis(r *http.Request, a *domain.Analysis) analysisResponse {
+	l := localizer(r)
+	labels := l.NewLabels()
+	l.AddAnalysis(labels, a)
+	return analysisResponse{Analysis: a, Labels: labels}
+}
+
+// analysisLabels собирает общий словарь переводов для списка анализов
+func analysisLabels(r *http.Request, analyses []domain.Analysis) *i18n.Labels {
+	l := localizer(r)
+	labels := l.NewLabels()
+	for i := range analyses {
+		l.AddAnalysis(labels, &analyses[i])
+	}
+	return labels
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
+
+// writeCSV отдаёт таблицу как CSV-файл с именем filename
+func writeCSV(w http.ResponseWriter, filename string, records [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	if err := csv.NewWriter(w).WriteAll(records); err != nil {
+		log.Printf("write csv response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+// writeServiceError преобразует ошибку сервиса в HTTP-ответ
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	case errors.Is(err, domain.ErrInvalidInput):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, domain.ErrForbidden):
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	case errors.Is(err, domain.ErrConflict):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	log.Printf("internal error: %v", err)
+	writeError(w, http.StatusInternalServerError, "internal server error")
+}
+
+// decodeJSON читает тело запроса в v, отклоняя неизвестные поля
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// pathUUID читает UUID из параметра маршрута
+func pathUUID(r *http.Request, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue(name))
+	return id, err == nil
+}
+
+// pagination читает limit и offset из query-параметров
+func pagination(r *http.Request) (limit, offset int) {
+	limit, offset = 50, 0
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	return limit, offset
+}
+
+// nonNil заменяет nil-срез пустым, чтобы в JSON был [] вместо null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// queryString возвращает непустой query-параметр или nil
+func queryString(r *http.Request, name string) *string {
+	if v := r.URL.Query().Get(name); v != "" {
+		return &v
+	}
+	return nil
+}
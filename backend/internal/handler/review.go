@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/review"
+)
+
+// ReviewHandler обслуживает ручную проверку результатов анализов
+type ReviewHandler struct {
+	svc *review.Service
+}
+
+// NewReviewHandler создаёт обработчик проверок
+func NewReviewHandler(svc *review.Service) *ReviewHandler {
+	return &ReviewHandler{svc: svc}
+}
+
+// Register регистрирует маршруты проверки (владельцы и администраторы)
+func (h *ReviewHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/reviews/queue", h.queue, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/analyses/{id}/reviews", h.history, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("POST /api/v1/analyses/{id}/reviews", h.submit, domain.RoleOwner, domain.RoleAdmin)
+}
+
+// queue возвращает анализы, ожидающие проверки.
+// GET /api/v1/reviews/queue?limit=50&offset=0
+func (h *ReviewHandler) queue(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	items, err := h.svc.Queue(r.Context(), limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(items), Limit: limit, Offset: offset,
+		Labels: analysisLabels(r, items)})
+}
+
+// history возвращает версии результата, полученные при проверках.
+// GET /api/v1/analyses/{id}/reviews
+func (h *ReviewHandler) history(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid analysis id")
+		return
+	}
+	reviews, err := h.svc.History(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(reviews))
+}
+
+// submit применяет действия проверяющего и создаёт новую версию результата.
+// POST /api/v1/analyses/{id}/reviews
+func (h *ReviewHandler) submit(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid analysis id")
+		return
+	}
+	var req domain.ReviewSubmitRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	rv, err := h.svc.Submit(r.Context(), user, id, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, rv)
+}
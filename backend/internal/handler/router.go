@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/i18n"
+)
+
+// Router собирает маршруты API. Все маршруты, кроме публичных, требуют аутентификации
+type Router struct {
+	mux          *http.ServeMux
+	authenticate func(http.Handler) http.Handler
+	localize     func(http.Handler) http.Handler
+}
+
+// NewRouter создаёт роутер с проверкой Bearer-токенов и выбором языка ответа
+func NewRouter(users UserLoader, jwtSecret []byte, bundle *i18n.Bundle) *Router {
+	rt := &Router{
+		mux:          http.NewServeMux(),
+		authenticate: Authenticate(users, jwtSecret),
+		localize:     Localize(bundle),
+	}
+	rt.mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return rt
+}
+
+// Handle регистрирует маршрут, доступный аутентифицированным пользователям.
+// Если переданы роли, доступ ограничивается ими
+func (rt *Router) Handle(pattern string, h http.HandlerFunc, roles ...domain.Role) {
+	var next http.Handler = h
+	if len(roles) > 0 {
+		next = RequireRole(roles...)(next)
+	}
+	// Язык выбирается после аутентификации, чтобы учесть настройки пользователя
+	rt.mux.Handle(pattern, rt.authenticate(rt.localize(next)))
+}
+
+// HandlePublic регистрирует маршрут без аутентификации
+func (rt *Router) HandlePublic(pattern string, h http.HandlerFunc) {
+	rt.mux.Handle(pattern, rt.localize(h))
+}
+
+// ServeHTTP реализует http.Handler
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	Logging(rt.mux).ServeHTTP(w, r)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DedovInside/AutoInspect/backend/api/schemas"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// SchemaHandler публикует JSON Schema результатов анализа для валидации на стороне клиентов
+type SchemaHandler struct{}
+
+// NewSchemaHandler создаёт обработчик схем
+func NewSchemaHandler() *SchemaHandler {
+	return &SchemaHandler{}
+}
+
+// Register регистрирует публичные маршруты схем
+func (h *SchemaHandler) Register(rt *Router) {
+	rt.HandlePublic("GET /api/v1/schemas/analysis-result", h.listVersions)
+	rt.HandlePublic("GET /api/v1/schemas/analysis-result/{version}", h.get)
+}
+
+type schemaVersionsResponse struct {
+	Current  int      `json:"current"`
+	Versions []string `json:"versions"`
+}
+
+// listVersions возвращает список опубликованных версий схемы.
+// GET /api/v1/schemas/analysis-result
+func (h *SchemaHandler) listVersions(w http.ResponseWriter, _ *http.Request) {
+	resp := schemaVersionsResponse{Current: domain.CurrentResultSchemaVersion}
+	for v := 1; v <= domain.CurrentResultSchemaVersion; v++ {
+		resp.Versions = append(resp.Versions, fmt.Sprintf("/api/v1/schemas/analysis-result/v%d.json", v))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// get возвращает JSON Schema версии.
+// GET /api/v1/schemas/analysis-result/v2.json
+func (h *SchemaHandler) get(w http.ResponseWriter, r *http.Request) {
+	raw := strings.TrimSuffix(strings.TrimPrefix(r.PathValue("version"), "v"), ".json")
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 1 || version > domain.CurrentResultSchemaVersion {
+		writeError(w, http.StatusNotFound, "unknown schema version")
+		return
+	}
+
+	schema, err := schemas.AnalysisResult(version)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(schema)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/shadow"
+)
+
+// ShadowHandler обслуживает теневой режим моделей-кандидатов
+type ShadowHandler struct {
+	svc *shadow.Service
+}
+
+// NewShadowHandler создаёт обработчик теневого режима
+func NewShadowHandler(svc *shadow.Service) *ShadowHandler {
+	return &ShadowHandler{svc: svc}
+}
+
+// Register регистрирует маршруты теневого режима (владельцы и администраторы)
+func (h *ShadowHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/admin/shadow-deployments", h.list, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("POST /api/v1/admin/shadow-deployments", h.create, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("PUT /api/v1/admin/shadow-deployments/{id}", h.update, domain.RoleOwner, domain.RoleAdmin)
+	rt.Handle("GET /api/v1/admin/shadow-deployments/{id}/report", h.report, domain.RoleOwner, domain.RoleAdmin)
+}
+
+// list возвращает теневые развёртывания.
+// GET /api/v1/admin/shadow-deployments?limit=50&offset=0
+func (h *ShadowHandler) list(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	user, _ := auth.UserFromContext(r.Context())
+	items, err := h.svc.List(r.Context(), user, limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: nonNil(items), Limit: limit, Offset: offset})
+}
+
+// create включает теневой режим для модели.
+// POST /api/v1/admin/shadow-deployments
+func (h *ShadowHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req domain.ShadowDeploymentRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	d, err := h.svc.Create(r.Context(), user, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, d)
+}
+
+// update меняет долю анализов или включает и отключает теневое развёртывание.
+// PUT /api/v1/admin/shadow-deployments/{id}
+func (h *ShadowHandler) update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid shadow deployment id")
+		return
+	}
+	var req domain.ShadowDeploymentUpdateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	d, err := h.svc.Update(r.Context(), user, id, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// report возвращает согласованность кандидата с активной моделью.
+// GET /api/v1/admin/shadow-deployments/{id}/report?from=2024-01-01&to=2024-02-01
+func (h *ShadowHandler) report(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid shadow deployment id")
+		return
+	}
+	from, to, ok := queryTimeRange(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	report, err := h.svc.Report(r.Context(), user, id, from, to)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
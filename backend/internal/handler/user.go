@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/i18n"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+)
+
+// UserHandler обслуживает профиль и настройки текущего пользователя
+type UserHandler struct {
+	users  *repository.UserRepository
+	bundle *i18n.Bundle
+}
+
+// NewUserHandler создаёт обработчик профиля
+func NewUserHandler(users *repository.UserRepository, bundle *i18n.Bundle) *UserHandler {
+	return &UserHandler{users: users, bundle: bundle}
+}
+
+// Register регистрирует маршруты профиля
+func (h *UserHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/me", h.me)
+	rt.Handle("PUT /api/v1/me/preferences", h.putPreferences)
+}
+
+// me возвращает текущего пользователя.
+// GET /api/v1/me
+func (h *UserHandler) me(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFromContext(r.Context())
+	writeJSON(w, http.StatusOK, user.ToUserResponse())
+}
+
+// putPreferences сохраняет настройки пользователя: язык ответов API и отчётов.
+// PUT /api/v1/me/preferences
+func (h *UserHandler) putPreferences(w http.ResponseWriter, r *http.Request) {
+	var req domain.UserPreferencesRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Language != nil {
+		lang := strings.ToLower(*req.Language)
+		if !h.bundle.Supports(i18n.Lang(lang)) {
+			writeError(w, http.StatusBadRequest, "unsupported language "+*req.Language)
+			return
+		}
+		req.Language = &lang
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	if err := h.users.SetLanguage(r.Context(), user.ID, req.Language); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	user.Language = req.Language
+	writeJSON(w, http.StatusOK, user.ToUserResponse())
+}
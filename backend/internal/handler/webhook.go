@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/auth"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/webhook"
+)
+
+// WebhookHandler обслуживает настройку webhook и историю доставок
+type WebhookHandler struct {
+	svc *webhook.Service
+}
+
+// NewWebhookHandler создаёт обработчик webhook
+func NewWebhookHandler(svc *webhook.Service) *WebhookHandler {
+	return &WebhookHandler{svc: svc}
+}
+
+// Register регистрирует маршруты webhook
+func (h *WebhookHandler) Register(rt *Router) {
+	rt.Handle("GET /api/v1/webhooks/endpoint", h.getEndpoint)
+	rt.Handle("PUT /api/v1/webhooks/endpoint", h.putEndpoint)
+	rt.Handle("POST /api/v1/webhooks/endpoint/rotate-secret", h.rotateSecret)
+	rt.Handle("GET /api/v1/analyses/{id}/webhook-deliveries", h.listDeliveries)
+	rt.Handle("GET /api/v1/batches/{id}/webhook-deliveries", h.listBatchDeliveries)
+	rt.Handle("GET /api/v1/webhooks/deliveries/{id}", h.getDelivery)
+	rt.Handle("POST /api/v1/webhooks/deliveries/{id}/redeliver", h.redeliver)
+}
+
+// getEndpoint возвращает webhook аккаунта и секрет подписи.
+// GET /api/v1/webhooks/endpoint
+func (h *WebhookHandler) getEndpoint(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFromContext(r.Context())
+	e, err := h.svc.Endpoint(r.Context(), user)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+// putEndpoint задаёт адрес webhook аккаунта.
+// PUT /api/v1/webhooks/endpoint
+func (h *WebhookHandler) putEndpoint(w http.ResponseWriter, r *http.Request) {
+	var req domain.WebhookEndpointRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	e, err := h.svc.PutEndpoint(r.Context(), user, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+// rotateSecret заменяет секрет подписи.
+// POST /api/v1/webhooks/endpoint/rotate-secret
+func (h *WebhookHandler) rotateSecret(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFromContext(r.Context())
+	e, err := h.svc.RotateSecret(r.Context(), user)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+// listDeliveries возвращает доставки событий анализа.
+// GET /api/v1/analyses/{id}/webhook-deliveries
+func (h *WebhookHandler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid analysis id")
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	deliveries, err := h.svc.Deliveries(r.Context(), user, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(deliveries))
+}
+
+// listBatchDeliveries возвращает доставки событий пакета.
+// GET /api/v1/batches/{id}/webhook-deliveries
+func (h *WebhookHandler) listBatchDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid batch id")
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	deliveries, err := h.svc.BatchDeliveries(r.Context(), user, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(deliveries))
+}
+
+// getDelivery возвращает доставку со всеми попытками.
+// GET /api/v1/webhooks/deliveries/{id}
+func (h *WebhookHandler) getDelivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid delivery id")
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	d, err := h.svc.Delivery(r.Context(), user, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// redeliver повторно отправляет событие новой доставкой.
+// POST /api/v1/webhooks/deliveries/{id}/redeliver
+func (h *WebhookHandler) redeliver(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid delivery id")
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	d, err := h.svc.Redeliver(r.Context(), user, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, d)
+}
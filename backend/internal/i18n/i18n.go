@@ -0,0 +1,135 @@
+// Package i18n содержит каталоги сообщений (ru, en) для названий дефектов, деталей,
+// действий и кодов ошибок, которые в API и данных хранятся английскими кодами
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang представляет код языка (ISO 639-1)
+type Lang string
+
+const (
+	LangRU Lang = "ru"
+	LangEN Lang = "en"
+)
+
+//go:embed catalogs/*.json
+var files embed.FS
+
+// Catalog - сообщения одного языка, сгруппированные по виду кода
+type Catalog struct {
+	DefectTypes     map[string]string `json:"defect_types"`
+	Severities      map[string]string `json:"severities"`
+	Actions         map[string]string `json:"actions"`
+	DamageClasses   map[string]string `json:"damage_classes"`
+	LaborOperations map[string]string `json:"labor_operations"`
+	Parts           map[string]string `json:"parts"` // по part_name (front_bumper, hood, ...)
+	ErrorCodes      map[string]string `json:"error_codes"`
+	Report          map[string]string `json:"report"` // заголовки колонок отчётов
+}
+
+// Bundle - набор каталогов всех поддерживаемых языков
+type Bundle struct {
+	catalogs map[Lang]*Catalog
+	fallback Lang
+}
+
+// Load читает встроенные каталоги. fallback - язык по умолчанию, из него же
+// берутся сообщения, отсутствующие в каталоге запрошенного языка
+func Load(fallback Lang) (*Bundle, error) {
+	b := &Bundle{catalogs: make(map[Lang]*Catalog), fallback: fallback}
+	for _, lang := range []Lang{LangRU, LangEN} {
+		raw, err := files.ReadFile("catalogs/" + string(lang) + ".json")
+		if err != nil {
+			return nil, err
+		}
+		var c Catalog
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, fmt.Errorf("decode %s catalog: %w", lang, err)
+		}
+		b.catalogs[lang] = &c
+	}
+	if !b.Supports(fallback) {
+		return nil, fmt.Errorf("unsupported default language %q", fallback)
+	}
+	return b, nil
+}
+
+// Supports проверяет, есть ли каталог языка
+func (b *Bundle) Supports(lang Lang) bool {
+	_, ok := b.catalogs[lang]
+	return ok
+}
+
+// Languages возвращает поддерживаемые языки
+func (b *Bundle) Languages() []Lang {
+	langs := make([]Lang, 0, len(b.catalogs))
+	for l := range b.catalogs {
+		langs = append(langs, l)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Match выбирает язык по заголовку Accept-Language с учётом весов q.
+// Региональные варианты сводятся к основному языку (ru-RU -> ru)
+func (b *Bundle) Match(acceptLanguage string) (Lang, bool) {
+	type candidate struct {
+		lang Lang
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if q > 0 && b.Supports(Lang(base)) {
+			candidates = append(candidates, candidate{Lang(base), q})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	// Стабильная сортировка: при равных весах важен порядок в заголовке
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang, true
+}
+
+// Localizer возвращает переводчик на язык lang (язык по умолчанию, если lang не поддерживается)
+func (b *Bundle) Localizer(lang Lang) *Localizer {
+	if !b.Supports(lang) {
+		lang = b.fallback
+	}
+	return &Localizer{lang: lang, catalog: b.catalogs[lang], fallback: b.catalogs[b.fallback]}
+}
+
+type localizerKey struct{}
+
+// WithLocalizer кладёт переводчик в контекст запроса
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, localizerKey{}, l)
+}
+
+// FromContext возвращает переводчик из контекста или nil
+func FromContext(ctx context.Context) *Localizer {
+	l, _ := ctx.Value(localizerKey{}).(*Localizer)
+	return l
+}
@@ -0,0 +1,123 @@
+package i18n
+
+import (
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// Localizer переводит коды на один язык. Если перевода нет, используется язык
+// по умолчанию, а затем сам код
+type Localizer struct {
+	lang     Lang
+	catalog  *Catalog
+	fallback *Catalog
+}
+
+// Lang возвращает язык переводчика
+func (l *Localizer) Lang() Lang {
+	return l.lang
+}
+
+func (l *Localizer) lookup(section func(*Catalog) map[string]string, code string) string {
+	if msg, ok := section(l.catalog)[code]; ok {
+		return msg
+	}
+	if msg, ok := section(l.fallback)[code]; ok {
+		return msg
+	}
+	return code
+}
+
+// DefectType возвращает название типа дефекта
+func (l *Localizer) DefectType(t domain.DefectType) string {
+	return l.lookup(func(c *Catalog) map[string]string { return c.DefectTypes }, string(t))
+}
+
+// Severity возвращает название серьёзности
+func (l *Localizer) Severity(s domain.DefectSeverity) string {
+	return l.lookup(func(c *Catalog) map[string]string { return c.Severities }, string(s))
+}
+
+// Action возвращает название рекомендуемого действия
+func (l *Localizer) Action(action string) string {
+	return l.lookup(func(c *Catalog) map[string]string { return c.Actions }, action)
+}
+
+// DamageClass возвращает название класса повреждения
+func (l *Localizer) DamageClass(dc domain.DamageClass) string {
+	return l.lookup(func(c *Catalog) map[string]string { return c.DamageClasses }, string(dc))
+}
+
+// LaborOperation возвращает название вида работ
+func (l *Localizer) LaborOperation(op domain.LaborOperation) string {
+	return l.lookup(func(c *Catalog) map[string]string { return c.LaborOperations }, string(op))
+}
+
+// Part возвращает название детали по коду part_name
+func (l *Localizer) Part(partName string) string {
+	return l.lookup(func(c *Catalog) map[string]string { return c.Parts }, partName)
+}
+
+// ErrorCode возвращает описание кода ошибки анализа
+func (l *Localizer) ErrorCode(code domain.ErrorCode) string {
+	return l.lookup(func(c *Catalog) map[string]string { return c.ErrorCodes }, string(code))
+}
+
+// ReportColumn возвращает заголовок колонки отчёта
+func (l *Localizer) ReportColumn(name string) string {
+	return l.lookup(func(c *Catalog) map[string]string { return c.Report }, name)
+}
+
+// Labels - переводы кодов, встречающихся в ответе. Коды в самих данных не меняются,
+// клиент подставляет названия из словарей
+type Labels struct {
+	Language        Lang              `json:"language"`
+	DefectTypes     map[string]string `json:"defect_types,omitempty"`
+	Severities      map[string]string `json:"severities,omitempty"`
+	Parts           map[string]string `json:"parts,omitempty"` // по part_name
+	Actions         map[string]string `json:"actions,omitempty"`
+	DamageClasses   map[string]string `json:"damage_classes,omitempty"`
+	LaborOperations map[string]string `json:"labor_operations,omitempty"`
+	ErrorCodes      map[string]string `json:"error_codes,omitempty"`
+}
+
+// NewLabels создаёт пустой набор переводов
+func (l *Localizer) NewLabels() *Labels {
+	return &Labels{Language: l.lang}
+}
+
+// AddAnalysis добавляет переводы кодов анализа: код ошибки и всё из текущего результата
+func (l *Localizer) AddAnalysis(labels *Labels, a *domain.Analysis) {
+	if a.ErrorCode != nil {
+		put(&labels.ErrorCodes, string(*a.ErrorCode), l.ErrorCode(*a.ErrorCode))
+	}
+	if a.Result != nil {
+		l.AddResult(labels, a.Result)
+	}
+}
+
+// AddResult добавляет переводы кодов результата анализа
+func (l *Localizer) AddResult(labels *Labels, r *domain.AnalysisResult) {
+	for _, d := range r.Defects {
+		put(&labels.DefectTypes, string(d.DefectType), l.DefectType(d.DefectType))
+		put(&labels.Severities, string(d.Severity), l.Severity(d.Severity))
+		if d.PartName != "" {
+			put(&labels.Parts, d.PartName, l.Part(d.PartName))
+		}
+		if d.RecommendedAction != nil {
+			put(&labels.Actions, *d.RecommendedAction, l.Action(*d.RecommendedAction))
+		}
+		if d.Labor != nil {
+			put(&labels.DamageClasses, string(d.Labor.DamageClass), l.DamageClass(d.Labor.DamageClass))
+			for op := range d.Labor.Hours {
+				put(&labels.LaborOperations, string(op), l.LaborOperation(op))
+			}
+		}
+	}
+}
+
+func put(m *map[string]string, code, msg string) {
+	if *m == nil {
+		*m = make(map[string]string)
+	}
+	(*m)[code] = msg
+}
@@ -0,0 +1,52 @@
+package inference
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ErrUnavailable возвращается, если сервис инференса недоступен (временная ошибка)
+var ErrUnavailable = errors.New("inference backend unavailable")
+
+// ErrIntegrity возвращается, если файлы модели в хранилище не совпадают с записанными дайджестами.
+// Такая модель не используется, пока файлы не восстановлены
+var ErrIntegrity = errors.New("model artifacts failed integrity check")
+
+// ArtifactVerifier сверяет прочитанный файл модели с дайджестом, записанным при регистрации.
+// Проверяются именно те байты, из которых бэкенд создаст модель: файл в хранилище
+// мог смениться между проверкой и чтением. Несовпадение возвращается как ErrIntegrity
+type ArtifactVerifier interface {
+	VerifyBytes(ctx context.Context, model *domain.MLModel, kind domain.ArtifactKind, path string, data []byte) error
+}
+
+// Detection представляет одну сырую детекцию модели
+type Detection struct {
+	Class      string             `json:"class"` // scratch, dent, crack, broken_glass и др.
+	Confidence float64            `json:"confidence"`
+	BBox       domain.BoundingBox `json:"bbox"`
+	Mask       *string            `json:"mask,omitempty"`
+	// Дополнительные выходы модели: part_name, part_id, severity
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Prediction представляет выход модели для одного изображения
+type Prediction struct {
+	ViewAngle  string      `json:"view_angle,omitempty"` // front, rear, side_left, side_right
+	Detections []Detection `json:"detections"`
+}
+
+// Backend выполняет модели на изображениях. Реализации: Fake (тесты и локальная разработка),
+// HTTP (сервер с протоколом KServe v2, например Triton) и ONNX (локальный onnxruntime)
+type Backend interface {
+	// Load загружает модель заранее. Infer загружает незагруженную модель сам
+	Load(ctx context.Context, model *domain.MLModel) error
+	// Infer выполняет модель на байтах изображения (JPEG, PNG) и возвращает сырые детекции
+	Infer(ctx context.Context, model *domain.MLModel, image []byte) (*Prediction, error)
+	// Health возвращает ошибку, если бэкенд не готов обслуживать запросы
+	Health(ctx context.Context) error
+	// Unload выгружает модель. Выгрузка незагруженной модели не считается ошибкой
+	Unload(ctx context.Context, modelID uuid.UUID) error
+}
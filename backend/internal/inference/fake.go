@@ -0,0 +1,129 @@
+package inference
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"image"
+	_ "image/jpeg" // декодер JPEG
+	_ "image/png"  // декодер PNG
+	"math/rand/v2"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// fakeParts - детали кузова, которые может "обнаружить" фейковый бэкенд
+var fakeParts = []struct{ name, id string }{
+	{"front_bumper", "bumper_01"},
+	{"rear_bumper", "bumper_02"},
+	{"hood", "hood_01"},
+	{"front_left_door", "door_01"},
+	{"front_right_door", "door_02"},
+	{"windshield", "glass_01"},
+}
+
+var fakeClasses = []domain.DefectType{
+	domain.DefectTypeScratch, domain.DefectTypeDent, domain.DefectTypeCrack, domain.DefectTypeBrokenGlass,
+}
+
+var fakeViewAngles = []string{"front", "rear", "side_left", "side_right"}
+
+// Fake - детерминированный бэкенд для тестов и локальной разработки.
+// Результат зависит только от версии модели и байтов изображения
+type Fake struct{}
+
+// NewFake создаёт фейковый бэкенд
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Load реализует Backend. Фейковому бэкенду нечего загружать
+func (f *Fake) Load(ctx context.Context, _ *domain.MLModel) error {
+	return ctx.Err()
+}
+
+// Health реализует Backend. Фейковый бэкенд всегда готов
+func (f *Fake) Health(context.Context) error {
+	return nil
+}
+
+// Unload реализует Backend
+func (f *Fake) Unload(context.Context, uuid.UUID) error {
+	return nil
+}
+
+// Infer реализует Backend
+func (f *Fake) Infer(ctx context.Context, model *domain.MLModel, img []byte) (*Prediction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	width, height := 1024, 768
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(img)); err == nil {
+		width, height = cfg.Width, cfg.Height
+	}
+
+	h := sha256.New()
+	h.Write([]byte(model.Version))
+	h.Write(img)
+	sum := h.Sum(nil)
+	rng := rand.New(rand.NewPCG(binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16])))
+
+	pred := &Prediction{
+		ViewAngle:  fakeViewAngles[rng.IntN(len(fakeViewAngles))],
+		Detections: make([]Detection, 0, 4),
+	}
+
+	n := rng.IntN(5) // от 0 до 4 дефектов
+	for range n {
+		part := fakeParts[rng.IntN(len(fakeParts))]
+		w := max(width/10+rng.IntN(max(width/4, 1)), 1)
+		hgt := max(height/10+rng.IntN(max(height/4, 1)), 1)
+
+		pred.Detections = append(pred.Detections, Detection{
+			Class:      string(fakeClasses[rng.IntN(len(fakeClasses))]),
+			Confidence: 0.5 + 0.49*rng.Float64(),
+			BBox: domain.BoundingBox{
+				X:      rng.IntN(max(width-w, 1)),
+				Y:      rng.IntN(max(height-hgt, 1)),
+				Width:  w,
+				Height: hgt,
+			},
+			Attributes: map[string]string{"part_name": part.name, "part_id": part.id},
+		})
+	}
+
+	// Номера и лица - из отдельного генератора, чтобы не менять детекции дефектов.
+	// Модель дефектов их отбрасывает как неизвестные классы, этап скрытия - использует
+	privacy := rand.New(rand.NewPCG(binary.LittleEndian.Uint64(sum[16:24]), binary.LittleEndian.Uint64(sum[24:32])))
+	if privacy.Float64() < 0.7 {
+		w := max(width/8, 1)
+		hgt := max(w/4, 1)
+		pred.Detections = append(pred.Detections, Detection{
+			Class:      string(domain.RedactionClassLicensePlate),
+			Confidence: 0.6 + 0.39*privacy.Float64(),
+			BBox: domain.BoundingBox{
+				X:      privacy.IntN(max(width-w, 1)),
+				Y:      height/2 + privacy.IntN(max(height/2-hgt, 1)),
+				Width:  w,
+				Height: hgt,
+			},
+		})
+	}
+	for range privacy.IntN(3) {
+		side := max(min(width, height)/12, 1)
+		pred.Detections = append(pred.Detections, Detection{
+			Class:      string(domain.RedactionClassFace),
+			Confidence: 0.5 + 0.49*privacy.Float64(),
+			BBox: domain.BoundingBox{
+				X:      privacy.IntN(max(width-side, 1)),
+				Y:      privacy.IntN(max(height/2, 1)),
+				Width:  side,
+				Height: side,
+			},
+		})
+	}
+	return pred, nil
+}
@@ -0,0 +1,98 @@
+package inference
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"reflect"
+	"testing"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+func solidPNG(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFakeIsDeterministic(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	img := solidPNG(t, 800, 600, color.RGBA{R: 200, G: 30, B: 30, A: 255})
+	model := &domain.MLModel{ID: uuid.New(), Version: "1.0.0"}
+
+	first, err := fake.Infer(ctx, model, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Идентификатор модели не влияет на результат, только версия
+	second, err := NewFake().Infer(ctx, &domain.MLModel{ID: uuid.New(), Version: "1.0.0"}, bytes.Clone(img))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("predictions differ for the same model and image:\n%+v\n%+v", first, second)
+	}
+
+	other := solidPNG(t, 800, 600, color.RGBA{R: 30, G: 200, B: 30, A: 255})
+	differs := false
+	for _, tc := range []struct {
+		version string
+		img     []byte
+	}{{"1.0.1", img}, {"1.0.0", other}, {"2.0.0", img}} {
+		p, err := fake.Infer(ctx, &domain.MLModel{Version: tc.version}, tc.img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		differs = differs || !reflect.DeepEqual(first, p)
+	}
+	if !differs {
+		t.Error("predictions do not depend on the model version or image")
+	}
+}
+
+func TestFakeBoxesInsideImage(t *testing.T) {
+	ctx := context.Background()
+	for i, size := range [][2]int{{1024, 768}, {320, 240}, {64, 48}, {1, 1}} {
+		img := solidPNG(t, size[0], size[1], color.Gray{Y: uint8(i * 50)})
+		for _, version := range []string{"a", "b", "c", "d", "e"} {
+			p, err := NewFake().Infer(ctx, &domain.MLModel{Version: version}, img)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range p.Detections {
+				b := d.BBox
+				if b.X < 0 || b.Y < 0 || b.Width < 1 || b.Height < 1 || b.X+b.Width > size[0] || b.Y+b.Height > size[1] {
+					t.Errorf("%dx%d: box %+v outside the image", size[0], size[1], b)
+				}
+				if d.Confidence < 0.5 || d.Confidence > 0.99 {
+					t.Errorf("confidence %v out of range", d.Confidence)
+				}
+			}
+		}
+	}
+}
+
+func TestFakeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewFake().Infer(ctx, &domain.MLModel{Version: "1"}, nil); err == nil {
+		t.Error("Infer with cancelled context succeeded")
+	}
+	if err := NewFake().Load(ctx, &domain.MLModel{Version: "1"}); err == nil {
+		t.Error("Load with cancelled context succeeded")
+	}
+}
@@ -0,0 +1,267 @@
+package inference
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// errModelNotLoaded возвращается, если сервер не знает модель или она не загружена
+var errModelNotLoaded = errors.New("model is not loaded on inference server")
+
+// HTTP вызывает сервер инференса по протоколу KServe v2 (Triton, KServe, Seldon MLServer).
+// Модель на сервере называется версией модели из реестра.
+//
+// Вход модели "image" - одна строка BYTES с изображением в base64, декодирование
+// и предобработка выполняются на сервере. Выходы:
+//   - boxes  FP32 [N, 4] - x, y, ширина и высота рамки в пикселях исходного изображения
+//   - scores FP32 [N]    - уверенность
+//   - labels BYTES [N]   - класс (scratch, dent, license_plate и др.)
+//   - view_angle BYTES [1] - ракурс, необязательный
+type HTTP struct {
+	baseURL string
+	client  *http.Client
+	loaded  *pool[httpModel]
+	loadMu  sync.Mutex
+}
+
+// httpModel - модель, загруженная на сервере. Owned - модель загрузил этот бэкенд,
+// и он же выгружает её при вытеснении. Модели, загруженные сервером самостоятельно, не выгружаются
+type httpModel struct {
+	name  string
+	owned bool
+}
+
+// NewHTTP создаёт бэкенд для сервера инференса по адресу cfg.URL
+func NewHTTP(cfg config.InferenceConfig) *HTTP {
+	return &HTTP{
+		baseURL: cfg.URL,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		loaded:  newPool[httpModel](cfg.MaxLoadedModels),
+	}
+}
+
+// kserveTensor - тензор запроса и ответа протокола KServe v2 в JSON-представлении
+type kserveTensor struct {
+	Name     string          `json:"name"`
+	Shape    []int           `json:"shape"`
+	Datatype string          `json:"datatype"`
+	Data     json.RawMessage `json:"data"`
+}
+
+type kserveInferRequest struct {
+	ID     string         `json:"id,omitempty"`
+	Inputs []kserveTensor `json:"inputs"`
+}
+
+type kserveInferResponse struct {
+	ModelName string         `json:"model_name"`
+	Outputs   []kserveTensor `json:"outputs"`
+}
+
+// Health реализует Backend: сервер должен быть готов принимать запросы
+func (b *HTTP) Health(ctx context.Context) error {
+	err := b.call(ctx, http.MethodGet, "/v2/health/ready", nil, nil)
+	if err != nil && !errors.Is(err, ErrUnavailable) && ctx.Err() == nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
+
+// Load реализует Backend. Если сервер уже обслуживает модель, она только запоминается
+func (b *HTTP) Load(ctx context.Context, model *domain.MLModel) error {
+	b.loadMu.Lock()
+	defer b.loadMu.Unlock()
+	if _, ok := b.loaded.get(model.ID); ok {
+		return nil
+	}
+
+	// Неготовая модель отвечает 4xx, в том числе 400 у Triton
+	m := httpModel{name: model.Version}
+	err := b.call(ctx, http.MethodGet, modelPath(m.name, "ready"), nil, nil)
+	if err != nil && !errors.Is(err, ErrUnavailable) && ctx.Err() == nil {
+		m.owned = true
+		err = b.call(ctx, http.MethodPost, "/v2/repository/models/"+url.PathEscape(m.name)+"/load", struct{}{}, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("load model %s: %w", model.Version, err)
+	}
+	for _, old := range b.loaded.put(model.ID, m) {
+		if err := b.unload(ctx, old); err != nil {
+			log.Printf("inference: %v", err)
+		}
+	}
+	return nil
+}
+
+// Unload реализует Backend
+func (b *HTTP) Unload(ctx context.Context, modelID uuid.UUID) error {
+	m, ok := b.loaded.remove(modelID)
+	if !ok {
+		return nil
+	}
+	return b.unload(ctx, m)
+}
+
+func (b *HTTP) unload(ctx context.Context, m httpModel) error {
+	if !m.owned {
+		return nil
+	}
+	err := b.call(ctx, http.MethodPost, "/v2/repository/models/"+url.PathEscape(m.name)+"/unload", struct{}{}, nil)
+	if err != nil && !errors.Is(err, errModelNotLoaded) {
+		return fmt.Errorf("unload model %s: %w", m.name, err)
+	}
+	return nil
+}
+
+// Infer реализует Backend. Если сервер выгрузил модель, она загружается повторно один раз
+func (b *HTTP) Infer(ctx context.Context, model *domain.MLModel, img []byte) (*Prediction, error) {
+	if err := b.Load(ctx, model); err != nil {
+		return nil, err
+	}
+	pred, err := b.infer(ctx, model, img)
+	if errors.Is(err, errModelNotLoaded) {
+		b.loaded.remove(model.ID)
+		if err := b.Load(ctx, model); err != nil {
+			return nil, err
+		}
+		pred, err = b.infer(ctx, model, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("infer model %s: %w", model.Version, err)
+	}
+	return pred, nil
+}
+
+func (b *HTTP) infer(ctx context.Context, model *domain.MLModel, img []byte) (*Prediction, error) {
+	data, err := json.Marshal([]string{base64.StdEncoding.EncodeToString(img)})
+	if err != nil {
+		return nil, err
+	}
+	req := kserveInferRequest{
+		ID:     uuid.NewString(),
+		Inputs: []kserveTensor{{Name: "image", Shape: []int{1}, Datatype: "BYTES", Data: data}},
+	}
+	var resp kserveInferResponse
+	if err := b.call(ctx, http.MethodPost, modelPath(model.Version, "infer"), req, &resp); err != nil {
+		return nil, err
+	}
+	return decodeKServeOutputs(resp.Outputs)
+}
+
+// decodeKServeOutputs собирает детекции из выходов boxes, scores, labels и view_angle
+func decodeKServeOutputs(outputs []kserveTensor) (*Prediction, error) {
+	var (
+		boxes, scores []float64
+		labels, view  []string
+	)
+	for _, t := range outputs {
+		var err error
+		switch t.Name {
+		case "boxes":
+			err = json.Unmarshal(t.Data, &boxes)
+		case "scores":
+			err = json.Unmarshal(t.Data, &scores)
+		case "labels":
+			err = json.Unmarshal(t.Data, &labels)
+		case "view_angle":
+			err = json.Unmarshal(t.Data, &view)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode output %s: %w", t.Name, err)
+		}
+	}
+	n := len(scores)
+	if len(labels) != n || len(boxes) != 4*n {
+		return nil, fmt.Errorf("inconsistent model outputs: %d boxes, %d scores, %d labels", len(boxes)/4, n, len(labels))
+	}
+
+	pred := &Prediction{Detections: make([]Detection, 0, n)}
+	if len(view) > 0 {
+		pred.ViewAngle = view[0]
+	}
+	for i := range n {
+		box := boxes[4*i : 4*i+4]
+		pred.Detections = append(pred.Detections, Detection{
+			Class:      labels[i],
+			Confidence: scores[i],
+			BBox: domain.BoundingBox{
+				X:      int(box[0]),
+				Y:      int(box[1]),
+				Width:  max(int(box[2]), 1),
+				Height: max(int(box[3]), 1),
+			},
+		})
+	}
+	return pred, nil
+}
+
+func modelPath(name, action string) string {
+	return "/v2/models/" + url.PathEscape(name) + "/" + action
+}
+
+// call выполняет запрос к серверу. Сетевые ошибки, 429 и 5xx считаются временными (ErrUnavailable),
+// 404 означает, что модель не загружена
+func (b *HTTP) call(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(raw, &e) != nil || e.Error == "" {
+			e.Error = string(raw)
+		}
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			return fmt.Errorf("%w: %s", errModelNotLoaded, e.Error)
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			return fmt.Errorf("%w: %s %s: status %d: %s", ErrUnavailable, method, path, resp.StatusCode, e.Error)
+		default:
+			return fmt.Errorf("%s %s: status %d: %s", method, path, resp.StatusCode, e.Error)
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s response: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package inference
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// kserveServer - сервер KServe v2 в памяти. Модели из preloaded загружены сервером
+// самостоятельно, остальные загружаются через репозиторий моделей
+type kserveServer struct {
+	t *testing.T
+
+	mu     sync.Mutex
+	ready  bool
+	loaded map[string]bool
+	calls  []string
+	// infer отвечает на запрос инференса загруженной модели: код и тело ответа
+	infer func(model string, req kserveInferRequest) (int, string)
+}
+
+func newKServeServer(t *testing.T, preloaded ...string) (*kserveServer, *httptest.Server) {
+	s := &kserveServer{t: t, ready: true, loaded: make(map[string]bool)}
+	for _, m := range preloaded {
+		s.loaded[m] = true
+	}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func (s *kserveServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, r.Method+" "+r.URL.Path)
+
+	fail := func(code int, msg string) {
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v2/"), "/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/health/ready":
+		if !s.ready {
+			fail(http.StatusServiceUnavailable, "server is starting")
+		}
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "models" && parts[2] == "ready":
+		if !s.loaded[parts[1]] {
+			fail(http.StatusBadRequest, "model is not ready")
+		}
+	case r.Method == http.MethodPost && len(parts) == 4 && parts[0] == "repository":
+		switch parts[3] {
+		case "load":
+			if parts[2] == "missing" {
+				fail(http.StatusBadRequest, "failed to load 'missing', no version is available")
+				return
+			}
+			s.loaded[parts[2]] = true
+		case "unload":
+			if !s.loaded[parts[2]] {
+				fail(http.StatusNotFound, "model is not loaded")
+				return
+			}
+			delete(s.loaded, parts[2])
+		}
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "infer":
+		if !s.loaded[parts[1]] {
+			fail(http.StatusNotFound, "unknown model: "+parts[1])
+			return
+		}
+		var req kserveInferRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.t.Errorf("decode infer request: %v", err)
+		}
+		code, body := s.infer(parts[1], req)
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(body))
+	default:
+		fail(http.StatusNotFound, "not found")
+	}
+}
+
+// callLog возвращает вызовы сервера и очищает журнал
+func (s *kserveServer) callLog() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := s.calls
+	s.calls = nil
+	return calls
+}
+
+func (s *kserveServer) unloadModel(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.loaded, name)
+}
+
+const okInferResponse = `{"model_name": "m", "outputs": [
+	{"name": "boxes", "shape": [2, 4], "datatype": "FP32", "data": [10.4, 20, 30, 40, 100, 200, 0.3, 50]},
+	{"name": "scores", "shape": [2], "datatype": "FP32", "data": [0.91, 0.42]},
+	{"name": "labels", "shape": [2], "datatype": "BYTES", "data": ["scratch", "license_plate"]},
+	{"name": "view_angle", "shape": [1], "datatype": "BYTES", "data": ["front"]}
+]}`
+
+func newTestHTTP(url string, maxLoaded int) *HTTP {
+	return NewHTTP(config.InferenceConfig{URL: url, Timeout: 5 * time.Second, MaxLoadedModels: maxLoaded})
+}
+
+func TestHTTPInfer(t *testing.T) {
+	s, srv := newKServeServer(t)
+	img := []byte("\xff\xd8 jpeg bytes")
+	s.infer = func(model string, req kserveInferRequest) (int, string) {
+		if model != "1.2.0" {
+			t.Errorf("infer called for model %q", model)
+		}
+		var data []string
+		if len(req.Inputs) != 1 || req.Inputs[0].Name != "image" || req.Inputs[0].Datatype != "BYTES" ||
+			json.Unmarshal(req.Inputs[0].Data, &data) != nil || len(data) != 1 {
+			t.Errorf("unexpected inputs %+v", req.Inputs)
+		} else if raw, _ := base64.StdEncoding.DecodeString(data[0]); string(raw) != string(img) {
+			t.Errorf("image = %q, want %q", raw, img)
+		}
+		return http.StatusOK, okInferResponse
+	}
+
+	b := newTestHTTP(srv.URL, 4)
+	pred, err := b.Infer(context.Background(), &domain.MLModel{ID: uuid.New(), Version: "1.2.0"}, img)
+	if err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+	want := &Prediction{ViewAngle: "front", Detections: []Detection{
+		{Class: "scratch", Confidence: 0.91, BBox: domain.BoundingBox{X: 10, Y: 20, Width: 30, Height: 40}},
+		{Class: "license_plate", Confidence: 0.42, BBox: domain.BoundingBox{X: 100, Y: 200, Width: 1, Height: 50}},
+	}}
+	if !equalPredictions(pred, want) {
+		t.Errorf("prediction = %+v, want %+v", pred, want)
+	}
+	wantCalls := []string{
+		"GET /v2/models/1.2.0/ready",
+		"POST /v2/repository/models/1.2.0/load",
+		"POST /v2/models/1.2.0/infer",
+	}
+	if calls := s.callLog(); !slices.Equal(calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", calls, wantCalls)
+	}
+}
+
+func TestHTTPInferErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        int
+		body        string
+		unavailable bool
+		contains    string
+	}{
+		{"server error", http.StatusInternalServerError, `{"error": "CUDA out of memory"}`, true, "CUDA out of memory"},
+		{"overloaded", http.StatusTooManyRequests, `rate limited`, true, "status 429"},
+		{"bad request", http.StatusBadRequest, `{"error": "unexpected input shape"}`, false, "unexpected input shape"},
+		{"invalid json", http.StatusOK, `{"outputs": [`, false, "decode"},
+		{"fewer labels than scores", http.StatusOK, `{"outputs": [
+			{"name": "boxes", "data": [1, 2, 3, 4, 5, 6, 7, 8]},
+			{"name": "scores", "data": [0.9, 0.8]},
+			{"name": "labels", "data": ["dent"]}]}`, false, "inconsistent model outputs"},
+		{"boxes not multiple of four", http.StatusOK, `{"outputs": [
+			{"name": "boxes", "data": [1, 2, 3]},
+			{"name": "scores", "data": [0.9]},
+			{"name": "labels", "data": ["dent"]}]}`, false, "inconsistent model outputs"},
+		{"missing scores", http.StatusOK, `{"outputs": [
+			{"name": "boxes", "data": [1, 2, 3, 4]},
+			{"name": "labels", "data": ["dent"]}]}`, false, "inconsistent model outputs"},
+		{"wrong datatype", http.StatusOK, `{"outputs": [
+			{"name": "boxes", "data": [1, 2, 3, 4]},
+			{"name": "scores", "data": ["high"]},
+			{"name": "labels", "data": ["dent"]}]}`, false, "decode output scores"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, srv := newKServeServer(t, "1.0.0")
+			s.infer = func(string, kserveInferRequest) (int, string) { return tt.code, tt.body }
+
+			_, err := newTestHTTP(srv.URL, 4).Infer(context.Background(), &domain.MLModel{ID: uuid.New(), Version: "1.0.0"}, []byte("img"))
+			if err == nil {
+				t.Fatal("Infer succeeded")
+			}
+			if errors.Is(err, ErrUnavailable) != tt.unavailable {
+				t.Errorf("err = %v, unavailable = %v, want %v", err, errors.Is(err, ErrUnavailable), tt.unavailable)
+			}
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.contains)
+			}
+		})
+	}
+}
+
+func TestDecodeKServeOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs string
+		want    *Prediction
+	}{
+		{
+			name:    "empty detections",
+			outputs: `[{"name": "boxes", "data": []}, {"name": "scores", "data": []}, {"name": "labels", "data": []}]`,
+			want:    &Prediction{Detections: []Detection{}},
+		},
+		{
+			name: "unknown outputs are ignored",
+			outputs: `[{"name": "boxes", "data": [0, 0, 0, 0]}, {"name": "scores", "data": [0.5]},
+				{"name": "labels", "data": ["crack"]}, {"name": "masks", "data": [[1, 2]]}]`,
+			want: &Prediction{Detections: []Detection{
+				{Class: "crack", Confidence: 0.5, BBox: domain.BoundingBox{Width: 1, Height: 1}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outputs []kserveTensor
+			if err := json.Unmarshal([]byte(tt.outputs), &outputs); err != nil {
+				t.Fatal(err)
+			}
+			got, err := decodeKServeOutputs(outputs)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !equalPredictions(got, tt.want) {
+				t.Errorf("prediction = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPReloadsModelUnloadedByServer(t *testing.T) {
+	s, srv := newKServeServer(t)
+	s.infer = func(string, kserveInferRequest) (int, string) { return http.StatusOK, okInferResponse }
+	b := newTestHTTP(srv.URL, 4)
+	model := &domain.MLModel{ID: uuid.New(), Version: "1.0.0"}
+	ctx := context.Background()
+
+	if err := b.Load(ctx, model); err != nil {
+		t.Fatal(err)
+	}
+	s.unloadModel("1.0.0")
+	s.callLog()
+
+	if _, err := b.Infer(ctx, model, []byte("img")); err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+	wantCalls := []string{
+		"POST /v2/models/1.0.0/infer",
+		"GET /v2/models/1.0.0/ready",
+		"POST /v2/repository/models/1.0.0/load",
+		"POST /v2/models/1.0.0/infer",
+	}
+	if calls := s.callLog(); !slices.Equal(calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", calls, wantCalls)
+	}
+}
+
+func TestHTTPLoadFailure(t *testing.T) {
+	_, srv := newKServeServer(t)
+	err := newTestHTTP(srv.URL, 4).Load(context.Background(), &domain.MLModel{ID: uuid.New(), Version: "missing"})
+	if err == nil || errors.Is(err, ErrUnavailable) || !strings.Contains(err.Error(), "no version is available") {
+		t.Errorf("err = %v, want permanent load error", err)
+	}
+}
+
+func TestHTTPEvictsLeastRecentlyUsedModel(t *testing.T) {
+	s, srv := newKServeServer(t, "shared")
+	b := newTestHTTP(srv.URL, 2)
+	ctx := context.Background()
+	m1 := &domain.MLModel{ID: uuid.New(), Version: "v1"}
+	m2 := &domain.MLModel{ID: uuid.New(), Version: "v2"}
+	m3 := &domain.MLModel{ID: uuid.New(), Version: "v3"}
+	shared := &domain.MLModel{ID: uuid.New(), Version: "shared"}
+
+	for _, m := range []*domain.MLModel{m1, m2} {
+		if err := b.Load(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Повторная загрузка только отмечает использование v1, вытеснена будет v2
+	if err := b.Load(ctx, m1); err != nil {
+		t.Fatal(err)
+	}
+	s.callLog()
+	if err := b.Load(ctx, m3); err != nil {
+		t.Fatal(err)
+	}
+	wantCalls := []string{
+		"GET /v2/models/v3/ready",
+		"POST /v2/repository/models/v3/load",
+		"POST /v2/repository/models/v2/unload",
+	}
+	if calls := s.callLog(); !slices.Equal(calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", calls, wantCalls)
+	}
+
+	// Модель, загруженную сервером самостоятельно, бэкенд при вытеснении не выгружает
+	for _, m := range []*domain.MLModel{shared, m2, m1} {
+		if err := b.Load(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantCalls = []string{
+		"GET /v2/models/shared/ready", // вытесняет v1
+		"POST /v2/repository/models/v1/unload",
+		"GET /v2/models/v2/ready", // вытесняет v3
+		"POST /v2/repository/models/v2/load",
+		"POST /v2/repository/models/v3/unload",
+		"GET /v2/models/v1/ready", // вытесняет shared без обращения к серверу
+		"POST /v2/repository/models/v1/load",
+	}
+	if calls := s.callLog(); !slices.Equal(calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", calls, wantCalls)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded["shared"] || s.loaded["v3"] {
+		t.Errorf("server models = %v, want shared kept and v3 unloaded", s.loaded)
+	}
+}
+
+func TestHTTPUnload(t *testing.T) {
+	s, srv := newKServeServer(t, "shared")
+	b := newTestHTTP(srv.URL, 4)
+	ctx := context.Background()
+	owned := &domain.MLModel{ID: uuid.New(), Version: "own"}
+	shared := &domain.MLModel{ID: uuid.New(), Version: "shared"}
+	for _, m := range []*domain.MLModel{owned, shared} {
+		if err := b.Load(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.callLog()
+
+	for _, id := range []uuid.UUID{owned.ID, shared.ID, uuid.New(), owned.ID} {
+		if err := b.Unload(ctx, id); err != nil {
+			t.Errorf("Unload(%s): %v", id, err)
+		}
+	}
+	if calls := s.callLog(); !slices.Equal(calls, []string{"POST /v2/repository/models/own/unload"}) {
+		t.Errorf("calls = %v, want only unload of the owned model", calls)
+	}
+
+	// Модель, которую сервер уже выгрузил сам, выгружается без ошибки
+	if err := b.Load(ctx, owned); err != nil {
+		t.Fatal(err)
+	}
+	s.unloadModel("own")
+	if err := b.Unload(ctx, owned.ID); err != nil {
+		t.Errorf("Unload of a model gone from the server: %v", err)
+	}
+}
+
+func TestHTTPHealth(t *testing.T) {
+	s, srv := newKServeServer(t)
+	b := newTestHTTP(srv.URL, 4)
+	ctx := context.Background()
+	if err := b.Health(ctx); err != nil {
+		t.Errorf("Health of a ready server: %v", err)
+	}
+
+	s.mu.Lock()
+	s.ready = false
+	s.mu.Unlock()
+	if err := b.Health(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Health of a starting server = %v, want ErrUnavailable", err)
+	}
+
+	srv.Close()
+	if err := b.Health(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Health of a stopped server = %v, want ErrUnavailable", err)
+	}
+	if _, err := b.Infer(ctx, &domain.MLModel{ID: uuid.New(), Version: "1"}, nil); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Infer on a stopped server = %v, want ErrUnavailable", err)
+	}
+}
+
+func equalPredictions(a, b *Prediction) bool {
+	if a.ViewAngle != b.ViewAngle || len(a.Detections) != len(b.Detections) {
+		return false
+	}
+	for i := range a.Detections {
+		x, y := a.Detections[i], b.Detections[i]
+		if x.Class != y.Class || x.Confidence != y.Confidence || x.BBox != y.BBox {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,359 @@
+package inference
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"slices"
+	"sync"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// errSessionClosed возвращается, если сессию выгрузили во время инференса
+var errSessionClosed = errors.New("onnx session closed")
+
+// onnxSession - сессия onnxruntime с одним входом и одним выходом типа float32.
+// Реализация на cgo собирается с тегом onnx (onnx_runtime.go)
+type onnxSession interface {
+	Run(input []float32, shape []int64) (output []float32, dims []int64, err error)
+	Close()
+}
+
+// ONNXModelConfig - файл конфигурации модели (config_path) для бэкенда onnx.
+// Без файла модель считается детектором YOLOv8 640x640 с классами дефектов в порядке ниже
+type ONNXModelConfig struct {
+	InputSize      int      `json:"input_size"`      // сторона квадратного входа
+	Classes        []string `json:"classes"`         // классы в порядке выходов модели
+	ScoreThreshold float64  `json:"score_threshold"` // детекции ниже порога отбрасываются до NMS
+	NMSIoU         float64  `json:"nms_iou"`         // IoU подавления пересекающихся рамок одного класса
+	MaxDetections  int      `json:"max_detections"`
+}
+
+func defaultONNXModelConfig() ONNXModelConfig {
+	return ONNXModelConfig{
+		InputSize:      640,
+		Classes:        []string{"scratch", "dent", "crack", "broken_glass"},
+		ScoreThreshold: 0.05,
+		NMSIoU:         0.45,
+		MaxDetections:  300,
+	}
+}
+
+// ONNX выполняет модели локально через onnxruntime. Веса (weights_path) - ONNX-граф детектора
+// с выходом YOLOv8: [1, 4+классы, N] или [1, N, 4+классы], рамки в формате cx, cy, w, h
+// в координатах входа. Изображение приводится к входу с сохранением пропорций (letterbox).
+// Веса и конфигурация сверяются с записанными дайджестами в том виде, в каком прочитаны для сессии
+type ONNX struct {
+	store      storage.ObjectStorage
+	verifier   ArtifactVerifier
+	loaded     *pool[*onnxModel]
+	loadMu     sync.Mutex
+	newSession func(weights []byte) (onnxSession, error)
+}
+
+// onnxModel - загруженная модель. Mu не даёт закрыть сессию во время инференса
+type onnxModel struct {
+	mu      sync.RWMutex
+	session onnxSession
+	cfg     ONNXModelConfig
+	closed  bool
+}
+
+// NewONNX создаёт локальный бэкенд. verifier проверяет прочитанные файлы моделей перед созданием
+// сессии; nil - без проверки. Без тега сборки onnx возвращает ошибку
+func NewONNX(store storage.ObjectStorage, verifier ArtifactVerifier, cfg config.InferenceConfig) (*ONNX, error) {
+	if err := onnxRuntimeInit(); err != nil {
+		return nil, err
+	}
+	return &ONNX{
+		store: store, verifier: verifier,
+		loaded: newPool[*onnxModel](cfg.MaxLoadedModels), newSession: newONNXSession,
+	}, nil
+}
+
+// Health реализует Backend. Среда onnxruntime проверена при создании бэкенда
+func (b *ONNX) Health(context.Context) error {
+	return nil
+}
+
+// Load реализует Backend: читает веса и конфигурацию модели из хранилища и создаёт сессию
+func (b *ONNX) Load(ctx context.Context, model *domain.MLModel) error {
+	_, err := b.load(ctx, model)
+	return err
+}
+
+func (b *ONNX) load(ctx context.Context, model *domain.MLModel) (*onnxModel, error) {
+	b.loadMu.Lock()
+	defer b.loadMu.Unlock()
+	if m, ok := b.loaded.get(model.ID); ok {
+		return m, nil
+	}
+
+	cfg := defaultONNXModelConfig()
+	if model.ConfigPath != nil {
+		raw, err := b.readArtifact(ctx, model, domain.ArtifactKindConfig, *model.ConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return nil, fmt.Errorf("parse config of model %s: %w", model.Version, err)
+		}
+		if cfg.InputSize <= 0 || len(cfg.Classes) == 0 {
+			return nil, fmt.Errorf("config of model %s: input_size and classes are required", model.Version)
+		}
+	}
+	weights, err := b.readArtifact(ctx, model, domain.ArtifactKindWeights, model.WeightsPath)
+	if err != nil {
+		return nil, err
+	}
+	session, err := b.newSession(weights)
+	if err != nil {
+		return nil, fmt.Errorf("load model %s: %w", model.Version, err)
+	}
+
+	m := &onnxModel{session: session, cfg: cfg}
+	for _, old := range b.loaded.put(model.ID, m) {
+		old.close()
+	}
+	return m, nil
+}
+
+// readArtifact читает файл модели и сверяет с дайджестом именно прочитанные байты
+func (b *ONNX) readArtifact(ctx context.Context, model *domain.MLModel, kind domain.ArtifactKind, path string) ([]byte, error) {
+	data, err := storage.ReadAll(ctx, b.store, path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: read %s of model %s: %v", ErrUnavailable, kind, model.Version, err)
+	}
+	if b.verifier != nil {
+		if err := b.verifier.VerifyBytes(ctx, model, kind, path, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Unload реализует Backend
+func (b *ONNX) Unload(_ context.Context, modelID uuid.UUID) error {
+	if m, ok := b.loaded.remove(modelID); ok {
+		m.close()
+	}
+	return nil
+}
+
+func (m *onnxModel) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.session.Close()
+		m.closed = true
+	}
+}
+
+// Infer реализует Backend. Если модель вытеснили во время инференса, она загружается заново
+func (b *ONNX) Infer(ctx context.Context, model *domain.MLModel, img []byte) (*Prediction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(bytes.NewReader(img))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	for range 2 {
+		m, err := b.load(ctx, model)
+		if err != nil {
+			return nil, err
+		}
+		pred, err := m.infer(src)
+		if errors.Is(err, errSessionClosed) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("infer model %s: %w", model.Version, err)
+		}
+		return pred, nil
+	}
+	return nil, fmt.Errorf("%w: model %s was unloaded during inference", ErrUnavailable, model.Version)
+}
+
+func (m *onnxModel) infer(src image.Image) (*Prediction, error) {
+	input, lb := letterbox(src, m.cfg.InputSize)
+
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return nil, errSessionClosed
+	}
+	size := int64(m.cfg.InputSize)
+	output, dims, err := m.session.Run(input, []int64{1, 3, size, size})
+	m.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	dets, err := decodeYOLO(output, dims, m.cfg, lb)
+	if err != nil {
+		return nil, err
+	}
+	return &Prediction{Detections: dets}, nil
+}
+
+// letterboxInfo описывает перевод координат входа модели в координаты исходного изображения
+type letterboxInfo struct {
+	scale         float64
+	padX, padY    float64
+	width, height int
+}
+
+// letterbox масштабирует изображение в квадрат size x size с сохранением пропорций, дополняя
+// серым (114), и возвращает тензор NCHW RGB со значениями 0..1
+func letterbox(src image.Image, size int) ([]float32, letterboxInfo) {
+	b := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+
+	lb := letterboxInfo{width: b.Dx(), height: b.Dy()}
+	lb.scale = min(float64(size)/float64(lb.width), float64(size)/float64(lb.height))
+	newW := min(max(int(float64(lb.width)*lb.scale+0.5), 1), size)
+	newH := min(max(int(float64(lb.height)*lb.scale+0.5), 1), size)
+	offX, offY := (size-newW)/2, (size-newH)/2
+	lb.padX, lb.padY = float64(offX), float64(offY)
+
+	plane := size * size
+	input := make([]float32, 3*plane)
+	for i := range input {
+		input[i] = 114.0 / 255
+	}
+	// Билинейная интерполяция по центрам пикселей
+	for y := range newH {
+		sy := min(max((float64(y)+0.5)/lb.scale-0.5, 0), float64(lb.height-1))
+		y0 := int(sy)
+		y1 := min(y0+1, lb.height-1)
+		fy := sy - float64(y0)
+		for x := range newW {
+			sx := min(max((float64(x)+0.5)/lb.scale-0.5, 0), float64(lb.width-1))
+			x0 := int(sx)
+			x1 := min(x0+1, lb.width-1)
+			fx := sx - float64(x0)
+			idx := (y+offY)*size + x + offX
+			for c := range 3 {
+				p00 := float64(rgba.Pix[y0*rgba.Stride+x0*4+c])
+				p01 := float64(rgba.Pix[y0*rgba.Stride+x1*4+c])
+				p10 := float64(rgba.Pix[y1*rgba.Stride+x0*4+c])
+				p11 := float64(rgba.Pix[y1*rgba.Stride+x1*4+c])
+				v := (p00*(1-fx)+p01*fx)*(1-fy) + (p10*(1-fx)+p11*fx)*fy
+				input[c*plane+idx] = float32(v / 255)
+			}
+		}
+	}
+	return input, lb
+}
+
+// decodeYOLO переводит выход детектора в детекции: лучший класс каждой рамки, порог
+// уверенности, NMS по классам и перевод рамок в координаты исходного изображения
+func decodeYOLO(output []float32, dims []int64, cfg ONNXModelConfig, lb letterboxInfo) ([]Detection, error) {
+	attrs := 4 + len(cfg.Classes)
+	if len(dims) != 3 || dims[0] != 1 {
+		return nil, fmt.Errorf("unexpected output shape %v", dims)
+	}
+	// at(i, k) - k-й атрибут i-й рамки. Если число рамок совпадает с числом атрибутов,
+	// выбирается раскладка экспорта YOLOv8 по умолчанию [1, 4+классы, N]
+	var n int
+	var at func(i, k int) float32
+	switch {
+	case int(dims[1]) == attrs:
+		n = int(dims[2])
+		at = func(i, k int) float32 { return output[k*n+i] }
+	case int(dims[2]) == attrs:
+		n = int(dims[1])
+		at = func(i, k int) float32 { return output[i*attrs+k] }
+	default:
+		return nil, fmt.Errorf("output shape %v does not match %d classes", dims, len(cfg.Classes))
+	}
+	if len(output) != n*attrs {
+		return nil, fmt.Errorf("output has %d values, shape %v", len(output), dims)
+	}
+
+	type candidate struct {
+		class int
+		score float64
+		box   [4]float64 // x1, y1, x2, y2 в координатах исходного изображения
+	}
+	var cands []candidate
+	for i := range n {
+		best, score := 0, float64(at(i, 4))
+		for c := 1; c < len(cfg.Classes); c++ {
+			if s := float64(at(i, 4+c)); s > score {
+				best, score = c, s
+			}
+		}
+		if score < cfg.ScoreThreshold {
+			continue
+		}
+		cx, cy := float64(at(i, 0)), float64(at(i, 1))
+		w, h := float64(at(i, 2)), float64(at(i, 3))
+		toX := func(v float64) float64 { return min(max((v-lb.padX)/lb.scale, 0), float64(lb.width)) }
+		toY := func(v float64) float64 { return min(max((v-lb.padY)/lb.scale, 0), float64(lb.height)) }
+		cands = append(cands, candidate{
+			class: best,
+			score: score,
+			box:   [4]float64{toX(cx - w/2), toY(cy - h/2), toX(cx + w/2), toY(cy + h/2)},
+		})
+	}
+	slices.SortStableFunc(cands, func(a, b candidate) int { return cmp.Compare(b.score, a.score) })
+
+	var kept []candidate
+	for _, c := range cands {
+		if cfg.MaxDetections > 0 && len(kept) >= cfg.MaxDetections {
+			break
+		}
+		suppressed := false
+		for _, k := range kept {
+			if k.class == c.class && boxIoU(k.box, c.box) > cfg.NMSIoU {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			kept = append(kept, c)
+		}
+	}
+
+	dets := make([]Detection, 0, len(kept))
+	for _, k := range kept {
+		dets = append(dets, Detection{
+			Class:      cfg.Classes[k.class],
+			Confidence: k.score,
+			BBox: domain.BoundingBox{
+				X:      int(k.box[0]),
+				Y:      int(k.box[1]),
+				Width:  max(int(k.box[2]-k.box[0]), 1),
+				Height: max(int(k.box[3]-k.box[1]), 1),
+			},
+		})
+	}
+	return dets, nil
+}
+
+// boxIoU считает IoU рамок в формате x1, y1, x2, y2
+func boxIoU(a, b [4]float64) float64 {
+	w := min(a[2], b[2]) - max(a[0], b[0])
+	h := min(a[3], b[3]) - max(a[1], b[1])
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	inter := w * h
+	union := (a[2]-a[0])*(a[3]-a[1]) + (b[2]-b[0])*(b[3]-b[1]) - inter
+	if union <= 0 {
+		return 0
+	}
+	return inter / union
+}
@@ -0,0 +1,219 @@
+//go:build onnx
+
+package inference
+
+/*
+#cgo LDFLAGS: -lonnxruntime
+#include <stdlib.h>
+#include <string.h>
+#include <onnxruntime_c_api.h>
+
+static const OrtApi* ort_api(void) {
+	return OrtGetApiBase()->GetApi(ORT_API_VERSION);
+}
+
+// ort_error копирует сообщение ошибки и освобождает статус. NULL - успех
+static char* ort_error(OrtStatus* st) {
+	if (st == NULL) {
+		return NULL;
+	}
+	char* msg = strdup(ort_api()->GetErrorMessage(st));
+	ort_api()->ReleaseStatus(st);
+	return msg;
+}
+
+static char* ort_create_env(OrtEnv** env) {
+	if (ort_api() == NULL) {
+		return strdup("onnxruntime library does not support the API version used at build time");
+	}
+	return ort_error(ort_api()->CreateEnv(ORT_LOGGING_LEVEL_WARNING, "autoinspect", env));
+}
+
+static char* ort_create_session(OrtEnv* env, const void* data, size_t len, OrtSession** out) {
+	const OrtApi* api = ort_api();
+	OrtSessionOptions* opts;
+	char* err = ort_error(api->CreateSessionOptions(&opts));
+	if (err != NULL) {
+		return err;
+	}
+	err = ort_error(api->CreateSessionFromArray(env, data, len, opts, out));
+	api->ReleaseSessionOptions(opts);
+	return err;
+}
+
+// ort_io_names возвращает имена первого входа и первого выхода сессии
+static char* ort_io_names(OrtSession* s, char** input, char** output) {
+	const OrtApi* api = ort_api();
+	OrtAllocator* alloc;
+	char* err = ort_error(api->GetAllocatorWithDefaultOptions(&alloc));
+	if (err != NULL) {
+		return err;
+	}
+	char* name;
+	if ((err = ort_error(api->SessionGetInputName(s, 0, alloc, &name))) != NULL) {
+		return err;
+	}
+	*input = strdup(name);
+	api->AllocatorFree(alloc, name);
+	if ((err = ort_error(api->SessionGetOutputName(s, 0, alloc, &name))) != NULL) {
+		free(*input);
+		return err;
+	}
+	*output = strdup(name);
+	api->AllocatorFree(alloc, name);
+	return NULL;
+}
+
+// ort_run выполняет сессию. Входной тензор ссылается на data и освобождается до возврата
+static char* ort_run(OrtSession* s, const char* in_name, const char* out_name,
+                     float* data, size_t n, const int64_t* shape, size_t rank, OrtValue** out) {
+	const OrtApi* api = ort_api();
+	OrtMemoryInfo* mem;
+	char* err = ort_error(api->CreateCpuMemoryInfo(OrtArenaAllocator, OrtMemTypeDefault, &mem));
+	if (err != NULL) {
+		return err;
+	}
+	OrtValue* input = NULL;
+	err = ort_error(api->CreateTensorWithDataAsOrtValue(mem, data, n * sizeof(float), shape, rank,
+	                                                    ONNX_TENSOR_ELEMENT_DATA_TYPE_FLOAT, &input));
+	api->ReleaseMemoryInfo(mem);
+	if (err != NULL) {
+		return err;
+	}
+	*out = NULL;
+	err = ort_error(api->Run(s, NULL, &in_name, (const OrtValue* const*)&input, 1, &out_name, 1, out));
+	api->ReleaseValue(input);
+	return err;
+}
+
+// ort_output возвращает размерности и данные выходного тензора float32
+static char* ort_output(OrtValue* v, int64_t* dims, size_t max_rank, size_t* rank, float** data) {
+	const OrtApi* api = ort_api();
+	OrtTensorTypeAndShapeInfo* info;
+	char* err = ort_error(api->GetTensorTypeAndShape(v, &info));
+	if (err != NULL) {
+		return err;
+	}
+	ONNXTensorElementDataType type;
+	err = ort_error(api->GetTensorElementType(info, &type));
+	if (err == NULL && type != ONNX_TENSOR_ELEMENT_DATA_TYPE_FLOAT) {
+		err = strdup("model output is not a float32 tensor");
+	}
+	if (err == NULL) {
+		err = ort_error(api->GetDimensionsCount(info, rank));
+	}
+	if (err == NULL && *rank > max_rank) {
+		err = strdup("model output has too many dimensions");
+	}
+	if (err == NULL) {
+		err = ort_error(api->GetDimensions(info, dims, *rank));
+	}
+	api->ReleaseTensorTypeAndShapeInfo(info);
+	if (err == NULL) {
+		err = ort_error(api->GetTensorMutableData(v, (void**)data));
+	}
+	return err;
+}
+
+static void ort_release_value(OrtValue* v) {
+	ort_api()->ReleaseValue(v);
+}
+
+static void ort_release_session(OrtSession* s) {
+	ort_api()->ReleaseSession(s);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"sync"
+	"unsafe"
+)
+
+// Среда onnxruntime одна на процесс
+var (
+	ortEnvOnce sync.Once
+	ortEnv     *C.OrtEnv
+	ortEnvErr  error
+)
+
+func onnxRuntimeInit() error {
+	ortEnvOnce.Do(func() {
+		ortEnvErr = cError(C.ort_create_env(&ortEnv))
+	})
+	return ortEnvErr
+}
+
+// cError переводит сообщение из C в ошибку и освобождает его
+func cError(msg *C.char) error {
+	if msg == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(msg))
+	return errors.New("onnxruntime: " + C.GoString(msg))
+}
+
+// ortSession - сессия onnxruntime. Сессия потокобезопасна, Run можно вызывать параллельно
+type ortSession struct {
+	session *C.OrtSession
+	input   *C.char
+	output  *C.char
+}
+
+func newONNXSession(weights []byte) (onnxSession, error) {
+	if err := onnxRuntimeInit(); err != nil {
+		return nil, err
+	}
+	if len(weights) == 0 {
+		return nil, errors.New("empty model weights")
+	}
+	s := &ortSession{}
+	if err := cError(C.ort_create_session(ortEnv, unsafe.Pointer(&weights[0]), C.size_t(len(weights)), &s.session)); err != nil {
+		return nil, err
+	}
+	if err := cError(C.ort_io_names(s.session, &s.input, &s.output)); err != nil {
+		C.ort_release_session(s.session)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run реализует onnxSession
+func (s *ortSession) Run(input []float32, shape []int64) ([]float32, []int64, error) {
+	var out *C.OrtValue
+	err := cError(C.ort_run(s.session, s.input, s.output,
+		(*C.float)(unsafe.Pointer(&input[0])), C.size_t(len(input)),
+		(*C.int64_t)(unsafe.Pointer(&shape[0])), C.size_t(len(shape)), &out))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer C.ort_release_value(out)
+
+	var (
+		dims [8]C.int64_t
+		rank C.size_t
+		data *C.float
+	)
+	if err := cError(C.ort_output(out, &dims[0], C.size_t(len(dims)), &rank, &data)); err != nil {
+		return nil, nil, err
+	}
+	shapeOut := make([]int64, int(rank))
+	n := 1
+	for i := range shapeOut {
+		shapeOut[i] = int64(dims[i])
+		n *= int(dims[i])
+	}
+	output := make([]float32, n)
+	if n > 0 {
+		copy(output, unsafe.Slice((*float32)(unsafe.Pointer(data)), n))
+	}
+	return output, shapeOut, nil
+}
+
+// Close реализует onnxSession
+func (s *ortSession) Close() {
+	C.ort_release_session(s.session)
+	C.free(unsafe.Pointer(s.input))
+	C.free(unsafe.Pointer(s.output))
+}
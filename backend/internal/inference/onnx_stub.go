@@ -0,0 +1,16 @@
+//go:build !onnx
+
+package inference
+
+import "errors"
+
+// errONNXUnsupported возвращается, если воркер собран без onnxruntime
+var errONNXUnsupported = errors.New("onnx inference backend requires building with -tags onnx and onnxruntime installed")
+
+func onnxRuntimeInit() error {
+	return errONNXUnsupported
+}
+
+func newONNXSession([]byte) (onnxSession, error) {
+	return nil, errONNXUnsupported
+}
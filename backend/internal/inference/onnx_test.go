@@ -0,0 +1,472 @@
+package inference
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// yoloRow - рамка выхода YOLO: cx, cy, w, h и оценки классов
+type yoloRow []float32
+
+// yoloOutput собирает выход в раскладке [1, N, 4+классы] или, если transposed, [1, 4+классы, N]
+func yoloOutput(rows []yoloRow, transposed bool) ([]float32, []int64) {
+	n, attrs := len(rows), len(rows[0])
+	out := make([]float32, n*attrs)
+	for i, r := range rows {
+		for k, v := range r {
+			if transposed {
+				out[k*n+i] = v
+			} else {
+				out[i*attrs+k] = v
+			}
+		}
+	}
+	if transposed {
+		return out, []int64{1, int64(attrs), int64(n)}
+	}
+	return out, []int64{1, int64(n), int64(attrs)}
+}
+
+var testONNXConfig = ONNXModelConfig{
+	InputSize:      640,
+	Classes:        []string{"scratch", "dent"},
+	ScoreThreshold: 0.25,
+	NMSIoU:         0.45,
+	MaxDetections:  100,
+}
+
+var identityLetterbox = letterboxInfo{scale: 1, width: 640, height: 640}
+
+func TestDecodeYOLO(t *testing.T) {
+	rows := []yoloRow{
+		{100, 100, 50, 50, 0.9, 0.1},  // scratch
+		{105, 100, 50, 50, 0.8, 0.05}, // scratch, IoU 0.82 с первой - подавляется
+		{105, 100, 50, 50, 0.1, 0.7},  // dent на том же месте - другой класс, остаётся
+		{400, 400, 20, 20, 0.2, 0.1},  // ниже порога
+		{300, 300, 40, 40, 0.3, 0.6},  // dent
+		{160, 100, 50, 50, 0.5, 0.0},  // scratch рядом с первой, IoU 0.11 - остаётся
+		{50, 50, 10, 10, 0.01, 0.02},  // фон
+	}
+	want := []Detection{
+		{Class: "scratch", Confidence: 0.9, BBox: domain.BoundingBox{X: 75, Y: 75, Width: 50, Height: 50}},
+		{Class: "dent", Confidence: 0.7, BBox: domain.BoundingBox{X: 80, Y: 75, Width: 50, Height: 50}},
+		{Class: "dent", Confidence: 0.6, BBox: domain.BoundingBox{X: 280, Y: 280, Width: 40, Height: 40}},
+		{Class: "scratch", Confidence: 0.5, BBox: domain.BoundingBox{X: 135, Y: 75, Width: 50, Height: 50}},
+	}
+
+	for _, transposed := range []bool{false, true} {
+		name := "boxes first"
+		if transposed {
+			name = "attributes first"
+		}
+		t.Run(name, func(t *testing.T) {
+			out, dims := yoloOutput(rows, transposed)
+			got, err := decodeYOLO(out, dims, testONNXConfig, identityLetterbox)
+			if err != nil {
+				t.Fatalf("decodeYOLO: %v", err)
+			}
+			assertDetections(t, got, want)
+		})
+	}
+
+	t.Run("max detections", func(t *testing.T) {
+		cfg := testONNXConfig
+		cfg.MaxDetections = 2
+		out, dims := yoloOutput(rows, true)
+		got, err := decodeYOLO(out, dims, cfg, identityLetterbox)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertDetections(t, got, want[:2])
+	})
+
+	t.Run("nms threshold", func(t *testing.T) {
+		cfg := testONNXConfig
+		cfg.NMSIoU = 0.9 // пересечение 0.82 уже не подавляется
+		out, dims := yoloOutput(rows[:2], false)
+		got, err := decodeYOLO(out, dims, cfg, identityLetterbox)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 {
+			t.Errorf("got %d detections, want 2", len(got))
+		}
+	})
+}
+
+func TestDecodeYOLOShapeErrors(t *testing.T) {
+	out, _ := yoloOutput([]yoloRow{{1, 1, 1, 1, 0.9, 0.1}}, false)
+	tests := []struct {
+		name   string
+		output []float32
+		dims   []int64
+		want   string
+	}{
+		{"rank 2", out, []int64{1, 6}, "unexpected output shape"},
+		{"batch of two", out, []int64{2, 1, 6}, "unexpected output shape"},
+		{"wrong class count", make([]float32, 7), []int64{1, 1, 7}, "does not match 2 classes"},
+		{"data shorter than shape", out, []int64{1, 6, 2}, "output has 6 values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeYOLO(tt.output, tt.dims, testONNXConfig, identityLetterbox)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLetterbox(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	tests := []struct {
+		name       string
+		w, h, size int
+		scale      float64
+		padX, padY float64
+	}{
+		{"wide", 1280, 640, 640, 0.5, 0, 160},
+		{"tall", 640, 1280, 640, 0.5, 160, 0},
+		{"square", 640, 640, 640, 1, 0, 0},
+		{"upscaled", 100, 50, 640, 6.4, 0, 160},
+		{"odd padding", 300, 200, 64, 64.0 / 300, 0, 10},
+		{"single row", 641, 1, 640, 640.0 / 641, 0, 319},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+			for y := range tt.h {
+				for x := range tt.w {
+					img.Set(x, y, red)
+				}
+			}
+			input, lb := letterbox(img, tt.size)
+			if math.Abs(lb.scale-tt.scale) > 1e-9 || lb.padX != tt.padX || lb.padY != tt.padY ||
+				lb.width != tt.w || lb.height != tt.h {
+				t.Fatalf("letterbox = %+v, want scale %v pad %v,%v", lb, tt.scale, tt.padX, tt.padY)
+			}
+			plane := tt.size * tt.size
+			if len(input) != 3*plane {
+				t.Fatalf("input has %d values, want %d", len(input), 3*plane)
+			}
+			// Первая строка изображения посередине - красная, угол с полем - серый
+			center := int(tt.padY)*tt.size + tt.size/2
+			if input[center] != 1 || input[plane+center] != 0 || input[2*plane+center] != 0 {
+				t.Errorf("center pixel = %v, %v, %v, want red", input[center], input[plane+center], input[2*plane+center])
+			}
+			if tt.padX > 0 || tt.padY > 0 {
+				gray := float32(114.0 / 255)
+				if input[0] != gray || input[plane] != gray || input[2*plane] != gray {
+					t.Errorf("padding pixel = %v, %v, %v, want gray", input[0], input[plane], input[2*plane])
+				}
+			}
+		})
+	}
+}
+
+func TestLetterboxInterpolation(t *testing.T) {
+	// Левый столбец чёрный, правый белый: при увеличении вдвое между ними линейный переход
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	img.SetGray(1, 0, color.Gray{Y: 255})
+	img.SetGray(1, 1, color.Gray{Y: 255})
+	input, lb := letterbox(img, 4)
+	if lb.scale != 2 || lb.padX != 0 || lb.padY != 0 {
+		t.Fatalf("letterbox = %+v", lb)
+	}
+	want := []float32{0, 0.25, 0.75, 1}
+	for c := range 3 {
+		for y := range 4 {
+			row := input[c*16+y*4 : c*16+y*4+4]
+			if !slices.EqualFunc(row, want, func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-6 }) {
+				t.Fatalf("channel %d row %d = %v, want %v", c, y, row, want)
+			}
+		}
+	}
+}
+
+func TestDecodeYOLOMapsBackToImage(t *testing.T) {
+	// Изображение 1280x640 вписано в 640x640 с масштабом 0.5 и полем 160 сверху
+	_, lb := letterbox(image.NewRGBA(image.Rect(0, 0, 1280, 640)), 640)
+	rows := []yoloRow{
+		{320, 320, 100, 50, 0.9, 0},  // в центре
+		{20, 170, 60, 40, 0, 0.8},    // выходит за левый край и в верхнее поле
+		{630, 470, 40, 40, 0.7, 0.1}, // выходит за правый край и в нижнее поле
+	}
+	out, dims := yoloOutput(rows, true)
+	got, err := decodeYOLO(out, dims, testONNXConfig, lb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDetections(t, got, []Detection{
+		{Class: "scratch", Confidence: 0.9, BBox: domain.BoundingBox{X: 540, Y: 270, Width: 200, Height: 100}},
+		{Class: "dent", Confidence: 0.8, BBox: domain.BoundingBox{X: 0, Y: 0, Width: 100, Height: 60}},
+		{Class: "scratch", Confidence: 0.7, BBox: domain.BoundingBox{X: 1220, Y: 580, Width: 60, Height: 60}},
+	})
+}
+
+func TestBoxIoU(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b [4]float64
+		want float64
+	}{
+		{"identical", [4]float64{0, 0, 10, 10}, [4]float64{0, 0, 10, 10}, 1},
+		{"half shifted", [4]float64{0, 0, 10, 10}, [4]float64{5, 0, 15, 10}, 1.0 / 3},
+		{"contained", [4]float64{0, 0, 10, 10}, [4]float64{0, 0, 5, 5}, 0.25},
+		{"touching", [4]float64{0, 0, 10, 10}, [4]float64{10, 0, 20, 10}, 0},
+		{"disjoint", [4]float64{0, 0, 10, 10}, [4]float64{20, 20, 30, 30}, 0},
+		{"degenerate", [4]float64{0, 0, 0, 0}, [4]float64{0, 0, 0, 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := boxIoU(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("boxIoU = %v, want %v", got, tt.want)
+			}
+			if got := boxIoU(tt.b, tt.a); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("boxIoU reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeSession отвечает заданным выходом и запоминает форму входа
+type fakeSession struct {
+	output []float32
+	dims   []int64
+
+	mu     sync.Mutex
+	shapes [][]int64
+	closed bool
+}
+
+func (s *fakeSession) Run(input []float32, shape []int64) ([]float32, []int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, nil, errors.New("run on closed session")
+	}
+	if len(input) != int(shape[1]*shape[2]*shape[3]) {
+		return nil, nil, errors.New("input does not match shape")
+	}
+	s.shapes = append(s.shapes, shape)
+	return s.output, s.dims, nil
+}
+
+func (s *fakeSession) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+}
+
+// newTestONNX создаёт бэкенд, сессии которого создаются из весов в хранилище через newSession
+func newTestONNX(t *testing.T, maxLoaded int, newSession func([]byte) (onnxSession, error)) (*ONNX, storage.ObjectStorage) {
+	t.Helper()
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &ONNX{store: store, loaded: newPool[*onnxModel](maxLoaded), newSession: newSession}
+	return b, store
+}
+
+func putTestModel(t *testing.T, store storage.ObjectStorage, version, configJSON string) *domain.MLModel {
+	t.Helper()
+	ctx := context.Background()
+	m := &domain.MLModel{ID: uuid.New(), Version: version, WeightsPath: "models/" + version + "/model.onnx"}
+	if err := store.Put(ctx, m.WeightsPath, strings.NewReader(version), "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+	if configJSON != "" {
+		p := "models/" + version + "/config.json"
+		if err := store.Put(ctx, p, strings.NewReader(configJSON), "application/json"); err != nil {
+			t.Fatal(err)
+		}
+		m.ConfigPath = &p
+	}
+	return m
+}
+
+func TestONNXInfer(t *testing.T) {
+	out, dims := yoloOutput([]yoloRow{{160, 160, 50, 20, 0.1, 0.95}}, true)
+	session := &fakeSession{output: out, dims: dims}
+	b, store := newTestONNX(t, 2, func([]byte) (onnxSession, error) { return session, nil })
+	model := putTestModel(t, store, "1.0.0", `{"input_size": 320, "classes": ["scratch", "dent"], "score_threshold": 0.5, "nms_iou": 0.5}`)
+
+	img := encodeTestPNG(t, image.NewRGBA(image.Rect(0, 0, 640, 320)))
+	pred, err := b.Infer(context.Background(), model, img)
+	if err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+	// Масштаб 0.5, поле 80 сверху: (160, 160) входа - точка (320, 160) изображения
+	assertDetections(t, pred.Detections, []Detection{
+		{Class: "dent", Confidence: float64(float32(0.95)), BBox: domain.BoundingBox{X: 270, Y: 140, Width: 100, Height: 40}},
+	})
+	if len(session.shapes) != 1 || !slices.Equal(session.shapes[0], []int64{1, 3, 320, 320}) {
+		t.Errorf("input shapes = %v, want [1 3 320 320]", session.shapes)
+	}
+
+	if _, err := b.Infer(context.Background(), model, []byte("not an image")); err == nil {
+		t.Error("Infer of a corrupt image succeeded")
+	}
+}
+
+func TestONNXLoadErrors(t *testing.T) {
+	b, store := newTestONNX(t, 2, func([]byte) (onnxSession, error) { return nil, errors.New("invalid graph") })
+	ctx := context.Background()
+
+	missing := &domain.MLModel{ID: uuid.New(), Version: "gone", WeightsPath: "models/gone/model.onnx"}
+	if err := b.Load(ctx, missing); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Load without weights = %v, want ErrUnavailable", err)
+	}
+	if err := b.Load(ctx, putTestModel(t, store, "bad-config", `{"input_size": 0}`)); err == nil ||
+		!strings.Contains(err.Error(), "input_size and classes are required") {
+		t.Errorf("Load with invalid config = %v", err)
+	}
+	if err := b.Load(ctx, putTestModel(t, store, "bad-graph", "")); err == nil || !strings.Contains(err.Error(), "invalid graph") {
+		t.Errorf("Load with invalid weights = %v", err)
+	}
+}
+
+// recordingVerifier запоминает проверенные файлы и отклоняет файлы, содержимое которых в reject
+type recordingVerifier struct {
+	mu       sync.Mutex
+	verified map[domain.ArtifactKind][]byte
+	reject   string
+}
+
+func (v *recordingVerifier) VerifyBytes(_ context.Context, _ *domain.MLModel, kind domain.ArtifactKind, _ string, data []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.verified[kind] = data
+	if string(data) == v.reject {
+		return ErrIntegrity
+	}
+	return nil
+}
+
+func TestONNXVerifiesLoadedBytes(t *testing.T) {
+	var sessionWeights [][]byte
+	b, store := newTestONNX(t, 2, func(weights []byte) (onnxSession, error) {
+		sessionWeights = append(sessionWeights, weights)
+		return &fakeSession{}, nil
+	})
+	verifier := &recordingVerifier{verified: make(map[domain.ArtifactKind][]byte), reject: "tampered"}
+	b.verifier = verifier
+	ctx := context.Background()
+
+	model := putTestModel(t, store, "1.0.0", `{"input_size": 320, "classes": ["scratch"]}`)
+	if err := b.Load(ctx, model); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(sessionWeights) != 1 || &sessionWeights[0][0] != &verifier.verified[domain.ArtifactKindWeights][0] {
+		t.Error("session was not created from the verified weights buffer")
+	}
+	if got := string(verifier.verified[domain.ArtifactKindConfig]); !strings.Contains(got, `"input_size": 320`) {
+		t.Errorf("verified config = %q", got)
+	}
+
+	tampered := putTestModel(t, store, "tampered", "")
+	if err := b.Load(ctx, tampered); !errors.Is(err, ErrIntegrity) {
+		t.Fatalf("Load of tampered weights = %v, want ErrIntegrity", err)
+	}
+	if len(sessionWeights) != 1 {
+		t.Errorf("session created from weights that failed verification")
+	}
+	if _, ok := b.loaded.get(tampered.ID); ok {
+		t.Error("tampered model is loaded")
+	}
+}
+
+func TestONNXEvictsAndUnloads(t *testing.T) {
+	var mu sync.Mutex
+	sessions := make(map[string]*fakeSession)
+	b, store := newTestONNX(t, 2, func(weights []byte) (onnxSession, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		s := &fakeSession{}
+		sessions[string(weights)] = s // веса тестовой модели - её версия
+		return s, nil
+	})
+	ctx := context.Background()
+	m1 := putTestModel(t, store, "v1", "")
+	m2 := putTestModel(t, store, "v2", "")
+	m3 := putTestModel(t, store, "v3", "")
+
+	for _, m := range []*domain.MLModel{m1, m2, m1, m3} {
+		if err := b.Load(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sessions) != 3 {
+		t.Fatalf("created %d sessions, want 3 (v1 loaded once)", len(sessions))
+	}
+	if sessions["v1"].closed || !sessions["v2"].closed || sessions["v3"].closed {
+		t.Errorf("closed: v1 %v, v2 %v, v3 %v, want only v2 closed", sessions["v1"].closed, sessions["v2"].closed, sessions["v3"].closed)
+	}
+
+	if err := b.Unload(ctx, m1.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Unload(ctx, m1.ID); err != nil {
+		t.Errorf("second Unload: %v", err)
+	}
+	if !sessions["v1"].closed {
+		t.Error("v1 session is open after Unload")
+	}
+	// Выгруженная модель загружается заново новой сессией
+	if err := b.Load(ctx, m1); err != nil {
+		t.Fatal(err)
+	}
+	if sessions["v1"].closed {
+		t.Error("v1 was not reloaded")
+	}
+}
+
+func TestONNXClosedModel(t *testing.T) {
+	session := &fakeSession{}
+	m := &onnxModel{session: session, cfg: testONNXConfig}
+	m.close()
+	m.close()
+	if !session.closed {
+		t.Fatal("session is open after close")
+	}
+	// Модель, вытесненную между загрузкой и инференсом, Infer загружает заново
+	if _, err := m.infer(image.NewRGBA(image.Rect(0, 0, 4, 4))); !errors.Is(err, errSessionClosed) {
+		t.Errorf("infer on closed model = %v, want errSessionClosed", err)
+	}
+}
+
+func encodeTestPNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func assertDetections(t *testing.T, got, want []Detection) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d detections %+v, want %d %+v", len(got), got, len(want), want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Class != w.Class || math.Abs(g.Confidence-w.Confidence) > 1e-6 || g.BBox != w.BBox {
+			t.Errorf("detection %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
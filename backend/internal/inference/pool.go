@@ -0,0 +1,77 @@
+package inference
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// pool хранит загруженные модели и при переполнении вытесняет давно не использованную
+type pool[T any] struct {
+	mu    sync.Mutex
+	max   int
+	tick  uint64
+	items map[uuid.UUID]*poolEntry[T]
+}
+
+type poolEntry[T any] struct {
+	value T
+	used  uint64
+}
+
+func newPool[T any](limit int) *pool[T] {
+	return &pool[T]{max: limit, items: make(map[uuid.UUID]*poolEntry[T])}
+}
+
+// get возвращает загруженную модель и отмечает её использование
+func (p *pool[T]) get(id uuid.UUID) (T, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.items[id]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	p.tick++
+	e.used = p.tick
+	return e.value, true
+}
+
+// put добавляет модель и возвращает вытесненные: прежнее значение той же модели
+// и давно не использованные сверх лимита. Вызывающий освобождает их сам
+func (p *pool[T]) put(id uuid.UUID, v T) []T {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var evicted []T
+	if e, ok := p.items[id]; ok {
+		evicted = append(evicted, e.value)
+	}
+	p.tick++
+	p.items[id] = &poolEntry[T]{value: v, used: p.tick}
+
+	for len(p.items) > p.max {
+		var oldest uuid.UUID
+		var oldestUsed uint64
+		for k, e := range p.items {
+			if k != id && (oldestUsed == 0 || e.used < oldestUsed) {
+				oldest, oldestUsed = k, e.used
+			}
+		}
+		evicted = append(evicted, p.items[oldest].value)
+		delete(p.items, oldest)
+	}
+	return evicted
+}
+
+// remove убирает модель из пула и возвращает её
+func (p *pool[T]) remove(id uuid.UUID) (T, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.items[id]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	delete(p.items, id)
+	return e.value, true
+}
@@ -0,0 +1,86 @@
+package inference
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPoolEvictsLeastRecentlyUsed(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	p := newPool[string](2)
+
+	if ev := p.put(a, "a"); len(ev) != 0 {
+		t.Fatalf("put a evicted %v", ev)
+	}
+	if ev := p.put(b, "b"); len(ev) != 0 {
+		t.Fatalf("put b evicted %v", ev)
+	}
+	// Использование a делает давно не использованной b
+	if v, ok := p.get(a); !ok || v != "a" {
+		t.Fatalf("get a = %q, %v", v, ok)
+	}
+	if ev := p.put(c, "c"); !slices.Equal(ev, []string{"b"}) {
+		t.Fatalf("put c evicted %v, want [b]", ev)
+	}
+	if _, ok := p.get(b); ok {
+		t.Error("b is still loaded")
+	}
+	if ev := p.put(d, "d"); !slices.Equal(ev, []string{"a"}) {
+		t.Fatalf("put d evicted %v, want [a]", ev)
+	}
+	for _, id := range []uuid.UUID{c, d} {
+		if _, ok := p.get(id); !ok {
+			t.Errorf("model %s was evicted", id)
+		}
+	}
+}
+
+func TestPoolReplaceReturnsPreviousValue(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	p := newPool[string](2)
+	p.put(a, "a1")
+	p.put(b, "b")
+
+	// Замена не увеличивает число моделей и вытесняет только прежнее значение
+	if ev := p.put(a, "a2"); !slices.Equal(ev, []string{"a1"}) {
+		t.Fatalf("replace evicted %v, want [a1]", ev)
+	}
+	if v, _ := p.get(a); v != "a2" {
+		t.Errorf("get a = %q, want a2", v)
+	}
+	if _, ok := p.get(b); !ok {
+		t.Error("b was evicted on replace")
+	}
+}
+
+func TestPoolNeverEvictsNewModel(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	p := newPool[string](1)
+	p.put(a, "a")
+	if ev := p.put(b, "b"); !slices.Equal(ev, []string{"a"}) {
+		t.Fatalf("put b evicted %v, want [a]", ev)
+	}
+	if v, ok := p.get(b); !ok || v != "b" {
+		t.Errorf("get b = %q, %v", v, ok)
+	}
+}
+
+func TestPoolRemove(t *testing.T) {
+	a := uuid.New()
+	p := newPool[string](2)
+	if _, ok := p.remove(a); ok {
+		t.Error("removed a model that was never loaded")
+	}
+	p.put(a, "a")
+	if v, ok := p.remove(a); !ok || v != "a" {
+		t.Errorf("remove = %q, %v", v, ok)
+	}
+	if _, ok := p.get(a); ok {
+		t.Error("model is still loaded after remove")
+	}
+	if _, ok := p.remove(a); ok {
+		t.Error("model removed twice")
+	}
+}
@@ -0,0 +1,100 @@
+// Package labor оценивает трудоёмкость ремонта по нормам из labor_times
+package labor
+
+import (
+	"context"
+	"math"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Service управляет нормами трудоёмкости и дополняет ими результаты анализов
+type Service struct {
+	times *repository.LaborTimeRepository
+	cfg   config.LaborConfig
+}
+
+// NewService создаёт сервис трудоёмкости
+func NewService(times *repository.LaborTimeRepository, cfg config.LaborConfig) *Service {
+	return &Service{times: times, cfg: cfg}
+}
+
+// List возвращает все нормы
+func (s *Service) List(ctx context.Context) ([]domain.LaborTime, error) {
+	return s.times.List(ctx)
+}
+
+// Put создаёт или заменяет норму для детали и класса повреждения
+func (s *Service) Put(ctx context.Context, req domain.LaborTimeRequest) (*domain.LaborTime, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.times.Upsert(ctx, req)
+}
+
+// Delete удаляет норму
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.times.Delete(ctx, id)
+}
+
+type laborKey struct {
+	partID string
+	class  domain.DamageClass
+}
+
+// Enrich реализует domain.ResultEnricher: проставляет каждому дефекту нормо-часы
+// по видам работ (норма детали, иначе норма по умолчанию "*") и считает итог в сводке
+func (s *Service) Enrich(ctx context.Context, _ *domain.Analysis, result *domain.AnalysisResult) error {
+	times, err := s.times.List(ctx)
+	if err != nil {
+		return err
+	}
+	table := make(map[laborKey]*domain.LaborTime, len(times))
+	for i := range times {
+		table[laborKey{times[i].PartID, times[i].DamageClass}] = &times[i]
+	}
+
+	var total float64
+	for i := range result.Defects {
+		d := &result.Defects[i]
+		class := domain.DamageClassOf(d.DefectType, d.Severity)
+		lt, ok := table[laborKey{d.PartID, class}]
+		if !ok {
+			lt, ok = table[laborKey{domain.AnyPart, class}]
+		}
+		if !ok {
+			d.Labor = nil
+			continue
+		}
+
+		labor := &domain.DefectLabor{DamageClass: class, Hours: lt.Hours()}
+		for _, h := range labor.Hours {
+			labor.TotalHours += h
+		}
+		labor.TotalHours = roundHours(labor.TotalHours)
+		d.Labor = labor
+		total += labor.TotalHours
+	}
+
+	total = roundHours(total)
+	days := s.workshopDays(total)
+	result.Summary.LaborHours = &total
+	result.Summary.WorkshopDays = &days
+	return nil
+}
+
+// workshopDays переводит нормо-часы в рабочие дни цеха с округлением вверх
+func (s *Service) workshopDays(hours float64) int {
+	if hours <= 0 {
+		return 0
+	}
+	return int(math.Ceil(hours / s.cfg.HoursPerDay))
+}
+
+// roundHours округляет до сотых, чтобы в JSON не попадали хвосты сложения float
+func roundHours(h float64) float64 {
+	return math.Round(h*100) / 100
+}
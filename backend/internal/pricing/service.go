@@ -0,0 +1,239 @@
+// Package pricing оценивает стоимость ремонта по региональным прайс-листам
+// и пересчитывает суммы между валютами по курсам из exchange_rates
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Service управляет прайс-листами и курсами и считает стоимость ремонта
+type Service struct {
+	db       *sql.DB
+	pricing  *repository.PricingRepository
+	analyses *repository.AnalysisRepository
+	cfg      config.PricingConfig
+}
+
+// NewService создаёт сервис цен
+func NewService(db *sql.DB, pricing *repository.PricingRepository, analyses *repository.AnalysisRepository,
+	cfg config.PricingConfig) *Service {
+	return &Service{db: db, pricing: pricing, analyses: analyses, cfg: cfg}
+}
+
+// CreateCatalog создаёт прайс-лист региона вместе с ценами деталей.
+// Прайс-листы не изменяются: новые цены вводятся новым прайс-листом с более поздней датой
+func (s *Service) CreateCatalog(ctx context.Context, user *domain.User, req domain.PriceCatalogRequest) (*domain.PriceCatalog, error) {
+	effectiveFrom, err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+	pc := &domain.PriceCatalog{
+		Region:            req.Region,
+		Currency:          req.Currency,
+		EffectiveFrom:     effectiveFrom,
+		LaborRate:         req.LaborRate,
+		PaintMaterialRate: req.PaintMaterialRate,
+		Items:             req.Items,
+		CreatedBy:         &user.ID,
+	}
+	if pc.Items == nil {
+		pc.Items = []domain.PriceCatalogItem{}
+	}
+	err = database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		return s.pricing.CreateCatalog(ctx, tx, pc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
+// GetCatalog возвращает прайс-лист с ценами деталей
+func (s *Service) GetCatalog(ctx context.Context, id uuid.UUID) (*domain.PriceCatalog, error) {
+	return s.pricing.GetCatalog(ctx, id)
+}
+
+// ListCatalogs возвращает прайс-листы без цен деталей
+func (s *Service) ListCatalogs(ctx context.Context, region *string, limit, offset int) ([]domain.PriceCatalog, error) {
+	return s.pricing.ListCatalogs(ctx, region, limit, offset)
+}
+
+// PutRate устанавливает курс пары на дату (повторная установка на ту же дату заменяет курс)
+func (s *Service) PutRate(ctx context.Context, req domain.ExchangeRateRequest) (*domain.ExchangeRate, error) {
+	date, err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return s.pricing.UpsertRate(ctx, req.Base, req.Quote, req.Rate, date)
+}
+
+// ListRates возвращает историю курсов
+func (s *Service) ListRates(ctx context.Context, currency *domain.Currency, limit, offset int) ([]domain.ExchangeRate, error) {
+	return s.pricing.ListRates(ctx, currency, limit, offset)
+}
+
+// Convert пересчитывает сумму в валюту to по курсу, действующему на дату at.
+// Возвращает и применённый курс (nil, если валюты совпадают)
+func (s *Service) Convert(ctx context.Context, m domain.Money, to domain.Currency, at time.Time) (domain.Money, *domain.ExchangeRate, error) {
+	if m.Currency == to {
+		return m, nil, nil
+	}
+	rate, er, err := s.Rate(ctx, m.Currency, to, at)
+	if err != nil {
+		return domain.Money{}, nil, err
+	}
+	return m.Convert(to, rate), er, nil
+}
+
+// Rate возвращает курс from/to на дату at: прямой курс пары, иначе обратный.
+// Если курса нет, возвращает ошибку конфликта
+func (s *Service) Rate(ctx context.Context, from, to domain.Currency, at time.Time) (*big.Rat, *domain.ExchangeRate, error) {
+	er, err := s.pricing.RateAt(ctx, from, to, at)
+	if err == nil {
+		rate, err := er.RateRat()
+		return rate, er, err
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, err
+	}
+
+	inv, err := s.pricing.RateAt(ctx, to, from, at)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, domain.Conflictf("no exchange rate %s/%s on %s", from, to, at.Format(time.DateOnly))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	rate, err := inv.RateRat()
+	if err != nil {
+		return nil, nil, err
+	}
+	rate.Inv(rate)
+	return rate, &domain.ExchangeRate{
+		Base:          from,
+		Quote:         to,
+		Rate:          rate.FloatString(10),
+		EffectiveDate: inv.EffectiveDate,
+		CreatedAt:     inv.CreatedAt,
+	}, nil
+}
+
+// Enrich реализует domain.ResultEnricher: считает summary.estimated_cost по прайс-листу
+// региона анализа на дату его создания. Должен выполняться после оценки трудоёмкости.
+// Если прайс-листа нет, стоимость не указывается
+func (s *Service) Enrich(ctx context.Context, a *domain.Analysis, result *domain.AnalysisResult) error {
+	pc, err := s.pricing.CatalogAt(ctx, s.region(a), a.CreatedAt)
+	if errors.Is(err, repository.ErrNotFound) {
+		result.Summary.EstimatedCost = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cost := estimate(pc, result)
+	result.Summary.EstimatedCost = &cost
+	return nil
+}
+
+// Quote оценивает стоимость ремонта по анализу в валюте currency. Прайс-лист - региона
+// анализа или region, если он задан; прайс-лист и курс берутся на дату создания анализа,
+// поэтому повторные расчёты дают одинаковый результат
+func (s *Service) Quote(ctx context.Context, user *domain.User, analysisID uuid.UUID, currency domain.Currency, region *string) (*domain.CostQuote, error) {
+	if !currency.IsValid() {
+		return nil, domain.InvalidInputf("currency must be one of RUB, EUR, KZT, USD")
+	}
+	if region != nil {
+		if err := domain.ValidateRegion(*region); err != nil {
+			return nil, err
+		}
+	}
+
+	a, err := s.analyses.GetByID(ctx, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != user.ID && !user.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+	if !a.IsCompleted() || a.Result == nil {
+		return nil, domain.Conflictf("only completed analyses can be quoted")
+	}
+	if a.Result.Summary.LaborHours == nil {
+		return nil, domain.Conflictf("analysis has no labor estimate")
+	}
+
+	r := s.region(a)
+	if region != nil {
+		r = *region
+	}
+	pc, err := s.pricing.CatalogAt(ctx, r, a.CreatedAt)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, domain.Conflictf("no price catalog for region %s on %s", r, a.CreatedAt.Format(time.DateOnly))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cost := estimate(pc, a.Result)
+	quoted, er, err := s.Convert(ctx, cost, currency, a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &domain.CostQuote{
+		AnalysisID:    a.ID,
+		Region:        r,
+		CatalogID:     pc.ID,
+		EffectiveFrom: pc.EffectiveFrom,
+		LaborHours:    *a.Result.Summary.LaborHours,
+		Cost:          cost,
+		Quoted:        quoted,
+	}
+	if er != nil {
+		q.Rate, q.RateDate = &er.Rate, &er.EffectiveDate
+	}
+	return q, nil
+}
+
+// ReportCurrency возвращает валюту отчётов по умолчанию
+func (s *Service) ReportCurrency() domain.Currency {
+	return s.cfg.ReportCurrency
+}
+
+func (s *Service) region(a *domain.Analysis) string {
+	if a.Region != nil {
+		return *a.Region
+	}
+	return s.cfg.DefaultRegion
+}
+
+// estimate считает стоимость ремонта: нормо-часы по ставке, материалы окраски
+// и, при замене детали (класс heavy), цену новой детали
+func estimate(pc *domain.PriceCatalog, result *domain.AnalysisResult) domain.Money {
+	total := domain.Money{Currency: pc.Currency}
+	labor := domain.Money{Amount: pc.LaborRate, Currency: pc.Currency}
+	paint := domain.Money{Amount: pc.PaintMaterialRate, Currency: pc.Currency}
+	for _, d := range result.Defects {
+		if d.Labor == nil {
+			continue
+		}
+		total.Amount += labor.Mul(d.Labor.TotalHours).Amount
+		total.Amount += paint.Mul(d.Labor.Hours[domain.LaborOperationPaint]).Amount
+		if d.Labor.DamageClass == domain.DamageClassHeavy {
+			if price, ok := pc.PartPrice(d.PartID); ok {
+				total.Amount += price
+			}
+		}
+	}
+	return total
+}
@@ -0,0 +1,160 @@
+// Package redaction скрывает номерные знаки и лица на изображениях анализов
+package redaction
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/inference"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+)
+
+const (
+	// regionPadding - доля ширины и высоты области, на которую она расширяется:
+	// детекции номеров и лиц обычно обрезаны по самому объекту
+	regionPadding = 0.1
+	// jpegQuality - качество копии со скрытыми областями
+	jpegQuality = 90
+)
+
+// RedactedKey возвращает ключ копии изображения со скрытыми областями:
+// images/<user_id>/<file> -> redacted/<user_id>/<file>
+func RedactedKey(imageKey string) string {
+	return "redacted/" + strings.TrimPrefix(imageKey, "images/")
+}
+
+// Redactor находит номера и лица моделью детекции через тот же бэкенд инференса,
+// что и дефекты, и сохраняет копию изображения, в которой они пикселизированы.
+// Оригинал не изменяется
+type Redactor struct {
+	models   *repository.ModelRepository
+	analyses *repository.AnalysisRepository
+	backend  inference.Backend
+	storage  storage.ObjectStorage
+	cfg      config.RedactionConfig
+}
+
+// NewRedactor создаёт этап скрытия номеров и лиц
+func NewRedactor(models *repository.ModelRepository, analyses *repository.AnalysisRepository,
+	backend inference.Backend, store storage.ObjectStorage, cfg config.RedactionConfig) *Redactor {
+	return &Redactor{models: models, analyses: analyses, backend: backend, storage: store, cfg: cfg}
+}
+
+// Redact сохраняет копию изображения img анализа a со скрытыми номерами и лицами.
+// Копия сохраняется и без найденных областей: при перекодировании из неё удаляются
+// EXIF с координатами съёмки. Ошибки классифицированы через domain.AnalysisError
+func (r *Redactor) Redact(ctx context.Context, a *domain.Analysis, img []byte) error {
+	model, err := r.models.GetByVersion(ctx, r.cfg.ModelVersion)
+	if errors.Is(err, repository.ErrNotFound) {
+		return domain.NewAnalysisError(domain.ErrorCodeModelNotFound,
+			fmt.Errorf("redaction model %s: %w", r.cfg.ModelVersion, err))
+	}
+	if err != nil {
+		return err
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(img))
+	if err != nil {
+		return domain.NewAnalysisError(domain.ErrorCodeCorruptImage, err)
+	}
+
+	pred, err := r.backend.Infer(ctx, model, img)
+	if err != nil {
+		switch {
+		case errors.Is(err, inference.ErrIntegrity):
+			return domain.NewAnalysisError(domain.ErrorCodeModelIntegrity, err)
+		case errors.Is(err, inference.ErrUnavailable):
+			return domain.NewAnalysisError(domain.ErrorCodeInferenceUnavailable, err)
+		case errors.Is(err, context.DeadlineExceeded):
+			return domain.NewAnalysisError(domain.ErrorCodeInferenceTimeout, err)
+		}
+		return fmt.Errorf("detect redaction regions: %w", err)
+	}
+
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Src)
+
+	regions := make(domain.RedactionRegions, 0, len(pred.Detections))
+	for _, det := range pred.Detections {
+		class := domain.RedactionClass(det.Class)
+		if !class.IsValid() || det.Confidence < r.cfg.MinConfidence {
+			continue
+		}
+		rect := padRect(det.BBox, bounds)
+		if rect.Empty() {
+			continue
+		}
+		pixelate(dst, rect)
+		regions = append(regions, domain.RedactionRegion{Class: class, BBox: det.BBox, Confidence: det.Confidence})
+	}
+
+	var buf bytes.Buffer
+	contentType := "image/jpeg"
+	if format == "png" {
+		contentType = "image/png"
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return fmt.Errorf("encode redacted image: %w", err)
+	}
+
+	key := RedactedKey(a.ImageKey)
+	if err := r.storage.Put(ctx, key, &buf, contentType); err != nil {
+		code := domain.ErrorCodeStorageUnavailable
+		if errors.Is(err, context.DeadlineExceeded) {
+			code = domain.ErrorCodeStorageTimeout
+		}
+		return domain.NewAnalysisError(code, err)
+	}
+	return r.analyses.SetRedaction(ctx, a, key, regions)
+}
+
+// padRect расширяет область детекции на regionPadding и обрезает по границам изображения
+func padRect(box domain.BoundingBox, bounds image.Rectangle) image.Rectangle {
+	dx := int(float64(box.Width) * regionPadding)
+	dy := int(float64(box.Height) * regionPadding)
+	rect := image.Rect(box.X-dx, box.Y-dy, box.X+box.Width+dx, box.Y+box.Height+dy)
+	return rect.Add(bounds.Min).Intersect(bounds)
+}
+
+// pixelate заменяет область крупными блоками среднего цвета. В отличие от размытия,
+// пикселизацию с крупным блоком нельзя обратить деконволюцией
+func pixelate(img *image.RGBA, rect image.Rectangle) {
+	block := max(8, max(rect.Dx(), rect.Dy())/8)
+	for y0 := rect.Min.Y; y0 < rect.Max.Y; y0 += block {
+		for x0 := rect.Min.X; x0 < rect.Max.X; x0 += block {
+			cell := image.Rect(x0, y0, x0+block, y0+block).Intersect(rect)
+
+			var r, g, b, a, n uint64
+			for y := cell.Min.Y; y < cell.Max.Y; y++ {
+				for x := cell.Min.X; x < cell.Max.X; x++ {
+					i := img.PixOffset(x, y)
+					r += uint64(img.Pix[i])
+					g += uint64(img.Pix[i+1])
+					b += uint64(img.Pix[i+2])
+					a += uint64(img.Pix[i+3])
+					n++
+				}
+			}
+			avg := [4]uint8{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)}
+			for y := cell.Min.Y; y < cell.Max.Y; y++ {
+				for x := cell.Min.X; x < cell.Max.X; x++ {
+					copy(img.Pix[img.PixOffset(x, y):], avg[:])
+				}
+			}
+		}
+	}
+}
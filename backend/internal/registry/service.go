@@ -0,0 +1,340 @@
+// Package registry ведёт реестр моделей: регистрация, родословная,
+// продвижение новой версии в активные и откат
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/artifact"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Service переключает активную модель. Переключения сериализуются advisory-блокировкой,
+// каждое выполняется в одной транзакции с записью истории и аудита
+type Service struct {
+	db        *sql.DB
+	models    *repository.ModelRepository
+	jobs      *repository.TrainingJobRepository
+	datasets  *repository.DatasetRepository
+	artifacts *repository.ArtifactRepository
+	audit     *repository.AuditLogRepository
+	store     storage.ObjectStorage
+}
+
+// NewService создаёт сервис реестра моделей
+func NewService(db *sql.DB, models *repository.ModelRepository, jobs *repository.TrainingJobRepository,
+	datasets *repository.DatasetRepository, artifacts *repository.ArtifactRepository,
+	audit *repository.AuditLogRepository, store storage.ObjectStorage) *Service {
+	return &Service{db: db, models: models, jobs: jobs, datasets: datasets, artifacts: artifacts, audit: audit, store: store}
+}
+
+// List возвращает модели, начиная с новых
+func (s *Service) List(ctx context.Context, status *domain.ModelStatus, limit, offset int) ([]domain.MLModel, error) {
+	if status != nil && !status.IsValid() {
+		return nil, domain.InvalidInputf("unknown model status %q", *status)
+	}
+	return s.models.List(ctx, status, limit, offset)
+}
+
+// Create регистрирует обученную модель в статусе ready и записывает дайджесты её файлов.
+// Файлы должны уже лежать в хранилище и совпадать с ожидаемыми дайджестами из запроса
+func (s *Service) Create(ctx context.Context, user *domain.User, req domain.ModelCreateRequest) (*domain.MLModel, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	m := &domain.MLModel{
+		Version:       req.Version,
+		Name:          req.Name,
+		WeightsPath:   req.WeightsPath,
+		ConfigPath:    req.ConfigPath,
+		CarMake:       req.CarMake,
+		CarModel:      req.CarModel,
+		Status:        domain.ModelStatusReady,
+		Metrics:       req.Metrics,
+		ParentModelID: req.ParentModelID,
+		Description:   req.Description,
+		CreatedBy:     &user.ID,
+	}
+
+	// Дайджесты считаются до транзакции: чтение весов может быть долгим
+	artifacts, err := artifact.Collect(ctx, s.store, m)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, domain.InvalidInputf("model file not found in storage: %v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	expected := map[domain.ArtifactKind]*string{
+		domain.ArtifactKindWeights: req.WeightsSHA256,
+		domain.ArtifactKindConfig:  req.ConfigSHA256,
+	}
+	for _, a := range artifacts {
+		if want := expected[a.Kind]; want != nil && *want != a.SHA256 {
+			return nil, domain.InvalidInputf("%s file %s has sha256 %s, expected %s", a.Kind, a.Path, a.SHA256, *want)
+		}
+	}
+
+	details := domain.AuditDetails{"version": m.Version}
+	if m.ParentModelID != nil {
+		details["parent_model_id"] = *m.ParentModelID
+	}
+	for _, a := range artifacts {
+		details[string(a.Kind)+"_sha256"] = a.SHA256
+	}
+	entity := domain.AuditEntityModel
+	err = database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.models.Create(ctx, tx, m); err != nil {
+			return err
+		}
+		for i := range artifacts {
+			artifacts[i].ModelID = m.ID
+			if err := s.artifacts.Create(ctx, tx, &artifacts[i]); err != nil {
+				return err
+			}
+		}
+		return s.audit.Create(ctx, tx, domain.AuditLogCreateRequest{
+			UserID:     &user.ID,
+			Action:     domain.AuditActionModelRegistered,
+			EntityType: &entity,
+			EntityID:   &m.ID,
+			Details:    &details,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.Artifacts = artifacts
+	return m, nil
+}
+
+// Lineage возвращает родословную модели: предков до базовой модели и дерево потомков.
+// Для каждой модели указываются задача обучения, её датасет и изменение метрик относительно родителя
+func (s *Service) Lineage(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.ModelLineage, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	model, err := s.models.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := s.models.Ancestors(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	descendants, err := s.models.Descendants(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(append([]domain.MLModel{*model}, ancestors...), descendants...)
+	byID := make(map[uuid.UUID]*domain.MLModel, len(all))
+	ids := make([]uuid.UUID, len(all))
+	for i := range all {
+		byID[all[i].ID] = &all[i]
+		ids[i] = all[i].ID
+	}
+	jobs, err := s.jobs.ByResultModels(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	datasetIDs := make([]uuid.UUID, 0, len(jobs))
+	for _, j := range jobs {
+		datasetIDs = append(datasetIDs, j.DatasetID)
+	}
+	datasets, err := s.datasets.GetMany(ctx, datasetIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	node := func(m *domain.MLModel) domain.LineageNode {
+		n := domain.LineageNode{Model: *m, TrainingJob: jobs[m.ID]}
+		if n.TrainingJob != nil {
+			n.Dataset = datasets[n.TrainingJob.DatasetID]
+		}
+		if m.ParentModelID != nil {
+			if parent, ok := byID[*m.ParentModelID]; ok {
+				n.MetricsDelta = domain.NewModelMetricsDelta(m.Metrics, parent.Metrics)
+			}
+		}
+		return n
+	}
+
+	children := make(map[uuid.UUID][]*domain.MLModel)
+	for i := range descendants {
+		d := byID[descendants[i].ID]
+		children[*d.ParentModelID] = append(children[*d.ParentModelID], d)
+	}
+	visited := map[uuid.UUID]bool{}
+	var tree func(m *domain.MLModel) domain.LineageNode
+	tree = func(m *domain.MLModel) domain.LineageNode {
+		visited[m.ID] = true
+		n := node(m)
+		for _, c := range children[m.ID] {
+			if !visited[c.ID] {
+				n.Children = append(n.Children, tree(c))
+			}
+		}
+		return n
+	}
+
+	lineage := &domain.ModelLineage{Model: tree(byID[model.ID]), Ancestors: make([]domain.LineageNode, len(ancestors))}
+	for i := range ancestors {
+		lineage.Ancestors[i] = node(byID[ancestors[i].ID])
+	}
+	// Цепочка обрывается только на корне; если у последнего звена есть родитель, он уже встречался
+	last := model
+	if len(ancestors) > 0 {
+		last = &ancestors[len(ancestors)-1]
+	}
+	lineage.CycleDetected = last.ParentModelID != nil
+	return lineage, nil
+}
+
+// Promotions возвращает историю переключений активной модели
+func (s *Service) Promotions(ctx context.Context, limit, offset int) ([]domain.ModelPromotion, error) {
+	return s.models.ListPromotions(ctx, limit, offset)
+}
+
+// Promote делает модель активной. Текущая активная модель переходит в ready
+func (s *Service) Promote(ctx context.Context, user *domain.User, modelID uuid.UUID, req domain.ModelPromoteRequest) (*domain.ModelPromotion, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+
+	var promotion *domain.ModelPromotion
+	err := database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.models.LockRegistry(ctx, tx); err != nil {
+			return err
+		}
+		target, err := s.models.GetForUpdate(ctx, tx, modelID)
+		if err != nil {
+			return err
+		}
+		if target.Active {
+			return domain.Conflictf("model %s is already active", target.Version)
+		}
+		if !target.IsReady() {
+			return domain.Conflictf("model %s is %s: only ready models can be promoted", target.Version, target.Status)
+		}
+		current, err := s.activeForUpdate(ctx, tx)
+		if err != nil {
+			return err
+		}
+		promotion, err = s.switchActive(ctx, tx, user, current, target, domain.PromotionActionPromote, req.Reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// Rollback возвращает активной модель, которую сменило последнее продвижение текущей.
+// Повторный откат идёт дальше по истории продвижений
+func (s *Service) Rollback(ctx context.Context, user *domain.User, req domain.ModelPromoteRequest) (*domain.ModelPromotion, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+
+	var promotion *domain.ModelPromotion
+	err := database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.models.LockRegistry(ctx, tx); err != nil {
+			return err
+		}
+		current, err := s.activeForUpdate(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return domain.Conflictf("no active model to roll back")
+		}
+		last, err := s.models.LastPromotion(ctx, tx, current.ID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && last.PreviousModelID == nil) {
+			return domain.Conflictf("model %s has no previous model to roll back to", current.Version)
+		}
+		if err != nil {
+			return err
+		}
+		target, err := s.models.GetForUpdate(ctx, tx, *last.PreviousModelID)
+		if err != nil {
+			return err
+		}
+		if !target.IsReady() {
+			return domain.Conflictf("previous model %s is %s and cannot be reactivated", target.Version, target.Status)
+		}
+		promotion, err = s.switchActive(ctx, tx, user, current, target, domain.PromotionActionRollback, req.Reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// activeForUpdate возвращает заблокированную активную модель или nil, если её нет
+func (s *Service) activeForUpdate(ctx context.Context, tx *sql.Tx) (*domain.MLModel, error) {
+	m, err := s.models.GetActiveForUpdate(ctx, tx)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return m, err
+}
+
+// switchActive снимает активность с current (если есть), активирует target
+// и записывает историю и аудит. Снятие идёт первым: idx_models_active допускает
+// одну активную модель
+func (s *Service) switchActive(ctx context.Context, tx *sql.Tx, user *domain.User, current, target *domain.MLModel,
+	action domain.PromotionAction, reason *string) (*domain.ModelPromotion, error) {
+	details := domain.AuditDetails{"version": target.Version}
+	promotion := &domain.ModelPromotion{
+		ModelID:     target.ID,
+		Action:      action,
+		Reason:      reason,
+		PerformedBy: &user.ID,
+	}
+	if current != nil {
+		if err := s.models.SetActive(ctx, tx, current, false); err != nil {
+			return nil, err
+		}
+		promotion.PreviousModelID = &current.ID
+		details["previous_model_id"] = current.ID
+		details["previous_version"] = current.Version
+	}
+	if err := s.models.SetActive(ctx, tx, target, true); err != nil {
+		return nil, err
+	}
+	if err := s.models.CreatePromotion(ctx, tx, promotion); err != nil {
+		return nil, err
+	}
+
+	auditAction := domain.AuditActionModelPromoted
+	if action == domain.PromotionActionRollback {
+		auditAction = domain.AuditActionModelRolledBack
+	}
+	details["promotion_id"] = promotion.ID
+	if reason != nil {
+		details["reason"] = *reason
+	}
+	entity := domain.AuditEntityModel
+	err := s.audit.Create(ctx, tx, domain.AuditLogCreateRequest{
+		UserID:     &user.ID,
+		Action:     auditAction,
+		EntityType: &entity,
+		EntityID:   &target.ID,
+		Details:    &details,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
@@ -0,0 +1,99 @@
+package report
+
+import (
+	"context"
+	"math"
+	"math/big"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// maxStatsLimit - максимальное число групп в аналитическом ответе
+const maxStatsLimit = 1000
+
+// DefectStats возвращает агрегаты по дефектам в запрошенном разрезе.
+// Данные берутся из материализованных представлений и отстают на интервал их обновления
+func (s *Service) DefectStats(ctx context.Context, f domain.DefectStatsFilter) (*domain.DefectStats, error) {
+	if !f.Group.IsValid() {
+		return nil, domain.InvalidInputf("unknown group_by %q", f.Group)
+	}
+	if !f.From.Before(f.To) {
+		return nil, domain.InvalidInputf("from must be before to")
+	}
+	if f.Limit < 0 || f.Limit > maxStatsLimit {
+		return nil, domain.InvalidInputf("limit must be between 1 and %d", maxStatsLimit)
+	}
+	if f.Currency == "" {
+		f.Currency = s.prices.ReportCurrency()
+	}
+	if !f.Currency.IsValid() {
+		return nil, domain.InvalidInputf("currency must be one of RUB, EUR, KZT, USD")
+	}
+
+	rows, refreshedAt, err := s.analytics.DefectStats(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	// Оценки в других валютах пересчитываются по курсу на конец периода
+	rates := make(map[domain.Currency]*big.Rat)
+	for i := range rows {
+		row := &rows[i]
+		if row.AvgEstimatedCost, err = s.averageCost(ctx, row.CostTotals, f, rates); err != nil {
+			return nil, err
+		}
+		if row.Defects > 0 {
+			row.MajorShare = float64(row.MajorDefects) / float64(row.Defects)
+		}
+		if !f.Group.IsDefectLevel() && row.Analyses > 0 {
+			perAnalysis := float64(row.Defects) / float64(row.Analyses)
+			row.DefectsPerAnalysis = &perAnalysis
+		}
+	}
+
+	return &domain.DefectStats{
+		Group:       f.Group,
+		Currency:    f.Currency,
+		From:        f.From,
+		To:          f.To,
+		RefreshedAt: refreshedAt,
+		Rows:        nonNilRows(rows),
+	}, nil
+}
+
+// averageCost считает среднюю оценку стоимости в валюте f.Currency.
+// В rates кэшируются курсы исходных валют к валюте отчёта
+func (s *Service) averageCost(ctx context.Context, totals []domain.CostTotal, f domain.DefectStatsFilter,
+	rates map[domain.Currency]*big.Rat) (*domain.Money, error) {
+	var (
+		sum   domain.Amount
+		count int64
+	)
+	for _, t := range totals {
+		total := domain.Money{Amount: domain.Amount(t.Sum), Currency: t.Currency}
+		if t.Currency != f.Currency {
+			rate, ok := rates[t.Currency]
+			if !ok {
+				var err error
+				if rate, _, err = s.prices.Rate(ctx, t.Currency, f.Currency, f.To); err != nil {
+					return nil, err
+				}
+				rates[t.Currency] = rate
+			}
+			total = total.Convert(f.Currency, rate)
+		}
+		sum += total.Amount
+		count += t.Count
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	avg := domain.Money{Amount: domain.Amount(math.Round(float64(sum) / float64(count))), Currency: f.Currency}
+	return &avg, nil
+}
+
+func nonNilRows(rows []domain.DefectStatsRow) []domain.DefectStatsRow {
+	if rows == nil {
+		return []domain.DefectStatsRow{}
+	}
+	return rows
+}
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+)
+
+// Refresher периодически пересчитывает материализованные представления аналитики.
+// Запускается в воркере; при нескольких воркерах обновление выполняет один из них
+type Refresher struct {
+	db        *sql.DB
+	analytics *repository.AnalyticsRepository
+	interval  time.Duration
+}
+
+// NewRefresher создаёт планировщик обновления аналитики
+func NewRefresher(db *sql.DB, analytics *repository.AnalyticsRepository, interval time.Duration) *Refresher {
+	return &Refresher{db: db, analytics: analytics, interval: interval}
+}
+
+// Run обновляет представления сразу и затем каждые interval до отмены ctx
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("refresh analytics: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh пересчитывает все представления в одной транзакции под advisory-блокировкой.
+// Если обновление уже идёт в другом процессе, ничего не делает
+func (r *Refresher) Refresh(ctx context.Context) error {
+	return database.WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		locked, err := r.analytics.TryLockRefresh(ctx, tx)
+		if err != nil || !locked {
+			return err
+		}
+		for _, view := range repository.AnalyticsViews {
+			if err := r.analytics.RefreshView(ctx, tx, view); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
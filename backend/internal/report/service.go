@@ -0,0 +1,57 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/pricing"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+)
+
+// maxPeriod - максимальный период отчёта
+const maxPeriod = 366 * 24 * time.Hour
+
+// Service строит операционные отчёты по анализам
+type Service struct {
+	analyses  *repository.AnalysisRepository
+	analytics *repository.AnalyticsRepository
+	prices    *pricing.Service
+}
+
+// NewService создаёт сервис отчётов
+func NewService(analyses *repository.AnalysisRepository, analytics *repository.AnalyticsRepository,
+	prices *pricing.Service) *Service {
+	return &Service{analyses: analyses, analytics: analytics, prices: prices}
+}
+
+// SLA возвращает перцентили ожидания в очереди и времени обработки
+// по версии модели: по дням и итогом за период
+func (s *Service) SLA(ctx context.Context, f domain.SLAReportFilter) (*domain.SLAReport, error) {
+	if !f.From.Before(f.To) {
+		return nil, domain.InvalidInputf("from must be before to")
+	}
+	if f.To.Sub(f.From) > maxPeriod {
+		return nil, domain.InvalidInputf("period must not exceed 366 days")
+	}
+
+	rows, err := s.analyses.SLAReport(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.SLAReport{
+		From:    f.From,
+		To:      f.To,
+		ByDay:   []domain.SLAReportRow{},
+		ByModel: []domain.SLAReportRow{},
+	}
+	for _, row := range rows {
+		if row.Day == nil {
+			report.ByModel = append(report.ByModel, row)
+		} else {
+			report.ByDay = append(report.ByDay, row)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,486 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// analysisColumns - список колонок analyses в порядке сканирования scanAnalysis
+const analysisColumns = `
+	id, user_id, batch_id, status,
+	image_key, image_metadata, image_deleted_at, anonymized_at,
+	redacted_image_key, redaction_regions, redacted_at,
+	car_make, car_model, region,
+	model_version, model_id, model_route, canary_rule_id, base_route,
+	result_json, original_result_json, result_version,
+	review_status, review_reason, reviewed_at, reviewed_by,
+	error_message, error_code, COALESCE(retry_count, 0), next_retry_at,
+	incident_location, image_phash, fraud_score, fraud_reasons, fraud_checked_at,
+	webhook_url, webhook_sent_at,
+	created_at, updated_at, queued_at, processing_at, completed_at,
+	processing_time_ms, queue_wait_time_ms`
+
+// AnalysisRepository реализует доступ к таблице analyses
+type AnalysisRepository struct {
+	db *sql.DB
+}
+
+// NewAnalysisRepository создаёт репозиторий анализов
+func NewAnalysisRepository(db *sql.DB) *AnalysisRepository {
+	return &AnalysisRepository{db: db}
+}
+
+// rowScanner объединяет *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAnalysis(row rowScanner) (*domain.Analysis, error) {
+	var a domain.Analysis
+	err := row.Scan(
+		&a.ID, &a.UserID, &a.BatchID, &a.Status,
+		&a.ImageKey, &a.ImageMetadata, &a.ImageDeletedAt, &a.AnonymizedAt,
+		&a.RedactedImageKey, &a.RedactionRegions, &a.RedactedAt,
+		&a.CarMake, &a.CarModel, &a.Region,
+		&a.ModelVersion, &a.ModelID, &a.ModelRoute, &a.CanaryRuleID, &a.BaseRoute,
+		&a.Result, &a.OriginalResult, &a.ResultVersion,
+		&a.ReviewStatus, &a.ReviewReason, &a.ReviewedAt, &a.ReviewedBy,
+		&a.ErrorMessage, &a.ErrorCode, &a.RetryCount, &a.NextRetryAt,
+		&a.IncidentLocation, &a.ImageHash, &a.FraudScore, &a.FraudReasons, &a.FraudCheckedAt,
+		&a.WebhookURL, &a.WebhookSentAt,
+		&a.CreatedAt, &a.UpdatedAt, &a.QueuedAt, &a.ProcessingAt, &a.CompletedAt,
+		&a.ProcessingTimeMs, &a.QueueWaitTimeMs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func scanAnalyses(rows *sql.Rows) ([]domain.Analysis, error) {
+	defer rows.Close()
+
+	var analyses []domain.Analysis
+	for rows.Next() {
+		a, err := scanAnalysis(rows)
+		if err != nil {
+			return nil, err
+		}
+		analyses = append(analyses, *a)
+	}
+	return analyses, rows.Err()
+}
+
+// GetByID возвращает анализ по идентификатору
+func (r *AnalysisRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Analysis, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+analysisColumns+` FROM analyses WHERE id = $1`, id)
+	a, err := scanAnalysis(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get analysis %s: %w", id, err)
+	}
+	return a, nil
+}
+
+// Create ставит анализ в очередь и заполняет ID, статус и временные метки.
+// Если q == nil, используется пул соединений
+func (r *AnalysisRepository) Create(ctx context.Context, q Querier, a *domain.Analysis) error {
+	if q == nil {
+		q = r.db
+	}
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO analyses (user_id, batch_id, status, image_key, car_make, car_model, region,
+		                      model_version, model_id, model_route, canary_rule_id, base_route,
+		                      incident_location, webhook_url)
+		VALUES ($1, $2, 'queued', $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, status, created_at, queued_at`,
+		a.UserID, a.BatchID, a.ImageKey, a.CarMake, a.CarModel, a.Region,
+		a.ModelVersion, a.ModelID, a.ModelRoute, a.CanaryRuleID, a.BaseRoute, a.IncidentLocation, a.WebhookURL,
+	).Scan(&a.ID, &a.Status, &a.CreatedAt, &a.QueuedAt)
+	if err != nil {
+		return fmt.Errorf("create analysis: %w", err)
+	}
+	a.ReviewStatus = domain.ReviewStatusNotRequired
+	a.ResultVersion = 1
+	return nil
+}
+
+// ClaimNext атомарно забирает из очереди самый старый анализ, время повтора которого наступило,
+// и переводит его в processing, фиксируя время ожидания в очереди. Если брать нечего,
+// возвращает ErrNotFound. FOR UPDATE SKIP LOCKED позволяет нескольким воркерам работать параллельно
+func (r *AnalysisRepository) ClaimNext(ctx context.Context) (*domain.Analysis, error) {
+	row := r.db.QueryRowContext(ctx, `
+		UPDATE analyses
+		SET status = 'processing', next_retry_at = NULL,
+		    processing_at = CURRENT_TIMESTAMP,
+		    queue_wait_time_ms = `+elapsedMs("COALESCE(queued_at, created_at)")+`
+		WHERE id = (
+			SELECT id FROM analyses
+			WHERE status = 'queued'
+			  AND (next_retry_at IS NULL OR next_retry_at <= CURRENT_TIMESTAMP)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+analysisColumns)
+	a, err := scanAnalysis(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim analysis: %w", err)
+	}
+	return a, nil
+}
+
+// elapsedMs возвращает SQL-выражение: миллисекунды от момента from до текущего времени
+func elapsedMs(from string) string {
+	return `GREATEST(0, (EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - ` + from + `)) * 1000)::bigint)`
+}
+
+// Complete сохраняет результат, переводит анализ в completed и фиксирует время обработки.
+// Статус, результат и тайминги записываются в a
+func (r *AnalysisRepository) Complete(ctx context.Context, a *domain.Analysis, result *domain.AnalysisResult) error {
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE analyses
+		SET status = 'completed', result_json = $2, error_message = NULL, error_code = NULL,
+		    completed_at = CURRENT_TIMESTAMP,
+		    processing_time_ms = `+elapsedMs("processing_at")+`
+		WHERE id = $1
+		RETURNING completed_at, processing_time_ms`,
+		a.ID, result).Scan(&a.CompletedAt, &a.ProcessingTimeMs)
+	if err != nil {
+		return fmt.Errorf("complete analysis %s: %w", a.ID, err)
+	}
+	a.Status = domain.AnalysisStatusCompleted
+	a.Result = result
+	a.ErrorCode, a.ErrorMessage = nil, nil
+	return nil
+}
+
+// ScheduleRetry возвращает анализ в очередь с увеличенным счётчиком повторов;
+// воркер возьмёт его не раньше nextRetryAt. Ожидание в очереди отсчитывается от nextRetryAt,
+// чтобы пауза политики повторов не попадала в метрику queue_wait_time_ms
+func (r *AnalysisRepository) ScheduleRetry(ctx context.Context, id uuid.UUID, code domain.ErrorCode, msg string, nextRetryAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE analyses
+		SET status = 'queued', error_code = $2, error_message = $3,
+		    retry_count = retry_count + 1, next_retry_at = $4, queued_at = $4
+		WHERE id = $1`,
+		id, code, msg, nextRetryAt)
+	if err != nil {
+		return fmt.Errorf("schedule retry %s: %w", id, err)
+	}
+	return nil
+}
+
+// Fail переводит анализ в failed без повторов и фиксирует время обработки.
+// Статус, ошибка и тайминги записываются в a
+func (r *AnalysisRepository) Fail(ctx context.Context, a *domain.Analysis, code domain.ErrorCode, msg string) error {
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE analyses
+		SET status = 'failed', error_code = $2, error_message = $3, next_retry_at = NULL,
+		    completed_at = CURRENT_TIMESTAMP,
+		    processing_time_ms = `+elapsedMs("processing_at")+`
+		WHERE id = $1
+		RETURNING completed_at, processing_time_ms`,
+		a.ID, code, msg).Scan(&a.CompletedAt, &a.ProcessingTimeMs)
+	if err != nil {
+		return fmt.Errorf("fail analysis %s: %w", a.ID, err)
+	}
+	a.Status = domain.AnalysisStatusFailed
+	a.ErrorCode = &code
+	a.ErrorMessage = &msg
+	return nil
+}
+
+// UpdateImageFingerprint сохраняет метаданные изображения (включая EXIF) и перцептивный хэш
+func (r *AnalysisRepository) UpdateImageFingerprint(ctx context.Context, id uuid.UUID, meta *domain.ImageMetadata, hash int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE analyses SET image_metadata = $2, image_phash = $3 WHERE id = $1`,
+		id, meta, hash)
+	if err != nil {
+		return fmt.Errorf("update image fingerprint %s: %w", id, err)
+	}
+	return nil
+}
+
+// SetRedaction сохраняет ключ копии изображения со скрытыми областями и сами области
+func (r *AnalysisRepository) SetRedaction(ctx context.Context, a *domain.Analysis, key string, regions domain.RedactionRegions) error {
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE analyses
+		SET redacted_image_key = $2, redaction_regions = $3, redacted_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING redacted_at`,
+		a.ID, key, regions).Scan(&a.RedactedAt)
+	if err != nil {
+		return fmt.Errorf("set redaction %s: %w", a.ID, err)
+	}
+	a.RedactedImageKey = &key
+	a.RedactionRegions = &regions
+	return nil
+}
+
+// UpdateFraudAssessment сохраняет результат антифрод-проверки
+func (r *AnalysisRepository) UpdateFraudAssessment(ctx context.Context, id uuid.UUID, fa *domain.FraudAssessment) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE analyses SET fraud_score = $2, fraud_reasons = $3, fraud_checked_at = $4 WHERE id = $1`,
+		id, fa.Score, fa.Reasons, fa.CheckedAt)
+	if err != nil {
+		return fmt.Errorf("update fraud assessment %s: %w", id, err)
+	}
+	return nil
+}
+
+// NearDuplicate представляет анализ другого пользователя с похожим изображением
+type NearDuplicate struct {
+	AnalysisID uuid.UUID
+	UserID     uuid.UUID
+	Distance   int // расстояние Хэмминга между перцептивными хэшами
+	CreatedAt  time.Time
+}
+
+// FindNearDuplicates ищет изображения других пользователей той же организации, загруженные
+// не раньше since, перцептивный хэш которых отличается не более чем на maxDistance бит.
+// Пользователи без организации сравниваются между собой. Расстояние Хэмминга не поддерживается
+// индексом, поэтому просмотр ограничен окном по времени (idx_analyses_phash_created)
+func (r *AnalysisRepository) FindNearDuplicates(ctx context.Context, userID uuid.UUID, hash int64, since time.Time, maxDistance, limit int) ([]NearDuplicate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT a.id, a.user_id, bit_count((a.image_phash # $2)::bit(64))::int AS distance, a.created_at
+		FROM analyses a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.image_phash IS NOT NULL
+		  AND a.created_at >= $3
+		  AND a.user_id <> $1
+		  AND u.organization_id IS NOT DISTINCT FROM (SELECT organization_id FROM users WHERE id = $1)
+		  AND bit_count((a.image_phash # $2)::bit(64)) <= $4
+		ORDER BY distance, a.created_at
+		LIMIT $5`,
+		userID, hash, since, maxDistance, limit)
+	if err != nil {
+		return nil, fmt.Errorf("find near duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	var dups []NearDuplicate
+	for rows.Next() {
+		var d NearDuplicate
+		if err := rows.Scan(&d.AnalysisID, &d.UserID, &d.Distance, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		dups = append(dups, d)
+	}
+	return dups, rows.Err()
+}
+
+// ListHighRisk возвращает анализы с антифрод-оценкой не ниже filter.MinScore,
+// отсортированные по убыванию оценки
+func (r *AnalysisRepository) ListHighRisk(ctx context.Context, filter domain.HighRiskAnalysisFilter) ([]domain.Analysis, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+analysisColumns+`
+		FROM analyses
+		WHERE fraud_score >= $1
+		ORDER BY fraud_score DESC, created_at DESC
+		LIMIT $2 OFFSET $3`,
+		filter.MinScore, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("list high risk analyses: %w", err)
+	}
+	return scanAnalyses(rows)
+}
+
+// RawResult представляет result_json без разбора и обновления схемы
+type RawResult struct {
+	AnalysisID uuid.UUID
+	JSON       []byte
+}
+
+// UpgradeResults переписывает на месте до batchSize записей с id > afterID, у которых
+// версия схемы result_json ниже текущей. upgrade получает сырой JSON и возвращает обновлённый.
+// Пачка обрабатывается в одной транзакции. Возвращает число просмотренных строк и id последней
+// из них - его нужно передать как afterID в следующий вызов (пагинация по ключу)
+func (r *AnalysisRepository) UpgradeResults(ctx context.Context, afterID uuid.UUID, batchSize int,
+	upgrade func(raw []byte) ([]byte, error)) (int, uuid.UUID, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, afterID, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, result_json
+		FROM analyses
+		WHERE id > $1
+		  AND result_json IS NOT NULL
+		  AND COALESCE((result_json->>'schema_version')::int, 1) < $2
+		ORDER BY id
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED`,
+		afterID, domain.CurrentResultSchemaVersion, batchSize)
+	if err != nil {
+		return 0, afterID, fmt.Errorf("select outdated results: %w", err)
+	}
+
+	var batch []RawResult
+	for rows.Next() {
+		var rr RawResult
+		if err := rows.Scan(&rr.AnalysisID, &rr.JSON); err != nil {
+			rows.Close()
+			return 0, afterID, err
+		}
+		batch = append(batch, rr)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, afterID, err
+	}
+	if len(batch) == 0 {
+		return 0, afterID, nil
+	}
+
+	for _, rr := range batch {
+		upgraded, err := upgrade(rr.JSON)
+		if err != nil {
+			return 0, afterID, fmt.Errorf("analysis %s: %w", rr.AnalysisID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE analyses SET result_json = $2 WHERE id = $1`, rr.AnalysisID, upgraded); err != nil {
+			return 0, afterID, fmt.Errorf("update result %s: %w", rr.AnalysisID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, afterID, fmt.Errorf("commit tx: %w", err)
+	}
+	return len(batch), batch[len(batch)-1].AnalysisID, nil
+}
+
+// CountOutdatedResults возвращает число записей со схемой result_json ниже текущей
+func (r *AnalysisRepository) CountOutdatedResults(ctx context.Context) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM analyses
+		WHERE result_json IS NOT NULL
+		  AND COALESCE((result_json->>'schema_version')::int, 1) < $1`,
+		domain.CurrentResultSchemaVersion).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count outdated results: %w", err)
+	}
+	return n, nil
+}
+
+// ListForExport возвращает до limit завершённых анализов с id > afterID, подходящих под фильтр
+// выгрузки (пагинация по ключу). Фильтр по типам и уверенности здесь отбирает анализы,
+// в которых есть хотя бы один подходящий дефект; сами дефекты фильтруются при выгрузке
+func (r *AnalysisRepository) ListForExport(ctx context.Context, f domain.AnalysisExportFilter, afterID uuid.UUID, limit int) ([]domain.Analysis, error) {
+	var w whereBuilder
+	w.add("status = 'completed'")
+	w.add("result_json IS NOT NULL")
+	w.add("image_deleted_at IS NULL")
+	w.add("id > ?", afterID)
+
+	if len(f.ModelVersions) > 0 {
+		w.add("model_version = ANY(?)", pq.Array(f.ModelVersions))
+	}
+	if f.From != nil {
+		w.add("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		w.add("created_at < ?", *f.To)
+	}
+	if f.ReviewedOnly {
+		w.add("reviewed_at IS NOT NULL")
+	}
+	if len(f.DefectTypes) > 0 || f.MinConfidence > 0 {
+		types := make([]string, len(f.DefectTypes))
+		for i, t := range f.DefectTypes {
+			types[i] = string(t)
+		}
+		w.add(`EXISTS (
+			SELECT 1 FROM jsonb_array_elements(result_json->'defects') d
+			WHERE (cardinality(?::text[]) = 0 OR d->>'defect_type' = ANY(?::text[]))
+			  AND (d->>'confidence')::float8 >= ?)`,
+			pq.Array(types), pq.Array(types), f.MinConfidence)
+	}
+
+	query := `SELECT ` + analysisColumns + ` FROM analyses ` + w.sql() + ` ORDER BY id LIMIT ` + w.arg(limit)
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("list analyses for export: %w", err)
+	}
+	return scanAnalyses(rows)
+}
+
+// GetForUpdate возвращает анализ, блокируя строку до конца транзакции
+func (r *AnalysisRepository) GetForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*domain.Analysis, error) {
+	a, err := scanAnalysis(tx.QueryRowContext(ctx, `SELECT `+analysisColumns+` FROM analyses WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get analysis for update %s: %w", id, err)
+	}
+	return a, nil
+}
+
+// MarkForReview ставит завершённый анализ в очередь ручной проверки
+func (r *AnalysisRepository) MarkForReview(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE analyses SET review_status = 'pending', review_reason = $2
+		WHERE id = $1 AND status = 'completed' AND review_status = 'not_required'`,
+		id, reason)
+	if err != nil {
+		return fmt.Errorf("mark analysis %s for review: %w", id, err)
+	}
+	return nil
+}
+
+// SaveReviewedResult сохраняет исправленный результат новой версии.
+// Выход модели копируется в original_result_json только при первой проверке
+func (r *AnalysisRepository) SaveReviewedResult(ctx context.Context, q Querier, id uuid.UUID,
+	result *domain.AnalysisResult, version int, reviewerID uuid.UUID) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE analyses
+		SET original_result_json = COALESCE(original_result_json, result_json),
+		    result_json = $2, result_version = $3,
+		    review_status = 'reviewed', reviewed_at = CURRENT_TIMESTAMP, reviewed_by = $4
+		WHERE id = $1`,
+		id, result, version, reviewerID)
+	if err != nil {
+		return fmt.Errorf("save reviewed result %s: %w", id, err)
+	}
+	return nil
+}
+
+// ListReviewQueue возвращает анализы, ожидающие ручной проверки, начиная с самых старых
+func (r *AnalysisRepository) ListReviewQueue(ctx context.Context, limit, offset int) ([]domain.Analysis, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+analysisColumns+`
+		FROM analyses
+		WHERE review_status = 'pending'
+		ORDER BY created_at
+		LIMIT $1 OFFSET $2`,
+		limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list review queue: %w", err)
+	}
+	return scanAnalyses(rows)
+}
+
+// MarkWebhookSent отмечает первую успешную доставку уведомления об анализе
+func (r *AnalysisRepository) MarkWebhookSent(ctx context.Context, q Querier, id uuid.UUID) error {
+	_, err := q.ExecContext(ctx,
+		`UPDATE analyses SET webhook_sent_at = COALESCE(webhook_sent_at, CURRENT_TIMESTAMP) WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("mark webhook sent %s: %w", id, err)
+	}
+	return nil
+}
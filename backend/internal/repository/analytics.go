@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// Материализованные представления аналитики (миграции 000015, 000033).
+// У каждого разреза своё представление: analysis_count и стоимость анализа нельзя суммировать
+// по измерению, которого нет в разрезе, иначе анализ учитывается несколько раз
+const (
+	partStatsView       = "mv_part_stats_weekly"
+	defectTypeStatsView = "mv_defect_type_stats_weekly"
+	analysisStatsView   = "mv_analysis_stats_weekly"
+)
+
+// AnalyticsViews - представления в порядке обновления
+var AnalyticsViews = []string{partStatsView, defectTypeStatsView, analysisStatsView}
+
+// analyticsRefreshLockKey - ключ advisory-блокировки, чтобы представления
+// обновлял только один воркер
+const analyticsRefreshLockKey int64 = 150015
+
+// AnalyticsRepository предоставляет доступ к агрегатам по дефектам
+type AnalyticsRepository struct {
+	db *sql.DB
+}
+
+// NewAnalyticsRepository создаёт репозиторий аналитики
+func NewAnalyticsRepository(db *sql.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+// TryLockRefresh захватывает блокировку обновления до конца транзакции q.
+// Возвращает false, если обновление уже выполняет другой процесс
+func (r *AnalyticsRepository) TryLockRefresh(ctx context.Context, q Querier) (bool, error) {
+	var locked bool
+	err := q.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, analyticsRefreshLockKey).Scan(&locked)
+	if err != nil {
+		return false, fmt.Errorf("lock analytics refresh: %w", err)
+	}
+	return locked, nil
+}
+
+// RefreshView пересчитывает представление без блокировки чтения и запоминает время обновления
+func (r *AnalyticsRepository) RefreshView(ctx context.Context, q Querier, view string) error {
+	start := time.Now()
+	// Имя представления берётся только из AnalyticsViews
+	if _, err := q.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY `+view); err != nil {
+		return fmt.Errorf("refresh %s: %w", view, err)
+	}
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO analytics_refreshes (view_name, refreshed_at, duration_ms)
+		VALUES ($1, NOW(), $2)
+		ON CONFLICT (view_name) DO UPDATE
+		SET refreshed_at = EXCLUDED.refreshed_at, duration_ms = EXCLUDED.duration_ms`,
+		view, time.Since(start).Milliseconds())
+	if err != nil {
+		return fmt.Errorf("record refresh of %s: %w", view, err)
+	}
+	return nil
+}
+
+// analyticsGroup описывает, как строится разрез: источник, колонки группировки и порядок
+type analyticsGroup struct {
+	view    string
+	dims    string
+	orderBy string
+}
+
+var analyticsGroups = map[domain.DefectStatsGroup]analyticsGroup{
+	domain.DefectStatsByPart:       {partStatsView, "part_id, part_name", "defects DESC, part_id, part_name"},
+	domain.DefectStatsByDefectType: {defectTypeStatsView, "defect_type", "defects DESC, defect_type"},
+	domain.DefectStatsByVehicle:    {analysisStatsView, "car_make, car_model", "analyses DESC, car_make, car_model"},
+	domain.DefectStatsByWeek:       {analysisStatsView, "week", "week"},
+}
+
+// DefectStats агрегирует недельные представления в запрошенный разрез.
+// Возвращает также время последнего обновления источника (nil, если он ещё не обновлялся)
+func (r *AnalyticsRepository) DefectStats(ctx context.Context, f domain.DefectStatsFilter) ([]domain.DefectStatsRow, *time.Time, error) {
+	g, ok := analyticsGroups[f.Group]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown analytics group %q", f.Group)
+	}
+
+	var w whereBuilder
+	w.add("week >= date_trunc('week', ?::timestamptz AT TIME ZONE 'UTC')::date", f.From)
+	w.add("week < (?::timestamptz AT TIME ZONE 'UTC')::date", f.To)
+	if f.CarMake != nil {
+		w.add("lower(car_make) = lower(?)", *f.CarMake)
+	}
+	if f.CarModel != nil {
+		w.add("lower(car_model) = lower(?)", *f.CarModel)
+	}
+
+	// Оценки стоимости хранятся в разных валютах: суммы по валютам собираются в JSON,
+	// пересчёт в валюту отчёта выполняет сервис
+	query := `
+		WITH per_currency AS (
+			SELECT ` + g.dims + `, cost_currency,
+			       SUM(analysis_count) AS analyses, SUM(defect_count) AS defects, SUM(major_count) AS majors,
+			       SUM(cost_sum) AS cost_sum, SUM(cost_count) AS cost_count
+			FROM ` + g.view + `
+			` + w.sql() + `
+			GROUP BY ` + g.dims + `, cost_currency
+		)
+		SELECT ` + g.dims + `,
+		       SUM(analyses) AS analyses,
+		       SUM(defects) AS defects,
+		       SUM(majors),
+		       COALESCE(jsonb_agg(jsonb_build_object('currency', cost_currency, 'sum', cost_sum, 'count', cost_count))
+		                FILTER (WHERE cost_count > 0), '[]')
+		FROM per_currency
+		GROUP BY ` + g.dims + `
+		ORDER BY ` + g.orderBy
+	if f.Limit > 0 && f.Group != domain.DefectStatsByWeek {
+		query += ` LIMIT ` + w.arg(f.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("defect stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.DefectStatsRow
+	for rows.Next() {
+		var (
+			row          domain.DefectStatsRow
+			dim1, dim2   string
+			week         time.Time
+			costs        []byte
+			measureDests = []any{&row.Analyses, &row.Defects, &row.MajorDefects, &costs}
+			dest         []any
+		)
+		switch f.Group {
+		case domain.DefectStatsByPart, domain.DefectStatsByVehicle:
+			dest = append([]any{&dim1, &dim2}, measureDests...)
+		case domain.DefectStatsByWeek:
+			dest = append([]any{&week}, measureDests...)
+		default:
+			dest = append([]any{&dim1}, measureDests...)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(costs, &row.CostTotals); err != nil {
+			return nil, nil, fmt.Errorf("decode cost totals: %w", err)
+		}
+
+		// В представлениях отсутствующее значение хранится как ''
+		switch f.Group {
+		case domain.DefectStatsByPart:
+			row.PartID, row.PartName = emptyToNil(dim1), emptyToNil(dim2)
+		case domain.DefectStatsByDefectType:
+			if dim1 != "" {
+				t := domain.DefectType(dim1)
+				row.DefectType = &t
+			}
+		case domain.DefectStatsByVehicle:
+			row.CarMake, row.CarModel = emptyToNil(dim1), emptyToNil(dim2)
+		case domain.DefectStatsByWeek:
+			row.Week = &week
+		}
+		stats = append(stats, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var refreshedAt time.Time
+	err = r.db.QueryRowContext(ctx,
+		`SELECT refreshed_at FROM analytics_refreshes WHERE view_name = $1`, g.view).Scan(&refreshedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return stats, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("get analytics refresh time: %w", err)
+	}
+	return stats, &refreshedAt, nil
+}
+
+func emptyToNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// artifactColumns - список колонок model_artifacts в порядке сканирования scanArtifact
+const artifactColumns = `
+	model_id, kind, path, sha256, size_bytes, created_at, verified_at, verification_error`
+
+// ArtifactRepository реализует доступ к таблице model_artifacts
+type ArtifactRepository struct {
+	db *sql.DB
+}
+
+// NewArtifactRepository создаёт репозиторий файлов моделей
+func NewArtifactRepository(db *sql.DB) *ArtifactRepository {
+	return &ArtifactRepository{db: db}
+}
+
+func scanArtifact(row rowScanner) (*domain.ModelArtifact, error) {
+	var a domain.ModelArtifact
+	err := row.Scan(&a.ModelID, &a.Kind, &a.Path, &a.SHA256, &a.SizeBytes, &a.CreatedAt,
+		&a.VerifiedAt, &a.VerificationError)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ListByModel возвращает записанные файлы модели
+func (r *ArtifactRepository) ListByModel(ctx context.Context, modelID uuid.UUID) ([]domain.ModelArtifact, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+artifactColumns+` FROM model_artifacts
+		WHERE model_id = $1
+		ORDER BY kind DESC`, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("list artifacts of model %s: %w", modelID, err)
+	}
+	defer rows.Close()
+
+	var items []domain.ModelArtifact
+	for rows.Next() {
+		a, err := scanArtifact(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *a)
+	}
+	return items, rows.Err()
+}
+
+// Create записывает дайджест файла модели и заполняет время создания
+func (r *ArtifactRepository) Create(ctx context.Context, q Querier, a *domain.ModelArtifact) error {
+	if q == nil {
+		q = r.db
+	}
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO model_artifacts (model_id, kind, path, sha256, size_bytes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`,
+		a.ModelID, a.Kind, a.Path, a.SHA256, a.SizeBytes,
+	).Scan(&a.CreatedAt)
+	if isUniqueViolation(err) {
+		return domain.Conflictf("model %s already has a recorded %s artifact", a.ModelID, a.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("create %s artifact of model %s: %w", a.Kind, a.ModelID, err)
+	}
+	return nil
+}
+
+// MarkVerified записывает итог проверки файла. verificationError == nil - файл совпал с дайджестом
+func (r *ArtifactRepository) MarkVerified(ctx context.Context, modelID uuid.UUID, kind domain.ArtifactKind,
+	at time.Time, verificationError *string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE model_artifacts SET verified_at = $3, verification_error = $4
+		WHERE model_id = $1 AND kind = $2`,
+		modelID, kind, at, verificationError)
+	if err != nil {
+		return fmt.Errorf("mark %s artifact of model %s verified: %w", kind, modelID, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// AuditLogRepository реализует запись в журнал аудита
+type AuditLogRepository struct {
+	db *sql.DB
+}
+
+// NewAuditLogRepository создаёт репозиторий журнала аудита
+func NewAuditLogRepository(db *sql.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// Create добавляет запись в журнал. q позволяет писать аудит в той же транзакции,
+// что и само изменение; если q == nil, используется пул соединений
+func (r *AuditLogRepository) Create(ctx context.Context, q Querier, req domain.AuditLogCreateRequest) error {
+	if q == nil {
+		q = r.db
+	}
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO audit_logs (user_id, action, entity_type, entity_id, ip_address, user_agent, request_id, details, status_code)
+		VALUES ($1, $2, $3, $4, $5::inet, $6, $7, $8, $9)`,
+		req.UserID, req.Action, req.EntityType, req.EntityID, req.IPAddress, req.UserAgent, req.RequestID,
+		req.Details, req.StatusCode)
+	if err != nil {
+		return fmt.Errorf("create audit log %s: %w", req.Action, err)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// batchSelect выбирает пакеты вместе с прогрессом, посчитанным по analyses,
+// в порядке сканирования scanBatch
+const batchSelect = `
+	SELECT b.id, b.user_id, b.name, b.model_version, b.status, b.total_count,
+	       p.queued, p.processing, p.completed, p.failed, p.cancelled,
+	       b.webhook_url, b.created_at, b.updated_at, b.completed_at
+	FROM analysis_batches b
+	CROSS JOIN LATERAL (
+		SELECT COUNT(*) FILTER (WHERE a.status = 'queued')     AS queued,
+		       COUNT(*) FILTER (WHERE a.status = 'processing') AS processing,
+		       COUNT(*) FILTER (WHERE a.status = 'completed')  AS completed,
+		       COUNT(*) FILTER (WHERE a.status = 'failed')     AS failed,
+		       COUNT(*) FILTER (WHERE a.status = 'cancelled')  AS cancelled
+		FROM analyses a
+		WHERE a.batch_id = b.id
+	) p`
+
+// BatchRepository реализует доступ к таблице analysis_batches
+type BatchRepository struct {
+	db *sql.DB
+}
+
+// NewBatchRepository создаёт репозиторий пакетов
+func NewBatchRepository(db *sql.DB) *BatchRepository {
+	return &BatchRepository{db: db}
+}
+
+func scanBatch(row rowScanner) (*domain.AnalysisBatch, error) {
+	var b domain.AnalysisBatch
+	p := &b.Progress
+	err := row.Scan(
+		&b.ID, &b.UserID, &b.Name, &b.ModelVersion, &b.Status, &p.Total,
+		&p.Queued, &p.Processing, &p.Completed, &p.Failed, &p.Cancelled,
+		&b.WebhookURL, &b.CreatedAt, &b.UpdatedAt, &b.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Create создаёт пакет с заданным ID (он нужен до вставки для ключей изображений)
+// и заполняет created_at
+func (r *BatchRepository) Create(ctx context.Context, q Querier, b *domain.AnalysisBatch) error {
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO analysis_batches (id, user_id, name, model_version, status, total_count, webhook_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at`,
+		b.ID, b.UserID, b.Name, b.ModelVersion, b.Status, b.Progress.Total, b.WebhookURL,
+	).Scan(&b.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create batch: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает пакет с текущим прогрессом
+func (r *BatchRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AnalysisBatch, error) {
+	b, err := scanBatch(r.db.QueryRowContext(ctx, batchSelect+` WHERE b.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get batch %s: %w", id, err)
+	}
+	return b, nil
+}
+
+// ListByUser возвращает пакеты пользователя, начиная с новых
+func (r *BatchRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]domain.AnalysisBatch, error) {
+	rows, err := r.db.QueryContext(ctx, batchSelect+`
+		WHERE b.user_id = $1
+		ORDER BY b.created_at DESC
+		LIMIT $2 OFFSET $3`,
+		userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list batches: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []domain.AnalysisBatch
+	for rows.Next() {
+		b, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, *b)
+	}
+	return batches, rows.Err()
+}
+
+// GetStatusForUpdate возвращает статус пакета, блокируя строку до конца транзакции.
+// Завершение пакета (MarkCompleted) ждёт снятия блокировки
+func (r *BatchRepository) GetStatusForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (domain.BatchStatus, error) {
+	var status domain.BatchStatus
+	err := tx.QueryRowContext(ctx, `SELECT status FROM analysis_batches WHERE id = $1 FOR UPDATE`, id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get batch %s for update: %w", id, err)
+	}
+	return status, nil
+}
+
+// SetStatus меняет статус пакета. Возврат в processing сбрасывает отметку завершения,
+// чтобы после повтора событие завершения сработало снова
+func (r *BatchRepository) SetStatus(ctx context.Context, q Querier, id uuid.UUID, status domain.BatchStatus) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE analysis_batches
+		SET status = $2,
+		    completed_at = CASE WHEN $2 = 'processing' THEN NULL ELSE completed_at END
+		WHERE id = $1`,
+		id, status)
+	if err != nil {
+		return fmt.Errorf("set batch status %s: %w", id, err)
+	}
+	return nil
+}
+
+// MarkCompleted отмечает пакет завершённым, если в нём не осталось анализов в очереди
+// и в обработке, и возвращает его. Пакет возвращается только тому вызову, который фактически
+// перевёл пакет, остальным - nil: условие completed_at IS NULL гарантирует единственное событие
+// завершения при параллельных воркерах. Отменённый пакет сохраняет статус cancelled
+func (r *BatchRepository) MarkCompleted(ctx context.Context, q Querier, id uuid.UUID) (*domain.AnalysisBatch, error) {
+	if q == nil {
+		q = r.db
+	}
+	var marked uuid.UUID
+	err := q.QueryRowContext(ctx, `
+		UPDATE analysis_batches b
+		SET completed_at = CURRENT_TIMESTAMP,
+		    status = CASE WHEN b.status = 'cancelled' THEN 'cancelled' ELSE 'completed' END
+		WHERE b.id = $1
+		  AND b.completed_at IS NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM analyses a
+			WHERE a.batch_id = b.id AND a.status IN ('queued', 'processing')
+		  )
+		RETURNING b.id`,
+		id).Scan(&marked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mark batch completed %s: %w", id, err)
+	}
+
+	b, err := scanBatch(q.QueryRowContext(ctx, batchSelect+` WHERE b.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("get batch %s: %w", id, err)
+	}
+	return b, nil
+}
+
+// ListFinishable возвращает ID незавершённых пакетов без анализов в очереди и в обработке,
+// начиная с самых старых. Такие пакеты остаются, если проверка после последнего анализа
+// не прошла (ошибка или остановка воркера)
+func (r *BatchRepository) ListFinishable(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT b.id
+		FROM analysis_batches b
+		WHERE b.completed_at IS NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM analyses a
+			WHERE a.batch_id = b.id AND a.status IN ('queued', 'processing')
+		  )
+		ORDER BY b.created_at
+		LIMIT $1`,
+		limit)
+	if err != nil {
+		return nil, fmt.Errorf("list finishable batches: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CreateBatchItems создаёт по анализу в очереди на каждый ключ изображения пакета.
+// routes[i] - модель, выбранная для req.ImageKeys[i]; автомобиль, место и webhook общие для пакета
+func (r *AnalysisRepository) CreateBatchItems(ctx context.Context, q Querier, b *domain.AnalysisBatch,
+	req domain.BatchCreateRequest, routes []domain.RoutingDecision) error {
+	imageKeys := req.ImageKeys
+	if len(routes) != len(imageKeys) {
+		return fmt.Errorf("create batch items %s: %d routes for %d images", b.ID, len(routes), len(imageKeys))
+	}
+	versions := make([]string, len(routes))
+	modelIDs := make([]string, len(routes))
+	modelRoutes := make([]string, len(routes))
+	ruleIDs := make([]sql.NullString, len(routes))
+	baseRoutes := make([]sql.NullString, len(routes))
+	for i, d := range routes {
+		versions[i] = d.Model.Version
+		modelIDs[i] = d.Model.ID.String()
+		modelRoutes[i] = string(d.Route)
+		if d.CanaryRuleID != nil {
+			ruleIDs[i] = sql.NullString{String: d.CanaryRuleID.String(), Valid: true}
+		}
+		if d.BaseRoute != nil {
+			baseRoutes[i] = sql.NullString{String: string(*d.BaseRoute), Valid: true}
+		}
+	}
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO analyses (user_id, batch_id, status, image_key, car_make, car_model, region,
+		                      model_version, model_id, model_route, canary_rule_id, base_route,
+		                      incident_location, webhook_url)
+		SELECT $1, $2, 'queued', k, $3, $4, $5, v, m::uuid, rt, cr::uuid, br, $6, $7
+		FROM unnest($8::text[], $9::text[], $10::text[], $11::text[], $12::text[], $13::text[])
+		     WITH ORDINALITY AS t(k, v, m, rt, cr, br, n)
+		ORDER BY n`,
+		b.UserID, b.ID, req.CarMake, req.CarModel, req.Region, req.IncidentLocation, req.WebhookURL,
+		pq.Array(imageKeys), pq.Array(versions), pq.Array(modelIDs), pq.Array(modelRoutes), pq.Array(ruleIDs),
+		pq.Array(baseRoutes))
+	if err != nil {
+		return fmt.Errorf("create batch items %s: %w", b.ID, err)
+	}
+	return nil
+}
+
+// ListByBatch возвращает анализы пакета, при необходимости только с заданным статусом
+func (r *AnalysisRepository) ListByBatch(ctx context.Context, batchID uuid.UUID, status *domain.AnalysisStatus, limit, offset int) ([]domain.Analysis, error) {
+	var w whereBuilder
+	w.add("batch_id = ?", batchID)
+	if status != nil {
+		w.add("status = ?", *status)
+	}
+	query := `SELECT ` + analysisColumns + ` FROM analyses ` + w.sql() +
+		` ORDER BY created_at, id LIMIT ` + w.arg(limit) + ` OFFSET ` + w.arg(offset)
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("list batch analyses: %w", err)
+	}
+	return scanAnalyses(rows)
+}
+
+// CancelQueuedInBatch отменяет анализы пакета, ещё не взятые воркером.
+// Анализы в обработке доводятся до конца. Возвращает число отменённых
+func (r *AnalysisRepository) CancelQueuedInBatch(ctx context.Context, q Querier, batchID uuid.UUID) (int, error) {
+	res, err := q.ExecContext(ctx, `
+		UPDATE analyses SET status = 'cancelled', next_retry_at = NULL
+		WHERE batch_id = $1 AND status = 'queued'`,
+		batchID)
+	if err != nil {
+		return 0, fmt.Errorf("cancel batch analyses %s: %w", batchID, err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// RequeueBatchFailures возвращает в очередь отменённые анализы пакета и анализы,
+// завершившиеся временной ошибкой (записи без кода считаются internal_error).
+// Анализы, изображения которых уже удалены политикой хранения, не повторяются.
+// Счётчик повторов сбрасывается: ручной повтор начинает политику повторов заново.
+// Возвращает число анализов, поставленных в очередь
+func (r *AnalysisRepository) RequeueBatchFailures(ctx context.Context, q Querier, batchID uuid.UUID) (int, error) {
+	codes := domain.TransientErrorCodes()
+	transient := make([]string, len(codes))
+	for i, c := range codes {
+		transient[i] = string(c)
+	}
+
+	res, err := q.ExecContext(ctx, `
+		UPDATE analyses
+		SET status = 'queued', error_code = NULL, error_message = NULL,
+		    retry_count = 0, next_retry_at = NULL, queued_at = CURRENT_TIMESTAMP,
+		    processing_at = NULL, completed_at = NULL, processing_time_ms = NULL, queue_wait_time_ms = NULL
+		WHERE batch_id = $1 AND image_deleted_at IS NULL
+		  AND (status = 'cancelled'
+		       OR (status = 'failed' AND (error_code IS NULL OR error_code = ANY($2))))`,
+		batchID, pq.Array(transient))
+	if err != nil {
+		return 0, fmt.Errorf("requeue batch analyses %s: %w", batchID, err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
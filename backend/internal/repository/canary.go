@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// canaryRuleColumns - список колонок canary_rules (c) и версии кандидата (m)
+// в порядке сканирования scanCanaryRule
+const canaryRuleColumns = `
+	c.id, c.candidate_model_id, m.version, c.percent::float8,
+	c.organization_id, c.car_make, c.enabled, c.created_by,
+	c.created_at, c.updated_at`
+
+const canaryRuleFrom = ` FROM canary_rules c JOIN models m ON m.id = c.candidate_model_id `
+
+// CanaryRepository реализует доступ к таблице canary_rules
+type CanaryRepository struct {
+	db *sql.DB
+}
+
+// NewCanaryRepository создаёт репозиторий канареечных правил
+func NewCanaryRepository(db *sql.DB) *CanaryRepository {
+	return &CanaryRepository{db: db}
+}
+
+func scanCanaryRule(row rowScanner) (*domain.CanaryRule, error) {
+	var c domain.CanaryRule
+	err := row.Scan(
+		&c.ID, &c.CandidateModelID, &c.CandidateVersion, &c.Percent,
+		&c.OrganizationID, &c.CarMake, &c.Enabled, &c.CreatedBy,
+		&c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CanaryRepository) list(ctx context.Context, query string, args ...any) ([]domain.CanaryRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list canary rules: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.CanaryRule
+	for rows.Next() {
+		c, err := scanCanaryRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *c)
+	}
+	return items, rows.Err()
+}
+
+// List возвращает правила, начиная с новых
+func (r *CanaryRepository) List(ctx context.Context, limit, offset int) ([]domain.CanaryRule, error) {
+	return r.list(ctx, `SELECT `+canaryRuleColumns+canaryRuleFrom+
+		`ORDER BY c.created_at DESC, c.id LIMIT $1 OFFSET $2`, limit, offset)
+}
+
+// ListEnabled возвращает включённые правила, начиная с новых
+func (r *CanaryRepository) ListEnabled(ctx context.Context) ([]domain.CanaryRule, error) {
+	return r.list(ctx, `SELECT `+canaryRuleColumns+canaryRuleFrom+
+		`WHERE c.enabled ORDER BY c.created_at DESC, c.id`)
+}
+
+// GetByID возвращает правило по идентификатору
+func (r *CanaryRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.CanaryRule, error) {
+	c, err := scanCanaryRule(r.db.QueryRowContext(ctx, `SELECT `+canaryRuleColumns+canaryRuleFrom+`WHERE c.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get canary rule %s: %w", id, err)
+	}
+	return c, nil
+}
+
+// Create создаёт включённое правило и заполняет ID и временные метки
+func (r *CanaryRepository) Create(ctx context.Context, q Querier, c *domain.CanaryRule) error {
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO canary_rules (candidate_model_id, percent, organization_id, car_make, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, enabled, created_at, updated_at`,
+		c.CandidateModelID, c.Percent, c.OrganizationID, c.CarMake, c.CreatedBy,
+	).Scan(&c.ID, &c.Enabled, &c.CreatedAt, &c.UpdatedAt)
+	if isUniqueViolation(err) {
+		return domain.Conflictf("an enabled canary rule already exists for this scope")
+	}
+	if isForeignKeyViolation(err) {
+		return domain.InvalidInputf("organization %s not found", c.OrganizationID)
+	}
+	if err != nil {
+		return fmt.Errorf("create canary rule: %w", err)
+	}
+	return nil
+}
+
+// Update меняет долю и (или) включённость правила
+func (r *CanaryRepository) Update(ctx context.Context, q Querier, id uuid.UUID, req domain.CanaryRuleUpdateRequest) error {
+	res, err := q.ExecContext(ctx, `
+		UPDATE canary_rules
+		SET percent = COALESCE($2, percent), enabled = COALESCE($3, enabled)
+		WHERE id = $1`,
+		id, req.Percent, req.Enabled)
+	if isUniqueViolation(err) {
+		return domain.Conflictf("an enabled canary rule already exists for this scope")
+	}
+	if err != nil {
+		return fmt.Errorf("update canary rule %s: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Report считает показатели анализов, маршрутизированных правилом, по веткам canary и control
+// отдельно для каждого способа выбора базовой модели (base_route). Дефекты берутся из выхода модели: правки проверяющих не должны влиять на сравнение моделей
+func (r *CanaryRepository) Report(ctx context.Context, ruleID uuid.UUID, from, to *time.Time) ([]domain.CanaryReportRow, error) {
+	const total = `(COALESCE(original_result_json, result_json)->'summary'->>'total_defects')::int`
+	const major = `(COALESCE(original_result_json, result_json)->'summary'->>'critical_count')::int`
+
+	var w whereBuilder
+	w.add("canary_rule_id = ?", ruleID)
+	w.add("model_route IN ('canary', 'control')")
+	if from != nil {
+		w.add("created_at >= ?", *from)
+	}
+	if to != nil {
+		w.add("created_at < ?", *to)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT model_route, base_route, model_version,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE status = 'completed'),
+		       COUNT(*) FILTER (WHERE status = 'failed'),
+		       COALESCE(SUM(`+total+`) FILTER (WHERE status = 'completed'), 0),
+		       COALESCE(SUM(`+major+`) FILTER (WHERE status = 'completed'), 0),
+		       COUNT(*) FILTER (WHERE status = 'completed' AND `+total+` > 0)
+		FROM analyses `+w.sql()+`
+		GROUP BY model_route, base_route, model_version
+		ORDER BY model_route, base_route, model_version`,
+		w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("canary report %s: %w", ruleID, err)
+	}
+	defer rows.Close()
+
+	var items []domain.CanaryReportRow
+	for rows.Next() {
+		var row domain.CanaryReportRow
+		var withDefects int
+		err := rows.Scan(&row.Route, &row.BaseRoute, &row.ModelVersion, &row.Analyses, &row.Completed, &row.Failed,
+			&row.Defects, &row.MajorDefects, &withDefects)
+		if err != nil {
+			return nil, err
+		}
+		if finished := row.Completed + row.Failed; finished > 0 {
+			row.FailureRate = float64(row.Failed) / float64(finished)
+		}
+		if row.Completed > 0 {
+			row.DefectsPerAnalysis = float64(row.Defects) / float64(row.Completed)
+			row.DefectRate = float64(withDefects) / float64(row.Completed)
+		}
+		items = append(items, row)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// datasetColumns - список колонок datasets в порядке сканирования scanDataset
+const datasetColumns = `
+	id, owner_id, name, description,
+	COALESCE(dataset_type, 'user_upload'), COALESCE(status, 'pending'),
+	file_key, total_size_bytes,
+	annotation_format, COALESCE(images_count, 0), COALESCE(annotations_count, 0), classes_json,
+	car_make, car_model,
+	created_at, updated_at, validated_at`
+
+// DatasetRepository реализует доступ к таблице datasets
+type DatasetRepository struct {
+	db *sql.DB
+}
+
+// NewDatasetRepository создаёт репозиторий датасетов
+func NewDatasetRepository(db *sql.DB) *DatasetRepository {
+	return &DatasetRepository{db: db}
+}
+
+func scanDataset(row rowScanner) (*domain.Dataset, error) {
+	var d domain.Dataset
+	err := row.Scan(
+		&d.ID, &d.OwnerID, &d.Name, &d.Description,
+		&d.DatasetType, &d.Status,
+		&d.FileKey, &d.TotalSizeBytes,
+		&d.AnnotationFormat, &d.ImagesCount, &d.AnnotationsCount, &d.Classes,
+		&d.CarMake, &d.CarModel,
+		&d.CreatedAt, &d.UpdatedAt, &d.ValidatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Create создаёт датасет и заполняет ID и временные метки
+func (r *DatasetRepository) Create(ctx context.Context, d *domain.Dataset) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO datasets (owner_id, name, description, dataset_type, status, annotation_format, car_make, car_model)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`,
+		d.OwnerID, d.Name, d.Description, d.DatasetType, d.Status, d.AnnotationFormat, d.CarMake, d.CarModel,
+	).Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create dataset: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает датасет по идентификатору
+func (r *DatasetRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Dataset, error) {
+	d, err := scanDataset(r.db.QueryRowContext(ctx, `SELECT `+datasetColumns+` FROM datasets WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get dataset %s: %w", id, err)
+	}
+	return d, nil
+}
+
+// GetMany возвращает датасеты по идентификаторам; отсутствующие пропускаются
+func (r *DatasetRepository) GetMany(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.Dataset, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+datasetColumns+` FROM datasets WHERE id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("get datasets: %w", err)
+	}
+	defer rows.Close()
+
+	datasets := make(map[uuid.UUID]*domain.Dataset, len(ids))
+	for rows.Next() {
+		d, err := scanDataset(rows)
+		if err != nil {
+			return nil, err
+		}
+		datasets[d.ID] = d
+	}
+	return datasets, rows.Err()
+}
+
+// UpdateStatus меняет статус датасета
+func (r *DatasetRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.DatasetStatus) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE datasets SET status = $2 WHERE id = $1`, id, status)
+	if err != nil {
+		return fmt.Errorf("update dataset status %s: %w", id, err)
+	}
+	return nil
+}
+
+// MarkReady сохраняет файл, счётчики и классы датасета и переводит его в ready
+func (r *DatasetRepository) MarkReady(ctx context.Context, d *domain.Dataset) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE datasets
+		SET status = 'ready', file_key = $2, total_size_bytes = $3,
+		    images_count = $4, annotations_count = $5, classes_json = $6,
+		    validated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		d.ID, d.FileKey, d.TotalSizeBytes, d.ImagesCount, d.AnnotationsCount, d.Classes)
+	if err != nil {
+		return fmt.Errorf("mark dataset ready %s: %w", d.ID, err)
+	}
+	return nil
+}
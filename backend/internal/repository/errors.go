@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// ErrNotFound возвращается, если запись не найдена
+var ErrNotFound = errors.New("record not found")
+
+// isUniqueViolation проверяет, что ошибка - нарушение уникального ограничения
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isForeignKeyViolation проверяет, что ошибка - ссылка на несуществующую запись
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// isCheckViolation проверяет, что ошибка - нарушение ограничения constraint (CHECK или триггер)
+func isCheckViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23514" && pqErr.Constraint == constraint
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// evaluationJobColumns - список колонок evaluation_jobs (j) и версии модели (m)
+// в порядке сканирования scanEvaluationJob
+const evaluationJobColumns = `
+	j.id, j.model_id, m.version, j.dataset_id, j.status, j.params_json,
+	j.metrics_json, j.latency_json, j.images_total, j.images_processed, j.images_failed,
+	j.error_message, j.created_by, j.created_at, j.updated_at, j.started_at, j.completed_at`
+
+const evaluationJobFrom = ` FROM evaluation_jobs j JOIN models m ON m.id = j.model_id `
+
+// EvaluationRepository реализует доступ к таблицам evaluation_jobs и evaluation_predictions
+type EvaluationRepository struct {
+	db *sql.DB
+}
+
+// NewEvaluationRepository создаёт репозиторий офлайн-оценки моделей
+func NewEvaluationRepository(db *sql.DB) *EvaluationRepository {
+	return &EvaluationRepository{db: db}
+}
+
+func scanEvaluationJob(row rowScanner) (*domain.EvaluationJob, error) {
+	var j domain.EvaluationJob
+	err := row.Scan(
+		&j.ID, &j.ModelID, &j.ModelVersion, &j.DatasetID, &j.Status, &j.Params,
+		&j.Metrics, &j.Latency, &j.ImagesTotal, &j.ImagesProcessed, &j.ImagesFailed,
+		&j.ErrorMessage, &j.CreatedBy, &j.CreatedAt, &j.UpdatedAt, &j.StartedAt, &j.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// Create ставит задачу оценки в очередь и заполняет ID, статус и время создания
+func (r *EvaluationRepository) Create(ctx context.Context, q Querier, j *domain.EvaluationJob) error {
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO evaluation_jobs (model_id, dataset_id, params_json, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at, updated_at`,
+		j.ModelID, j.DatasetID, j.Params, j.CreatedBy,
+	).Scan(&j.ID, &j.Status, &j.CreatedAt, &j.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("create evaluation job: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает задачу оценки по идентификатору
+func (r *EvaluationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.EvaluationJob, error) {
+	j, err := scanEvaluationJob(r.db.QueryRowContext(ctx,
+		`SELECT `+evaluationJobColumns+evaluationJobFrom+`WHERE j.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get evaluation job %s: %w", id, err)
+	}
+	return j, nil
+}
+
+// ListByModel возвращает задачи оценки модели, начиная с новых
+func (r *EvaluationRepository) ListByModel(ctx context.Context, modelID uuid.UUID, limit, offset int) ([]domain.EvaluationJob, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+evaluationJobColumns+evaluationJobFrom+`
+		WHERE j.model_id = $1
+		ORDER BY j.created_at DESC, j.id
+		LIMIT $2 OFFSET $3`, modelID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list evaluation jobs of model %s: %w", modelID, err)
+	}
+	defer rows.Close()
+
+	var items []domain.EvaluationJob
+	for rows.Next() {
+		j, err := scanEvaluationJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *j)
+	}
+	return items, rows.Err()
+}
+
+// LatestCommon находит последние завершённые оценки двух моделей на одном наборе:
+// том же датасете с той же долей валидационной части. Если datasetID задан, ищет только на нём.
+// Из нескольких общих наборов выбирается тот, где оценки свежее. Если общих нет, возвращает ErrNotFound
+func (r *EvaluationRepository) LatestCommon(ctx context.Context, baselineID, candidateID uuid.UUID,
+	datasetID *uuid.UUID) (*domain.EvaluationJob, *domain.EvaluationJob, error) {
+	var a, b uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+		WITH latest AS (
+			SELECT DISTINCT ON (model_id, dataset_id, params_json->'validation_percent')
+			       id, model_id, dataset_id, params_json->'validation_percent' AS split, completed_at
+			FROM evaluation_jobs
+			WHERE model_id IN ($1, $2) AND status = 'completed'
+			  AND ($3::uuid IS NULL OR dataset_id = $3)
+			ORDER BY model_id, dataset_id, params_json->'validation_percent', completed_at DESC
+		)
+		SELECT a.id, b.id
+		FROM latest a
+		JOIN latest b ON b.dataset_id = a.dataset_id AND b.split = a.split AND b.model_id = $2
+		WHERE a.model_id = $1
+		ORDER BY GREATEST(a.completed_at, b.completed_at) DESC
+		LIMIT 1`,
+		baselineID, candidateID, datasetID).Scan(&a, &b)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("find common evaluation of models %s and %s: %w", baselineID, candidateID, err)
+	}
+	baseline, err := r.GetByID(ctx, a)
+	if err != nil {
+		return nil, nil, err
+	}
+	candidate, err := r.GetByID(ctx, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return baseline, candidate, nil
+}
+
+// ClaimNext забирает самую старую задачу из очереди и переводит её в running.
+// Задача running, прогресс которой не обновлялся дольше lease (воркер остановлен),
+// забирается повторно. Если брать нечего, возвращает ErrNotFound
+func (r *EvaluationRepository) ClaimNext(ctx context.Context, lease time.Duration) (*domain.EvaluationJob, error) {
+	j, err := scanEvaluationJob(r.db.QueryRowContext(ctx, `
+		WITH claimed AS (
+			UPDATE evaluation_jobs
+			SET status = 'running', started_at = COALESCE(started_at, CURRENT_TIMESTAMP)
+			WHERE id = (
+				SELECT id FROM evaluation_jobs
+				WHERE status = 'queued'
+				   OR (status = 'running' AND updated_at < CURRENT_TIMESTAMP - $1::bigint * INTERVAL '1 millisecond')
+				ORDER BY created_at
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT `+evaluationJobColumns+` FROM claimed j JOIN models m ON m.id = j.model_id`,
+		lease.Milliseconds()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim evaluation job: %w", err)
+	}
+	return j, nil
+}
+
+// UpdateProgress записывает прогресс задачи; заодно продлевает её аренду (updated_at)
+func (r *EvaluationRepository) UpdateProgress(ctx context.Context, id uuid.UUID, total, processed, failed int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE evaluation_jobs
+		SET images_total = $2, images_processed = $3, images_failed = $4
+		WHERE id = $1 AND status = 'running'`,
+		id, total, processed, failed)
+	if err != nil {
+		return fmt.Errorf("update evaluation job progress %s: %w", id, err)
+	}
+	return nil
+}
+
+// Complete сохраняет итог оценки и переводит задачу в completed
+func (r *EvaluationRepository) Complete(ctx context.Context, q Querier, j *domain.EvaluationJob) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE evaluation_jobs
+		SET status = 'completed', metrics_json = $2, latency_json = $3,
+		    images_total = $4, images_processed = $5, images_failed = $6,
+		    error_message = NULL, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		j.ID, j.Metrics, j.Latency, j.ImagesTotal, j.ImagesProcessed, j.ImagesFailed)
+	if err != nil {
+		return fmt.Errorf("complete evaluation job %s: %w", j.ID, err)
+	}
+	return nil
+}
+
+// Fail переводит задачу в failed с причиной
+func (r *EvaluationRepository) Fail(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE evaluation_jobs
+		SET status = 'failed', error_message = $2, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		id, reason)
+	if err != nil {
+		return fmt.Errorf("fail evaluation job %s: %w", id, err)
+	}
+	return nil
+}
+
+// SavePrediction записывает выход модели на изображении, заменяя выход прошлой попытки
+func (r *EvaluationRepository) SavePrediction(ctx context.Context, p *domain.EvaluationPrediction) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO evaluation_predictions (job_id, image_key, detections_json, error_message, processing_time_ms)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (job_id, image_key) DO UPDATE
+		SET detections_json = EXCLUDED.detections_json, error_message = EXCLUDED.error_message,
+		    processing_time_ms = EXCLUDED.processing_time_ms, created_at = CURRENT_TIMESTAMP
+		RETURNING created_at`,
+		p.JobID, p.ImageKey, p.Detections, p.ErrorMessage, p.ProcessingTimeMs,
+	).Scan(&p.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("save evaluation prediction %s: %w", p.ImageKey, err)
+	}
+	return nil
+}
+
+// Predictions возвращает выходы модели по всем изображениям задачи, ключ - ключ изображения
+func (r *EvaluationRepository) Predictions(ctx context.Context, jobID uuid.UUID) (map[string]*domain.EvaluationPrediction, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT job_id, image_key, detections_json, error_message, processing_time_ms, created_at
+		FROM evaluation_predictions
+		WHERE job_id = $1`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("list evaluation predictions %s: %w", jobID, err)
+	}
+	defer rows.Close()
+
+	items := make(map[string]*domain.EvaluationPrediction)
+	for rows.Next() {
+		var p domain.EvaluationPrediction
+		if err := rows.Scan(&p.JobID, &p.ImageKey, &p.Detections, &p.ErrorMessage, &p.ProcessingTimeMs, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		items[p.ImageKey] = &p
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// laborTimeColumns - список колонок labor_times в порядке сканирования scanLaborTime
+const laborTimeColumns = `
+	id, part_id, damage_class,
+	remove_refit_hours, repair_hours, paint_hours, blend_hours,
+	created_at, updated_at`
+
+// LaborTimeRepository реализует доступ к нормам трудоёмкости
+type LaborTimeRepository struct {
+	db *sql.DB
+}
+
+// NewLaborTimeRepository создаёт репозиторий норм трудоёмкости
+func NewLaborTimeRepository(db *sql.DB) *LaborTimeRepository {
+	return &LaborTimeRepository{db: db}
+}
+
+func scanLaborTime(row rowScanner) (*domain.LaborTime, error) {
+	var lt domain.LaborTime
+	err := row.Scan(
+		&lt.ID, &lt.PartID, &lt.DamageClass,
+		&lt.RemoveRefitHours, &lt.RepairHours, &lt.PaintHours, &lt.BlendHours,
+		&lt.CreatedAt, &lt.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &lt, nil
+}
+
+// List возвращает все нормы. Таблица небольшая и читается целиком
+func (r *LaborTimeRepository) List(ctx context.Context) ([]domain.LaborTime, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+laborTimeColumns+` FROM labor_times ORDER BY part_id, damage_class`)
+	if err != nil {
+		return nil, fmt.Errorf("list labor times: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.LaborTime
+	for rows.Next() {
+		lt, err := scanLaborTime(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *lt)
+	}
+	return items, rows.Err()
+}
+
+// Upsert создаёт или заменяет норму для пары (part_id, damage_class)
+func (r *LaborTimeRepository) Upsert(ctx context.Context, req domain.LaborTimeRequest) (*domain.LaborTime, error) {
+	lt, err := scanLaborTime(r.db.QueryRowContext(ctx, `
+		INSERT INTO labor_times (part_id, damage_class, remove_refit_hours, repair_hours, paint_hours, blend_hours)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (part_id, damage_class) DO UPDATE
+		SET remove_refit_hours = EXCLUDED.remove_refit_hours,
+		    repair_hours = EXCLUDED.repair_hours,
+		    paint_hours = EXCLUDED.paint_hours,
+		    blend_hours = EXCLUDED.blend_hours
+		RETURNING `+laborTimeColumns,
+		req.PartID, req.DamageClass, req.RemoveRefitHours, req.RepairHours, req.PaintHours, req.BlendHours))
+	if err != nil {
+		return nil, fmt.Errorf("upsert labor time: %w", err)
+	}
+	return lt, nil
+}
+
+// Delete удаляет норму
+func (r *LaborTimeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM labor_times WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete labor time: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// modelColumns - список колонок models в порядке сканирования scanModel
+const modelColumns = `
+	id, version, name,
+	weights_path, config_path,
+	car_make, car_model,
+	COALESCE(status, 'training'), COALESCE(active, FALSE),
+	metrics_json,
+	parent_model_id, trained_at, description,
+	created_at, updated_at, created_by`
+
+// ModelRepository реализует доступ к таблице models
+type ModelRepository struct {
+	db *sql.DB
+}
+
+// NewModelRepository создаёт репозиторий моделей
+func NewModelRepository(db *sql.DB) *ModelRepository {
+	return &ModelRepository{db: db}
+}
+
+func scanModel(row rowScanner) (*domain.MLModel, error) {
+	var m domain.MLModel
+	err := row.Scan(
+		&m.ID, &m.Version, &m.Name,
+		&m.WeightsPath, &m.ConfigPath,
+		&m.CarMake, &m.CarModel,
+		&m.Status, &m.Active,
+		&m.Metrics,
+		&m.ParentModelID, &m.TrainedAt, &m.Description,
+		&m.CreatedAt, &m.UpdatedAt, &m.CreatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *ModelRepository) getOne(ctx context.Context, where string, args ...any) (*domain.MLModel, error) {
+	m, err := scanModel(r.db.QueryRowContext(ctx, `SELECT `+modelColumns+` FROM models WHERE `+where, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get model: %w", err)
+	}
+	return m, nil
+}
+
+// GetByID возвращает модель по идентификатору
+func (r *ModelRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.MLModel, error) {
+	return r.getOne(ctx, `id = $1`, id)
+}
+
+// GetByVersion возвращает модель по версии
+func (r *ModelRepository) GetByVersion(ctx context.Context, version string) (*domain.MLModel, error) {
+	return r.getOne(ctx, `version = $1`, version)
+}
+
+// GetActive возвращает активную модель (она единственная по индексу idx_models_active)
+func (r *ModelRepository) GetActive(ctx context.Context) (*domain.MLModel, error) {
+	return r.getOne(ctx, `active = TRUE`)
+}
+
+// FindForVehicle возвращает лучшую готовую модель, специализированную для марки carMake:
+// сначала обученные на ту же модель автомобиля, затем на марку целиком (car_model не задан).
+// Среди равных побеждает модель с большим mAP, затем более новая. Если подходящих нет,
+// возвращает ErrNotFound
+func (r *ModelRepository) FindForVehicle(ctx context.Context, carMake string, carModel *string) (*domain.MLModel, error) {
+	m, err := scanModel(r.db.QueryRowContext(ctx, `
+		SELECT `+modelColumns+` FROM models
+		WHERE status IN ('ready', 'active')
+		  AND lower(car_make) = lower($1)
+		  AND (car_model IS NULL OR lower(car_model) = lower($2))
+		ORDER BY car_model IS NOT NULL DESC,
+		         COALESCE(metrics_json->>'map', metrics_json->>'mAP')::float8 DESC NULLS LAST,
+		         created_at DESC
+		LIMIT 1`,
+		carMake, carModel))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find model for %s: %w", carMake, err)
+	}
+	return m, nil
+}
+
+// List возвращает модели, начиная с новых
+func (r *ModelRepository) List(ctx context.Context, status *domain.ModelStatus, limit, offset int) ([]domain.MLModel, error) {
+	var w whereBuilder
+	if status != nil {
+		w.add("status = ?", *status)
+	}
+	query := `SELECT ` + modelColumns + ` FROM models ` + w.sql() +
+		` ORDER BY created_at DESC, id LIMIT ` + w.arg(limit) + ` OFFSET ` + w.arg(offset)
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("list models: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.MLModel
+	for rows.Next() {
+		m, err := scanModel(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *m)
+	}
+	return items, rows.Err()
+}
+
+// Create регистрирует модель и заполняет ID и временные метки.
+// Родитель не может быть потомком модели (триггер check_models_lineage_acyclic)
+func (r *ModelRepository) Create(ctx context.Context, q Querier, m *domain.MLModel) error {
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO models (version, name, weights_path, config_path, car_make, car_model,
+		                    status, active, metrics_json, parent_model_id, trained_at, description, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, FALSE, $8, $9, $10, $11, $12)
+		RETURNING id, created_at, updated_at`,
+		m.Version, m.Name, m.WeightsPath, m.ConfigPath, m.CarMake, m.CarModel,
+		m.Status, m.Metrics, m.ParentModelID, m.TrainedAt, m.Description, m.CreatedBy,
+	).Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
+	switch {
+	case isUniqueViolation(err):
+		return domain.Conflictf("model version %q already exists", m.Version)
+	case isForeignKeyViolation(err):
+		return domain.InvalidInputf("parent model %s not found", m.ParentModelID)
+	case isCheckViolation(err, "chk_models_lineage_acyclic"):
+		return domain.Conflictf("model lineage would contain a cycle")
+	case err != nil:
+		return fmt.Errorf("create model: %w", err)
+	}
+	return nil
+}
+
+// UpdateMetrics заменяет метрики модели
+func (r *ModelRepository) UpdateMetrics(ctx context.Context, q Querier, id uuid.UUID, metrics *domain.ModelMetrics) error {
+	res, err := q.ExecContext(ctx, `UPDATE models SET metrics_json = $2 WHERE id = $1`, id, metrics)
+	if err != nil {
+		return fmt.Errorf("update metrics of model %s: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Ancestors возвращает предков модели от родителя к корню. Обход останавливается
+// на первом повторе, поэтому замкнутая цепочка (записанная в обход триггера) не зацикливает запрос
+func (r *ModelRepository) Ancestors(ctx context.Context, id uuid.UUID) ([]domain.MLModel, error) {
+	return r.lineage(ctx, `
+		WITH RECURSIVE chain(model_id, next_id, depth, path) AS (
+			SELECT id, parent_model_id, 0, ARRAY[id] FROM models WHERE id = $1
+			UNION ALL
+			SELECT m.id, m.parent_model_id, c.depth + 1, c.path || m.id
+			FROM chain c
+			JOIN models m ON m.id = c.next_id
+			WHERE NOT m.id = ANY(c.path)
+		)
+		SELECT `+modelColumns+`
+		FROM chain JOIN models ON models.id = chain.model_id
+		WHERE chain.depth > 0
+		ORDER BY chain.depth`, id)
+}
+
+// Descendants возвращает всех потомков модели по уровням, внутри уровня - по времени создания
+func (r *ModelRepository) Descendants(ctx context.Context, id uuid.UUID) ([]domain.MLModel, error) {
+	return r.lineage(ctx, `
+		WITH RECURSIVE tree(model_id, depth, path) AS (
+			SELECT id, 0, ARRAY[id] FROM models WHERE id = $1
+			UNION ALL
+			SELECT m.id, t.depth + 1, t.path || m.id
+			FROM tree t
+			JOIN models m ON m.parent_model_id = t.model_id
+			WHERE NOT m.id = ANY(t.path)
+		)
+		SELECT `+modelColumns+`
+		FROM tree JOIN models ON models.id = tree.model_id
+		WHERE tree.depth > 0
+		ORDER BY tree.depth, models.created_at, models.id`, id)
+}
+
+func (r *ModelRepository) lineage(ctx context.Context, query string, id uuid.UUID) ([]domain.MLModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("model lineage %s: %w", id, err)
+	}
+	defer rows.Close()
+
+	var items []domain.MLModel
+	for rows.Next() {
+		m, err := scanModel(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *m)
+	}
+	return items, rows.Err()
+}
+
+// modelRegistryLockKey - ключ advisory-блокировки, сериализующей переключения активной модели
+const modelRegistryLockKey int64 = 150041
+
+// LockRegistry блокирует переключение активной модели до конца транзакции tx
+func (r *ModelRepository) LockRegistry(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, modelRegistryLockKey); err != nil {
+		return fmt.Errorf("lock model registry: %w", err)
+	}
+	return nil
+}
+
+// GetForUpdate возвращает модель, блокируя строку до конца транзакции
+func (r *ModelRepository) GetForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*domain.MLModel, error) {
+	m, err := scanModel(tx.QueryRowContext(ctx, `SELECT `+modelColumns+` FROM models WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get model %s for update: %w", id, err)
+	}
+	return m, nil
+}
+
+// GetActiveForUpdate возвращает активную модель, блокируя строку до конца транзакции.
+// Если активной модели нет, возвращает ErrNotFound
+func (r *ModelRepository) GetActiveForUpdate(ctx context.Context, tx *sql.Tx) (*domain.MLModel, error) {
+	m, err := scanModel(tx.QueryRowContext(ctx, `SELECT `+modelColumns+` FROM models WHERE active = TRUE FOR UPDATE`))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get active model for update: %w", err)
+	}
+	return m, nil
+}
+
+// SetActive делает модель активной или снимает с неё активность, согласованно меняя статус:
+// активная модель - active, снятая - ready (её можно продвинуть снова)
+func (r *ModelRepository) SetActive(ctx context.Context, tx *sql.Tx, m *domain.MLModel, active bool) error {
+	status := domain.ModelStatusReady
+	if active {
+		status = domain.ModelStatusActive
+	}
+	err := tx.QueryRowContext(ctx, `
+		UPDATE models SET active = $2, status = $3 WHERE id = $1
+		RETURNING updated_at`, m.ID, active, status).Scan(&m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("set model %s active=%t: %w", m.ID, active, err)
+	}
+	m.Active = active
+	m.Status = status
+	return nil
+}
+
+// promotionColumns - список колонок model_promotions в порядке сканирования scanPromotion
+const promotionColumns = `id, model_id, previous_model_id, action, reason, performed_by, created_at`
+
+func scanPromotion(row rowScanner) (*domain.ModelPromotion, error) {
+	var p domain.ModelPromotion
+	err := row.Scan(&p.ID, &p.ModelID, &p.PreviousModelID, &p.Action, &p.Reason, &p.PerformedBy, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreatePromotion записывает переключение активной модели и заполняет ID и время
+func (r *ModelRepository) CreatePromotion(ctx context.Context, tx *sql.Tx, p *domain.ModelPromotion) error {
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO model_promotions (model_id, previous_model_id, action, reason, performed_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		p.ModelID, p.PreviousModelID, p.Action, p.Reason, p.PerformedBy).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create model promotion: %w", err)
+	}
+	return nil
+}
+
+// LastPromotion возвращает последнее продвижение (не откат) модели.
+// Если модель не продвигалась через реестр, возвращает ErrNotFound
+func (r *ModelRepository) LastPromotion(ctx context.Context, q Querier, modelID uuid.UUID) (*domain.ModelPromotion, error) {
+	if q == nil {
+		q = r.db
+	}
+	p, err := scanPromotion(q.QueryRowContext(ctx, `
+		SELECT `+promotionColumns+` FROM model_promotions
+		WHERE model_id = $1 AND action = 'promote'
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`, modelID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get last promotion of model %s: %w", modelID, err)
+	}
+	return p, nil
+}
+
+// ListPromotions возвращает историю переключений, начиная с последних
+func (r *ModelRepository) ListPromotions(ctx context.Context, limit, offset int) ([]domain.ModelPromotion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+promotionColumns+` FROM model_promotions
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list model promotions: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.ModelPromotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *p)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// organizationColumns - список колонок organizations в порядке сканирования scanOrganization
+const organizationColumns = `
+	id, name, image_retention_days, result_retention_days, created_at, updated_at`
+
+// OrganizationRepository реализует доступ к таблице organizations
+type OrganizationRepository struct {
+	db *sql.DB
+}
+
+// NewOrganizationRepository создаёт репозиторий организаций
+func NewOrganizationRepository(db *sql.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+func scanOrganization(row rowScanner) (*domain.Organization, error) {
+	var o domain.Organization
+	err := row.Scan(&o.ID, &o.Name, &o.ImageRetentionDays, &o.ResultRetentionDays, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// List возвращает все организации по имени
+func (r *OrganizationRepository) List(ctx context.Context) ([]domain.Organization, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+organizationColumns+` FROM organizations ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.Organization
+	for rows.Next() {
+		o, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *o)
+	}
+	return items, rows.Err()
+}
+
+// Create создаёт организацию
+func (r *OrganizationRepository) Create(ctx context.Context, req domain.OrganizationRequest) (*domain.Organization, error) {
+	o, err := scanOrganization(r.db.QueryRowContext(ctx, `
+		INSERT INTO organizations (name, image_retention_days, result_retention_days)
+		VALUES ($1, $2, $3)
+		RETURNING `+organizationColumns,
+		req.Name, req.ImageRetentionDays, req.ResultRetentionDays))
+	if isUniqueViolation(err) {
+		return nil, domain.Conflictf("organization %q already exists", req.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create organization: %w", err)
+	}
+	return o, nil
+}
+
+// Update заменяет имя и сроки хранения организации
+func (r *OrganizationRepository) Update(ctx context.Context, id uuid.UUID, req domain.OrganizationRequest) (*domain.Organization, error) {
+	o, err := scanOrganization(r.db.QueryRowContext(ctx, `
+		UPDATE organizations
+		SET name = $2, image_retention_days = $3, result_retention_days = $4
+		WHERE id = $1
+		RETURNING `+organizationColumns,
+		id, req.Name, req.ImageRetentionDays, req.ResultRetentionDays))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if isUniqueViolation(err) {
+		return nil, domain.Conflictf("organization %q already exists", req.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update organization %s: %w", id, err)
+	}
+	return o, nil
+}
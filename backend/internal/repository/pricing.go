@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// priceCatalogColumns - список колонок price_catalogs в порядке сканирования scanPriceCatalog
+const priceCatalogColumns = `
+	id, region, currency, effective_from,
+	labor_rate, paint_material_rate,
+	created_at, created_by`
+
+// exchangeRateColumns - список колонок exchange_rates в порядке сканирования scanExchangeRate
+const exchangeRateColumns = `base, quote, rate::text, effective_date, created_at`
+
+// PricingRepository реализует доступ к прайс-листам и курсам валют
+type PricingRepository struct {
+	db *sql.DB
+}
+
+// NewPricingRepository создаёт репозиторий цен
+func NewPricingRepository(db *sql.DB) *PricingRepository {
+	return &PricingRepository{db: db}
+}
+
+func scanPriceCatalog(row rowScanner) (*domain.PriceCatalog, error) {
+	var pc domain.PriceCatalog
+	err := row.Scan(
+		&pc.ID, &pc.Region, &pc.Currency, &pc.EffectiveFrom,
+		&pc.LaborRate, &pc.PaintMaterialRate,
+		&pc.CreatedAt, &pc.CreatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pc, nil
+}
+
+func scanExchangeRate(row rowScanner) (*domain.ExchangeRate, error) {
+	var er domain.ExchangeRate
+	if err := row.Scan(&er.Base, &er.Quote, &er.Rate, &er.EffectiveDate, &er.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &er, nil
+}
+
+// CreateCatalog сохраняет прайс-лист вместе с ценами деталей (в транзакции q).
+// Заполняет ID и CreatedAt. Прайс-лист региона на ту же дату - ошибка конфликта
+func (r *PricingRepository) CreateCatalog(ctx context.Context, q Querier, pc *domain.PriceCatalog) error {
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO price_catalogs (region, currency, effective_from, labor_rate, paint_material_rate, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		pc.Region, pc.Currency, pc.EffectiveFrom, pc.LaborRate, pc.PaintMaterialRate, pc.CreatedBy,
+	).Scan(&pc.ID, &pc.CreatedAt)
+	if isUniqueViolation(err) {
+		return domain.Conflictf("price catalog for region %s effective from %s already exists",
+			pc.Region, pc.EffectiveFrom.Format(time.DateOnly))
+	}
+	if err != nil {
+		return fmt.Errorf("create price catalog: %w", err)
+	}
+
+	if len(pc.Items) == 0 {
+		return nil
+	}
+	parts := make([]string, len(pc.Items))
+	prices := make([]int64, len(pc.Items))
+	for i, it := range pc.Items {
+		parts[i], prices[i] = it.PartID, int64(it.PartPrice)
+	}
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO price_catalog_items (catalog_id, part_id, part_price)
+		SELECT $1, p, c FROM unnest($2::text[], $3::bigint[]) AS t(p, c)`,
+		pc.ID, pq.Array(parts), pq.Array(prices))
+	if err != nil {
+		return fmt.Errorf("create price catalog items: %w", err)
+	}
+	return nil
+}
+
+// GetCatalog возвращает прайс-лист с ценами деталей
+func (r *PricingRepository) GetCatalog(ctx context.Context, id uuid.UUID) (*domain.PriceCatalog, error) {
+	return r.getCatalog(ctx, `id = $1`, id)
+}
+
+// CatalogAt возвращает прайс-лист региона, действующий на дату at
+func (r *PricingRepository) CatalogAt(ctx context.Context, region string, at time.Time) (*domain.PriceCatalog, error) {
+	return r.getCatalog(ctx, `region = $1 AND effective_from <= ($2::timestamptz AT TIME ZONE 'UTC')::date
+		ORDER BY effective_from DESC LIMIT 1`, region, at)
+}
+
+func (r *PricingRepository) getCatalog(ctx context.Context, where string, args ...any) (*domain.PriceCatalog, error) {
+	pc, err := scanPriceCatalog(r.db.QueryRowContext(ctx,
+		`SELECT `+priceCatalogColumns+` FROM price_catalogs WHERE `+where, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get price catalog: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT part_id, part_price FROM price_catalog_items WHERE catalog_id = $1 ORDER BY part_id`, pc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list price catalog items: %w", err)
+	}
+	defer rows.Close()
+
+	pc.Items = []domain.PriceCatalogItem{}
+	for rows.Next() {
+		var it domain.PriceCatalogItem
+		if err := rows.Scan(&it.PartID, &it.PartPrice); err != nil {
+			return nil, err
+		}
+		pc.Items = append(pc.Items, it)
+	}
+	return pc, rows.Err()
+}
+
+// ListCatalogs возвращает прайс-листы (без цен деталей), новые первыми
+func (r *PricingRepository) ListCatalogs(ctx context.Context, region *string, limit, offset int) ([]domain.PriceCatalog, error) {
+	var w whereBuilder
+	if region != nil {
+		w.add("region = ?", *region)
+	}
+	query := `SELECT ` + priceCatalogColumns + ` FROM price_catalogs ` + w.sql() +
+		` ORDER BY region, effective_from DESC LIMIT ` + w.arg(limit) + ` OFFSET ` + w.arg(offset)
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("list price catalogs: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.PriceCatalog
+	for rows.Next() {
+		pc, err := scanPriceCatalog(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *pc)
+	}
+	return items, rows.Err()
+}
+
+// UpsertRate устанавливает курс пары на дату
+func (r *PricingRepository) UpsertRate(ctx context.Context, base, quote domain.Currency, rate string, date time.Time) (*domain.ExchangeRate, error) {
+	er, err := scanExchangeRate(r.db.QueryRowContext(ctx, `
+		INSERT INTO exchange_rates (base, quote, rate, effective_date)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (base, quote, effective_date) DO UPDATE
+		SET rate = EXCLUDED.rate, created_at = CURRENT_TIMESTAMP
+		RETURNING `+exchangeRateColumns,
+		base, quote, rate, date))
+	if err != nil {
+		return nil, fmt.Errorf("upsert exchange rate: %w", err)
+	}
+	return er, nil
+}
+
+// RateAt возвращает курс пары base/quote, действующий на дату at
+func (r *PricingRepository) RateAt(ctx context.Context, base, quote domain.Currency, at time.Time) (*domain.ExchangeRate, error) {
+	er, err := scanExchangeRate(r.db.QueryRowContext(ctx, `
+		SELECT `+exchangeRateColumns+` FROM exchange_rates
+		WHERE base = $1 AND quote = $2 AND effective_date <= ($3::timestamptz AT TIME ZONE 'UTC')::date
+		ORDER BY effective_date DESC
+		LIMIT 1`,
+		base, quote, at))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get exchange rate: %w", err)
+	}
+	return er, nil
+}
+
+// ListRates возвращает историю курсов, новые первыми. Фильтр по валюте - base или quote
+func (r *PricingRepository) ListRates(ctx context.Context, currency *domain.Currency, limit, offset int) ([]domain.ExchangeRate, error) {
+	var w whereBuilder
+	if currency != nil {
+		w.add("(base = ? OR quote = ?)", *currency, *currency)
+	}
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates ` + w.sql() +
+		` ORDER BY effective_date DESC, base, quote LIMIT ` + w.arg(limit) + ` OFFSET ` + w.arg(offset)
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("list exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.ExchangeRate
+	for rows.Next() {
+		er, err := scanExchangeRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *er)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Querier - общий интерфейс *sql.DB и *sql.Tx. Методы репозиториев, принимающие Querier,
+// можно вызывать как отдельно, так и внутри транзакции (database.WithTx)
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// whereBuilder собирает условие WHERE с позиционными параметрами $1, $2, ...
+type whereBuilder struct {
+	conds []string
+	args  []any
+}
+
+// add добавляет условие. Плейсхолдер "?" в cond заменяется на очередной $N
+func (w *whereBuilder) add(cond string, args ...any) {
+	for _, a := range args {
+		w.args = append(w.args, a)
+		cond = strings.Replace(cond, "?", fmt.Sprintf("$%d", len(w.args)), 1)
+	}
+	w.conds = append(w.conds, cond)
+}
+
+// arg добавляет параметр без условия и возвращает его плейсхолдер (для LIMIT, ORDER BY и т.п.)
+func (w *whereBuilder) arg(v any) string {
+	w.args = append(w.args, v)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+// sql возвращает "WHERE ..." или пустую строку
+func (w *whereBuilder) sql() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(w.conds, " AND ")
+}
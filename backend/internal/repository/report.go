@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// SLAReport считает p50/p95/p99 ожидания в очереди и обработки завершённых анализов
+// по версии модели и дню завершения, а также итог по версии за весь период (GROUPING SETS)
+func (r *AnalysisRepository) SLAReport(ctx context.Context, f domain.SLAReportFilter) ([]domain.SLAReportRow, error) {
+	var w whereBuilder
+	w.add("status = 'completed'")
+	w.add("completed_at >= ?", f.From)
+	w.add("completed_at < ?", f.To)
+	if f.ModelVersion != nil {
+		w.add("model_version = ?", *f.ModelVersion)
+	}
+
+	query := `
+		SELECT model_version,
+		       (completed_at AT TIME ZONE 'UTC')::date AS day,
+		       COUNT(*),
+		       percentile_cont(0.50) WITHIN GROUP (ORDER BY queue_wait_time_ms),
+		       percentile_cont(0.95) WITHIN GROUP (ORDER BY queue_wait_time_ms),
+		       percentile_cont(0.99) WITHIN GROUP (ORDER BY queue_wait_time_ms),
+		       percentile_cont(0.50) WITHIN GROUP (ORDER BY processing_time_ms),
+		       percentile_cont(0.95) WITHIN GROUP (ORDER BY processing_time_ms),
+		       percentile_cont(0.99) WITHIN GROUP (ORDER BY processing_time_ms)
+		FROM analyses
+		` + w.sql() + `
+		GROUP BY GROUPING SETS ((model_version, (completed_at AT TIME ZONE 'UTC')::date), (model_version))
+		ORDER BY day NULLS LAST, model_version`
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("sla report: %w", err)
+	}
+	defer rows.Close()
+
+	var report []domain.SLAReportRow
+	for rows.Next() {
+		var row domain.SLAReportRow
+		q, p := &row.QueueWaitMs, &row.ProcessingMs
+		if err := rows.Scan(&row.ModelVersion, &row.Day, &row.Count,
+			&q.P50, &q.P95, &q.P99, &p.P50, &p.P95, &p.P99); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// imageRetentionDays - срок хранения изображения анализа a пользователя u из организации o.
+// $1, $2 - сроки по умолчанию для изображений и результатов, 0 - хранить бессрочно.
+// Изображение удаляется не позже обезличивания: по нему можно узнать автомобиль и место
+const imageRetentionDays = `LEAST(NULLIF(COALESCE(o.image_retention_days, $1), 0),
+	NULLIF(COALESCE(o.result_retention_days, $2), 0))`
+
+// retentionScope - анализы, к которым применяется политика хранения (для подзапросов по a)
+const retentionScope = `
+	FROM analyses a
+	JOIN users u ON u.id = a.user_id
+	LEFT JOIN organizations o ON o.id = u.organization_id
+	WHERE a.status IN ('completed', 'failed', 'cancelled')`
+
+// ExpiredImage - изображение анализа, срок хранения которого истёк
+type ExpiredImage struct {
+	AnalysisID  uuid.UUID
+	Key         string
+	RedactedKey *string // копия со скрытыми номерами и лицами
+}
+
+// UserData - данные пользователя, удаляемые вместе с ним
+type UserData struct {
+	AnalysisIDs []uuid.UUID
+	DatasetIDs  []uuid.UUID
+	ObjectKeys  []string // изображения анализов (с копиями без номеров и лиц) и архивы датасетов, без повторов
+}
+
+// RetentionRepository реализует применение политики хранения и удаление данных пользователей
+type RetentionRepository struct {
+	db *sql.DB
+}
+
+// NewRetentionRepository создаёт репозиторий политики хранения
+func NewRetentionRepository(db *sql.DB) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+// ClaimExpiredImages блокирует до limit анализов с просроченными изображениями.
+// Если тот же объект используют более поздние анализы с неудалённым изображением,
+// он будет удалён вместе с последним из них. SKIP LOCKED позволяет нескольким
+// воркерам применять политику параллельно
+func (r *RetentionRepository) ClaimExpiredImages(ctx context.Context, tx *sql.Tx, imageDays, resultDays, limit int) ([]ExpiredImage, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT a.id, a.image_key, a.redacted_image_key`+retentionScope+`
+		  AND a.image_deleted_at IS NULL
+		  AND a.created_at < CURRENT_TIMESTAMP - make_interval(days => `+imageRetentionDays+`)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM analyses b
+		      WHERE b.image_key = a.image_key AND b.image_deleted_at IS NULL AND b.created_at > a.created_at)
+		ORDER BY a.created_at
+		LIMIT $3
+		FOR UPDATE OF a SKIP LOCKED`,
+		imageDays, resultDays, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim expired images: %w", err)
+	}
+	defer rows.Close()
+
+	var items []ExpiredImage
+	for rows.Next() {
+		var img ExpiredImage
+		if err := rows.Scan(&img.AnalysisID, &img.Key, &img.RedactedKey); err != nil {
+			return nil, err
+		}
+		items = append(items, img)
+	}
+	return items, rows.Err()
+}
+
+// MarkImagesDeleted отмечает удалёнными изображения всех анализов с указанными ключами
+func (r *RetentionRepository) MarkImagesDeleted(ctx context.Context, q Querier, keys []string) (int, error) {
+	res, err := q.ExecContext(ctx, `
+		UPDATE analyses SET image_deleted_at = CURRENT_TIMESTAMP
+		WHERE image_key = ANY($1) AND image_deleted_at IS NULL`, pq.Array(keys))
+	if err != nil {
+		return 0, fmt.Errorf("mark images deleted: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// AnonymizeExpiredResults обезличивает до limit анализов с истёкшим сроком хранения результатов
+// (resultDays - срок по умолчанию, 0 - бессрочно):
+// стирает EXIF и размеры изображения, место происшествия, перцептивный хэш, причины
+// антифрод-оценки и адрес webhook. Дефекты, марка и модель остаются для аналитики.
+// Возвращает число обезличенных анализов
+func (r *RetentionRepository) AnonymizeExpiredResults(ctx context.Context, resultDays, limit int) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE analyses
+		SET image_metadata = NULL, incident_location = NULL, image_phash = NULL,
+		    fraud_reasons = NULL, webhook_url = NULL, anonymized_at = CURRENT_TIMESTAMP
+		WHERE id IN (
+		    SELECT a.id`+retentionScope+`
+		      AND a.anonymized_at IS NULL
+		      AND a.created_at < CURRENT_TIMESTAMP
+		          - make_interval(days => NULLIF(COALESCE(o.result_retention_days, $1), 0))
+		    ORDER BY a.created_at
+		    LIMIT $2
+		    FOR UPDATE OF a SKIP LOCKED)`,
+		resultDays, limit)
+	if err != nil {
+		return 0, fmt.Errorf("anonymize expired results: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// AddPendingDeletions записывает объекты хранилища, которые нужно удалить после фиксации
+// транзакции q. Уже записанные ключи пропускаются
+func (r *RetentionRepository) AddPendingDeletions(ctx context.Context, q Querier, keys []string, reason string) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO pending_object_deletions (object_key, reason)
+		SELECT k, $2 FROM unnest($1::text[]) AS k
+		ON CONFLICT (object_key) DO NOTHING`,
+		pq.Array(keys), reason)
+	if err != nil {
+		return fmt.Errorf("add pending deletions: %w", err)
+	}
+	return nil
+}
+
+// ListPendingDeletions возвращает до limit ключей, ожидающих удаления, начиная с давно неудачных
+func (r *RetentionRepository) ListPendingDeletions(ctx context.Context, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT object_key FROM pending_object_deletions
+		ORDER BY updated_at, object_key
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list pending deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// CompletePendingDeletion снимает ключ с удаления после того, как объект удалён
+func (r *RetentionRepository) CompletePendingDeletion(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM pending_object_deletions WHERE object_key = $1`, key); err != nil {
+		return fmt.Errorf("complete pending deletion %s: %w", key, err)
+	}
+	return nil
+}
+
+// FailPendingDeletion записывает неудачную попытку удаления объекта
+func (r *RetentionRepository) FailPendingDeletion(ctx context.Context, key, msg string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE pending_object_deletions SET attempts = attempts + 1, last_error = $2
+		WHERE object_key = $1`, key, msg)
+	if err != nil {
+		return fmt.Errorf("fail pending deletion %s: %w", key, err)
+	}
+	return nil
+}
+
+// LockUser блокирует строку пользователя до конца транзакции: пока она заблокирована,
+// новые анализы и датасеты пользователя не создаются
+func (r *RetentionRepository) LockUser(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	var id uuid.UUID
+	err := tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("lock user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// CollectUserData возвращает анализы, датасеты и объекты хранилища пользователя.
+// Если q == nil, используется пул соединений
+func (r *RetentionRepository) CollectUserData(ctx context.Context, q Querier, userID uuid.UUID) (*UserData, error) {
+	if q == nil {
+		q = r.db
+	}
+	var data UserData
+	err := q.QueryRowContext(ctx, `
+		SELECT
+		    COALESCE((SELECT array_agg(id ORDER BY id) FROM analyses WHERE user_id = $1), '{}'),
+		    COALESCE((SELECT array_agg(id ORDER BY id) FROM datasets WHERE owner_id = $1), '{}'),
+		    COALESCE((SELECT array_agg(DISTINCT k ORDER BY k) FROM (
+		        SELECT image_key AS k FROM analyses WHERE user_id = $1
+		        UNION
+		        SELECT redacted_image_key FROM analyses WHERE user_id = $1 AND redacted_image_key IS NOT NULL
+		        UNION
+		        SELECT file_key FROM datasets WHERE owner_id = $1 AND file_key IS NOT NULL) keys), '{}')`,
+		userID).Scan(pq.Array(&data.AnalysisIDs), pq.Array(&data.DatasetIDs), pq.Array(&data.ObjectKeys))
+	if err != nil {
+		return nil, fmt.Errorf("collect user data %s: %w", userID, err)
+	}
+	return &data, nil
+}
+
+// PseudonymizeAuditLogs заменяет пользователя в журнале аудита псевдонимом и стирает
+// адрес и клиент запроса. Возвращает число изменённых записей
+func (r *RetentionRepository) PseudonymizeAuditLogs(ctx context.Context, tx *sql.Tx, userID uuid.UUID, pseudonym string) (int, error) {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE audit_logs
+		SET user_id = NULL, actor_pseudonym = $2, ip_address = NULL, user_agent = NULL
+		WHERE user_id = $1`, userID, pseudonym)
+	if err != nil {
+		return 0, fmt.Errorf("pseudonymize audit logs: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// DeleteUser удаляет пользователя. Анализы, датасеты, пакеты и webhook удаляются каскадно;
+// ссылки на него из моделей, проверок и прайс-листов обнуляются
+func (r *RetentionRepository) DeleteUser(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE models SET created_by = NULL WHERE created_by = $1`, userID); err != nil {
+		return fmt.Errorf("detach user models: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("delete user %s: %w", userID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CreateErasure сохраняет подтверждение удаления и заполняет ID и время завершения
+func (r *RetentionRepository) CreateErasure(ctx context.Context, tx *sql.Tx, e *domain.UserErasure) error {
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO user_erasures (subject_pseudonym, requested_by, reason,
+		                           analyses_deleted, datasets_deleted, objects_deleted,
+		                           audit_logs_pseudonymized, manifest_sha256, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, completed_at`,
+		e.SubjectPseudonym, e.RequestedBy, e.Reason,
+		e.AnalysesDeleted, e.DatasetsDeleted, e.ObjectsDeleted,
+		e.AuditLogsPseudonymized, e.ManifestSHA256, e.StartedAt,
+	).Scan(&e.ID, &e.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("create user erasure: %w", err)
+	}
+	return nil
+}
+
+// ListErasures возвращает подтверждения удаления по псевдониму субъекта
+func (r *RetentionRepository) ListErasures(ctx context.Context, pseudonym string) ([]domain.UserErasure, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, subject_pseudonym, requested_by, reason,
+		       analyses_deleted, datasets_deleted, objects_deleted,
+		       audit_logs_pseudonymized, manifest_sha256, started_at, completed_at
+		FROM user_erasures WHERE subject_pseudonym = $1
+		ORDER BY completed_at`, pseudonym)
+	if err != nil {
+		return nil, fmt.Errorf("list user erasures: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.UserErasure
+	for rows.Next() {
+		var e domain.UserErasure
+		if err := rows.Scan(&e.ID, &e.SubjectPseudonym, &e.RequestedBy, &e.Reason,
+			&e.AnalysesDeleted, &e.DatasetsDeleted, &e.ObjectsDeleted,
+			&e.AuditLogsPseudonymized, &e.ManifestSHA256, &e.StartedAt, &e.CompletedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	return items, rows.Err()
+}
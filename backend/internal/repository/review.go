@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ReviewRepository реализует доступ к таблице analysis_reviews
+type ReviewRepository struct {
+	db *sql.DB
+}
+
+// NewReviewRepository создаёт репозиторий проверок
+func NewReviewRepository(db *sql.DB) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+// Create сохраняет проверку и заполняет ID и created_at
+func (r *ReviewRepository) Create(ctx context.Context, q Querier, rv *domain.AnalysisReview) error {
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO analysis_reviews (analysis_id, reviewer_id, result_version, operations_json, result_json, comment)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		rv.AnalysisID, rv.ReviewerID, rv.ResultVersion, rv.Operations, rv.Result, rv.Comment,
+	).Scan(&rv.ID, &rv.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create review: %w", err)
+	}
+	return nil
+}
+
+// ListByAnalysis возвращает историю проверок анализа по возрастанию версии
+func (r *ReviewRepository) ListByAnalysis(ctx context.Context, analysisID uuid.UUID) ([]domain.AnalysisReview, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, analysis_id, reviewer_id, result_version, operations_json, result_json, comment, created_at
+		FROM analysis_reviews
+		WHERE analysis_id = $1
+		ORDER BY result_version`,
+		analysisID)
+	if err != nil {
+		return nil, fmt.Errorf("list reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []domain.AnalysisReview
+	for rows.Next() {
+		var rv domain.AnalysisReview
+		if err := rows.Scan(&rv.ID, &rv.AnalysisID, &rv.ReviewerID, &rv.ResultVersion,
+			&rv.Operations, &rv.Result, &rv.Comment, &rv.CreatedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, rv)
+	}
+	return reviews, rows.Err()
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/lib/pq"
+)
+
+// searchOrder - выражения ORDER BY для порядков сортировки поиска
+var searchOrder = map[domain.AnalysisSort]string{
+	domain.AnalysisSortNewest:       "created_at DESC, id DESC",
+	domain.AnalysisSortOldest:       "created_at, id",
+	domain.AnalysisSortMostDefects:  "(result_json->'summary'->>'total_defects')::int DESC NULLS LAST, created_at DESC, id DESC",
+	domain.AnalysisSortFewestDefect: "(result_json->'summary'->>'total_defects')::int NULLS LAST, created_at DESC, id DESC",
+}
+
+// Search ищет завершённые анализы по дефектам результата и возвращает страницу
+// и общее число найденных.
+//
+// Равенства по полям дефекта и ракурсу передаются как JSONB-включение (@>), которое
+// обслуживается GIN-индексом idx_analyses_result_json. Включение с одним элементом массива
+// требует, чтобы все поля совпали в одном дефекте. Порог уверенности не выражается через
+// включение, поэтому дополнительно проверяется jsonpath-выражением по тому же дефекту:
+// индекс сужает выборку, jsonpath перепроверяет только подходящие строки
+func (r *AnalysisRepository) Search(ctx context.Context, f domain.AnalysisSearchFilter) ([]domain.Analysis, int, error) {
+	var w whereBuilder
+	w.add("status = 'completed'")
+	w.add("result_json IS NOT NULL")
+
+	if f.UserID != nil {
+		w.add("user_id = ?", *f.UserID)
+	}
+	if len(f.ModelVersions) > 0 {
+		w.add("model_version = ANY(?)", pq.Array(f.ModelVersions))
+	}
+	if f.CarMake != nil {
+		w.add("lower(car_make) = lower(?)", *f.CarMake)
+	}
+	if f.From != nil {
+		w.add("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		w.add("created_at < ?", *f.To)
+	}
+
+	contains := map[string]any{}
+	if f.ViewAngle != nil {
+		contains["view_angle"] = *f.ViewAngle
+	}
+
+	defect := map[string]any{}
+	if f.DefectType != nil {
+		defect["defect_type"] = *f.DefectType
+	}
+	if f.PartID != nil {
+		defect["part_id"] = *f.PartID
+	}
+	if f.Severity != nil {
+		defect["severity"] = *f.Severity
+	}
+	if len(defect) > 0 {
+		contains["defects"] = []any{defect}
+	}
+	if len(contains) > 0 {
+		doc, err := json.Marshal(contains)
+		if err != nil {
+			return nil, 0, err
+		}
+		w.add("result_json @> ?::jsonb", string(doc))
+	}
+
+	if f.MinConfidence != nil {
+		path, vars, err := defectPath(defect, *f.MinConfidence)
+		if err != nil {
+			return nil, 0, err
+		}
+		w.add("jsonb_path_exists(result_json, ?::jsonpath, ?::jsonb)", path, vars)
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM analyses `+w.sql(), w.args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count search results: %w", err)
+	}
+	if total == 0 || f.Offset >= total {
+		return nil, total, nil
+	}
+
+	order, ok := searchOrder[f.Sort]
+	if !ok {
+		order = searchOrder[domain.AnalysisSortNewest]
+	}
+	query := `SELECT ` + analysisColumns + ` FROM analyses ` + w.sql() +
+		` ORDER BY ` + order + ` LIMIT ` + w.arg(f.Limit) + ` OFFSET ` + w.arg(f.Offset)
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search analyses: %w", err)
+	}
+	analyses, err := scanAnalyses(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return analyses, total, nil
+}
+
+// defectPath строит jsonpath "есть дефект с уверенностью не ниже порога и заданными полями".
+// Значения передаются через переменные jsonpath, в текст выражения попадают только имена полей
+func defectPath(defect map[string]any, minConfidence float64) (string, string, error) {
+	conds := []string{"@.confidence >= $min_confidence"}
+	vars := map[string]any{"min_confidence": minConfidence}
+	for _, field := range []string{"defect_type", "part_id", "severity"} {
+		if v, ok := defect[field]; ok {
+			conds = append(conds, fmt.Sprintf("@.%s == $%s", field, field))
+			vars[field] = v
+		}
+	}
+
+	doc, err := json.Marshal(vars)
+	if err != nil {
+		return "", "", err
+	}
+	return "$.defects[*] ? (" + strings.Join(conds, " && ") + ")", string(doc), nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// shadowDeploymentColumns - список колонок shadow_deployments (d) и версии модели (m)
+// в порядке сканирования scanShadowDeployment
+const shadowDeploymentColumns = `
+	d.id, d.model_id, m.version, d.percent::float8, d.enabled, d.created_by, d.created_at, d.updated_at`
+
+const shadowDeploymentFrom = ` FROM shadow_deployments d JOIN models m ON m.id = d.model_id `
+
+// ShadowRepository реализует доступ к таблицам shadow_deployments и shadow_results
+type ShadowRepository struct {
+	db *sql.DB
+}
+
+// NewShadowRepository создаёт репозиторий теневого режима
+func NewShadowRepository(db *sql.DB) *ShadowRepository {
+	return &ShadowRepository{db: db}
+}
+
+func scanShadowDeployment(row rowScanner) (*domain.ShadowDeployment, error) {
+	var d domain.ShadowDeployment
+	err := row.Scan(&d.ID, &d.ModelID, &d.ModelVersion, &d.Percent, &d.Enabled, &d.CreatedBy, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *ShadowRepository) list(ctx context.Context, query string, args ...any) ([]domain.ShadowDeployment, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list shadow deployments: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.ShadowDeployment
+	for rows.Next() {
+		d, err := scanShadowDeployment(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *d)
+	}
+	return items, rows.Err()
+}
+
+// List возвращает теневые развёртывания, начиная с новых
+func (r *ShadowRepository) List(ctx context.Context, limit, offset int) ([]domain.ShadowDeployment, error) {
+	return r.list(ctx, `SELECT `+shadowDeploymentColumns+shadowDeploymentFrom+
+		`ORDER BY d.created_at DESC, d.id LIMIT $1 OFFSET $2`, limit, offset)
+}
+
+// ListEnabled возвращает включённые теневые развёртывания готовых моделей
+func (r *ShadowRepository) ListEnabled(ctx context.Context) ([]domain.ShadowDeployment, error) {
+	return r.list(ctx, `SELECT `+shadowDeploymentColumns+shadowDeploymentFrom+
+		`WHERE d.enabled AND m.status IN ('ready', 'active') ORDER BY d.created_at, d.id`)
+}
+
+// GetByID возвращает теневое развёртывание по идентификатору
+func (r *ShadowRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ShadowDeployment, error) {
+	d, err := scanShadowDeployment(r.db.QueryRowContext(ctx,
+		`SELECT `+shadowDeploymentColumns+shadowDeploymentFrom+`WHERE d.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get shadow deployment %s: %w", id, err)
+	}
+	return d, nil
+}
+
+// Create создаёт включённое теневое развёртывание и заполняет ID и временные метки
+func (r *ShadowRepository) Create(ctx context.Context, q Querier, d *domain.ShadowDeployment) error {
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO shadow_deployments (model_id, percent, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, enabled, created_at, updated_at`,
+		d.ModelID, d.Percent, d.CreatedBy,
+	).Scan(&d.ID, &d.Enabled, &d.CreatedAt, &d.UpdatedAt)
+	if isUniqueViolation(err) {
+		return domain.Conflictf("model %s already has an enabled shadow deployment", d.ModelVersion)
+	}
+	if err != nil {
+		return fmt.Errorf("create shadow deployment: %w", err)
+	}
+	return nil
+}
+
+// Update меняет долю и (или) включённость теневого развёртывания
+func (r *ShadowRepository) Update(ctx context.Context, q Querier, id uuid.UUID, req domain.ShadowDeploymentUpdateRequest) error {
+	res, err := q.ExecContext(ctx, `
+		UPDATE shadow_deployments
+		SET percent = COALESCE($2, percent), enabled = COALESCE($3, enabled)
+		WHERE id = $1`,
+		id, req.Percent, req.Enabled)
+	if isUniqueViolation(err) {
+		return domain.Conflictf("model already has an enabled shadow deployment")
+	}
+	if err != nil {
+		return fmt.Errorf("update shadow deployment %s: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SaveResult записывает теневой результат анализа, заменяя результат прошлой попытки
+func (r *ShadowRepository) SaveResult(ctx context.Context, sr *domain.ShadowResult) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO shadow_results (deployment_id, analysis_id, model_version, primary_version,
+		                            result_json, error_message, processing_time_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (deployment_id, analysis_id) DO UPDATE
+		SET model_version = EXCLUDED.model_version, primary_version = EXCLUDED.primary_version,
+		    result_json = EXCLUDED.result_json, error_message = EXCLUDED.error_message,
+		    processing_time_ms = EXCLUDED.processing_time_ms, created_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at`,
+		sr.DeploymentID, sr.AnalysisID, sr.ModelVersion, sr.PrimaryVersion,
+		sr.Result, sr.ErrorMessage, sr.ProcessingTimeMs,
+	).Scan(&sr.ID, &sr.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("save shadow result of analysis %s: %w", sr.AnalysisID, err)
+	}
+	return nil
+}
+
+// ForEachComparison передаёт в fn выход активной модели (до ручной проверки) и теневой
+// результат для каждого завершённого анализа развёртывания за период [from, to).
+// Shadow равен nil, если инференс кандидата завершился ошибкой
+func (r *ShadowRepository) ForEachComparison(ctx context.Context, deploymentID uuid.UUID, from, to *time.Time,
+	fn func(primary, shadow *domain.AnalysisResult) error) error {
+	var w whereBuilder
+	w.add("s.deployment_id = ?", deploymentID)
+	w.add("a.status = 'completed'")
+	if from != nil {
+		w.add("s.created_at >= ?", *from)
+	}
+	if to != nil {
+		w.add("s.created_at < ?", *to)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT COALESCE(a.original_result_json, a.result_json), s.result_json
+		FROM shadow_results s
+		JOIN analyses a ON a.id = s.analysis_id
+		`+w.sql()+`
+		ORDER BY s.created_at, s.id`,
+		w.args...)
+	if err != nil {
+		return fmt.Errorf("shadow comparison %s: %w", deploymentID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var primary, shadow *domain.AnalysisResult
+		if err := rows.Scan(&primary, &shadow); err != nil {
+			return err
+		}
+		if primary == nil {
+			continue
+		}
+		if err := fn(primary, shadow); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
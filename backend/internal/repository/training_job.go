@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// trainingJobColumns - список колонок training_jobs в порядке сканирования scanTrainingJob
+const trainingJobColumns = `
+	id, dataset_id, base_model_id, status, params_json, result_model_id,
+	logs_path, metrics_json, created_at, started_at, completed_at,
+	worker_id, error_message, webhook_url, notified_at`
+
+// TrainingJobRepository реализует доступ к таблице training_jobs
+type TrainingJobRepository struct {
+	db *sql.DB
+}
+
+// NewTrainingJobRepository создаёт репозиторий задач обучения
+func NewTrainingJobRepository(db *sql.DB) *TrainingJobRepository {
+	return &TrainingJobRepository{db: db}
+}
+
+func scanTrainingJob(row rowScanner) (*domain.TrainingJob, error) {
+	var j domain.TrainingJob
+	err := row.Scan(
+		&j.ID, &j.DatasetID, &j.BaseModelID, &j.Status, &j.Params, &j.ResultModelID,
+		&j.LogsPath, &j.Metrics, &j.CreatedAt, &j.StartedAt, &j.CompletedAt,
+		&j.WorkerID, &j.ErrorMessage, &j.WebhookURL, &j.NotifiedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// ByResultModels возвращает для каждой из моделей задачу обучения, которая её создала.
+// Если задач несколько, берётся последняя завершённая
+func (r *TrainingJobRepository) ByResultModels(ctx context.Context, modelIDs []uuid.UUID) (map[uuid.UUID]*domain.TrainingJob, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (result_model_id) `+trainingJobColumns+`
+		FROM training_jobs
+		WHERE result_model_id = ANY($1::uuid[])
+		ORDER BY result_model_id, completed_at DESC NULLS LAST, created_at DESC`,
+		pq.Array(modelIDs))
+	if err != nil {
+		return nil, fmt.Errorf("training jobs by result models: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make(map[uuid.UUID]*domain.TrainingJob)
+	for rows.Next() {
+		j, err := scanTrainingJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs[*j.ResultModelID] = j
+	}
+	return jobs, rows.Err()
+}
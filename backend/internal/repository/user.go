@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// UserRepository реализует доступ к таблице users
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository создаёт репозиторий пользователей
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// GetByID возвращает пользователя по идентификатору
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	var u domain.User
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, username, email, password_hash, role,
+		       COALESCE(email_verified, FALSE), COALESCE(is_active, TRUE), organization_id, language,
+		       created_at, updated_at, last_login,
+		       COALESCE(api_calls_count, 0), api_quota_reset_at
+		FROM users WHERE id = $1`, id).Scan(
+		&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.Role,
+		&u.EmailVerified, &u.IsActive, &u.OrganizationID, &u.Language,
+		&u.CreatedAt, &u.UpdatedAt, &u.LastLogin,
+		&u.APICallsCount, &u.APIQuotaResetAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get user %s: %w", id, err)
+	}
+	return &u, nil
+}
+
+// SetLanguage сохраняет язык интерфейса пользователя (nil - сбросить)
+func (r *UserRepository) SetLanguage(ctx context.Context, id uuid.UUID, language *string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET language = $2 WHERE id = $1`, id, language)
+	if err != nil {
+		return fmt.Errorf("set user language: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetOrganization переводит пользователя в организацию (nil - исключить из организации)
+func (r *UserRepository) SetOrganization(ctx context.Context, id uuid.UUID, orgID *uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET organization_id = $2 WHERE id = $1`, id, orgID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return domain.InvalidInputf("organization %s not found", orgID)
+		}
+		return fmt.Errorf("set user organization: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
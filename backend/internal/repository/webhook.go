@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// webhookDeliveryColumns - список колонок webhook_deliveries в порядке сканирования scanWebhookDelivery
+const webhookDeliveryColumns = `
+	id, analysis_id, batch_id, user_id, redelivery_of, event, url, payload, status,
+	attempt_count, next_attempt_at, last_error, last_response_code,
+	created_at, updated_at, delivered_at`
+
+// WebhookRepository реализует доступ к таблицам webhook_endpoints, webhook_deliveries
+// и webhook_delivery_attempts
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository создаёт репозиторий webhook
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func scanWebhookDelivery(row rowScanner) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	var payload []byte
+	err := row.Scan(
+		&d.ID, &d.AnalysisID, &d.BatchID, &d.UserID, &d.RedeliveryOf, &d.Event, &d.URL, &payload, &d.Status,
+		&d.AttemptCount, &d.NextAttemptAt, &d.LastError, &d.LastResponseCode,
+		&d.CreatedAt, &d.UpdatedAt, &d.DeliveredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	return &d, nil
+}
+
+// GetEndpoint возвращает webhook аккаунта пользователя
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, userID uuid.UUID) (*domain.WebhookEndpoint, error) {
+	var e domain.WebhookEndpoint
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, url, secret, active, created_at, updated_at
+		FROM webhook_endpoints WHERE user_id = $1`,
+		userID).Scan(&e.ID, &e.UserID, &e.URL, &e.Secret, &e.Active, &e.CreatedAt, &e.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webhook endpoint: %w", err)
+	}
+	return &e, nil
+}
+
+// UpsertEndpoint создаёт или обновляет webhook аккаунта. Секрет записывается только
+// при создании; у существующего webhook он не меняется
+func (r *WebhookRepository) UpsertEndpoint(ctx context.Context, e *domain.WebhookEndpoint) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_endpoints (user_id, url, secret, active)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET url = EXCLUDED.url, active = EXCLUDED.active
+		RETURNING id, secret, created_at, updated_at`,
+		e.UserID, e.URL, e.Secret, e.Active,
+	).Scan(&e.ID, &e.Secret, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("upsert webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// EnsureSecret создаёт запись webhook аккаунта без адреса, если её ещё нет,
+// чтобы события с webhook_url анализа было чем подписать. Если q == nil, используется пул соединений
+func (r *WebhookRepository) EnsureSecret(ctx context.Context, q Querier, userID uuid.UUID, secret string) error {
+	if q == nil {
+		q = r.db
+	}
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO webhook_endpoints (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO NOTHING`,
+		userID, secret)
+	if err != nil {
+		return fmt.Errorf("ensure webhook secret: %w", err)
+	}
+	return nil
+}
+
+// RotateSecret заменяет секрет подписи
+func (r *WebhookRepository) RotateSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE webhook_endpoints SET secret = $2 WHERE user_id = $1`, userID, secret)
+	if err != nil {
+		return fmt.Errorf("rotate webhook secret: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CreateDelivery ставит событие в очередь доставки и заполняет ID и временные метки.
+// Если q == nil, используется пул соединений
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, q Querier, d *domain.WebhookDelivery) error {
+	if q == nil {
+		q = r.db
+	}
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries (analysis_id, batch_id, user_id, redelivery_of, event, url, payload,
+		                                status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending', CURRENT_TIMESTAMP)
+		RETURNING id, status, next_attempt_at, created_at`,
+		d.AnalysisID, d.BatchID, d.UserID, d.RedeliveryOf, d.Event, d.URL, []byte(d.Payload),
+	).Scan(&d.ID, &d.Status, &d.NextAttemptAt, &d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// GetDelivery возвращает доставку вместе с попытками
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(r.db.QueryRowContext(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webhook delivery %s: %w", id, err)
+	}
+	if d.Attempts, err = r.listAttempts(ctx, id); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListDeliveries возвращает доставки по анализу, начиная с новых
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, analysisID uuid.UUID) ([]domain.WebhookDelivery, error) {
+	return r.listDeliveries(ctx, `analysis_id = $1`, analysisID)
+}
+
+// ListBatchDeliveries возвращает доставки событий пакета, начиная с новых
+func (r *WebhookRepository) ListBatchDeliveries(ctx context.Context, batchID uuid.UUID) ([]domain.WebhookDelivery, error) {
+	return r.listDeliveries(ctx, `batch_id = $1`, batchID)
+}
+
+func (r *WebhookRepository) listDeliveries(ctx context.Context, where string, id uuid.UUID) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE `+where+`
+		ORDER BY created_at DESC`,
+		id)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookRepository) listAttempts(ctx context.Context, deliveryID uuid.UUID) ([]domain.WebhookAttempt, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, delivery_id, attempt, response_code, error, duration_ms, created_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempt`,
+		deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []domain.WebhookAttempt
+	for rows.Next() {
+		var at domain.WebhookAttempt
+		if err := rows.Scan(&at.ID, &at.DeliveryID, &at.Attempt, &at.ResponseCode, &at.Error,
+			&at.DurationMs, &at.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, at)
+	}
+	return attempts, rows.Err()
+}
+
+// ClaimDue забирает доставку, время попытки которой наступило, увеличивает счётчик попыток
+// и сдвигает next_attempt_at на lease вперёд: если процесс упадёт во время отправки,
+// доставка вернётся в работу после окончания аренды. Если брать нечего, возвращает ErrNotFound
+func (r *WebhookRepository) ClaimDue(ctx context.Context, lease time.Duration) (*domain.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, `
+		UPDATE webhook_deliveries
+		SET attempt_count = attempt_count + 1,
+		    next_attempt_at = CURRENT_TIMESTAMP + $1::bigint * INTERVAL '1 millisecond'
+		WHERE id = (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+webhookDeliveryColumns,
+		lease.Milliseconds()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+// RecordAttempt сохраняет попытку доставки
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, q Querier, at *domain.WebhookAttempt) error {
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, response_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		at.DeliveryID, at.Attempt, at.ResponseCode, at.Error, at.DurationMs,
+	).Scan(&at.ID, &at.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// FinishDelivery фиксирует итог попытки. status == pending означает повтор в nextAttemptAt
+func (r *WebhookRepository) FinishDelivery(ctx context.Context, q Querier, id uuid.UUID, status domain.WebhookDeliveryStatus,
+	responseCode *int, lastError *string, nextAttemptAt *time.Time) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, last_response_code = $3, last_error = $4, next_attempt_at = $5,
+		    delivered_at = CASE WHEN $2 = 'succeeded' THEN CURRENT_TIMESTAMP END
+		WHERE id = $1`,
+		id, status, responseCode, lastError, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("finish webhook delivery %s: %w", id, err)
+	}
+	return nil
+}
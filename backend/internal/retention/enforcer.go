@@ -0,0 +1,128 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+)
+
+// Enforcer периодически применяет политику хранения: удаляет просроченные изображения
+// из хранилища, обезличивает просроченные результаты и дочищает объекты удалённых
+// пользователей, которые не удалось удалить сразу. Запускается в воркере
+type Enforcer struct {
+	db        *sql.DB
+	retention *repository.RetentionRepository
+	storage   storage.ObjectStorage
+	cfg       config.RetentionConfig
+}
+
+// NewEnforcer создаёт планировщик политики хранения
+func NewEnforcer(db *sql.DB, retention *repository.RetentionRepository, store storage.ObjectStorage, cfg config.RetentionConfig) *Enforcer {
+	return &Enforcer{db: db, retention: retention, storage: store, cfg: cfg}
+}
+
+// Run применяет политику сразу и затем каждые cfg.Interval до отмены ctx
+func (e *Enforcer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		run, err := e.Enforce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("enforce retention: %v", err)
+		}
+		if run.ImagesDeleted > 0 || run.ResultsAnonymized > 0 || run.ObjectsPurged > 0 {
+			log.Printf("Retention: %d images deleted, %d results anonymized, %d erased objects purged",
+				run.ImagesDeleted, run.ResultsAnonymized, run.ObjectsPurged)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enforce выполняет один проход политики пакетами по cfg.BatchSize анализов.
+// Возвращает число обработанных анализов, в том числе при ошибке
+func (e *Enforcer) Enforce(ctx context.Context) (domain.RetentionRun, error) {
+	var run domain.RetentionRun
+	for {
+		keys, err := e.retention.ListPendingDeletions(ctx, e.cfg.BatchSize)
+		if err != nil {
+			return run, err
+		}
+		n := purgeObjects(ctx, e.retention, e.storage, keys)
+		run.ObjectsPurged += n
+		// Оставшиеся объекты не удалились и будут повторены в следующий проход
+		if len(keys) < e.cfg.BatchSize || n < len(keys) {
+			break
+		}
+	}
+	for {
+		n, claimed, err := e.deleteImages(ctx)
+		run.ImagesDeleted += n
+		if err != nil {
+			return run, err
+		}
+		if claimed < e.cfg.BatchSize {
+			break
+		}
+	}
+	for {
+		n, err := e.retention.AnonymizeExpiredResults(ctx, e.cfg.ResultDays, e.cfg.BatchSize)
+		run.ResultsAnonymized += n
+		if err != nil {
+			return run, err
+		}
+		if n < e.cfg.BatchSize {
+			break
+		}
+	}
+	return run, nil
+}
+
+// deleteImages удаляет из хранилища изображения одного пакета просроченных анализов.
+// Отметки об удалении фиксируются только вместе с удалением объектов: если хранилище
+// недоступно, транзакция откатывается и пакет будет обработан в следующий раз.
+// Возвращает число отмеченных анализов и размер пакета
+func (e *Enforcer) deleteImages(ctx context.Context) (marked, claimed int, err error) {
+	err = database.WithTx(ctx, e.db, func(tx *sql.Tx) error {
+		images, err := e.retention.ClaimExpiredImages(ctx, tx, e.cfg.ImageDays, e.cfg.ResultDays, e.cfg.BatchSize)
+		if err != nil || len(images) == 0 {
+			return err
+		}
+		claimed = len(images)
+
+		keys := make([]string, 0, len(images))
+		seen := make(map[string]bool, len(images))
+		for _, img := range images {
+			if seen[img.Key] {
+				continue
+			}
+			seen[img.Key] = true
+			if img.RedactedKey != nil {
+				if err := e.storage.Delete(ctx, *img.RedactedKey); err != nil {
+					return fmt.Errorf("delete redacted image of analysis %s: %w", img.AnalysisID, err)
+				}
+			}
+			if err := e.storage.Delete(ctx, img.Key); err != nil {
+				return fmt.Errorf("delete image %s of analysis %s: %w", img.Key, img.AnalysisID, err)
+			}
+			keys = append(keys, img.Key)
+		}
+		marked, err = e.retention.MarkImagesDeleted(ctx, tx, keys)
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return marked, claimed, nil
+}
@@ -0,0 +1,38 @@
+package retention
+
+import (
+	"context"
+	"log"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+)
+
+// pendingReasonErasure - причина удаления объектов по запросу на удаление пользователя
+const pendingReasonErasure = "user_erasure"
+
+// purgeObjects удаляет из хранилища объекты из очереди удаления и снимает их с очереди.
+// Удаление идемпотентно, поэтому объект, удалённый до сбоя, можно удалить повторно.
+// Ошибки записываются в очередь и журнал, объект остаётся для следующей попытки.
+// Возвращает число удалённых объектов
+func purgeObjects(ctx context.Context, retention *repository.RetentionRepository, store storage.ObjectStorage, keys []string) int {
+	purged := 0
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("purge object %s: %v", key, err)
+			if err := retention.FailPendingDeletion(ctx, key, err.Error()); err != nil {
+				log.Printf("purge object %s: %v", key, err)
+			}
+			continue
+		}
+		if err := retention.CompletePendingDeletion(ctx, key); err != nil {
+			log.Printf("purge object %s: %v", key, err)
+			continue
+		}
+		purged++
+	}
+	return purged
+}
@@ -0,0 +1,183 @@
+// Package retention применяет политику хранения данных и удаляет данные пользователей
+package retention
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Service управляет организациями и их сроками хранения и удаляет данные пользователей
+type Service struct {
+	db        *sql.DB
+	orgs      *repository.OrganizationRepository
+	users     *repository.UserRepository
+	retention *repository.RetentionRepository
+	auditLogs *repository.AuditLogRepository
+	storage   storage.ObjectStorage
+	cfg       config.RetentionConfig
+}
+
+// NewService создаёт сервис политики хранения
+func NewService(db *sql.DB, orgs *repository.OrganizationRepository, users *repository.UserRepository,
+	retention *repository.RetentionRepository, auditLogs *repository.AuditLogRepository,
+	store storage.ObjectStorage, cfg config.RetentionConfig) *Service {
+	return &Service{db: db, orgs: orgs, users: users, retention: retention, auditLogs: auditLogs, storage: store, cfg: cfg}
+}
+
+// ListOrganizations возвращает все организации
+func (s *Service) ListOrganizations(ctx context.Context) ([]domain.Organization, error) {
+	return s.orgs.List(ctx)
+}
+
+// CreateOrganization создаёт организацию
+func (s *Service) CreateOrganization(ctx context.Context, req domain.OrganizationRequest) (*domain.Organization, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.orgs.Create(ctx, req)
+}
+
+// UpdateOrganization меняет имя и сроки хранения организации.
+// Новые сроки применяются при следующем проходе политики, в том числе к старым анализам
+func (s *Service) UpdateOrganization(ctx context.Context, id uuid.UUID, req domain.OrganizationRequest) (*domain.Organization, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.orgs.Update(ctx, id, req)
+}
+
+// SetUserOrganization переводит пользователя в организацию или исключает из неё
+func (s *Service) SetUserOrganization(ctx context.Context, userID uuid.UUID, req domain.UserOrganizationRequest) (*domain.User, error) {
+	if err := s.users.SetOrganization(ctx, userID, req.OrganizationID); err != nil {
+		return nil, err
+	}
+	return s.users.GetByID(ctx, userID)
+}
+
+// Pseudonym возвращает псевдоним пользователя: HMAC-SHA256 его идентификатора.
+// Один и тот же пользователь всегда получает один псевдоним
+func (s *Service) Pseudonym(userID uuid.UUID) (string, error) {
+	if s.cfg.PseudonymKey == "" {
+		return "", errors.New("RETENTION_PSEUDONYM_KEY is not set")
+	}
+	mac := hmac.New(sha256.New, []byte(s.cfg.PseudonymKey))
+	mac.Write([]byte(userID.String()))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Erase удаляет пользователя и все его данные: изображения и архивы из хранилища,
+// анализы, датасеты, пакеты и webhook из БД. Записи аудита пользователя остаются,
+// но вместо user_id в них записывается псевдоним. В той же транзакции сохраняется
+// подтверждение удаления. Возвращает подтверждение и манифест - список удалённых
+// записей и объектов, SHA-256 которого хранится в подтверждении. Манифест содержит
+// идентификаторы субъекта, поэтому не сохраняется и передаётся инициатору удаления.
+// Объекты хранилища ставятся в очередь удаления в той же транзакции и удаляются после
+// фиксации: при откате файлы остаются на месте и команду можно повторить, а объекты,
+// которые не удалось удалить сразу, дочищает планировщик политики хранения
+func (s *Service) Erase(ctx context.Context, userID uuid.UUID, requestedBy *uuid.UUID, reason string) (*domain.UserErasure, []byte, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, nil, domain.InvalidInputf("erasure reason is required")
+	}
+	pseudonym, err := s.Pseudonym(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	erasure := &domain.UserErasure{
+		SubjectPseudonym: pseudonym,
+		RequestedBy:      requestedBy,
+		Reason:           reason,
+		StartedAt:        time.Now().UTC(),
+	}
+	var (
+		manifest []byte
+		keys     []string
+	)
+	err = database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		// Пока строка пользователя заблокирована, новые анализы и датасеты не появятся
+		if err := s.retention.LockUser(ctx, tx, userID); err != nil {
+			return err
+		}
+		data, err := s.retention.CollectUserData(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := s.retention.AddPendingDeletions(ctx, tx, data.ObjectKeys, pendingReasonErasure); err != nil {
+			return err
+		}
+		keys = data.ObjectKeys
+
+		erasure.AnalysesDeleted = len(data.AnalysisIDs)
+		erasure.DatasetsDeleted = len(data.DatasetIDs)
+		erasure.ObjectsDeleted = len(data.ObjectKeys)
+		if erasure.AuditLogsPseudonymized, err = s.retention.PseudonymizeAuditLogs(ctx, tx, userID, pseudonym); err != nil {
+			return err
+		}
+		if err := s.retention.DeleteUser(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		manifest = buildManifest(userID, data)
+		sum := sha256.Sum256(manifest)
+		erasure.ManifestSHA256 = hex.EncodeToString(sum[:])
+		if err := s.retention.CreateErasure(ctx, tx, erasure); err != nil {
+			return err
+		}
+
+		entity := domain.AuditEntityErasure
+		details := domain.AuditDetails{"subject_pseudonym": pseudonym, "reason": reason}
+		return s.auditLogs.Create(ctx, tx, domain.AuditLogCreateRequest{
+			UserID:     requestedBy,
+			Action:     domain.AuditActionUserErased,
+			EntityType: &entity,
+			EntityID:   &erasure.ID,
+			Details:    &details,
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	purgeObjects(ctx, s.retention, s.storage, keys)
+	return erasure, manifest, nil
+}
+
+// Erasures возвращает подтверждения удаления пользователя с указанным идентификатором
+func (s *Service) Erasures(ctx context.Context, userID uuid.UUID) ([]domain.UserErasure, error) {
+	pseudonym, err := s.Pseudonym(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.retention.ListErasures(ctx, pseudonym)
+}
+
+// buildManifest перечисляет удалённые данные построчно: "user <id>", "analysis <id>",
+// "dataset <id>", "object <key>". Порядок строк определяется сортировкой в БД
+func buildManifest(userID uuid.UUID, data *repository.UserData) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "user %s\n", userID)
+	for _, id := range data.AnalysisIDs {
+		fmt.Fprintf(&b, "analysis %s\n", id)
+	}
+	for _, id := range data.DatasetIDs {
+		fmt.Fprintf(&b, "dataset %s\n", id)
+	}
+	for _, key := range data.ObjectKeys {
+		fmt.Fprintf(&b, "object %s\n", key)
+	}
+	return []byte(b.String())
+}
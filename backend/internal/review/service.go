@@ -0,0 +1,151 @@
+package review
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Service реализует ручную проверку и исправление результатов анализов
+type Service struct {
+	db        *sql.DB
+	analyses  *repository.AnalysisRepository
+	reviews   *repository.ReviewRepository
+	audit     *repository.AuditLogRepository
+	cfg       config.ReviewConfig
+	enrichers []domain.ResultEnricher
+}
+
+// NewService создаёт сервис проверки. Enrichers пересчитывают исправленный результат
+// (трудоёмкость и т.п.) так же, как воркер после инференса
+func NewService(db *sql.DB, analyses *repository.AnalysisRepository, reviews *repository.ReviewRepository,
+	audit *repository.AuditLogRepository, cfg config.ReviewConfig, enrichers ...domain.ResultEnricher) *Service {
+	return &Service{db: db, analyses: analyses, reviews: reviews, audit: audit, cfg: cfg, enrichers: enrichers}
+}
+
+// Submit применяет действия проверяющего к текущему результату анализа.
+// В одной транзакции: сохраняет исходный выход модели (при первой проверке),
+// записывает новую версию результата, снимок проверки и запись аудита
+func (s *Service) Submit(ctx context.Context, reviewer *domain.User, analysisID uuid.UUID, req domain.ReviewSubmitRequest) (*domain.AnalysisReview, error) {
+	if !reviewer.CanReview() {
+		return nil, domain.ErrForbidden
+	}
+	if len(req.Operations) == 0 {
+		return nil, domain.InvalidInputf("at least one operation is required")
+	}
+
+	var rv *domain.AnalysisReview
+	err := database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		a, err := s.analyses.GetForUpdate(ctx, tx, analysisID)
+		if err != nil {
+			return err
+		}
+		if !a.IsCompleted() || a.Result == nil {
+			return domain.Conflictf("only completed analyses can be reviewed")
+		}
+		if req.ResultVersion != a.ResultVersion {
+			return domain.Conflictf("result version %d is outdated, current version is %d", req.ResultVersion, a.ResultVersion)
+		}
+
+		newVersion := a.ResultVersion + 1
+		corrected, err := domain.ApplyReview(a.Result, req.Operations, newVersion)
+		if err != nil {
+			return err
+		}
+		for _, e := range s.enrichers {
+			if err := e.Enrich(ctx, a, corrected); err != nil {
+				return fmt.Errorf("enrich reviewed result: %w", err)
+			}
+		}
+
+		if err := s.analyses.SaveReviewedResult(ctx, tx, a.ID, corrected, newVersion, reviewer.ID); err != nil {
+			return err
+		}
+
+		rv = &domain.AnalysisReview{
+			AnalysisID:    a.ID,
+			ReviewerID:    &reviewer.ID,
+			ResultVersion: newVersion,
+			Operations:    req.Operations,
+			Result:        corrected,
+			Comment:       req.Comment,
+		}
+		if err := s.reviews.Create(ctx, tx, rv); err != nil {
+			return err
+		}
+
+		entity := domain.AuditEntityAnalysis
+		details := domain.AuditDetails{
+			"result_version":  newVersion,
+			"operations":      len(req.Operations),
+			"defects_before":  len(a.Result.Defects),
+			"defects_after":   len(corrected.Defects),
+			"previous_status": string(a.ReviewStatus),
+		}
+		return s.audit.Create(ctx, tx, domain.AuditLogCreateRequest{
+			UserID:     &reviewer.ID,
+			Action:     domain.AuditActionAnalysisReviewed,
+			EntityType: &entity,
+			EntityID:   &a.ID,
+			Details:    &details,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// Queue возвращает анализы, ожидающие проверки
+func (s *Service) Queue(ctx context.Context, limit, offset int) ([]domain.Analysis, error) {
+	return s.analyses.ListReviewQueue(ctx, limit, offset)
+}
+
+// History возвращает все версии результата анализа, полученные при проверках
+func (s *Service) History(ctx context.Context, analysisID uuid.UUID) ([]domain.AnalysisReview, error) {
+	if _, err := s.analyses.GetByID(ctx, analysisID); err != nil {
+		return nil, err
+	}
+	return s.reviews.ListByAnalysis(ctx, analysisID)
+}
+
+// RouteIfNeeded ставит завершённый анализ в очередь проверки, если модель не уверена в результате.
+// Используется как хук воркера
+func (s *Service) RouteIfNeeded(ctx context.Context, a *domain.Analysis) error {
+	if !a.IsCompleted() || a.Result == nil {
+		return nil
+	}
+	needs, reason := NeedsReview(a.Result, s.cfg.LowConfidence)
+	if !needs {
+		return nil
+	}
+	if err := s.analyses.MarkForReview(ctx, a.ID, reason); err != nil {
+		return err
+	}
+	// Следующие хуки (webhook) видят актуальный статус проверки
+	a.ReviewStatus = domain.ReviewStatusPending
+	a.ReviewReason = &reason
+	return nil
+}
+
+// NeedsReview проверяет, есть ли в результате дефекты с уверенностью ниже порога
+func NeedsReview(result *domain.AnalysisResult, lowConfidence float64) (bool, string) {
+	var low int
+	minConf := 1.0
+	for _, d := range result.Defects {
+		if d.Confidence < lowConfidence {
+			low++
+			minConf = min(minConf, d.Confidence)
+		}
+	}
+	if low == 0 {
+		return false, ""
+	}
+	return true, fmt.Sprintf("%d defect(s) below confidence %.2f (min %.2f)", low, lowConfidence, minConf)
+}
@@ -0,0 +1,142 @@
+package shadow
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// IoU возвращает отношение площади пересечения рамок к площади объединения
+func IoU(a, b domain.BoundingBox) float64 {
+	w := min(a.X+a.Width, b.X+b.Width) - max(a.X, b.X)
+	h := min(a.Y+a.Height, b.Y+b.Height) - max(a.Y, b.Y)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	inter := float64(w * h)
+	union := float64(a.Width*a.Height+b.Width*b.Height) - inter
+	if union <= 0 {
+		return 0
+	}
+	return inter / union
+}
+
+// Match представляет сопоставленную пару дефектов: индексы в выходах активной модели и кандидата
+type Match struct {
+	Primary int
+	Shadow  int
+	IoU     float64
+}
+
+// MatchDefects жадно сопоставляет дефекты по убыванию IoU, не ниже minIoU.
+// Тип дефекта не учитывается, чтобы пары с разным типом попали в матрицу путаницы
+func MatchDefects(primary, shadow []domain.Defect, minIoU float64) []Match {
+	var candidates []Match
+	for i := range primary {
+		for j := range shadow {
+			if iou := IoU(primary[i].BBox, shadow[j].BBox); iou >= minIoU && iou > 0 {
+				candidates = append(candidates, Match{Primary: i, Shadow: j, IoU: iou})
+			}
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b Match) int { return cmp.Compare(b.IoU, a.IoU) })
+
+	usedPrimary := make([]bool, len(primary))
+	usedShadow := make([]bool, len(shadow))
+	var matches []Match
+	for _, c := range candidates {
+		if usedPrimary[c.Primary] || usedShadow[c.Shadow] {
+			continue
+		}
+		usedPrimary[c.Primary], usedShadow[c.Shadow] = true, true
+		matches = append(matches, c)
+	}
+	return matches
+}
+
+// accumulator накапливает показатели согласованности по анализам
+type accumulator struct {
+	report    *domain.ShadowReport
+	confusion map[[2]domain.DefectType]int
+	iouSum    float64
+	deltaSum  float64
+	absSum    float64
+}
+
+func newAccumulator(report *domain.ShadowReport) *accumulator {
+	return &accumulator{report: report, confusion: make(map[[2]domain.DefectType]int)}
+}
+
+// add учитывает один анализ. Shadow == nil - инференс кандидата завершился ошибкой
+func (acc *accumulator) add(primary, shadow *domain.AnalysisResult) {
+	rep := acc.report
+	if shadow == nil {
+		rep.ShadowFailed++
+		return
+	}
+	rep.Analyses++
+	rep.PrimaryDefects += len(primary.Defects)
+	rep.ShadowDefects += len(shadow.Defects)
+
+	matches := MatchDefects(primary.Defects, shadow.Defects, rep.MatchIoU)
+	matchedPrimary := make([]bool, len(primary.Defects))
+	matchedShadow := make([]bool, len(shadow.Defects))
+	sameType := 0
+	for _, m := range matches {
+		p, s := primary.Defects[m.Primary], shadow.Defects[m.Shadow]
+		matchedPrimary[m.Primary], matchedShadow[m.Shadow] = true, true
+		acc.confusion[[2]domain.DefectType{p.DefectType, s.DefectType}]++
+		if p.DefectType == s.DefectType {
+			sameType++
+		}
+		delta := s.Confidence - p.Confidence
+		acc.iouSum += m.IoU
+		acc.deltaSum += delta
+		acc.absSum += math.Abs(delta)
+	}
+	for i, ok := range matchedPrimary {
+		if !ok {
+			acc.confusion[[2]domain.DefectType{primary.Defects[i].DefectType, ""}]++
+			rep.OnlyPrimary++
+		}
+	}
+	for j, ok := range matchedShadow {
+		if !ok {
+			acc.confusion[[2]domain.DefectType{"", shadow.Defects[j].DefectType}]++
+			rep.OnlyShadow++
+		}
+	}
+	rep.Matched += len(matches)
+	rep.SameType += sameType
+	if sameType == len(primary.Defects) && sameType == len(shadow.Defects) {
+		rep.FullAgreement++
+	}
+}
+
+// finish считает доли и средние и заполняет матрицу путаницы
+func (acc *accumulator) finish() {
+	rep := acc.report
+	if rep.Analyses > 0 {
+		rep.AgreementRate = float64(rep.FullAgreement) / float64(rep.Analyses)
+	}
+	if total := rep.PrimaryDefects + rep.ShadowDefects; total > 0 {
+		rep.DefectF1 = 2 * float64(rep.SameType) / float64(total)
+	}
+	if rep.Matched > 0 {
+		n := float64(rep.Matched)
+		rep.MeanIoU = acc.iouSum / n
+		rep.TypeAgreement = float64(rep.SameType) / n
+		rep.MeanConfidenceDelta = acc.deltaSum / n
+		rep.MeanAbsConfidenceDelta = acc.absSum / n
+	}
+
+	rep.TypeConfusion = make([]domain.ShadowTypeConfusion, 0, len(acc.confusion))
+	for k, n := range acc.confusion {
+		rep.TypeConfusion = append(rep.TypeConfusion, domain.ShadowTypeConfusion{Primary: k[0], Shadow: k[1], Count: n})
+	}
+	slices.SortFunc(rep.TypeConfusion, func(a, b domain.ShadowTypeConfusion) int {
+		return cmp.Or(cmp.Compare(a.Primary, b.Primary), cmp.Compare(a.Shadow, b.Shadow))
+	})
+}
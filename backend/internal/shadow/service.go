@@ -0,0 +1,148 @@
+// Package shadow управляет теневым режимом моделей-кандидатов и сравнивает
+// их выход с активной моделью
+package shadow
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Service управляет теневыми развёртываниями и отчётами согласованности
+type Service struct {
+	db      *sql.DB
+	shadows *repository.ShadowRepository
+	models  *repository.ModelRepository
+	audit   *repository.AuditLogRepository
+	cfg     config.ShadowConfig
+}
+
+// NewService создаёт сервис теневого режима
+func NewService(db *sql.DB, shadows *repository.ShadowRepository, models *repository.ModelRepository,
+	audit *repository.AuditLogRepository, cfg config.ShadowConfig) *Service {
+	return &Service{db: db, shadows: shadows, models: models, audit: audit, cfg: cfg}
+}
+
+// List возвращает теневые развёртывания, начиная с новых
+func (s *Service) List(ctx context.Context, user *domain.User, limit, offset int) ([]domain.ShadowDeployment, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	return s.shadows.List(ctx, limit, offset)
+}
+
+// Create включает теневой режим для готовой неактивной модели
+func (s *Service) Create(ctx context.Context, user *domain.User, req domain.ShadowDeploymentRequest) (*domain.ShadowDeployment, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	model, err := s.models.GetByID(ctx, req.ModelID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, domain.InvalidInputf("model %s not found", req.ModelID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if model.Active {
+		return nil, domain.Conflictf("model %s is already active", model.Version)
+	}
+	if !model.IsReady() {
+		return nil, domain.Conflictf("model %s is %s: only ready models can run in shadow mode", model.Version, model.Status)
+	}
+
+	d := &domain.ShadowDeployment{
+		ModelID:      model.ID,
+		ModelVersion: model.Version,
+		Percent:      *req.Percent,
+		CreatedBy:    &user.ID,
+	}
+	err = database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.shadows.Create(ctx, tx, d); err != nil {
+			return err
+		}
+		return s.writeAudit(ctx, tx, user, domain.AuditActionShadowCreated, d.ID, domain.AuditDetails{
+			"model_version": model.Version,
+			"percent":       d.Percent,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Update меняет долю анализов или включает и отключает теневое развёртывание
+func (s *Service) Update(ctx context.Context, user *domain.User, id uuid.UUID, req domain.ShadowDeploymentUpdateRequest) (*domain.ShadowDeployment, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	details := domain.AuditDetails{}
+	if req.Percent != nil {
+		details["percent"] = *req.Percent
+	}
+	if req.Enabled != nil {
+		details["enabled"] = *req.Enabled
+	}
+	err := database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.shadows.Update(ctx, tx, id, req); err != nil {
+			return err
+		}
+		return s.writeAudit(ctx, tx, user, domain.AuditActionShadowUpdated, id, details)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.shadows.GetByID(ctx, id)
+}
+
+// Report сравнивает выход кандидата с активной моделью на анализах за период [from, to)
+func (s *Service) Report(ctx context.Context, user *domain.User, id uuid.UUID, from, to *time.Time) (*domain.ShadowReport, error) {
+	if !user.CanManageModels() {
+		return nil, domain.ErrForbidden
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, domain.InvalidInputf("from must be before to")
+	}
+	d, err := s.shadows.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.ShadowReport{Deployment: *d, From: from, To: to, MatchIoU: s.cfg.MatchIoU}
+	acc := newAccumulator(report)
+	err = s.shadows.ForEachComparison(ctx, id, from, to, func(primary, shadow *domain.AnalysisResult) error {
+		acc.add(primary, shadow)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	acc.finish()
+	return report, nil
+}
+
+func (s *Service) writeAudit(ctx context.Context, q repository.Querier, user *domain.User, action string,
+	id uuid.UUID, details domain.AuditDetails) error {
+	entity := domain.AuditEntityShadow
+	return s.audit.Create(ctx, q, domain.AuditLogCreateRequest{
+		UserID:     &user.ID,
+		Action:     action,
+		EntityType: &entity,
+		EntityID:   &id,
+		Details:    &details,
+	})
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound возвращается, если объекта с указанным ключом нет в хранилище
+var ErrNotFound = errors.New("object not found")
+
+// ObjectStorage описывает объектное хранилище (MinIO / S3) для изображений,
+// датасетов и весов моделей. Ключи имеют вид "images/<user_id>/<file>.jpg"
+type ObjectStorage interface {
+	// Get открывает объект на чтение. Вызывающий обязан закрыть reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Put записывает объект целиком из reader
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Delete удаляет объект. Отсутствие объекта не считается ошибкой
+	Delete(ctx context.Context, key string) error
+}
+
+// ReadAll читает объект из хранилища целиком
+func ReadAll(ctx context.Context, s ObjectStorage, key string) ([]byte, error) {
+	rc, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+)
+
+// ErrPrivateTarget возвращается при попытке подключиться к адресу внутренней сети
+var ErrPrivateTarget = errors.New("webhook target resolves to a local or private network address")
+
+// newHTTPClient создаёт клиент для отправки webhook. Если адреса внутренних сетей не разрешены,
+// IP-адрес проверяется при каждом подключении: проверки имени хоста при сохранении URL недостаточно,
+// DNS-запись может указывать на внутренний адрес или смениться позже (DNS rebinding)
+func newHTTPClient(cfg config.WebhookConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout, KeepAlive: 30 * time.Second}
+	if !cfg.AllowPrivateTargets {
+		dialer.Control = publicOnly
+	}
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			// Прокси не используется: иначе проверялся бы адрес прокси, а не получателя
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		// Перенаправления не выполняются: 3xx считается неуспешной попыткой
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicOnly - net.Dialer.Control, запрещающий подключение к непубличным адресам.
+// Вызывается после разрешения имени, address содержит IP-адрес
+func publicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("parse dial address %q: %w", address, err)
+	}
+	if !domain.IsPublicAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrPrivateTarget, addr)
+	}
+	return nil
+}
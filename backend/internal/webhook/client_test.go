@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+)
+
+func TestPublicOnly(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1::1]:443", false},
+		{"127.0.0.1:5432", true},
+		{"169.254.169.254:80", true},
+		{"10.0.0.7:8080", true},
+		{"192.168.1.10:443", true},
+		{"0.0.0.0:80", true},
+		{"[::1]:80", true},
+		{"[fd00::1]:80", true},
+		{"[::ffff:127.0.0.1]:80", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := publicOnly("tcp", tt.address, nil)
+			if tt.wantErr && !errors.Is(err, ErrPrivateTarget) {
+				t.Errorf("publicOnly(%s) error = %v, want ErrPrivateTarget", tt.address, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("publicOnly(%s) error = %v, want nil", tt.address, err)
+			}
+		})
+	}
+}
+
+func TestHTTPClientRefusesPrivateTargets(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	// Для имени хоста проверяется адрес, в который оно разрешилось при подключении
+	byName := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	cfg := config.WebhookConfig{Timeout: 5 * time.Second}
+	client := newHTTPClient(cfg)
+	for _, target := range []string{srv.URL, byName} {
+		resp, err := client.Post(target, "application/json", strings.NewReader(`{}`))
+		if err == nil {
+			resp.Body.Close()
+			t.Fatalf("POST %s succeeded, want private target error", target)
+		}
+		if !errors.Is(err, ErrPrivateTarget) {
+			t.Errorf("POST %s error = %v, want ErrPrivateTarget", target, err)
+		}
+	}
+	if hits != 0 {
+		t.Errorf("server received %d requests, want none", hits)
+	}
+
+	cfg.AllowPrivateTargets = true
+	resp, err := newHTTPClient(cfg).Post(srv.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("POST with private targets allowed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || hits != 1 {
+		t.Errorf("status %d, hits %d, want 204 and 1", resp.StatusCode, hits)
+	}
+}
+
+func TestHTTPClientDoesNotFollowRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hook" {
+			http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+			return
+		}
+		t.Errorf("redirect to %s was followed", r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := newHTTPClient(config.WebhookConfig{Timeout: 5 * time.Second, AllowPrivateTargets: true})
+	resp, err := client.Post(srv.URL+"/hook", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Errorf("status %d, want %d", resp.StatusCode, http.StatusTemporaryRedirect)
+	}
+}
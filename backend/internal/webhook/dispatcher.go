@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/database"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+)
+
+// maxResponseBytes - сколько байт ответа получателя читается (остальное отбрасывается)
+const maxResponseBytes = 64 << 10
+
+// Dispatcher забирает доставки из очереди и отправляет их получателям
+type Dispatcher struct {
+	db       *sql.DB
+	webhooks *repository.WebhookRepository
+	analyses *repository.AnalysisRepository
+	client   *http.Client
+	cfg      config.WebhookConfig
+}
+
+// NewDispatcher создаёт отправителя webhook
+func NewDispatcher(db *sql.DB, webhooks *repository.WebhookRepository, analyses *repository.AnalysisRepository,
+	cfg config.WebhookConfig) *Dispatcher {
+	return &Dispatcher{db: db, webhooks: webhooks, analyses: analyses, client: newHTTPClient(cfg), cfg: cfg}
+}
+
+// Run запускает cfg.Concurrency отправителей и блокируется до отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < d.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) loop(ctx context.Context) {
+	// Аренда с запасом: доставка не вернётся в очередь, пока идёт запрос
+	lease := d.cfg.Timeout + time.Minute
+	for {
+		del, err := d.webhooks.ClaimDue(ctx, lease)
+		switch {
+		case err == nil:
+			d.deliver(ctx, del)
+			continue
+		case errors.Is(err, repository.ErrNotFound):
+			// очередь пуста
+		case ctx.Err() != nil:
+			return
+		default:
+			log.Printf("claim webhook delivery: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.cfg.PollInterval):
+		}
+	}
+}
+
+// deliver выполняет одну попытку и записывает её результат.
+// Запись выполняется с отдельным контекстом, чтобы остановка не потеряла итог попытки
+func (d *Dispatcher) deliver(ctx context.Context, del *domain.WebhookDelivery) {
+	started := time.Now()
+	code, sendErr := d.send(ctx, del)
+
+	attempt := &domain.WebhookAttempt{
+		DeliveryID:   del.ID,
+		Attempt:      del.AttemptCount,
+		ResponseCode: code,
+		DurationMs:   time.Since(started).Milliseconds(),
+	}
+
+	status := domain.WebhookDeliverySucceeded
+	var nextAttemptAt *time.Time
+	if sendErr != nil {
+		msg := sendErr.Error()
+		attempt.Error = &msg
+		status = domain.WebhookDeliveryFailed
+
+		// AttemptCount уже включает текущую попытку
+		retries := del.AttemptCount - 1
+		if d.cfg.Retry.Allows(retries) {
+			status = domain.WebhookDeliveryPending
+			next := time.Now().Add(d.cfg.Retry.Delay(retries, d.cfg.Jitter, rand.Float64))
+			nextAttemptAt = &next
+			log.Printf("webhook delivery %s: attempt %d failed: %s, next at %s",
+				del.ID, del.AttemptCount, msg, next.Format(time.RFC3339))
+		} else {
+			log.Printf("webhook delivery %s: giving up after %d attempts: %s", del.ID, del.AttemptCount, msg)
+		}
+	}
+
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	err := database.WithTx(saveCtx, d.db, func(tx *sql.Tx) error {
+		if err := d.webhooks.RecordAttempt(saveCtx, tx, attempt); err != nil {
+			return err
+		}
+		if err := d.webhooks.FinishDelivery(saveCtx, tx, del.ID, status, code, attempt.Error, nextAttemptAt); err != nil {
+			return err
+		}
+		if status == domain.WebhookDeliverySucceeded && del.AnalysisID != nil {
+			return d.analyses.MarkWebhookSent(saveCtx, tx, *del.AnalysisID)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("webhook delivery %s: %v", del.ID, err)
+	}
+}
+
+// send отправляет подписанный запрос. Возвращает код ответа (nil, если ответа не было)
+// и ошибку для любого ответа вне 2xx
+func (d *Dispatcher) send(ctx context.Context, del *domain.WebhookDelivery) (*int, error) {
+	endpoint, err := d.webhooks.GetEndpoint(ctx, del.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("load signing secret: %w", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, del.URL, bytes.NewReader(del.Payload))
+	if err != nil {
+		return nil, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AutoInspect-Webhook/1.0")
+	req.Header.Set(HeaderEvent, string(del.Event))
+	req.Header.Set(HeaderDelivery, del.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, ts, del.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	code := resp.StatusCode
+	if code < 200 || code > 299 {
+		return &code, fmt.Errorf("unexpected response status %d", code)
+	}
+	return &code, nil
+}
@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Service управляет webhook аккаунтов и ставит события в очередь доставки
+type Service struct {
+	webhooks *repository.WebhookRepository
+	analyses *repository.AnalysisRepository
+	batches  *repository.BatchRepository
+	cfg      config.WebhookConfig
+}
+
+// NewService создаёт сервис webhook
+func NewService(webhooks *repository.WebhookRepository, analyses *repository.AnalysisRepository,
+	batches *repository.BatchRepository, cfg config.WebhookConfig) *Service {
+	return &Service{webhooks: webhooks, analyses: analyses, batches: batches, cfg: cfg}
+}
+
+// Endpoint возвращает webhook аккаунта. Секрет подписи создаётся при первом обращении,
+// чтобы его можно было получить до регистрации webhook_url отдельных анализов
+func (s *Service) Endpoint(ctx context.Context, user *domain.User) (*domain.WebhookEndpoint, error) {
+	if err := s.ensureSecret(ctx, nil, user.ID); err != nil {
+		return nil, err
+	}
+	return s.webhooks.GetEndpoint(ctx, user.ID)
+}
+
+// PutEndpoint задаёт адрес webhook аккаунта и включает или выключает его
+func (s *Service) PutEndpoint(ctx context.Context, user *domain.User, req domain.WebhookEndpointRequest) (*domain.WebhookEndpoint, error) {
+	if req.URL != nil {
+		if err := domain.ValidateWebhookURL(*req.URL, s.cfg.AllowPrivateTargets); err != nil {
+			return nil, err
+		}
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &domain.WebhookEndpoint{UserID: user.ID, URL: req.URL, Secret: secret, Active: true}
+	if req.Active != nil {
+		e.Active = *req.Active
+	}
+	if err := s.webhooks.UpsertEndpoint(ctx, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// RotateSecret заменяет секрет подписи. Доставки, ещё не отправленные, подписываются новым секретом
+func (s *Service) RotateSecret(ctx context.Context, user *domain.User) (*domain.WebhookEndpoint, error) {
+	if err := s.ensureSecret(ctx, nil, user.ID); err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.webhooks.RotateSecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+	return s.webhooks.GetEndpoint(ctx, user.ID)
+}
+
+// HandleAnalysisFinished ставит в очередь событие о завершении анализа на webhook_url анализа
+// или, если он не задан, на активный webhook аккаунта. Используется как хук воркера
+func (s *Service) HandleAnalysisFinished(ctx context.Context, a *domain.Analysis) error {
+	return s.enqueue(ctx, nil, a.UserID, a.WebhookURL, domain.NewWebhookPayload(a, time.Now().UTC()),
+		&domain.WebhookDelivery{AnalysisID: &a.ID})
+}
+
+// HandleBatchCompleted ставит в очередь событие batch.completed на webhook_url пакета
+// или, если он не задан, на активный webhook аккаунта. Подписывается на завершение пакета
+// (batch.Service.OnCompleted): доставка создаётся в транзакции, отмечающей пакет завершённым
+func (s *Service) HandleBatchCompleted(ctx context.Context, q repository.Querier, b *domain.AnalysisBatch) error {
+	return s.enqueue(ctx, q, b.UserID, b.WebhookURL, domain.NewBatchWebhookPayload(b, time.Now().UTC()),
+		&domain.WebhookDelivery{BatchID: &b.ID})
+}
+
+// enqueue ставит событие payload в очередь доставки d на own или на webhook аккаунта
+func (s *Service) enqueue(ctx context.Context, q repository.Querier, userID uuid.UUID, own *string,
+	payload domain.WebhookPayload, d *domain.WebhookDelivery) error {
+	url, err := s.targetURL(ctx, userID, own)
+	if err != nil || url == "" {
+		return err
+	}
+	if err := s.ensureSecret(ctx, q, userID); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	d.UserID, d.Event, d.URL, d.Payload = userID, payload.Event, url, body
+	return s.webhooks.CreateDelivery(ctx, q, d)
+}
+
+// Deliveries возвращает доставки по анализу
+func (s *Service) Deliveries(ctx context.Context, user *domain.User, analysisID uuid.UUID) ([]domain.WebhookDelivery, error) {
+	a, err := s.analyses.GetByID(ctx, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != user.ID && !user.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+	return s.webhooks.ListDeliveries(ctx, analysisID)
+}
+
+// BatchDeliveries возвращает доставки событий пакета
+func (s *Service) BatchDeliveries(ctx context.Context, user *domain.User, batchID uuid.UUID) ([]domain.WebhookDelivery, error) {
+	b, err := s.batches.GetByID(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if !b.IsOwnedBy(user.ID) && !user.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+	return s.webhooks.ListBatchDeliveries(ctx, batchID)
+}
+
+// Delivery возвращает доставку со всеми попытками
+func (s *Service) Delivery(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	d, err := s.webhooks.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.UserID != user.ID && !user.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+	return d, nil
+}
+
+// Redeliver ставит в очередь новую доставку с тем же телом и адресом.
+// Исходная доставка и её попытки сохраняются
+func (s *Service) Redeliver(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	orig, err := s.Delivery(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	if orig.Status == domain.WebhookDeliveryPending {
+		return nil, domain.Conflictf("delivery is still pending")
+	}
+
+	d := &domain.WebhookDelivery{
+		AnalysisID:   orig.AnalysisID,
+		BatchID:      orig.BatchID,
+		UserID:       orig.UserID,
+		RedeliveryOf: &orig.ID,
+		Event:        orig.Event,
+		URL:          orig.URL,
+		Payload:      orig.Payload,
+	}
+	if err := s.webhooks.CreateDelivery(ctx, nil, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// targetURL выбирает адрес доставки: собственный адрес анализа или пакета, иначе webhook аккаунта.
+// Пустая строка - отправлять некуда
+func (s *Service) targetURL(ctx context.Context, userID uuid.UUID, own *string) (string, error) {
+	if own != nil && *own != "" {
+		return *own, nil
+	}
+	e, err := s.webhooks.GetEndpoint(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !e.Active || e.URL == nil {
+		return "", nil
+	}
+	return *e.URL, nil
+}
+
+func (s *Service) ensureSecret(ctx context.Context, q repository.Querier, userID uuid.UUID) error {
+	secret, err := newSecret()
+	if err != nil {
+		return err
+	}
+	return s.webhooks.EnsureSecret(ctx, q, userID, secret)
+}
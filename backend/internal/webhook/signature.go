@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Заголовки запроса webhook
+const (
+	HeaderEvent     = "X-AutoInspect-Event"
+	HeaderDelivery  = "X-AutoInspect-Delivery"
+	HeaderTimestamp = "X-AutoInspect-Timestamp" // Unix-время отправки в секундах
+	HeaderSignature = "X-AutoInspect-Signature" // sha256=<hex HMAC>
+)
+
+// Sign вычисляет подпись запроса: HMAC-SHA256 от "<timestamp>.<body>" на секрете аккаунта.
+// Метка времени входит в подпись, чтобы получатель мог отвергать повторно отправленные
+// перехваченные запросы
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newSecret генерирует секрет подписи
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,127 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // декодер JPEG
+	_ "image/png"  // декодер PNG
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/inference"
+	"github.com/DedovInside/AutoInspect/backend/internal/redaction"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+)
+
+// Processor выполняет анализ одного изображения.
+// Ошибки должны быть классифицированы через domain.AnalysisError
+type Processor interface {
+	Process(ctx context.Context, a *domain.Analysis) (*domain.AnalysisResult, error)
+}
+
+// InferenceProcessor загружает изображение и модель, запускает инференс и собирает результат
+type InferenceProcessor struct {
+	models        *repository.ModelRepository
+	storage       storage.ObjectStorage
+	backend       inference.Backend
+	redactor      *redaction.Redactor
+	minConfidence float64
+	enrichers     []domain.ResultEnricher
+}
+
+// NewInferenceProcessor создаёт процессор анализов. Redactor сохраняет копию изображения
+// со скрытыми номерами и лицами до инференса (nil - скрытие отключено).
+// Enrichers применяются к собранному результату по порядку
+func NewInferenceProcessor(models *repository.ModelRepository, store storage.ObjectStorage,
+	backend inference.Backend, redactor *redaction.Redactor, minConfidence float64,
+	enrichers ...domain.ResultEnricher) *InferenceProcessor {
+	return &InferenceProcessor{models: models, storage: store, backend: backend, redactor: redactor,
+		minConfidence: minConfidence, enrichers: enrichers}
+}
+
+// Process реализует Processor
+func (p *InferenceProcessor) Process(ctx context.Context, a *domain.Analysis) (*domain.AnalysisResult, error) {
+	model, err := p.loadModel(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := storage.ReadAll(ctx, p.storage, a.ImageKey)
+	if err != nil {
+		return nil, classifyStorageError(err)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(img))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, domain.NewAnalysisError(domain.ErrorCodeUnsupportedFormat, err)
+	}
+	if err != nil {
+		return nil, domain.NewAnalysisError(domain.ErrorCodeCorruptImage, err)
+	}
+
+	// Скрытие - часть приёма изображения: без копии анализ не завершается
+	if p.redactor != nil {
+		if err := p.redactor.Redact(ctx, a, img); err != nil {
+			return nil, err
+		}
+	}
+
+	pred, err := p.backend.Infer(ctx, model, img)
+	if err != nil {
+		return nil, classifyInferenceError(err)
+	}
+
+	result := BuildResult(pred, cfg.Width, cfg.Height, p.minConfidence)
+	for _, e := range p.enrichers {
+		if err := e.Enrich(ctx, a, result); err != nil {
+			return nil, fmt.Errorf("enrich result: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func (p *InferenceProcessor) loadModel(ctx context.Context, a *domain.Analysis) (*domain.MLModel, error) {
+	model, err := analysisModel(ctx, p.models, a)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, domain.NewAnalysisError(domain.ErrorCodeModelNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+// analysisModel находит модель анализа: по model_id, если он записан, иначе по версии
+func analysisModel(ctx context.Context, models *repository.ModelRepository, a *domain.Analysis) (*domain.MLModel, error) {
+	if a.ModelID != nil {
+		return models.GetByID(ctx, *a.ModelID)
+	}
+	return models.GetByVersion(ctx, a.ModelVersion)
+}
+
+func classifyStorageError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return domain.NewAnalysisError(domain.ErrorCodeImageNotFound, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return domain.NewAnalysisError(domain.ErrorCodeStorageTimeout, err)
+	default:
+		return domain.NewAnalysisError(domain.ErrorCodeStorageUnavailable, err)
+	}
+}
+
+func classifyInferenceError(err error) error {
+	switch {
+	case errors.Is(err, inference.ErrIntegrity):
+		return domain.NewAnalysisError(domain.ErrorCodeModelIntegrity, err)
+	case errors.Is(err, inference.ErrUnavailable):
+		return domain.NewAnalysisError(domain.ErrorCodeInferenceUnavailable, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return domain.NewAnalysisError(domain.ErrorCodeInferenceTimeout, err)
+	default:
+		return err
+	}
+}
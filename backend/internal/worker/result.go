@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"fmt"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/inference"
+)
+
+// majorAreaShare - доля площади кадра, начиная с которой вмятина или царапина считается серьёзной
+const majorAreaShare = 0.05
+
+// BuildResult преобразует сырые детекции модели в AnalysisResult.
+// Детекции с неизвестным классом или уверенностью ниже minConfidence отбрасываются
+func BuildResult(pred *inference.Prediction, width, height int, minConfidence float64) *domain.AnalysisResult {
+	result := &domain.AnalysisResult{
+		SchemaVersion: domain.CurrentResultSchemaVersion,
+		ViewAngle:     pred.ViewAngle,
+		Defects:       make([]domain.Defect, 0, len(pred.Detections)),
+	}
+
+	for _, det := range pred.Detections {
+		dt := domain.DefectType(det.Class)
+		if !dt.IsValid() || det.Confidence < minConfidence {
+			continue
+		}
+
+		severity := domain.DefectSeverity(det.Attributes["severity"])
+		if !severity.IsValid() {
+			severity = estimateSeverity(dt, det.BBox, width, height)
+		}
+		action := recommendAction(dt, severity)
+
+		result.Defects = append(result.Defects, domain.Defect{
+			ID:                fmt.Sprintf("defect_%d", len(result.Defects)+1),
+			PartName:          det.Attributes["part_name"],
+			PartID:            det.Attributes["part_id"],
+			DefectType:        dt,
+			Severity:          severity,
+			BBox:              det.BBox,
+			Mask:              det.Mask,
+			Confidence:        det.Confidence,
+			RecommendedAction: &action,
+			Source:            domain.DefectSourceModel,
+		})
+	}
+	result.RecomputeSummary()
+
+	return result
+}
+
+// estimateSeverity оценивает серьёзность, если модель её не вернула:
+// трещины и разбитое стекло всегда серьёзные, остальное - по площади
+func estimateSeverity(dt domain.DefectType, box domain.BoundingBox, width, height int) domain.DefectSeverity {
+	if dt == domain.DefectTypeCrack || dt == domain.DefectTypeBrokenGlass {
+		return domain.DefectSeverityMajor
+	}
+	if width > 0 && height > 0 {
+		share := float64(box.Width*box.Height) / float64(width*height)
+		if share >= majorAreaShare {
+			return domain.DefectSeverityMajor
+		}
+	}
+	return domain.DefectSeverityMinor
+}
+
+// recommendAction подбирает рекомендуемое действие: paint, repair или replace
+func recommendAction(dt domain.DefectType, severity domain.DefectSeverity) string {
+	switch {
+	case dt == domain.DefectTypeBrokenGlass, dt == domain.DefectTypeCrack:
+		return "replace"
+	case dt == domain.DefectTypeDent && severity == domain.DefectSeverityMajor:
+		return "replace"
+	case dt == domain.DefectTypeDent:
+		return "repair"
+	default:
+		return "paint"
+	}
+}
@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/inference"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+	"github.com/DedovInside/AutoInspect/backend/internal/storage"
+)
+
+// ShadowRunner прогоняет изображение анализа через модели теневых развёртываний.
+// Запускается хуком после завершения анализа, поэтому не задерживает результат
+// и не расходует таймаут обработки. Ошибки записываются в теневой результат и журнал
+type ShadowRunner struct {
+	shadows       *repository.ShadowRepository
+	models        *repository.ModelRepository
+	storage       storage.ObjectStorage
+	backend       inference.Backend
+	minConfidence float64
+	timeout       time.Duration
+}
+
+// NewShadowRunner создаёт исполнитель теневого инференса
+func NewShadowRunner(shadows *repository.ShadowRepository, models *repository.ModelRepository, store storage.ObjectStorage,
+	backend inference.Backend, minConfidence float64, cfg config.ShadowConfig) *ShadowRunner {
+	return &ShadowRunner{shadows: shadows, models: models, storage: store, backend: backend,
+		minConfidence: minConfidence, timeout: cfg.Timeout}
+}
+
+// HandleAnalysisFinished реализует Hook: завершённый анализ активной модели прогоняется
+// через кандидатов. Теневой инференс ограничен своим таймаутом и не меняет анализ
+func (r *ShadowRunner) HandleAnalysisFinished(ctx context.Context, a *domain.Analysis) error {
+	if !a.IsCompleted() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+	defer cancel()
+
+	deployments, err := r.shadows.ListEnabled(ctx)
+	if err != nil || len(deployments) == 0 {
+		return err
+	}
+	// Кандидаты сравниваются только с активной моделью
+	primary, err := analysisModel(ctx, r.models, a)
+	if err != nil {
+		return fmt.Errorf("shadow: %w", err)
+	}
+	if !primary.Active {
+		return nil
+	}
+
+	var img []byte
+	var width, height int
+	for _, d := range deployments {
+		if d.ModelID == primary.ID || rand.Float64()*100 >= d.Percent {
+			continue
+		}
+		if img == nil {
+			if img, err = storage.ReadAll(ctx, r.storage, a.ImageKey); err != nil {
+				return fmt.Errorf("shadow: %w", err)
+			}
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(img))
+			if err != nil {
+				return fmt.Errorf("shadow: %w", err)
+			}
+			width, height = cfg.Width, cfg.Height
+		}
+		r.runOne(ctx, a, primary, &d, img, width, height)
+	}
+	return nil
+}
+
+func (r *ShadowRunner) runOne(ctx context.Context, a *domain.Analysis, primary *domain.MLModel,
+	d *domain.ShadowDeployment, img []byte, width, height int) {
+	sr := &domain.ShadowResult{
+		DeploymentID:   d.ID,
+		AnalysisID:     a.ID,
+		ModelVersion:   d.ModelVersion,
+		PrimaryVersion: primary.Version,
+	}
+
+	started := time.Now()
+	model, err := r.models.GetByID(ctx, d.ModelID)
+	var pred *inference.Prediction
+	if err == nil {
+		pred, err = r.backend.Infer(ctx, model, img)
+	}
+	elapsed := time.Since(started).Milliseconds()
+	sr.ProcessingTimeMs = &elapsed
+	if err != nil {
+		msg := err.Error()
+		sr.ErrorMessage = &msg
+		log.Printf("analysis %s shadow %s: %v", a.ID, d.ModelVersion, err)
+	} else {
+		sr.Result = BuildResult(pred, width, height, r.minConfidence)
+	}
+
+	// Результат сохраняется, даже если время обработки анализа вышло
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := r.shadows.SaveResult(saveCtx, sr); err != nil {
+		log.Printf("analysis %s shadow %s: %v", a.ID, d.ModelVersion, err)
+	}
+}
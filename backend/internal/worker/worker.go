@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/DedovInside/AutoInspect/backend/internal/config"
+	"github.com/DedovInside/AutoInspect/backend/internal/domain"
+	"github.com/DedovInside/AutoInspect/backend/internal/repository"
+)
+
+// Hook вызывается после того, как анализ перешёл в completed или failed.
+// Ошибки хуков логируются и не влияют на статус анализа
+type Hook func(ctx context.Context, a *domain.Analysis) error
+
+// Worker забирает анализы из очереди и обрабатывает их
+type Worker struct {
+	analyses  *repository.AnalysisRepository
+	processor Processor
+	policy    domain.RetryPolicy
+	cfg       config.WorkerConfig
+	hooks     []Hook
+}
+
+// New создаёт воркер анализов
+func New(analyses *repository.AnalysisRepository, processor Processor, policy domain.RetryPolicy, cfg config.WorkerConfig) *Worker {
+	return &Worker{analyses: analyses, processor: processor, policy: policy, cfg: cfg}
+}
+
+// AddHook регистрирует обработчик завершения анализа
+func (w *Worker) AddHook(h Hook) {
+	w.hooks = append(w.hooks, h)
+}
+
+// Run запускает cfg.Concurrency обработчиков и блокируется до отмены ctx
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		a, err := w.analyses.ClaimNext(ctx)
+		switch {
+		case err == nil:
+			w.handle(ctx, a)
+			continue
+		case errors.Is(err, repository.ErrNotFound):
+			// очередь пуста
+		case ctx.Err() != nil:
+			return
+		default:
+			log.Printf("claim analysis: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.cfg.PollInterval):
+		}
+	}
+}
+
+// handle обрабатывает анализ и фиксирует переход статуса.
+// Запись в БД выполняется с отдельным контекстом, чтобы остановка воркера
+// не оставила анализ навсегда в processing
+func (w *Worker) handle(ctx context.Context, a *domain.Analysis) {
+	procCtx, cancel := context.WithTimeout(ctx, w.cfg.ProcessingTimeout)
+	result, procErr := w.processor.Process(procCtx, a)
+	cancel()
+
+	saveCtx, cancelSave := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancelSave()
+
+	if procErr == nil {
+		if err := w.analyses.Complete(saveCtx, a, result); err != nil {
+			log.Printf("analysis %s: %v", a.ID, err)
+			return
+		}
+		w.runHooks(ctx, a)
+		return
+	}
+
+	code := domain.ErrorCodeOf(procErr)
+	msg := procErr.Error()
+
+	if w.policy.ShouldRetry(code, a.RetryCount) {
+		delay := w.policy.NextDelay(code, a.RetryCount, rand.Float64)
+		log.Printf("analysis %s: %s, retry #%d in %s", a.ID, msg, a.RetryCount+1, delay.Round(time.Second))
+		if err := w.analyses.ScheduleRetry(saveCtx, a.ID, code, msg, time.Now().Add(delay)); err != nil {
+			log.Printf("analysis %s: %v", a.ID, err)
+		}
+		return
+	}
+
+	log.Printf("analysis %s failed (%s): %s", a.ID, code.Class(), msg)
+	if err := w.analyses.Fail(saveCtx, a, code, msg); err != nil {
+		log.Printf("analysis %s: %v", a.ID, err)
+		return
+	}
+	w.runHooks(ctx, a)
+}
+
+// runHooks вызывает хуки по порядку. У каждого хука свой таймаут, не зависящий от остановки
+// воркера: медленный хук не лишает времени следующие (например, проверку завершения пакета)
+func (w *Worker) runHooks(ctx context.Context, a *domain.Analysis) {
+	for _, h := range w.hooks {
+		hookCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.cfg.HookTimeout)
+		err := h(hookCtx, a)
+		cancel()
+		if err != nil {
+			log.Printf("analysis %s hook: %v", a.ID, err)
+		}
+	}
+}
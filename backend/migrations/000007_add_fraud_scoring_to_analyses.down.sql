@@ -0,0 +1,10 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_analyses_image_phash;
+DROP INDEX IF EXISTS idx_analyses_fraud_score;
+
+ALTER TABLE analyses
+    DROP COLUMN IF EXISTS fraud_checked_at,
+    DROP COLUMN IF EXISTS fraud_reasons,
+    DROP COLUMN IF EXISTS fraud_score,
+    DROP COLUMN IF EXISTS image_phash,
+    DROP COLUMN IF EXISTS incident_location;
@@ -0,0 +1,22 @@
+-- +migrate Up
+ALTER TABLE analyses
+    ADD COLUMN incident_location JSONB,       -- {"latitude": 55.75, "longitude": 37.61}, заявлено клиентом
+    ADD COLUMN image_phash       BIGINT,      -- перцептивный хэш (dHash, 64 бита) для поиска почти-дубликатов
+    ADD COLUMN fraud_score       REAL CHECK (fraud_score BETWEEN 0 AND 1),
+    ADD COLUMN fraud_reasons     JSONB,
+    /*
+    Структура fraud_reasons:
+    [
+      {
+        "signal": "capture_time_gap|editing_software|gps_mismatch|duplicate_image|high_defect_count",
+        "weight": 0.35,
+        "details": "software tag: Adobe Photoshop 25.0",
+        "related_analysis_id": "uuid"  // только для duplicate_image
+      }
+    ]
+    */
+    ADD COLUMN fraud_checked_at  TIMESTAMPTZ;
+
+-- Для выборки подозрительных анализов администратором
+CREATE INDEX idx_analyses_fraud_score ON analyses(fraud_score DESC) WHERE fraud_score IS NOT NULL;
+CREATE INDEX idx_analyses_image_phash ON analyses(image_phash) WHERE image_phash IS NOT NULL;
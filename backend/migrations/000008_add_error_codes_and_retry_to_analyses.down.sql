@@ -0,0 +1,5 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_analyses_queue;
+
+ALTER TABLE analyses DROP COLUMN IF EXISTS next_retry_at;
+ALTER TABLE analyses DROP CONSTRAINT IF EXISTS analyses_error_code_check;
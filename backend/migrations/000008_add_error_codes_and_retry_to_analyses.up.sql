@@ -0,0 +1,24 @@
+-- +migrate Up
+
+-- Старые коды ошибок были произвольными строками: приводим их к общему коду
+UPDATE analyses
+SET error_code = 'internal_error'
+WHERE error_code IS NOT NULL
+  AND error_code NOT IN ('storage_timeout', 'storage_unavailable', 'inference_unavailable',
+                         'inference_timeout', 'internal_error', 'corrupt_image',
+                         'unsupported_format', 'image_not_found', 'model_not_found');
+
+ALTER TABLE analyses
+    ADD CONSTRAINT analyses_error_code_check
+    CHECK (error_code IN (
+        -- временные ошибки (повторяются по политике)
+        'storage_timeout', 'storage_unavailable', 'inference_unavailable', 'inference_timeout', 'internal_error',
+        -- постоянные ошибки (не повторяются)
+        'corrupt_image', 'unsupported_format', 'image_not_found', 'model_not_found'
+    ));
+
+ALTER TABLE analyses
+    ADD COLUMN next_retry_at TIMESTAMPTZ;  -- воркер не берёт анализ в работу раньше этого времени
+
+-- Очередь воркера: только queued, в порядке создания
+CREATE INDEX idx_analyses_queue ON analyses(created_at) WHERE status = 'queued';
@@ -0,0 +1,7 @@
+-- +migrate Up
+-- Отметка ручной проверки результата: по ней отбираются анализы для выгрузки в обучающие датасеты
+ALTER TABLE analyses
+    ADD COLUMN reviewed_at TIMESTAMPTZ,
+    ADD COLUMN reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_analyses_completed_created ON analyses(created_at) WHERE status = 'completed';
@@ -0,0 +1,10 @@
+-- +migrate Down
+DROP TABLE IF EXISTS analysis_reviews CASCADE;
+
+DROP INDEX IF EXISTS idx_analyses_review_queue;
+
+ALTER TABLE analyses
+    DROP COLUMN IF EXISTS review_reason,
+    DROP COLUMN IF EXISTS review_status,
+    DROP COLUMN IF EXISTS result_version,
+    DROP COLUMN IF EXISTS original_result_json;
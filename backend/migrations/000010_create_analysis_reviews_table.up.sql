@@ -0,0 +1,29 @@
+-- +migrate Up
+ALTER TABLE analyses
+    ADD COLUMN original_result_json JSONB,              -- выход модели, сохраняется при первой проверке
+    ADD COLUMN result_version       INTEGER NOT NULL DEFAULT 1,  -- растёт с каждой проверкой
+    ADD COLUMN review_status        VARCHAR(20) NOT NULL DEFAULT 'not_required'
+                                    CHECK (review_status IN ('not_required', 'pending', 'reviewed')),
+    ADD COLUMN review_reason        TEXT;               -- почему анализ попал в очередь проверки
+
+-- Очередь на проверку
+CREATE INDEX idx_analyses_review_queue ON analyses(created_at) WHERE review_status = 'pending';
+
+CREATE TABLE analysis_reviews (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    analysis_id     UUID NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+    reviewer_id     UUID REFERENCES users(id) ON DELETE SET NULL,
+
+    result_version  INTEGER NOT NULL,   -- версия результата после этой проверки
+    operations_json JSONB NOT NULL,     -- [{"action": "edit", "defect_id": "defect_1", "patch": {"severity": "major"}}]
+    result_json     JSONB NOT NULL,     -- результат после проверки (снимок версии)
+    comment         TEXT,
+
+    created_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (analysis_id, result_version)
+);
+
+CREATE INDEX idx_analysis_reviews_reviewer_id ON analysis_reviews(reviewer_id);
+
+-- Примечание: updated_at не требуется, т.к. проверки не редактируются (каждая - новая версия)
@@ -0,0 +1,7 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_analyses_batch_status;
+
+ALTER TABLE analyses
+    DROP COLUMN IF EXISTS batch_id;
+
+DROP TABLE IF EXISTS analysis_batches CASCADE;
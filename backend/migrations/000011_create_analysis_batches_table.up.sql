@@ -0,0 +1,30 @@
+-- +migrate Up
+-- Пакетная отправка: один пакет - много анализов (по одному на изображение)
+CREATE TABLE analysis_batches (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id         UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    name            VARCHAR(255),
+    model_version   VARCHAR(50) NOT NULL,   -- модель, с которой созданы все анализы пакета
+
+    status          VARCHAR(20) NOT NULL DEFAULT 'processing'
+                    CHECK (status IN ('processing', 'completed', 'cancelled')),
+    total_count     INTEGER NOT NULL,       -- число анализов в пакете
+
+    created_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    completed_at    TIMESTAMPTZ             -- когда все анализы пакета перешли в конечный статус
+);
+
+CREATE INDEX idx_analysis_batches_user_created ON analysis_batches(user_id, created_at DESC);
+
+CREATE TRIGGER update_analysis_batches_updated_at
+    BEFORE UPDATE ON analysis_batches
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE analyses
+    ADD COLUMN batch_id UUID REFERENCES analysis_batches(id) ON DELETE SET NULL;
+
+-- Прогресс пакета считается группировкой по статусу
+CREATE INDEX idx_analyses_batch_status ON analyses(batch_id, status) WHERE batch_id IS NOT NULL;
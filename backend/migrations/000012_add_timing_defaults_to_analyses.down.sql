@@ -0,0 +1,5 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_analyses_completed_at;
+
+ALTER TABLE analyses
+    ALTER COLUMN queued_at DROP DEFAULT;
@@ -0,0 +1,9 @@
+-- +migrate Up
+-- Тайминги анализа: queued_at заполняется при создании, остальное - воркером
+UPDATE analyses SET queued_at = created_at WHERE queued_at IS NULL;
+
+ALTER TABLE analyses
+    ALTER COLUMN queued_at SET DEFAULT CURRENT_TIMESTAMP;
+
+-- Для SLA-отчёта по дням завершения
+CREATE INDEX idx_analyses_completed_at ON analyses(completed_at) WHERE completed_at IS NOT NULL;
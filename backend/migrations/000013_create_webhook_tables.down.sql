@@ -0,0 +1,4 @@
+-- +migrate Down
+DROP TABLE IF EXISTS webhook_delivery_attempts CASCADE;
+DROP TABLE IF EXISTS webhook_deliveries CASCADE;
+DROP TABLE IF EXISTS webhook_endpoints CASCADE;
@@ -0,0 +1,62 @@
+-- +migrate Up
+-- Webhook аккаунта: адрес по умолчанию и секрет подписи.
+-- Секрет нужен и для webhook_url конкретного анализа, поэтому url может быть пустым
+CREATE TABLE webhook_endpoints (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+
+    url         TEXT,                          -- куда отправлять события анализов без своего webhook_url
+    secret      VARCHAR(100) NOT NULL,         -- ключ HMAC-SHA256
+    active      BOOLEAN NOT NULL DEFAULT TRUE,
+
+    created_at  TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_webhook_endpoints_updated_at
+    BEFORE UPDATE ON webhook_endpoints
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Одна доставка = одно событие на один адрес; попытки - в webhook_delivery_attempts
+CREATE TABLE webhook_deliveries (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    analysis_id     UUID NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+    user_id         UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redelivery_of   UUID REFERENCES webhook_deliveries(id) ON DELETE SET NULL,  -- ручная повторная отправка
+
+    event           VARCHAR(50) NOT NULL,      -- analysis.completed | analysis.failed
+    url             TEXT NOT NULL,
+    payload         JSONB NOT NULL,            -- тело запроса, фиксируется при постановке в очередь
+
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending'
+                    CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempt_count   INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,               -- для pending: когда пробовать (или окончание аренды попытки)
+    last_error      TEXT,
+    last_response_code INTEGER,
+
+    created_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webhook_deliveries_analysis_id ON webhook_deliveries(analysis_id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+CREATE TRIGGER update_webhook_deliveries_updated_at
+    BEFORE UPDATE ON webhook_deliveries
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE webhook_delivery_attempts (
+    id              BIGSERIAL PRIMARY KEY,
+    delivery_id     UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt         INTEGER NOT NULL,
+    response_code   INTEGER,                   -- NULL, если ответа не было (таймаут, сеть)
+    error           TEXT,
+    duration_ms     BIGINT NOT NULL,
+    created_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (delivery_id, attempt)
+);
@@ -0,0 +1,7 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_analyses_total_defects;
+DROP INDEX IF EXISTS idx_analyses_car_make;
+
+ALTER TABLE analyses
+    DROP COLUMN IF EXISTS car_model,
+    DROP COLUMN IF EXISTS car_make;
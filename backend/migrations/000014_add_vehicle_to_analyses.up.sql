@@ -0,0 +1,16 @@
+-- +migrate Up
+-- Автомобиль на снимке (заявлен клиентом) - для поиска по дефектам с фильтром по марке
+ALTER TABLE analyses
+    ADD COLUMN car_make  VARCHAR(100),
+    ADD COLUMN car_model VARCHAR(100);
+
+CREATE INDEX idx_analyses_car_make ON analyses(lower(car_make)) WHERE car_make IS NOT NULL;
+
+-- Поиск идёт только по завершённым анализам: частичный индекс для сортировки по количеству дефектов
+CREATE INDEX idx_analyses_total_defects ON analyses(((result_json->'summary'->>'total_defects')::int))
+    WHERE status = 'completed';
+
+-- Пример поиска (GIN idx_analyses_result_json обслуживает @> и @?):
+-- SELECT * FROM analyses
+-- WHERE result_json @> '{"defects": [{"defect_type": "dent", "part_id": "bumper_01"}]}'
+--   AND result_json @? '$.defects[*] ? (@.defect_type == "dent" && @.confidence >= 0.8)';
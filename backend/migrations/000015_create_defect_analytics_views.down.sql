@@ -0,0 +1,4 @@
+-- +migrate Down
+DROP TABLE IF EXISTS analytics_refreshes;
+DROP MATERIALIZED VIEW IF EXISTS mv_analysis_stats_weekly;
+DROP MATERIALIZED VIEW IF EXISTS mv_defect_stats_weekly;
@@ -0,0 +1,58 @@
+-- +migrate Up
+-- Аналитика по дефектам: недельные агрегаты завершённых анализов.
+-- Представления обновляются воркером (REFRESH MATERIALIZED VIEW CONCURRENTLY),
+-- пустые марка/модель/деталь хранятся как '' - уникальный индекс для CONCURRENTLY не допускает NULL-дубликатов
+
+-- Дефекты по неделе, автомобилю, детали и типу.
+-- analysis_count - число анализов с таким дефектом, cost_* - по стоимости этих анализов
+CREATE MATERIALIZED VIEW mv_defect_stats_weekly AS
+WITH per_analysis AS (
+    SELECT a.id,
+           date_trunc('week', a.created_at AT TIME ZONE 'UTC')::date AS week,
+           COALESCE(a.car_make, '')   AS car_make,
+           COALESCE(a.car_model, '')  AS car_model,
+           COALESCE(d->>'part_id', '')     AS part_id,
+           COALESCE(d->>'defect_type', '') AS defect_type,
+           COUNT(*) AS defect_count,
+           COUNT(*) FILTER (WHERE d->>'severity' = 'major') AS major_count,
+           (a.result_json->'summary'->>'estimated_cost')::numeric AS estimated_cost
+    FROM analyses a
+    CROSS JOIN LATERAL jsonb_array_elements(a.result_json->'defects') d
+    WHERE a.status = 'completed' AND a.result_json IS NOT NULL
+    GROUP BY a.id, week, 3, 4, 5, 6
+)
+SELECT week, car_make, car_model, part_id, defect_type,
+       SUM(defect_count)::bigint  AS defect_count,
+       SUM(major_count)::bigint   AS major_count,
+       COUNT(*)::bigint           AS analysis_count,
+       SUM(estimated_cost)        AS cost_sum,
+       COUNT(estimated_cost)::bigint AS cost_count
+FROM per_analysis
+GROUP BY week, car_make, car_model, part_id, defect_type;
+
+CREATE UNIQUE INDEX idx_mv_defect_stats_weekly_key
+    ON mv_defect_stats_weekly(week, car_make, car_model, part_id, defect_type);
+
+-- Анализы по неделе и автомобилю
+CREATE MATERIALIZED VIEW mv_analysis_stats_weekly AS
+SELECT date_trunc('week', a.created_at AT TIME ZONE 'UTC')::date AS week,
+       COALESCE(a.car_make, '')  AS car_make,
+       COALESCE(a.car_model, '') AS car_model,
+       COUNT(*)::bigint AS analysis_count,
+       COALESCE(SUM((a.result_json->'summary'->>'total_defects')::int), 0)::bigint  AS defect_count,
+       COALESCE(SUM((a.result_json->'summary'->>'critical_count')::int), 0)::bigint AS major_count,
+       SUM((a.result_json->'summary'->>'estimated_cost')::numeric) AS cost_sum,
+       COUNT((a.result_json->'summary'->>'estimated_cost')::numeric)::bigint AS cost_count
+FROM analyses a
+WHERE a.status = 'completed' AND a.result_json IS NOT NULL
+GROUP BY 1, 2, 3;
+
+CREATE UNIQUE INDEX idx_mv_analysis_stats_weekly_key
+    ON mv_analysis_stats_weekly(week, car_make, car_model);
+
+-- Время последнего обновления представлений (показывается в ответах API)
+CREATE TABLE analytics_refreshes (
+    view_name    VARCHAR(100) PRIMARY KEY,
+    refreshed_at TIMESTAMPTZ NOT NULL,
+    duration_ms  BIGINT NOT NULL
+);
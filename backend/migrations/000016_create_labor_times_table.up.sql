@@ -0,0 +1,30 @@
+-- +migrate Up
+-- Нормы трудоёмкости (нормо-часы) по детали и классу повреждения.
+-- part_id = '*' - норма по умолчанию для деталей без собственной строки
+CREATE TABLE labor_times (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    part_id             VARCHAR(100) NOT NULL,
+    damage_class        VARCHAR(20) NOT NULL CHECK (damage_class IN ('light', 'medium', 'heavy')),
+
+    remove_refit_hours  NUMERIC(6, 2) NOT NULL DEFAULT 0 CHECK (remove_refit_hours >= 0),
+    repair_hours        NUMERIC(6, 2) NOT NULL DEFAULT 0 CHECK (repair_hours >= 0),
+    paint_hours         NUMERIC(6, 2) NOT NULL DEFAULT 0 CHECK (paint_hours >= 0),
+    blend_hours         NUMERIC(6, 2) NOT NULL DEFAULT 0 CHECK (blend_hours >= 0),
+
+    created_at          TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at          TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (part_id, damage_class)
+);
+
+CREATE TRIGGER update_labor_times_updated_at
+    BEFORE UPDATE ON labor_times
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Нормы по умолчанию: light - полировка и локальная окраска,
+-- medium - ремонт с окраской детали, heavy - замена детали с окраской
+INSERT INTO labor_times (part_id, damage_class, remove_refit_hours, repair_hours, paint_hours, blend_hours) VALUES
+    ('*', 'light',  0.0, 0.5, 1.0, 0.5),
+    ('*', 'medium', 0.5, 1.5, 2.0, 0.8),
+    ('*', 'heavy',  1.5, 0.0, 2.5, 1.0);
@@ -0,0 +1,61 @@
+-- +migrate Down
+-- Представления аналитики возвращаются к виду из миграции 000015.
+-- Оценки в формате v5 (сумма с валютой) в старых представлениях не учитываются
+DROP MATERIALIZED VIEW IF EXISTS mv_defect_stats_weekly;
+DROP MATERIALIZED VIEW IF EXISTS mv_analysis_stats_weekly;
+DELETE FROM analytics_refreshes;
+
+CREATE MATERIALIZED VIEW mv_defect_stats_weekly AS
+WITH per_analysis AS (
+    SELECT a.id,
+           date_trunc('week', a.created_at AT TIME ZONE 'UTC')::date AS week,
+           COALESCE(a.car_make, '')   AS car_make,
+           COALESCE(a.car_model, '')  AS car_model,
+           COALESCE(d->>'part_id', '')     AS part_id,
+           COALESCE(d->>'defect_type', '') AS defect_type,
+           COUNT(*) AS defect_count,
+           COUNT(*) FILTER (WHERE d->>'severity' = 'major') AS major_count,
+           (CASE WHEN jsonb_typeof(a.result_json->'summary'->'estimated_cost') = 'number'
+                 THEN (a.result_json->'summary'->>'estimated_cost')::numeric END) AS estimated_cost
+    FROM analyses a
+    CROSS JOIN LATERAL jsonb_array_elements(a.result_json->'defects') d
+    WHERE a.status = 'completed' AND a.result_json IS NOT NULL
+    GROUP BY a.id, week, 3, 4, 5, 6
+)
+SELECT week, car_make, car_model, part_id, defect_type,
+       SUM(defect_count)::bigint  AS defect_count,
+       SUM(major_count)::bigint   AS major_count,
+       COUNT(*)::bigint           AS analysis_count,
+       SUM(estimated_cost)        AS cost_sum,
+       COUNT(estimated_cost)::bigint AS cost_count
+FROM per_analysis
+GROUP BY week, car_make, car_model, part_id, defect_type;
+
+CREATE UNIQUE INDEX idx_mv_defect_stats_weekly_key
+    ON mv_defect_stats_weekly(week, car_make, car_model, part_id, defect_type);
+
+-- Анализы по неделе и автомобилю
+CREATE MATERIALIZED VIEW mv_analysis_stats_weekly AS
+SELECT date_trunc('week', a.created_at AT TIME ZONE 'UTC')::date AS week,
+       COALESCE(a.car_make, '')  AS car_make,
+       COALESCE(a.car_model, '') AS car_model,
+       COUNT(*)::bigint AS analysis_count,
+       COALESCE(SUM((a.result_json->'summary'->>'total_defects')::int), 0)::bigint  AS defect_count,
+       COALESCE(SUM((a.result_json->'summary'->>'critical_count')::int), 0)::bigint AS major_count,
+       SUM((CASE WHEN jsonb_typeof(a.result_json->'summary'->'estimated_cost') = 'number'
+                 THEN (a.result_json->'summary'->>'estimated_cost')::numeric END)) AS cost_sum,
+       COUNT((CASE WHEN jsonb_typeof(a.result_json->'summary'->'estimated_cost') = 'number'
+                 THEN (a.result_json->'summary'->>'estimated_cost')::numeric END))::bigint AS cost_count
+FROM analyses a
+WHERE a.status = 'completed' AND a.result_json IS NOT NULL
+GROUP BY 1, 2, 3;
+
+CREATE UNIQUE INDEX idx_mv_analysis_stats_weekly_key
+    ON mv_analysis_stats_weekly(week, car_make, car_model);
+
+DROP TABLE IF EXISTS exchange_rates;
+DROP TABLE IF EXISTS price_catalog_items;
+DROP TABLE IF EXISTS price_catalogs;
+
+ALTER TABLE analyses
+    DROP COLUMN IF EXISTS region;
@@ -0,0 +1,118 @@
+-- +migrate Up
+-- Регион ремонта анализа: по нему выбирается прайс-лист (NULL - регион по умолчанию)
+ALTER TABLE analyses
+    ADD COLUMN region VARCHAR(10);
+
+-- Региональные прайс-листы. Прайс-лист действует с effective_from до следующего прайс-листа региона.
+-- Суммы хранятся в минимальных единицах валюты (копейки, центы, тиыны)
+CREATE TABLE price_catalogs (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    region               VARCHAR(10) NOT NULL,
+    currency             CHAR(3) NOT NULL CHECK (currency IN ('RUB', 'EUR', 'KZT', 'USD')),
+    effective_from       DATE NOT NULL,
+
+    labor_rate           BIGINT NOT NULL CHECK (labor_rate >= 0),           -- стоимость нормо-часа
+    paint_material_rate  BIGINT NOT NULL CHECK (paint_material_rate >= 0),  -- материалы на нормо-час окраски
+
+    created_at           TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    created_by           UUID REFERENCES users(id) ON DELETE SET NULL,
+
+    UNIQUE (region, effective_from)
+);
+
+-- Цены новых деталей (учитываются при замене)
+CREATE TABLE price_catalog_items (
+    catalog_id  UUID NOT NULL REFERENCES price_catalogs(id) ON DELETE CASCADE,
+    part_id     VARCHAR(100) NOT NULL,
+    part_price  BIGINT NOT NULL CHECK (part_price >= 0),
+
+    PRIMARY KEY (catalog_id, part_id)
+);
+
+-- Курсы валют: сколько единиц quote стоит единица base начиная с effective_date
+CREATE TABLE exchange_rates (
+    base            CHAR(3) NOT NULL,
+    quote           CHAR(3) NOT NULL,
+    effective_date  DATE NOT NULL,
+    rate            NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    created_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (base, quote, effective_date),
+    CHECK (base <> quote)
+);
+
+-- Аналитика: summary.estimated_cost стал суммой с валютой (схема результата v5).
+-- Представления пересоздаются с валютой в ключе, средняя стоимость пересчитывается
+-- в валюту отчёта при запросе. Старые записи с числом считаются в рублях
+DROP MATERIALIZED VIEW mv_defect_stats_weekly;
+DROP MATERIALIZED VIEW mv_analysis_stats_weekly;
+DELETE FROM analytics_refreshes;
+
+CREATE MATERIALIZED VIEW mv_defect_stats_weekly AS
+WITH costs AS (
+    SELECT a.id,
+           CASE jsonb_typeof(ec)
+               WHEN 'object' THEN round((ec->>'amount')::numeric * 100)::bigint
+               WHEN 'number' THEN round((ec #>> '{}')::numeric * 100)::bigint
+           END AS cost_minor,
+           CASE jsonb_typeof(ec)
+               WHEN 'object' THEN ec->>'currency'
+               WHEN 'number' THEN 'RUB'
+               ELSE ''
+           END AS cost_currency
+    FROM analyses a
+    CROSS JOIN LATERAL (SELECT a.result_json->'summary'->'estimated_cost' AS ec) s
+    WHERE a.status = 'completed' AND a.result_json IS NOT NULL
+),
+per_analysis AS (
+    SELECT a.id,
+           date_trunc('week', a.created_at AT TIME ZONE 'UTC')::date AS week,
+           COALESCE(a.car_make, '')   AS car_make,
+           COALESCE(a.car_model, '')  AS car_model,
+           COALESCE(d->>'part_id', '')     AS part_id,
+           COALESCE(d->>'defect_type', '') AS defect_type,
+           c.cost_currency,
+           COUNT(*) AS defect_count,
+           COUNT(*) FILTER (WHERE d->>'severity' = 'major') AS major_count,
+           c.cost_minor
+    FROM analyses a
+    JOIN costs c ON c.id = a.id
+    CROSS JOIN LATERAL jsonb_array_elements(a.result_json->'defects') d
+    GROUP BY a.id, week, 3, 4, 5, 6, c.cost_currency, c.cost_minor
+)
+SELECT week, car_make, car_model, part_id, defect_type, cost_currency,
+       SUM(defect_count)::bigint  AS defect_count,
+       SUM(major_count)::bigint   AS major_count,
+       COUNT(*)::bigint           AS analysis_count,
+       COALESCE(SUM(cost_minor), 0)::bigint AS cost_sum,
+       COUNT(cost_minor)::bigint  AS cost_count
+FROM per_analysis
+GROUP BY week, car_make, car_model, part_id, defect_type, cost_currency;
+
+CREATE UNIQUE INDEX idx_mv_defect_stats_weekly_key
+    ON mv_defect_stats_weekly(week, car_make, car_model, part_id, defect_type, cost_currency);
+
+CREATE MATERIALIZED VIEW mv_analysis_stats_weekly AS
+SELECT date_trunc('week', a.created_at AT TIME ZONE 'UTC')::date AS week,
+       COALESCE(a.car_make, '')  AS car_make,
+       COALESCE(a.car_model, '') AS car_model,
+       CASE jsonb_typeof(ec)
+           WHEN 'object' THEN ec->>'currency'
+           WHEN 'number' THEN 'RUB'
+           ELSE ''
+       END AS cost_currency,
+       COUNT(*)::bigint AS analysis_count,
+       COALESCE(SUM((a.result_json->'summary'->>'total_defects')::int), 0)::bigint  AS defect_count,
+       COALESCE(SUM((a.result_json->'summary'->>'critical_count')::int), 0)::bigint AS major_count,
+       COALESCE(SUM(CASE jsonb_typeof(ec)
+           WHEN 'object' THEN round((ec->>'amount')::numeric * 100)::bigint
+           WHEN 'number' THEN round((ec #>> '{}')::numeric * 100)::bigint
+       END), 0)::bigint AS cost_sum,
+       COUNT(*) FILTER (WHERE jsonb_typeof(ec) IN ('object', 'number'))::bigint AS cost_count
+FROM analyses a
+CROSS JOIN LATERAL (SELECT a.result_json->'summary'->'estimated_cost' AS ec) s
+WHERE a.status = 'completed' AND a.result_json IS NOT NULL
+GROUP BY 1, 2, 3, 4;
+
+CREATE UNIQUE INDEX idx_mv_analysis_stats_weekly_key
+    ON mv_analysis_stats_weekly(week, car_make, car_model, cost_currency);
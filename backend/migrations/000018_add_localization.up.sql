@@ -0,0 +1,54 @@
+-- +migrate Up
+-- Язык интерфейса пользователя (ru, en). NULL - по заголовку Accept-Language
+ALTER TABLE users
+    ADD COLUMN language VARCHAR(10);
+
+-- Аналитика по деталям: в ключ добавляется part_name (код детали, например front_bumper),
+-- чтобы отчёты показывали локализованное название детали
+DROP MATERIALIZED VIEW mv_defect_stats_weekly;
+DELETE FROM analytics_refreshes WHERE view_name = 'mv_defect_stats_weekly';
+
+CREATE MATERIALIZED VIEW mv_defect_stats_weekly AS
+WITH costs AS (
+    SELECT a.id,
+           CASE jsonb_typeof(ec)
+               WHEN 'object' THEN round((ec->>'amount')::numeric * 100)::bigint
+               WHEN 'number' THEN round((ec #>> '{}')::numeric * 100)::bigint
+           END AS cost_minor,
+           CASE jsonb_typeof(ec)
+               WHEN 'object' THEN ec->>'currency'
+               WHEN 'number' THEN 'RUB'
+               ELSE ''
+           END AS cost_currency
+    FROM analyses a
+    CROSS JOIN LATERAL (SELECT a.result_json->'summary'->'estimated_cost' AS ec) s
+    WHERE a.status = 'completed' AND a.result_json IS NOT NULL
+),
+per_analysis AS (
+    SELECT a.id,
+           date_trunc('week', a.created_at AT TIME ZONE 'UTC')::date AS week,
+           COALESCE(a.car_make, '')   AS car_make,
+           COALESCE(a.car_model, '')  AS car_model,
+           COALESCE(d->>'part_id', '')     AS part_id,
+           COALESCE(d->>'part_name', '')   AS part_name,
+           COALESCE(d->>'defect_type', '') AS defect_type,
+           c.cost_currency,
+           COUNT(*) AS defect_count,
+           COUNT(*) FILTER (WHERE d->>'severity' = 'major') AS major_count,
+           c.cost_minor
+    FROM analyses a
+    JOIN costs c ON c.id = a.id
+    CROSS JOIN LATERAL jsonb_array_elements(a.result_json->'defects') d
+    GROUP BY a.id, week, 3, 4, 5, 6, 7, c.cost_currency, c.cost_minor
+)
+SELECT week, car_make, car_model, part_id, part_name, defect_type, cost_currency,
+       SUM(defect_count)::bigint  AS defect_count,
+       SUM(major_count)::bigint   AS major_count,
+       COUNT(*)::bigint           AS analysis_count,
+       COALESCE(SUM(cost_minor), 0)::bigint AS cost_sum,
+       COUNT(cost_minor)::bigint  AS cost_count
+FROM per_analysis
+GROUP BY week, car_make, car_model, part_id, part_name, defect_type, cost_currency;
+
+CREATE UNIQUE INDEX idx_mv_defect_stats_weekly_key
+    ON mv_defect_stats_weekly(week, car_make, car_model, part_id, part_name, defect_type, cost_currency);
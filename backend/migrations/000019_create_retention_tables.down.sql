@@ -0,0 +1,17 @@
+-- +migrate Down
+DROP TABLE IF EXISTS user_erasures;
+
+DROP INDEX IF EXISTS idx_audit_logs_actor_pseudonym;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS actor_pseudonym;
+
+DROP INDEX IF EXISTS idx_analyses_image_key;
+DROP INDEX IF EXISTS idx_analyses_retention_result;
+DROP INDEX IF EXISTS idx_analyses_retention_image;
+ALTER TABLE analyses
+    DROP COLUMN IF EXISTS anonymized_at,
+    DROP COLUMN IF EXISTS image_deleted_at;
+
+DROP INDEX IF EXISTS idx_users_organization_id;
+ALTER TABLE users DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,63 @@
+-- +migrate Up
+-- Организации клиентов и их сроки хранения данных.
+-- NULL - значение по умолчанию из конфигурации (RETENTION_*), 0 там - хранить бессрочно
+CREATE TABLE organizations (
+    id                    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name                  VARCHAR(255) UNIQUE NOT NULL,
+
+    image_retention_days  INTEGER CHECK (image_retention_days > 0),   -- удалить изображения через N дней
+    result_retention_days INTEGER CHECK (result_retention_days > 0),  -- обезличить результаты через M дней
+
+    created_at            TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at            TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_organizations_updated_at
+    BEFORE UPDATE ON organizations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE users
+    ADD COLUMN organization_id UUID REFERENCES organizations(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_users_organization_id ON users(organization_id) WHERE organization_id IS NOT NULL;
+
+-- Отметки применения политики хранения
+ALTER TABLE analyses
+    ADD COLUMN image_deleted_at TIMESTAMPTZ,  -- изображение удалено из хранилища
+    ADD COLUMN anonymized_at    TIMESTAMPTZ;  -- удалены EXIF, место происшествия, хэш и адрес webhook
+
+-- Кандидаты на очистку: завершённые анализы, ещё не обработанные политикой
+CREATE INDEX idx_analyses_retention_image ON analyses(created_at)
+    WHERE image_deleted_at IS NULL AND status IN ('completed', 'failed', 'cancelled');
+CREATE INDEX idx_analyses_retention_result ON analyses(created_at)
+    WHERE anonymized_at IS NULL AND status IN ('completed', 'failed', 'cancelled');
+CREATE INDEX idx_analyses_image_key ON analyses(image_key);
+
+-- Записи аудита удалённого пользователя: user_id обнуляется, вместо него - псевдоним
+-- HMAC-SHA256(RETENTION_PSEUDONYM_KEY, user_id), по которому записи одного субъекта
+-- можно связать между собой, но не с человеком
+ALTER TABLE audit_logs
+    ADD COLUMN actor_pseudonym VARCHAR(64);
+
+CREATE INDEX idx_audit_logs_actor_pseudonym ON audit_logs(actor_pseudonym) WHERE actor_pseudonym IS NOT NULL;
+
+-- Подтверждение удаления данных пользователя. Не содержит персональных данных:
+-- только псевдоним, счётчики и SHA-256 манифеста удалённых объектов и записей
+CREATE TABLE user_erasures (
+    id                       UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subject_pseudonym        VARCHAR(64) NOT NULL,
+    requested_by             UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason                   TEXT NOT NULL,
+
+    analyses_deleted         INTEGER NOT NULL DEFAULT 0,
+    datasets_deleted         INTEGER NOT NULL DEFAULT 0,
+    objects_deleted          INTEGER NOT NULL DEFAULT 0,
+    audit_logs_pseudonymized INTEGER NOT NULL DEFAULT 0,
+    manifest_sha256          CHAR(64) NOT NULL,
+
+    started_at               TIMESTAMPTZ NOT NULL,
+    completed_at             TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_erasures_subject ON user_erasures(subject_pseudonym);
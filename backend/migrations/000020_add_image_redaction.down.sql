@@ -0,0 +1,5 @@
+-- +migrate Down
+ALTER TABLE analyses
+    DROP COLUMN IF EXISTS redacted_at,
+    DROP COLUMN IF EXISTS redaction_regions,
+    DROP COLUMN IF EXISTS redacted_image_key;
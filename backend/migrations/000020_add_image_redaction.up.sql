@@ -0,0 +1,7 @@
+-- +migrate Up
+-- Копия изображения со скрытыми номерами и лицами. Клиентам отдаётся только она,
+-- оригинал (image_key) доступен владельцам сервиса и администраторам
+ALTER TABLE analyses
+    ADD COLUMN redacted_image_key VARCHAR(500),
+    ADD COLUMN redaction_regions  JSONB,        -- [{"class": "license_plate|face", "bbox": {...}, "confidence": 0.9}]
+    ADD COLUMN redacted_at        TIMESTAMPTZ;
@@ -0,0 +1,5 @@
+-- +migrate Down
+DROP TABLE IF EXISTS model_promotions;
+
+ALTER TABLE models DROP CONSTRAINT IF EXISTS chk_models_active_status;
+ALTER TABLE models DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,28 @@
+-- +migrate Up
+-- Триггер update_models_updated_at создан в 000002, но колонки не было:
+-- любое UPDATE models завершалось ошибкой
+ALTER TABLE models
+    ADD COLUMN updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP;
+
+-- Согласуем status и active: активна ровно та модель, у которой status = 'active'
+UPDATE models SET status = 'ready' WHERE status = 'active' AND NOT COALESCE(active, FALSE);
+UPDATE models SET status = 'active' WHERE active AND status IS DISTINCT FROM 'active';
+
+ALTER TABLE models
+    ADD CONSTRAINT chk_models_active_status
+    CHECK (COALESCE(active, FALSE) = (COALESCE(status, 'training') = 'active'));
+
+-- История переключения активной модели. Откат возвращает previous_model_id
+-- последнего продвижения текущей модели
+CREATE TABLE model_promotions (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    model_id          UUID NOT NULL REFERENCES models(id),  -- ставшая активной
+    previous_model_id UUID REFERENCES models(id),           -- активная до переключения
+    action            VARCHAR(20) NOT NULL CHECK (action IN ('promote', 'rollback')),
+    reason            TEXT,
+    performed_by      UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_model_promotions_model ON model_promotions(model_id, created_at DESC);
+CREATE INDEX idx_model_promotions_created_at ON model_promotions(created_at DESC);
@@ -0,0 +1,7 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_analyses_canary_rule;
+ALTER TABLE analyses
+    DROP COLUMN IF EXISTS canary_rule_id,
+    DROP COLUMN IF EXISTS model_route;
+
+DROP TABLE IF EXISTS canary_rules;
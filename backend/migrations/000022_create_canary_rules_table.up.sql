@@ -0,0 +1,37 @@
+-- +migrate Up
+-- Канареечная маршрутизация: доля новых анализов (в рамках организации и/или марки)
+-- отправляется на модель-кандидата, остальные - на активную модель
+CREATE TABLE canary_rules (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    candidate_model_id UUID NOT NULL REFERENCES models(id),
+    percent            NUMERIC(5, 2) NOT NULL CHECK (percent > 0 AND percent <= 100),
+
+    -- Область действия; NULL - без ограничения
+    organization_id    UUID REFERENCES organizations(id) ON DELETE CASCADE,
+    car_make           VARCHAR(100),
+
+    enabled            BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by         UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at         TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at         TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Не больше одного включённого правила на область
+CREATE UNIQUE INDEX idx_canary_rules_scope ON canary_rules(
+    COALESCE(organization_id, '00000000-0000-0000-0000-000000000000'::uuid),
+    COALESCE(lower(car_make), ''))
+    WHERE enabled;
+
+CREATE TRIGGER update_canary_rules_updated_at
+    BEFORE UPDATE ON canary_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Как была выбрана модель анализа:
+-- active - активная модель (правил нет), pinned - версия указана клиентом,
+-- canary / control - по правилу canary_rule_id на кандидата / на активную модель
+ALTER TABLE analyses
+    ADD COLUMN model_route    VARCHAR(20) CHECK (model_route IN ('active', 'pinned', 'canary', 'control')),
+    ADD COLUMN canary_rule_id UUID REFERENCES canary_rules(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_analyses_canary_rule ON analyses(canary_rule_id, created_at) WHERE canary_rule_id IS NOT NULL;
@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP TABLE IF EXISTS shadow_results;
+DROP TABLE IF EXISTS shadow_deployments;
@@ -0,0 +1,37 @@
+-- +migrate Up
+-- Теневой режим: воркер после активной модели прогоняет изображение через модель-кандидата.
+-- Результат кандидата хранится отдельно и клиентам не показывается
+CREATE TABLE shadow_deployments (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    model_id   UUID NOT NULL REFERENCES models(id),
+    percent    NUMERIC(5, 2) NOT NULL CHECK (percent > 0 AND percent <= 100), -- доля анализов активной модели
+    enabled    BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Модель не может одновременно быть в двух включённых теневых развёртываниях
+CREATE UNIQUE INDEX idx_shadow_deployments_model ON shadow_deployments(model_id) WHERE enabled;
+
+CREATE TRIGGER update_shadow_deployments_updated_at
+    BEFORE UPDATE ON shadow_deployments
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE shadow_results (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    deployment_id      UUID NOT NULL REFERENCES shadow_deployments(id) ON DELETE CASCADE,
+    analysis_id        UUID NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+    model_version      VARCHAR(50) NOT NULL,  -- модель-кандидат
+    primary_version    VARCHAR(50) NOT NULL,  -- активная модель, выполнившая анализ
+    result_json        JSONB,                 -- NULL, если инференс кандидата завершился ошибкой
+    error_message      TEXT,
+    processing_time_ms BIGINT,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Повторная обработка анализа перезаписывает теневой результат
+    UNIQUE (deployment_id, analysis_id)
+);
+
+CREATE INDEX idx_shadow_results_deployment ON shadow_results(deployment_id, created_at);
@@ -0,0 +1,8 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_models_car_make;
+
+UPDATE analyses SET model_route = 'active' WHERE model_route IN ('make_model', 'make');
+ALTER TABLE analyses DROP CONSTRAINT IF EXISTS analyses_model_route_check;
+ALTER TABLE analyses
+    ADD CONSTRAINT analyses_model_route_check
+    CHECK (model_route IN ('active', 'pinned', 'canary', 'control'));
@@ -0,0 +1,11 @@
+-- +migrate Up
+-- Модель анализа подбирается по марке и модели автомобиля:
+-- make_model - специализированная модель для марки и модели, make - для марки,
+-- active - базовая (активная) модель, если специализированной нет
+ALTER TABLE analyses DROP CONSTRAINT IF EXISTS analyses_model_route_check;
+ALTER TABLE analyses
+    ADD CONSTRAINT analyses_model_route_check
+    CHECK (model_route IN ('active', 'make_model', 'make', 'pinned', 'canary', 'control'));
+
+CREATE INDEX idx_models_car_make ON models(lower(car_make), lower(car_model))
+    WHERE car_make IS NOT NULL AND status IN ('ready', 'active');
@@ -0,0 +1,6 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_training_jobs_result_model;
+DROP INDEX IF EXISTS idx_models_parent;
+
+DROP TRIGGER IF EXISTS check_models_lineage_acyclic ON models;
+DROP FUNCTION IF EXISTS check_model_lineage_acyclic();
@@ -0,0 +1,34 @@
+-- +migrate Up
+-- Родословная моделей (parent_model_id) должна оставаться деревом:
+-- модель не может стать потомком самой себя
+CREATE OR REPLACE FUNCTION check_model_lineage_acyclic()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.parent_model_id IS NULL THEN
+        RETURN NEW;
+    END IF;
+    IF NEW.parent_model_id = NEW.id OR EXISTS (
+        WITH RECURSIVE chain(model_id, parent_id, path) AS (
+            SELECT id, parent_model_id, ARRAY[id] FROM models WHERE id = NEW.parent_model_id
+            UNION ALL
+            SELECT m.id, m.parent_model_id, c.path || m.id
+            FROM chain c
+            JOIN models m ON m.id = c.parent_id
+            WHERE NOT m.id = ANY(c.path)
+        )
+        SELECT 1 FROM chain WHERE model_id = NEW.id
+    ) THEN
+        RAISE EXCEPTION 'model % cannot descend from its own descendant %', NEW.id, NEW.parent_model_id
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'chk_models_lineage_acyclic';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER check_models_lineage_acyclic
+    BEFORE INSERT OR UPDATE OF parent_model_id ON models
+    FOR EACH ROW
+    EXECUTE FUNCTION check_model_lineage_acyclic();
+
+CREATE INDEX idx_models_parent ON models(parent_model_id) WHERE parent_model_id IS NOT NULL;
+CREATE INDEX idx_training_jobs_result_model ON training_jobs(result_model_id) WHERE result_model_id IS NOT NULL;
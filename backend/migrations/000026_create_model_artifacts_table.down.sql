@@ -0,0 +1,11 @@
+-- +migrate Down
+UPDATE analyses SET error_code = 'internal_error' WHERE error_code = 'model_integrity';
+ALTER TABLE analyses DROP CONSTRAINT IF EXISTS analyses_error_code_check;
+ALTER TABLE analyses
+    ADD CONSTRAINT analyses_error_code_check
+    CHECK (error_code IN (
+        'storage_timeout', 'storage_unavailable', 'inference_unavailable', 'inference_timeout', 'internal_error',
+        'corrupt_image', 'unsupported_format', 'image_not_found', 'model_not_found'
+    ));
+
+DROP TABLE IF EXISTS model_artifacts;
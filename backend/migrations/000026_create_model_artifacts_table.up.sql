@@ -0,0 +1,29 @@
+-- +migrate Up
+-- Дайджесты файлов моделей (веса, конфиг), записанные при регистрации.
+-- Воркер сверяет файлы в хранилище с дайджестом перед использованием модели
+CREATE TABLE model_artifacts (
+    model_id           UUID NOT NULL REFERENCES models(id) ON DELETE CASCADE,
+    kind               VARCHAR(20) NOT NULL CHECK (kind IN ('weights', 'config')),
+    path               TEXT NOT NULL,
+    sha256             CHAR(64) NOT NULL,
+    size_bytes         BIGINT NOT NULL CHECK (size_bytes >= 0),
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Последняя проверка; verification_error - причина несовпадения, NULL - файл цел
+    verified_at        TIMESTAMPTZ,
+    verification_error TEXT,
+
+    PRIMARY KEY (model_id, kind)
+);
+
+-- Новый код ошибки анализа: файлы модели не совпадают с дайджестом
+ALTER TABLE analyses DROP CONSTRAINT IF EXISTS analyses_error_code_check;
+ALTER TABLE analyses
+    ADD CONSTRAINT analyses_error_code_check
+    CHECK (error_code IN (
+        -- временные ошибки (повторяются по политике)
+        'storage_timeout', 'storage_unavailable', 'inference_unavailable', 'inference_timeout', 'internal_error',
+        'model_integrity',
+        -- постоянные ошибки (не повторяются)
+        'corrupt_image', 'unsupported_format', 'image_not_found', 'model_not_found'
+    ));
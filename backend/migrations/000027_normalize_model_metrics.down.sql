@@ -0,0 +1,3 @@
+-- +migrate Down
+-- Исходное написание ключа не сохранялось; map читается бэкендом в любом случае
+SELECT 1;
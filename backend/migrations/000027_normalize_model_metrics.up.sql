@@ -0,0 +1,11 @@
+-- +migrate Up
+-- Ключ mAP в metrics_json (так он описан в 000002) приводится к map, под которым его
+-- пишет и ищет бэкенд. Расширенные метрики (evaluation_dataset_id, iou_thresholds,
+-- per_class, confusion) хранятся в том же JSON и схемы не меняют
+UPDATE models
+SET metrics_json = (metrics_json - 'mAP') || jsonb_build_object('map', metrics_json->'mAP')
+WHERE metrics_json ? 'mAP' AND NOT metrics_json ? 'map';
+
+UPDATE models
+SET metrics_json = metrics_json - 'mAP'
+WHERE metrics_json ? 'mAP';
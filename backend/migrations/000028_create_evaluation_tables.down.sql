@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP TABLE IF EXISTS evaluation_predictions;
+DROP TABLE IF EXISTS evaluation_jobs;
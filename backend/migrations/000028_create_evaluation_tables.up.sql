@@ -0,0 +1,45 @@
+-- +migrate Up
+-- Офлайн-оценка модели на валидационной части размеченного датасета.
+-- Задачи забирает воркер; итоговые метрики записываются и в задачу, и в models.metrics_json
+CREATE TABLE evaluation_jobs (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    model_id         UUID NOT NULL REFERENCES models(id) ON DELETE CASCADE,
+    dataset_id       UUID NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    status           VARCHAR(20) NOT NULL DEFAULT 'queued'
+                     CHECK (status IN ('queued', 'running', 'completed', 'failed', 'cancelled')),
+    params_json      JSONB NOT NULL,  -- пороги IoU, доля валидационной части, порог уверенности
+    metrics_json     JSONB,           -- ModelMetrics по итогам оценки
+    latency_json     JSONB,           -- время инференса по изображениям
+
+    images_total     INT NOT NULL DEFAULT 0,
+    images_processed INT NOT NULL DEFAULT 0,
+    images_failed    INT NOT NULL DEFAULT 0,
+
+    error_message    TEXT,
+    created_by       UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    started_at       TIMESTAMPTZ,
+    completed_at     TIMESTAMPTZ
+);
+
+CREATE INDEX idx_evaluation_jobs_model ON evaluation_jobs(model_id, created_at DESC);
+CREATE INDEX idx_evaluation_jobs_queue ON evaluation_jobs(created_at) WHERE status IN ('queued', 'running');
+
+CREATE TRIGGER update_evaluation_jobs_updated_at
+    BEFORE UPDATE ON evaluation_jobs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Выход модели по каждому изображению оценки: нужен для сравнения моделей на одном наборе
+CREATE TABLE evaluation_predictions (
+    job_id             UUID NOT NULL REFERENCES evaluation_jobs(id) ON DELETE CASCADE,
+    image_key          TEXT NOT NULL,
+    detections_json    JSONB,   -- NULL, если инференс завершился ошибкой
+    error_message      TEXT,
+    processing_time_ms BIGINT,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Повторный запуск после сбоя воркера перезаписывает выход
+    PRIMARY KEY (job_id, image_key)
+);
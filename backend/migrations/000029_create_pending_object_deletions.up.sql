@@ -0,0 +1,19 @@
+-- +migrate Up
+-- Объекты хранилища, которые нужно удалить после фиксации транзакции.
+-- Ключи записываются в той же транзакции, что и удаление строк, а объекты удаляются
+-- после COMMIT: откат не оставляет записи, ссылающиеся на удалённые файлы,
+-- а недоудалённые объекты дочищает планировщик политики хранения
+CREATE TABLE pending_object_deletions (
+    object_key  TEXT PRIMARY KEY,
+    reason      VARCHAR(50) NOT NULL,            -- user_erasure
+    attempts    INTEGER NOT NULL DEFAULT 0,
+    last_error  TEXT,
+
+    created_at  TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_pending_object_deletions_updated_at
+    BEFORE UPDATE ON pending_object_deletions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_analyses_phash_created;
+CREATE INDEX idx_analyses_image_phash ON analyses(image_phash) WHERE image_phash IS NOT NULL;
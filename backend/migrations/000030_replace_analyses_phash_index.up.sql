@@ -0,0 +1,6 @@
+-- +migrate Up
+-- B-tree по image_phash не помогает поиску по расстоянию Хэмминга: почти-дубликаты
+-- ищутся перебором, ограниченным окном по времени. Индекс покрывает этот перебор
+DROP INDEX IF EXISTS idx_analyses_image_phash;
+CREATE INDEX idx_analyses_phash_created ON analyses(created_at) INCLUDE (image_phash, user_id)
+    WHERE image_phash IS NOT NULL;
@@ -0,0 +1,11 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_webhook_deliveries_batch_id;
+
+DELETE FROM webhook_deliveries WHERE analysis_id IS NULL;
+ALTER TABLE webhook_deliveries
+    DROP CONSTRAINT IF EXISTS webhook_deliveries_subject_check,
+    DROP COLUMN IF EXISTS batch_id,
+    ALTER COLUMN analysis_id SET NOT NULL;
+
+ALTER TABLE analysis_batches
+    DROP COLUMN IF EXISTS webhook_url;
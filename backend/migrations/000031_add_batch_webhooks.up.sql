@@ -0,0 +1,12 @@
+-- +migrate Up
+-- Адрес для события batch.completed; без него событие уходит на webhook аккаунта
+ALTER TABLE analysis_batches
+    ADD COLUMN webhook_url TEXT;
+
+-- Доставка относится либо к анализу (analysis.*), либо к пакету (batch.*)
+ALTER TABLE webhook_deliveries
+    ALTER COLUMN analysis_id DROP NOT NULL,
+    ADD COLUMN batch_id UUID REFERENCES analysis_batches(id) ON DELETE CASCADE,
+    ADD CONSTRAINT webhook_deliveries_subject_check CHECK ((analysis_id IS NULL) <> (batch_id IS NULL));
+
+CREATE INDEX idx_webhook_deliveries_batch_id ON webhook_deliveries(batch_id) WHERE batch_id IS NOT NULL;
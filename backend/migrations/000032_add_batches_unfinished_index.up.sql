@@ -0,0 +1,3 @@
+-- +migrate Up
+-- Незавершённые пакеты для периодической проверки завершения (batch.Sweeper)
+CREATE INDEX idx_analysis_batches_unfinished ON analysis_batches(created_at) WHERE completed_at IS NULL;
@@ -0,0 +1,96 @@
+-- +migrate Up
+-- mv_defect_stats_weekly хранила строку на (анализ, деталь, тип дефекта): при группировке
+-- по детали или по типу analysis_count и стоимость анализа суммировались по второму измерению
+-- и учитывались несколько раз. Разрезы по детали и по типу строятся по отдельным представлениям
+DROP MATERIALIZED VIEW mv_defect_stats_weekly;
+DELETE FROM analytics_refreshes WHERE view_name = 'mv_defect_stats_weekly';
+
+-- Дефекты по неделе, автомобилю и детали: строка per_analysis - пара (анализ, деталь),
+-- поэтому анализ и его стоимость учитываются в детали один раз при любом числе типов дефектов
+CREATE MATERIALIZED VIEW mv_part_stats_weekly AS
+WITH costs AS (
+    SELECT a.id,
+           CASE jsonb_typeof(ec)
+               WHEN 'object' THEN round((ec->>'amount')::numeric * 100)::bigint
+               WHEN 'number' THEN round((ec #>> '{}')::numeric * 100)::bigint
+           END AS cost_minor,
+           CASE jsonb_typeof(ec)
+               WHEN 'object' THEN ec->>'currency'
+               WHEN 'number' THEN 'RUB'
+               ELSE ''
+           END AS cost_currency
+    FROM analyses a
+    CROSS JOIN LATERAL (SELECT a.result_json->'summary'->'estimated_cost' AS ec) s
+    WHERE a.status = 'completed' AND a.result_json IS NOT NULL
+),
+per_analysis AS (
+    SELECT a.id,
+           date_trunc('week', a.created_at AT TIME ZONE 'UTC')::date AS week,
+           COALESCE(a.car_make, '')   AS car_make,
+           COALESCE(a.car_model, '')  AS car_model,
+           COALESCE(d->>'part_id', '')     AS part_id,
+           COALESCE(d->>'part_name', '')   AS part_name,
+           c.cost_currency,
+           COUNT(*) AS defect_count,
+           COUNT(*) FILTER (WHERE d->>'severity' = 'major') AS major_count,
+           c.cost_minor
+    FROM analyses a
+    JOIN costs c ON c.id = a.id
+    CROSS JOIN LATERAL jsonb_array_elements(a.result_json->'defects') d
+    GROUP BY a.id, week, 3, 4, 5, 6, c.cost_currency, c.cost_minor
+)
+SELECT week, car_make, car_model, part_id, part_name, cost_currency,
+       SUM(defect_count)::bigint  AS defect_count,
+       SUM(major_count)::bigint   AS major_count,
+       COUNT(*)::bigint           AS analysis_count,
+       COALESCE(SUM(cost_minor), 0)::bigint AS cost_sum,
+       COUNT(cost_minor)::bigint  AS cost_count
+FROM per_analysis
+GROUP BY week, car_make, car_model, part_id, part_name, cost_currency;
+
+CREATE UNIQUE INDEX idx_mv_part_stats_weekly_key
+    ON mv_part_stats_weekly(week, car_make, car_model, part_id, part_name, cost_currency);
+
+-- Дефекты по неделе, автомобилю и типу: строка per_analysis - пара (анализ, тип дефекта)
+CREATE MATERIALIZED VIEW mv_defect_type_stats_weekly AS
+WITH costs AS (
+    SELECT a.id,
+           CASE jsonb_typeof(ec)
+               WHEN 'object' THEN round((ec->>'amount')::numeric * 100)::bigint
+               WHEN 'number' THEN round((ec #>> '{}')::numeric * 100)::bigint
+           END AS cost_minor,
+           CASE jsonb_typeof(ec)
+               WHEN 'object' THEN ec->>'currency'
+               WHEN 'number' THEN 'RUB'
+               ELSE ''
+           END AS cost_currency
+    FROM analyses a
+    CROSS JOIN LATERAL (SELECT a.result_json->'summary'->'estimated_cost' AS ec) s
+    WHERE a.status = 'completed' AND a.result_json IS NOT NULL
+),
+per_analysis AS (
+    SELECT a.id,
+           date_trunc('week', a.created_at AT TIME ZONE 'UTC')::date AS week,
+           COALESCE(a.car_make, '')   AS car_make,
+           COALESCE(a.car_model, '')  AS car_model,
+           COALESCE(d->>'defect_type', '') AS defect_type,
+           c.cost_currency,
+           COUNT(*) AS defect_count,
+           COUNT(*) FILTER (WHERE d->>'severity' = 'major') AS major_count,
+           c.cost_minor
+    FROM analyses a
+    JOIN costs c ON c.id = a.id
+    CROSS JOIN LATERAL jsonb_array_elements(a.result_json->'defects') d
+    GROUP BY a.id, week, 3, 4, 5, c.cost_currency, c.cost_minor
+)
+SELECT week, car_make, car_model, defect_type, cost_currency,
+       SUM(defect_count)::bigint  AS defect_count,
+       SUM(major_count)::bigint   AS major_count,
+       COUNT(*)::bigint           AS analysis_count,
+       COALESCE(SUM(cost_minor), 0)::bigint AS cost_sum,
+       COUNT(cost_minor)::bigint  AS cost_count
+FROM per_analysis
+GROUP BY week, car_make, car_model, defect_type, cost_currency;
+
+CREATE UNIQUE INDEX idx_mv_defect_type_stats_weekly_key
+    ON mv_defect_type_stats_weekly(week, car_make, car_model, defect_type, cost_currency);
@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE analyses
+    DROP COLUMN IF EXISTS base_route;
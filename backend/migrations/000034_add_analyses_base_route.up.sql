@@ -0,0 +1,6 @@
+-- +migrate Up
+-- Для анализов по канареечному правилу (canary, control) model_route не сохраняет, как была
+-- выбрана базовая модель. base_route - причина выбора базовой модели: отчёт по правилу
+-- сравнивает кандидата со специализированной и с активной моделью раздельно
+ALTER TABLE analyses
+    ADD COLUMN base_route VARCHAR(20) CHECK (base_route IN ('active', 'make_model', 'make'));